@@ -0,0 +1,298 @@
+// Command mockoidc is a local OpenID Connect provider for developing and
+// testing single sign-on. It signs in any listed user without a password.
+//
+//	go run ./cmd/mockoidc -users "alice@company.com=vista-admins,bob@company.com"
+//
+// Then start the backend with OIDC_ISSUER_URL=http://localhost:9400,
+// OIDC_CLIENT_ID=vista and OIDC_CLIENT_SECRET=vista-secret. Adding
+// login_hint=<email> to the authorization URL signs that user in without
+// showing the user picker, for scripted tests.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock-1"
+
+type user struct {
+	Email  string
+	Groups []string
+}
+
+// grant is an issued authorization code waiting to be redeemed
+type grant struct {
+	user          user
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type server struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	users        []user
+	key          *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]grant
+	tokens map[string]jwt.MapClaims // Access tokens for userinfo
+}
+
+func main() {
+	addr := flag.String("addr", ":9400", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9400", "issuer URL, as the backend reaches it")
+	clientID := flag.String("client-id", "vista", "client ID")
+	clientSecret := flag.String("client-secret", "vista-secret", "client secret")
+	users := flag.String("users", "admin@company.com=vista-admins,employee@company.com", "users as email=group|group, comma separated")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	s := &server{
+		issuer:       strings.TrimRight(*issuer, "/"),
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		users:        parseUsers(*users),
+		key:          key,
+		codes:        make(map[string]grant),
+		tokens:       make(map[string]jwt.MapClaims),
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	http.HandleFunc("/authorize", s.authorize)
+	http.HandleFunc("/token", s.token)
+	http.HandleFunc("/userinfo", s.userinfo)
+	http.HandleFunc("/jwks", s.jwks)
+
+	log.Printf("Mock OIDC provider %s listening on %s with %d users", s.issuer, *addr, len(s.users))
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func parseUsers(spec string) []user {
+	var users []user
+	for _, entry := range strings.Split(spec, ",") {
+		email, groups, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if email == "" {
+			continue
+		}
+		u := user{Email: email}
+		if groups != "" {
+			u.Groups = strings.Split(groups, "|")
+		}
+		users = append(users, u)
+	}
+	return users
+}
+
+func (s *server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"userinfo_endpoint":                     s.issuer + "/userinfo",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+var pickerTemplate = template.Must(template.New("picker").Parse(`<!doctype html>
+<html><head><title>Mock OIDC sign-in</title></head>
+<body style="font-family: sans-serif; max-width: 28rem; margin: 4rem auto">
+<h2>Mock OIDC sign-in</h2>
+<form method="post" action="/authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}{{range .Users}}<p><button name="login_hint" value="{{.Email}}">{{.Email}}{{if .Groups}} ({{range $i, $g := .Groups}}{{if $i}}, {{end}}{{$g}}{{end}}){{end}}</button></p>
+{{end}}</form>
+</body></html>`))
+
+// authorize signs in the login_hint user, or shows a picker of the configured users
+func (s *server) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client_id") != s.clientID || r.Form.Get("redirect_uri") == "" || r.Form.Get("response_type") != "code" {
+		http.Error(w, "invalid client_id, redirect_uri or response_type", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("code_challenge_method") != "S256" || r.Form.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	email := r.Form.Get("login_hint")
+	if email == "" {
+		params := map[string]string{}
+		for name := range r.Form {
+			params[name] = r.Form.Get(name)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		pickerTemplate.Execute(w, map[string]interface{}{"Params": params, "Users": s.users})
+		return
+	}
+
+	u := user{Email: email}
+	for _, known := range s.users {
+		if strings.EqualFold(known.Email, email) {
+			u = known
+		}
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = grant{
+		user:          u,
+		clientID:      r.Form.Get("client_id"),
+		redirectURI:   r.Form.Get("redirect_uri"),
+		nonce:         r.Form.Get("nonce"),
+		codeChallenge: r.Form.Get("code_challenge"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token redeems an authorization code for a signed ID token
+func (s *server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if clientID != s.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.clientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	g, found := s.codes[r.Form.Get("code")]
+	delete(s.codes, r.Form.Get("code"))
+	s.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !found || time.Now().After(g.expiresAt) || g.clientID != clientID || g.redirectURI != r.Form.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != g.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	name, _, _ := strings.Cut(g.user.Email, "@")
+	claims := jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            "mock|" + strings.ToLower(g.user.Email),
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email":          g.user.Email,
+		"email_verified": true,
+		"name":           name,
+		"groups":         g.user.Groups,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.tokens[accessToken] = claims
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (s *server) userinfo(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	claims, ok := s.tokens[token]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func (s *server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
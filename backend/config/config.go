@@ -3,20 +3,36 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Crypto   CryptoConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Crypto      CryptoConfig
+	Jobs        JobsConfig
+	Currency    CurrencyConfig
+	Inventory   InventoryConfig
+	Login       LoginConfig
+	MFA         MFAConfig
+	Mail        MailConfig
+	Password    PasswordConfig
+	OIDC        OIDCConfig
+	Webhooks    WebhooksConfig
+	Attachments AttachmentsConfig
 }
 
 type ServerConfig struct {
 	Port         string
 	Environment  string
 	AllowOrigins []string
+	AppURL       string // Frontend URL used in links sent by email
+
+	// Reverse proxies whose X-Forwarded-For header is trusted for the client
+	// IP; with none, the connection's address is used
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -33,12 +49,96 @@ type CryptoConfig struct {
 	EncryptionKey string
 }
 
+type JobsConfig struct {
+	Workers      int
+	MaxAttempts  int
+	PollInterval time.Duration
+	RetryBackoff time.Duration // Delay before the first retry, doubled on each attempt
+	MaxBackoff   time.Duration
+}
+
+type InventoryConfig struct {
+	CheckInterval time.Duration // How often stock is compared with each product's minimum
+	AutoReplenish bool          // Raise replenishment requests for new low stock alerts
+}
+
+type LoginConfig struct {
+	MaxAccountFailures int           // Failed logins for one email before it is locked out
+	MaxIPFailures      int           // Failed logins from one IP before it is locked out
+	FailureWindow      time.Duration // Failures older than this are forgotten
+	LockoutDuration    time.Duration
+}
+
+type MFAConfig struct {
+	Enforce         bool          // Require two-factor authentication for admins and general managers
+	Issuer          string        // Name shown in authenticator apps
+	ChallengeExpiry time.Duration // How long a login has to complete its second factor
+}
+
+type MailConfig struct {
+	Driver       string // smtp, or log to write messages to OutboxDir
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string
+}
+
+type PasswordConfig struct {
+	ResetExpiry   time.Duration // How long a password reset link is valid
+	InviteExpiry  time.Duration // How long an invitation link is valid
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DenyListFile  string        // Optional file of passwords to reject, one per line, on top of the built-in list
+	MaxAge        time.Duration // Passwords older than this must be changed at the next login; 0 disables
+	HistorySize   int           // Number of recent passwords, including the current one, that cannot be reused
+}
+
+type OIDCConfig struct {
+	IssuerURL     string // Identity provider; SSO is off when empty
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string // Frontend page the provider redirects back to
+	Scopes        string
+	ProviderName  string // Shown on the sign-in button
+	GroupsClaim   string
+	RoleMapping   string // group=role pairs, comma separated
+	DefaultRole   string // Role of provisioned users in no mapped group; empty refuses them
+	AutoProvision bool   // Create users on their first SSO login
+	SyncRoles     bool   // Update roles from mapped groups at every SSO login
+	TrustEmail    bool   // Link accounts by email even when the provider does not send email_verified
+}
+
+type WebhooksConfig struct {
+	Timeout      time.Duration // How long a receiver has to respond to a delivery
+	AllowPrivate bool          // Deliver to loopback and private network addresses
+}
+
+type AttachmentsConfig struct {
+	Dir     string // Where request attachments are stored; must not be served publicly
+	MaxSize int64  // Largest attachment accepted, in bytes
+}
+
+type CurrencyConfig struct {
+	BaseCurrency string // Reporting currency request amounts are normalized to
+	RatesFile    string // Optional CSV of exchange rates imported at startup
+}
+
 func Load() *Config {
+	appURL := strings.TrimRight(getEnv("APP_URL", "http://localhost:3000"), "/")
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"),
 			Environment:  getEnv("ENVIRONMENT", "development"),
 			AllowOrigins: []string{getEnv("CORS_ORIGIN", "http://localhost:3000")},
+			AppURL:       appURL,
+
+			TrustedProxies: getListEnv("TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Path: getEnv("DATABASE_PATH", "./vista.db"),
@@ -51,6 +151,75 @@ func Load() *Config {
 		Crypto: CryptoConfig{
 			EncryptionKey: getEnv("ENCRYPTION_KEY", "32-byte-long-key-for-aes256!!!!!"), // Must be 32 bytes for AES-256
 		},
+		Jobs: JobsConfig{
+			Workers:      getIntEnv("JOB_WORKERS", 2),
+			MaxAttempts:  getIntEnv("JOB_MAX_ATTEMPTS", 5),
+			PollInterval: 5 * time.Second,
+			RetryBackoff: getDurationEnv("JOB_RETRY_BACKOFF", 1*time.Minute),
+			MaxBackoff:   getDurationEnv("JOB_MAX_BACKOFF", 1*time.Hour),
+		},
+		Currency: CurrencyConfig{
+			BaseCurrency: getEnv("BASE_CURRENCY", "MXN"),
+			RatesFile:    getEnv("EXCHANGE_RATES_FILE", ""),
+		},
+		Inventory: InventoryConfig{
+			CheckInterval: getDurationEnv("INVENTORY_CHECK_INTERVAL", 1*time.Hour),
+			AutoReplenish: getBoolEnv("AUTO_REPLENISH", false),
+		},
+		Login: LoginConfig{
+			MaxAccountFailures: getIntEnv("LOGIN_MAX_FAILURES", 5),
+			MaxIPFailures:      getIntEnv("LOGIN_MAX_IP_FAILURES", 20),
+			FailureWindow:      getDurationEnv("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			LockoutDuration:    getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		},
+		MFA: MFAConfig{
+			Enforce:         getBoolEnv("MFA_ENFORCE", true),
+			Issuer:          getEnv("MFA_ISSUER", "IRIS Vista"),
+			ChallengeExpiry: getDurationEnv("MFA_CHALLENGE_EXPIRY", 5*time.Minute),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "IRIS Vista <no-reply@localhost>"),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getIntEnv("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			OutboxDir:    getEnv("MAIL_OUTBOX_DIR", "./mail"),
+		},
+		Password: PasswordConfig{
+			ResetExpiry:   getDurationEnv("PASSWORD_RESET_EXPIRY", 1*time.Hour),
+			InviteExpiry:  getDurationEnv("INVITE_EXPIRY", 72*time.Hour),
+			MinLength:     getIntEnv("PASSWORD_MIN_LENGTH", 10),
+			RequireUpper:  getBoolEnv("PASSWORD_REQUIRE_UPPERCASE", true),
+			RequireLower:  getBoolEnv("PASSWORD_REQUIRE_LOWERCASE", true),
+			RequireDigit:  getBoolEnv("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol: getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+			DenyListFile:  getEnv("PASSWORD_DENYLIST_FILE", ""),
+			MaxAge:        time.Duration(getIntEnv("PASSWORD_MAX_AGE_DAYS", 90)) * 24 * time.Hour,
+			HistorySize:   getIntEnv("PASSWORD_HISTORY", 5),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:     getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:      getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:   getEnv("OIDC_REDIRECT_URL", appURL+"/login"),
+			Scopes:        getEnv("OIDC_SCOPES", "openid email profile"),
+			ProviderName:  getEnv("OIDC_PROVIDER_NAME", "Single sign-on"),
+			GroupsClaim:   getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			RoleMapping:   getEnv("OIDC_ROLE_MAPPING", ""),
+			DefaultRole:   getEnv("OIDC_DEFAULT_ROLE", "employee"),
+			AutoProvision: getBoolEnv("OIDC_AUTO_PROVISION", true),
+			SyncRoles:     getBoolEnv("OIDC_SYNC_ROLES", true),
+			TrustEmail:    getBoolEnv("OIDC_TRUST_EMAIL", false),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:      time.Duration(getIntEnv("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+			AllowPrivate: getBoolEnv("WEBHOOK_ALLOW_PRIVATE", false),
+		},
+		Attachments: AttachmentsConfig{
+			Dir:     getEnv("ATTACHMENTS_DIR", "./attachments"),
+			MaxSize: int64(getIntEnv("ATTACHMENT_MAX_SIZE_MB", 10)) * 1024 * 1024,
+		},
 	}
 }
 
@@ -61,6 +230,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getListEnv splits a comma separated value, returning nil when it is unset
+func getListEnv(key string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if minutes, err := strconv.Atoi(value); err == nil {
@@ -69,3 +249,21 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"strings"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -18,7 +19,14 @@ func InitDatabase(cfg *Config) (*gorm.DB, error) {
 		logLevel = logger.Silent
 	}
 
-	db, err := gorm.Open(sqlite.Open(cfg.Database.Path), &gorm.Config{
+	// Background workers write concurrently with request handlers, so wait
+	// for locks instead of failing immediately with SQLITE_BUSY
+	dsn := cfg.Database.Path
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_busy_timeout=5000"
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 	})
 	if err != nil {
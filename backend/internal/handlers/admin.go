@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -9,6 +11,13 @@ import (
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/attachments"
+	"vista-backend/internal/services/budget"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/services/jobs"
 	"vista-backend/pkg/crypto"
 	"vista-backend/pkg/response"
 )
@@ -17,13 +26,25 @@ type AdminHandler struct {
 	db            *gorm.DB
 	encryptionSvc *crypto.EncryptionService
 	amazonSvc     *amazon.AutomationService
+	jobQueue      *jobs.Queue
+	budgetTracker *budget.Tracker
+	converter     *currency.Converter
+	inventorySvc  *inventory.Service
+	store         *attachments.Store
+	events        *events.Dispatcher
 }
 
-func NewAdminHandler(db *gorm.DB, encryptionSvc *crypto.EncryptionService, amazonSvc *amazon.AutomationService) *AdminHandler {
+func NewAdminHandler(db *gorm.DB, encryptionSvc *crypto.EncryptionService, amazonSvc *amazon.AutomationService, jobQueue *jobs.Queue, budgetTracker *budget.Tracker, converter *currency.Converter, inventorySvc *inventory.Service, store *attachments.Store, dispatcher *events.Dispatcher) *AdminHandler {
 	return &AdminHandler{
 		db:            db,
 		encryptionSvc: encryptionSvc,
 		amazonSvc:     amazonSvc,
+		jobQueue:      jobQueue,
+		budgetTracker: budgetTracker,
+		converter:     converter,
+		inventorySvc:  inventorySvc,
+		store:         store,
+		events:        dispatcher,
 	}
 }
 
@@ -50,6 +71,41 @@ type AmazonConfigResponse struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+func amazonConfigToResponse(config models.AmazonConfig) AmazonConfigResponse {
+	return AmazonConfigResponse{
+		ID:          config.ID,
+		Email:       config.Email,
+		Marketplace: config.Marketplace,
+		HasPassword: config.EncryptedPassword != "",
+		IsActive:    config.IsActive,
+		LastLoginAt: config.LastLoginAt,
+		LastTestAt:  config.LastTestAt,
+		TestStatus:  config.TestStatus,
+		TestMessage: config.TestMessage,
+		CreatedAt:   config.CreatedAt,
+		UpdatedAt:   config.UpdatedAt,
+	}
+}
+
+// amazonConfigAuditState is the Amazon configuration recorded in the audit log.
+// The password is never recorded, only whether it changed.
+type amazonConfigAuditState struct {
+	Email           string `json:"email"`
+	Marketplace     string `json:"marketplace"`
+	HasPassword     bool   `json:"has_password"`
+	PasswordChanged bool   `json:"password_changed,omitempty"`
+	IsActive        bool   `json:"is_active"`
+}
+
+func amazonConfigAuditSnapshot(config models.AmazonConfig) amazonConfigAuditState {
+	return amazonConfigAuditState{
+		Email:       config.Email,
+		Marketplace: config.Marketplace,
+		HasPassword: config.EncryptedPassword != "",
+		IsActive:    config.IsActive,
+	}
+}
+
 // GetAmazonConfig returns the current Amazon configuration
 func (h *AdminHandler) GetAmazonConfig(c *gin.Context) {
 	var config models.AmazonConfig
@@ -66,19 +122,7 @@ func (h *AdminHandler) GetAmazonConfig(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, AmazonConfigResponse{
-		ID:          config.ID,
-		Email:       config.Email,
-		Marketplace: config.Marketplace,
-		HasPassword: config.EncryptedPassword != "",
-		IsActive:    config.IsActive,
-		LastLoginAt: config.LastLoginAt,
-		LastTestAt:  config.LastTestAt,
-		TestStatus:  config.TestStatus,
-		TestMessage: config.TestMessage,
-		CreatedAt:   config.CreatedAt,
-		UpdatedAt:   config.UpdatedAt,
-	})
+	response.Success(c, amazonConfigToResponse(config))
 }
 
 // SaveAmazonConfig saves or updates the Amazon configuration
@@ -94,6 +138,11 @@ func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 	var config models.AmazonConfig
 	isNew := h.db.First(&config).Error == gorm.ErrRecordNotFound
 
+	var before interface{}
+	if !isNew {
+		before = amazonConfigAuditSnapshot(config)
+	}
+
 	config.Email = req.Email
 
 	if req.Marketplace != "" {
@@ -117,32 +166,35 @@ func (h *AdminHandler) SaveAmazonConfig(c *gin.Context) {
 		config.IsActive = true
 	}
 
+	after := amazonConfigAuditSnapshot(config)
+	after.PasswordChanged = req.Password != ""
+
 	if isNew {
 		config.CreatedByID = userID
-		if err := h.db.Create(&config).Error; err != nil {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&config).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceAmazonConfig, config.ID, nil, after)
+		})
+		if err != nil {
 			response.InternalServerError(c, "Failed to create Amazon config")
 			return
 		}
 	} else {
-		if err := h.db.Save(&config).Error; err != nil {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&config).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceAmazonConfig, config.ID, before, after)
+		})
+		if err != nil {
 			response.InternalServerError(c, "Failed to update Amazon config")
 			return
 		}
 	}
 
-	response.SuccessWithMessage(c, "Amazon configuration saved", AmazonConfigResponse{
-		ID:          config.ID,
-		Email:       config.Email,
-		Marketplace: config.Marketplace,
-		HasPassword: config.EncryptedPassword != "",
-		IsActive:    config.IsActive,
-		LastLoginAt: config.LastLoginAt,
-		LastTestAt:  config.LastTestAt,
-		TestStatus:  config.TestStatus,
-		TestMessage: config.TestMessage,
-		CreatedAt:   config.CreatedAt,
-		UpdatedAt:   config.UpdatedAt,
-	})
+	response.SuccessWithMessage(c, "Amazon configuration saved", amazonConfigToResponse(config))
 }
 
 // TestAmazonConnection tests the Amazon Business connection
@@ -173,7 +225,7 @@ func (h *AdminHandler) TestAmazonConnection(c *gin.Context) {
 	h.amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
 
 	// Initialize browser
-	if err := h.amazonSvc.Initialize(); err != nil {
+	if err := h.amazonSvc.Initialize(c.Request.Context()); err != nil {
 		now := time.Now()
 		config.LastTestAt = &now
 		config.TestStatus = "failed"
@@ -185,7 +237,7 @@ func (h *AdminHandler) TestAmazonConnection(c *gin.Context) {
 	}
 
 	// Try to login
-	if err := h.amazonSvc.Login(); err != nil {
+	if err := h.amazonSvc.Login(c.Request.Context()); err != nil {
 		now := time.Now()
 		config.LastTestAt = &now
 		config.TestStatus = "failed"
@@ -265,7 +317,8 @@ func (h *AdminHandler) GetApprovedOrders(c *gin.Context) {
 	})
 }
 
-// MarkAsPurchased marks an approved order as purchased
+// MarkAsPurchased marks an approved order as purchased. Invoices can be
+// attached by sending a multipart form with the files in "invoices".
 func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -274,9 +327,9 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	}
 
 	var input struct {
-		Notes string `json:"notes"`
+		Notes string `json:"notes" form:"notes"`
 	}
-	c.ShouldBindJSON(&input)
+	c.ShouldBind(&input)
 
 	userID := middleware.GetUserID(c)
 
@@ -295,6 +348,12 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 		return
 	}
 
+	invoices, ok := storeUploads(c, h.store, "invoices")
+	if !ok {
+		return
+	}
+
+	before := requestAuditState(&request, "")
 	now := time.Now()
 	request.Status = models.StatusPurchased
 	request.PurchasedByID = &userID
@@ -302,22 +361,75 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	request.PurchaseNotes = input.Notes
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&request).Error; err != nil {
+		// Only while still approved, so that of two concurrent calls only one
+		// spends the budget and moves the stock
+		if err := approval.SaveUnchanged(tx, &request, models.StatusApproved, request.CurrentStep); err != nil {
+			return err
+		}
+
+		if err := h.budgetTracker.Spend(tx, &request); err != nil {
+			return err
+		}
+
+		// Fulfill catalog lines from the stock reserved at approval
+		issued, err := h.inventorySvc.Issue(tx, &request, userID)
+		if err != nil {
+			return err
+		}
+
+		// Replenishment orders are booked into stock
+		received, err := h.inventorySvc.Receive(tx, &request, userID)
+		if err != nil {
+			return err
+		}
+
+		comment := "Marked as purchased"
+		if issued > 0 {
+			comment += fmt.Sprintf(" (%d catalog line(s) issued from stock)", issued)
+		}
+		if received > 0 {
+			comment += fmt.Sprintf(" (%d line(s) received into stock)", received)
+		}
+		if len(invoices) > 0 {
+			comment += fmt.Sprintf(" (%d invoice(s) attached)", len(invoices))
+		}
+		history := models.NewHistory(request.ID, userID, models.ActionCompleted, models.StatusApproved, models.StatusPurchased, comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, c, models.AuditActionPurchase, models.AuditResourceRequest, request.ID, before, requestAuditState(&request, comment)); err != nil {
+			return err
+		}
+
+		created, err := createAttachments(tx, &request, userID, models.AttachmentInvoice, nil, invoices)
+		if err != nil {
 			return err
 		}
+		for i := range created {
+			if err := recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceAttachment, created[i].ID, nil, attachmentAuditState(&created[i])); err != nil {
+				return err
+			}
+		}
 
-		history := models.NewHistory(request.ID, userID, models.ActionCompleted, models.StatusApproved, models.StatusPurchased, "Marked as purchased")
-		return tx.Create(history).Error
+		return h.events.Dispatch(tx, events.Event{Type: events.OrderPurchased, Request: &request, ActorID: userID, Comment: input.Notes})
 	})
 
 	if err != nil {
-		response.InternalServerError(c, "Failed to mark as purchased")
+		removeFiles(h.store, invoices)
+		if errors.Is(err, approval.ErrConcurrentDecision) {
+			response.Conflict(c, "The request was marked as purchased or changed in the meantime; reload it and try again")
+		} else {
+			response.InternalServerError(c, "Failed to mark as purchased")
+		}
 		return
 	}
+	h.jobQueue.Wake()
 
 	// Reload with relations
 	h.db.
 		Preload("Requester").
+		Preload("Items", preloadRequestItems).
 		Preload("ApprovedBy").
 		Preload("PurchasedBy").
 		First(&request, request.ID)
@@ -325,7 +437,7 @@ func (h *AdminHandler) MarkAsPurchased(c *gin.Context) {
 	response.SuccessWithMessage(c, "Order marked as purchased", requestToResponse(request))
 }
 
-// RetryAddToCart retries adding an Amazon product to cart
+// RetryAddToCart queues another attempt at adding an Amazon product to cart
 func (h *AdminHandler) RetryAddToCart(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -353,6 +465,11 @@ func (h *AdminHandler) RetryAddToCart(c *gin.Context) {
 		return
 	}
 
+	if request.AddedToCart {
+		response.BadRequest(c, "Product is already in the Amazon cart")
+		return
+	}
+
 	// Check Amazon config
 	var config models.AmazonConfig
 	if err := h.db.First(&config).Error; err != nil {
@@ -365,46 +482,30 @@ func (h *AdminHandler) RetryAddToCart(c *gin.Context) {
 		return
 	}
 
-	// Decrypt password
-	password, err := h.encryptionSvc.Decrypt(config.EncryptedPassword)
+	active, err := h.jobQueue.ActiveJobForRequest(models.JobTypeAmazonCart, request.ID)
 	if err != nil {
-		response.InternalServerError(c, "Failed to decrypt credentials")
+		response.InternalServerError(c, "Failed to check pending jobs")
 		return
 	}
-
-	// Set credentials
-	h.amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
-
-	// Initialize browser
-	if err := h.amazonSvc.Initialize(); err != nil {
-		response.BadRequest(c, "Failed to initialize browser: "+err.Error())
+	if active != nil {
+		response.Conflict(c, "Adding to cart is already queued for this request")
 		return
 	}
 
-	// Login if needed
-	if !h.amazonSvc.IsLoggedIn() {
-		if err := h.amazonSvc.Login(); err != nil {
-			response.BadRequest(c, "Failed to login to Amazon: "+err.Error())
-			return
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := h.jobQueue.EnqueueAmazonCart(tx, request.ID); err != nil {
+			return err
 		}
-	}
-
-	// Add to cart
-	if err := h.amazonSvc.AddToCart(request.URL, request.Quantity); err != nil {
-		request.CartError = "Retry failed: " + err.Error()
-		h.db.Save(&request)
-		response.BadRequest(c, request.CartError)
+		request.CartError = ""
+		return tx.Model(&request).Update("cart_error", "").Error
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to queue add to cart")
 		return
 	}
+	h.jobQueue.Wake()
 
-	// Update as successful
-	now := time.Now()
-	request.AddedToCart = true
-	request.AddedToCartAt = &now
-	request.CartError = ""
-	h.db.Save(&request)
-
-	response.SuccessWithMessage(c, "Product added to Amazon cart", requestToResponse(request))
+	response.SuccessWithMessage(c, "Add to cart queued", requestToResponse(request))
 }
 
 // GetDashboardStats returns admin dashboard statistics
@@ -420,6 +521,13 @@ func (h *AdminHandler) GetDashboardStats(c *gin.Context) {
 		AmazonInCart     int64 `json:"amazon_in_cart"`
 		PendingManual    int64 `json:"pending_manual"`
 		AmazonConfigured bool  `json:"amazon_configured"`
+
+		// Totals in the base reporting currency
+		BaseCurrency        string  `json:"base_currency"`
+		PendingAmount       float64 `json:"pending_amount"`
+		ApprovedAmount      float64 `json:"approved_amount"`
+		PurchasedAmount     float64 `json:"purchased_amount"`
+		UnconvertedRequests int64   `json:"unconverted_requests"` // Requests missing an exchange rate
 	}
 
 	h.db.Model(&models.User{}).Count(&stats.TotalUsers)
@@ -436,5 +544,22 @@ func (h *AdminHandler) GetDashboardStats(c *gin.Context) {
 	var config models.AmazonConfig
 	stats.AmazonConfigured = h.db.First(&config).Error == nil && config.IsConfigured()
 
+	base := h.converter.Base()
+	stats.BaseCurrency = base
+	stats.PendingAmount = sumNormalizedAmount(h.db.Where("status = ?", models.StatusPending), base)
+	stats.ApprovedAmount = sumNormalizedAmount(h.db.Where("status = ?", models.StatusApproved), base)
+	stats.PurchasedAmount = sumNormalizedAmount(h.db.Where("status = ?", models.StatusPurchased), base)
+	h.db.Model(&models.PurchaseRequest{}).Where("normalized_amount IS NULL OR base_currency <> ?", base).Count(&stats.UnconvertedRequests)
+
 	response.Success(c, stats)
 }
+
+// sumNormalizedAmount totals the base currency amounts of the requests matched by query
+func sumNormalizedAmount(query *gorm.DB, base string) float64 {
+	var total float64
+	query.Model(&models.PurchaseRequest{}).
+		Where("base_currency = ?", base).
+		Select("COALESCE(SUM(normalized_amount), 0)").
+		Scan(&total)
+	return total
+}
@@ -0,0 +1,49 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/models"
+)
+
+func TestMarkAsPurchasedTwiceSpendsOnce(t *testing.T) {
+	e := newEnv(t)
+	b := e.createBudget(t, 10000)
+	requester := e.createUser(t, "requester", models.RoleEmployee)
+	gm := e.createUser(t, "gm", models.RoleGeneralManager)
+	buyer := e.createUser(t, "buyer", models.RoleSupplyChainManager)
+	request := e.submitRequest(t, requester, 500)
+
+	if w := e.call(t, e.approvals.ApproveRequest, gm, request.ID, gin.H{}); w.Code != http.StatusOK {
+		t.Fatalf("approve: got %d %s, want 200", w.Code, w.Body)
+	}
+
+	var first int
+	afterFirstLoad(t, e.db, func() {
+		first = e.call(t, e.admin.MarkAsPurchased, buyer, request.ID, gin.H{}).Code
+	})
+	w := e.call(t, e.admin.MarkAsPurchased, buyer, request.ID, gin.H{})
+
+	if first != http.StatusOK {
+		t.Fatalf("first call: got %d, want 200", first)
+	}
+	if w.Code != http.StatusConflict {
+		t.Errorf("second call: got %d %s, want 409", w.Code, w.Body)
+	}
+	got := loadBudget(t, e.db, b.ID)
+	if got.CommittedAmount != 0 || got.SpentAmount != 500 {
+		t.Errorf("got committed %.2f spent %.2f, want 0 and 500", got.CommittedAmount, got.SpentAmount)
+	}
+
+	var completed int64
+	if err := e.db.Model(&models.RequestHistory{}).
+		Where("request_id = ? AND action = ?", request.ID, models.ActionCompleted).
+		Count(&completed).Error; err != nil {
+		t.Fatalf("count history: %v", err)
+	}
+	if completed != 1 {
+		t.Errorf("got %d purchase history entries, want 1", completed)
+	}
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/visibility"
+	"vista-backend/pkg/response"
+)
+
+// Request statuses counted as spend once approved
+var spendStatuses = []models.RequestStatus{models.StatusApproved, models.StatusPurchased}
+
+type AnalyticsHandler struct {
+	db        *gorm.DB
+	converter *currency.Converter
+}
+
+func NewAnalyticsHandler(db *gorm.DB, converter *currency.Converter) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		db:        db,
+		converter: converter,
+	}
+}
+
+// analyticsFilter holds the date range and requester filters shared by all analytics endpoints
+type analyticsFilter struct {
+	User       *models.User // Caller; only the requests they can list are counted
+	From       *time.Time
+	To         *time.Time // Exclusive
+	Department string
+	CostCenter string
+	Limit      int
+}
+
+type SpendByMonth struct {
+	Month           string  `json:"month"`
+	Requests        int64   `json:"requests"`
+	Amount          float64 `json:"amount"`
+	PurchasedAmount float64 `json:"purchased_amount"`
+}
+
+type SpendRanking struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Requests int64   `json:"requests"`
+	Amount   float64 `json:"amount"`
+}
+
+type LeadTimes struct {
+	ApprovedRequests   int64    `json:"approved_requests"`
+	PurchasedRequests  int64    `json:"purchased_requests"`
+	AvgHoursToApproval *float64 `json:"avg_hours_to_approval"` // CreatedAt → ApprovedAt
+	AvgHoursToPurchase *float64 `json:"avg_hours_to_purchase"` // ApprovedAt → PurchasedAt
+	AvgHoursEndToEnd   *float64 `json:"avg_hours_end_to_end"`  // CreatedAt → PurchasedAt
+	MaxHoursToApproval *float64 `json:"max_hours_to_approval"`
+}
+
+type RejectionRate struct {
+	Department string  `json:"department"`
+	Decided    int64   `json:"decided"`
+	Rejected   int64   `json:"rejected"`
+	Rate       float64 `json:"rate"` // Percentage of decided requests that were rejected
+}
+
+// parseAnalyticsFilter reads from/to (YYYY-MM-DD, inclusive), department, cost_center and limit
+func parseAnalyticsFilter(c *gin.Context) (*analyticsFilter, bool) {
+	f := &analyticsFilter{
+		Department: c.Query("department"),
+		CostCenter: c.Query("cost_center"),
+		Limit:      10,
+	}
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			response.BadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return nil, false
+		}
+		f.From = &date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			response.BadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return nil, false
+		}
+		end := date.AddDate(0, 0, 1)
+		f.To = &end
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 100 {
+		f.Limit = limit
+	}
+
+	return f, true
+}
+
+// parseFilter reads the analytics filter and loads the caller into it
+func (h *AnalyticsHandler) parseFilter(c *gin.Context) (*analyticsFilter, bool) {
+	f, ok := parseAnalyticsFilter(c)
+	if !ok {
+		return nil, false
+	}
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return nil, false
+	}
+	f.User = user
+	return f, true
+}
+
+// query returns the purchase requests the caller can list joined with their
+// requester, filtered by department, cost center and the date range applied
+// to dateColumn
+func (h *AnalyticsHandler) query(f *analyticsFilter, dateColumn string) *gorm.DB {
+	q := h.db.Model(&models.PurchaseRequest{}).
+		Joins("JOIN users ON users.id = purchase_requests.requester_id").
+		Scopes(visibility.Requests(f.User))
+
+	if f.Department != "" {
+		q = q.Where("users.department = ?", f.Department)
+	}
+	if f.CostCenter != "" {
+		q = q.Where("users.cost_center = ?", f.CostCenter)
+	}
+	if f.From != nil {
+		q = q.Where("purchase_requests."+dateColumn+" >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("purchase_requests."+dateColumn+" < ?", *f.To)
+	}
+	return q
+}
+
+// spendQuery returns approved or purchased requests with a base currency amount,
+// dated by approval
+func (h *AnalyticsHandler) spendQuery(f *analyticsFilter) *gorm.DB {
+	return h.query(f, "approved_at").
+		Where("purchase_requests.status IN ?", spendStatuses).
+		Where("purchase_requests.base_currency = ?", h.converter.Base())
+}
+
+// GetSummary returns headline totals for the filtered period
+func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
+	f, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	var summary struct {
+		BaseCurrency     string  `json:"base_currency"`
+		TotalRequests    int64   `json:"total_requests"`
+		Pending          int64   `json:"pending"`
+		Approved         int64   `json:"approved"`
+		Rejected         int64   `json:"rejected"`
+		Purchased        int64   `json:"purchased"`
+		TotalSpend       float64 `json:"total_spend"`
+		PurchasedSpend   float64 `json:"purchased_spend"`
+		AverageRequest   float64 `json:"average_request"`
+		RejectionRate    float64 `json:"rejection_rate"`
+		UnconvertedSpend int64   `json:"unconverted_spend"` // Approved requests excluded for lack of an exchange rate
+	}
+	summary.BaseCurrency = h.converter.Base()
+
+	h.query(f, "created_at").Count(&summary.TotalRequests)
+	h.query(f, "created_at").Where("purchase_requests.status IN ?", []models.RequestStatus{models.StatusPending, models.StatusInfoRequested}).Count(&summary.Pending)
+	h.query(f, "created_at").Where("purchase_requests.status = ?", models.StatusApproved).Count(&summary.Approved)
+	h.query(f, "created_at").Where("purchase_requests.status = ?", models.StatusRejected).Count(&summary.Rejected)
+	h.query(f, "created_at").Where("purchase_requests.status = ?", models.StatusPurchased).Count(&summary.Purchased)
+
+	h.spendQuery(f).Select("COALESCE(SUM(purchase_requests.normalized_amount), 0)").Scan(&summary.TotalSpend)
+	h.spendQuery(f).Where("purchase_requests.status = ?", models.StatusPurchased).
+		Select("COALESCE(SUM(purchase_requests.normalized_amount), 0)").Scan(&summary.PurchasedSpend)
+	h.spendQuery(f).Select("COALESCE(AVG(purchase_requests.normalized_amount), 0)").Scan(&summary.AverageRequest)
+	h.query(f, "approved_at").
+		Where("purchase_requests.status IN ?", spendStatuses).
+		Where("purchase_requests.normalized_amount IS NULL OR purchase_requests.base_currency <> ?", h.converter.Base()).
+		Count(&summary.UnconvertedSpend)
+
+	if decided := summary.Approved + summary.Purchased + summary.Rejected; decided > 0 {
+		summary.RejectionRate = float64(summary.Rejected) / float64(decided) * 100
+	}
+
+	response.Success(c, summary)
+}
+
+// GetSpendByMonth returns approved spend per month of approval
+func (h *AnalyticsHandler) GetSpendByMonth(c *gin.Context) {
+	f, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	var rows []SpendByMonth
+	err := h.spendQuery(f).
+		Select("STRFTIME('%Y-%m', purchase_requests.approved_at) AS month, " +
+			"COUNT(*) AS requests, " +
+			"SUM(purchase_requests.normalized_amount) AS amount, " +
+			"SUM(CASE WHEN purchase_requests.status = 'purchased' THEN purchase_requests.normalized_amount ELSE 0 END) AS purchased_amount").
+		Group("month").
+		Order("month ASC").
+		Scan(&rows).Error
+	if err != nil {
+		response.InternalServerError(c, "Failed to calculate spend by month")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"base_currency": h.converter.Base(),
+		"months":        rows,
+	})
+}
+
+// GetTopRequesters returns the users with the highest approved spend
+func (h *AnalyticsHandler) GetTopRequesters(c *gin.Context) {
+	h.ranking(c, "CAST(users.id AS TEXT)", "users.name", "top requesters")
+}
+
+// GetTopDepartments returns the requester departments with the highest approved spend
+func (h *AnalyticsHandler) GetTopDepartments(c *gin.Context) {
+	h.ranking(c, "users.department", "users.department", "top departments")
+}
+
+// GetTopCostCenters returns the requester cost centers with the highest approved spend
+func (h *AnalyticsHandler) GetTopCostCenters(c *gin.Context) {
+	h.ranking(c, "users.cost_center", "users.cost_center", "top cost centers")
+}
+
+// GetTopSites returns the sites products were requested from with the highest approved spend
+func (h *AnalyticsHandler) GetTopSites(c *gin.Context) {
+	h.ranking(c, "purchase_requests.site_name", "purchase_requests.site_name", "top sites")
+}
+
+// ranking groups approved spend by keyColumn and returns the largest groups
+func (h *AnalyticsHandler) ranking(c *gin.Context, keyColumn, labelColumn, name string) {
+	f, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	var rows []SpendRanking
+	err := h.spendQuery(f).
+		Select(keyColumn + " AS key, MAX(" + labelColumn + ") AS label, " +
+			"COUNT(*) AS requests, SUM(purchase_requests.normalized_amount) AS amount").
+		Group(keyColumn).
+		Order("amount DESC").
+		Limit(f.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		response.InternalServerError(c, "Failed to calculate "+name)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"base_currency": h.converter.Base(),
+		"items":         rows,
+	})
+}
+
+// GetLeadTimes returns average hours between submission, approval and purchase
+func (h *AnalyticsHandler) GetLeadTimes(c *gin.Context) {
+	f, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	var leadTimes LeadTimes
+	var approval struct {
+		Count int64
+		Avg   *float64
+		Max   *float64
+	}
+	err := h.query(f, "created_at").
+		Where("purchase_requests.approved_at IS NOT NULL").
+		Select("COUNT(*) AS count, " +
+			"AVG(" + hoursBetween("approved_at", "created_at") + ") AS avg, " +
+			"MAX(" + hoursBetween("approved_at", "created_at") + ") AS max").
+		Scan(&approval).Error
+	if err != nil {
+		response.InternalServerError(c, "Failed to calculate lead times")
+		return
+	}
+	leadTimes.ApprovedRequests = approval.Count
+	leadTimes.AvgHoursToApproval = approval.Avg
+	leadTimes.MaxHoursToApproval = approval.Max
+
+	var purchase struct {
+		Count    int64
+		ToBuy    *float64
+		EndToEnd *float64
+	}
+	err = h.query(f, "created_at").
+		Where("purchase_requests.approved_at IS NOT NULL AND purchase_requests.purchased_at IS NOT NULL").
+		Select("COUNT(*) AS count, " +
+			"AVG(" + hoursBetween("purchased_at", "approved_at") + ") AS to_buy, " +
+			"AVG(" + hoursBetween("purchased_at", "created_at") + ") AS end_to_end").
+		Scan(&purchase).Error
+	if err != nil {
+		response.InternalServerError(c, "Failed to calculate lead times")
+		return
+	}
+	leadTimes.PurchasedRequests = purchase.Count
+	leadTimes.AvgHoursToPurchase = purchase.ToBuy
+	leadTimes.AvgHoursEndToEnd = purchase.EndToEnd
+
+	response.Success(c, leadTimes)
+}
+
+// GetRejectionRates returns the share of decided requests that were rejected, overall and per department
+func (h *AnalyticsHandler) GetRejectionRates(c *gin.Context) {
+	f, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	decided := []models.RequestStatus{models.StatusApproved, models.StatusPurchased, models.StatusRejected}
+
+	var rows []RejectionRate
+	err := h.query(f, "created_at").
+		Where("purchase_requests.status IN ?", decided).
+		Select("users.department AS department, COUNT(*) AS decided, " +
+			"SUM(CASE WHEN purchase_requests.status = 'rejected' THEN 1 ELSE 0 END) AS rejected").
+		Group("users.department").
+		Order("users.department ASC").
+		Scan(&rows).Error
+	if err != nil {
+		response.InternalServerError(c, "Failed to calculate rejection rates")
+		return
+	}
+
+	overall := RejectionRate{Department: "all"}
+	for i := range rows {
+		if rows[i].Decided > 0 {
+			rows[i].Rate = float64(rows[i].Rejected) / float64(rows[i].Decided) * 100
+		}
+		overall.Decided += rows[i].Decided
+		overall.Rejected += rows[i].Rejected
+	}
+	if overall.Decided > 0 {
+		overall.Rate = float64(overall.Rejected) / float64(overall.Decided) * 100
+	}
+
+	response.Success(c, gin.H{
+		"overall":       overall,
+		"by_department": rows,
+	})
+}
+
+// hoursBetween returns the SQL expression for the hours between two request timestamps
+func hoursBetween(end, start string) string {
+	return fmt.Sprintf("(JULIANDAY(purchase_requests.%s) - JULIANDAY(purchase_requests.%s)) * 24", end, start)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type ApprovalRuleHandler struct {
+	db    *gorm.DB
+	roles *services.RoleService
+}
+
+func NewApprovalRuleHandler(db *gorm.DB, roles *services.RoleService) *ApprovalRuleHandler {
+	return &ApprovalRuleHandler{db: db, roles: roles}
+}
+
+type ApprovalRuleStepInput struct {
+	Level        int    `json:"level" binding:"required,gte=1"`
+	Name         string `json:"name" binding:"required"`
+	ApproverRole string `json:"approver_role"`
+	ApproverID   *uint  `json:"approver_id"`
+}
+
+type ApprovalRuleRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Description string                  `json:"description"`
+	MinAmount   *float64                `json:"min_amount" binding:"omitempty,gte=0"`
+	MaxAmount   *float64                `json:"max_amount" binding:"omitempty,gte=0"`
+	CostCenter  string                  `json:"cost_center"`
+	Department  string                  `json:"department"`
+	Urgency     string                  `json:"urgency" binding:"omitempty,oneof=normal urgent"`
+	Priority    int                     `json:"priority"`
+	IsActive    *bool                   `json:"is_active"`
+	Steps       []ApprovalRuleStepInput `json:"steps" binding:"required,min=1,dive"`
+}
+
+// ListApprovalRules returns all approval chain rules ordered by priority
+func (h *ApprovalRuleHandler) ListApprovalRules(c *gin.Context) {
+	var rules []models.ApprovalRule
+	if err := h.db.
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("level ASC") }).
+		Preload("Steps.Approver").
+		Order("priority DESC, id ASC").
+		Find(&rules).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch approval rules")
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// CreateApprovalRule creates a new approval chain rule
+func (h *ApprovalRuleHandler) CreateApprovalRule(c *gin.Context) {
+	var req ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if msg := h.validateRule(&req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+
+	rule := models.ApprovalRule{IsActive: true}
+	applyApprovalRuleRequest(&rule, &req)
+
+	if err := h.db.Create(&rule).Error; err != nil {
+		response.InternalServerError(c, "Failed to create approval rule")
+		return
+	}
+	// GORM skips zero values for columns with defaults on insert
+	if !rule.IsActive {
+		h.db.Model(&rule).Update("is_active", false)
+	}
+
+	h.db.Preload("Steps").Preload("Steps.Approver").First(&rule, rule.ID)
+	response.Created(c, rule)
+}
+
+// UpdateApprovalRule replaces an approval chain rule and its steps.
+// Requests already in approval keep the chain they were created with.
+func (h *ApprovalRuleHandler) UpdateApprovalRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	var rule models.ApprovalRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Approval rule not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch approval rule")
+		}
+		return
+	}
+
+	var req ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if msg := h.validateRule(&req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+
+	applyApprovalRuleRequest(&rule, &req)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", rule.ID).Delete(&models.ApprovalRuleStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&rule).Error
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to update approval rule")
+		return
+	}
+
+	h.db.Preload("Steps").Preload("Steps.Approver").First(&rule, rule.ID)
+	response.Success(c, rule)
+}
+
+// DeleteApprovalRule deletes an approval chain rule
+func (h *ApprovalRuleHandler) DeleteApprovalRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	var rule models.ApprovalRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Approval rule not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch approval rule")
+		}
+		return
+	}
+
+	if err := h.db.Delete(&rule).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete approval rule")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Approval rule deleted successfully", nil)
+}
+
+// validateRule checks the consistency of a rule request and returns an error message
+func (h *ApprovalRuleHandler) validateRule(req *ApprovalRuleRequest) string {
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MaxAmount <= *req.MinAmount {
+		return "max_amount must be greater than min_amount"
+	}
+
+	levels := make(map[int]bool, len(req.Steps))
+	for _, step := range req.Steps {
+		if levels[step.Level] {
+			return "Each step must have a unique level"
+		}
+		levels[step.Level] = true
+
+		if step.ApproverID == nil && step.ApproverRole == "" {
+			return "Each step requires an approver_role or approver_id"
+		}
+		if step.ApproverRole != "" && !h.roles.Exists(models.UserRole(step.ApproverRole)) {
+			return "Unknown approver_role for step " + step.Name
+		}
+		if step.ApproverID != nil {
+			var approver models.User
+			if err := h.db.First(&approver, *step.ApproverID).Error; err != nil {
+				return "Approver not found for step " + step.Name
+			}
+		}
+	}
+	return ""
+}
+
+func applyApprovalRuleRequest(rule *models.ApprovalRule, req *ApprovalRuleRequest) {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.MinAmount = req.MinAmount
+	rule.MaxAmount = req.MaxAmount
+	rule.CostCenter = req.CostCenter
+	rule.Department = req.Department
+	rule.Urgency = models.Urgency(req.Urgency)
+	rule.Priority = req.Priority
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	rule.Steps = make([]models.ApprovalRuleStep, len(req.Steps))
+	for i, step := range req.Steps {
+		rule.Steps[i] = models.ApprovalRuleStep{
+			Level:        step.Level,
+			Name:         step.Name,
+			ApproverRole: models.UserRole(step.ApproverRole),
+			ApproverID:   step.ApproverID,
+		}
+	}
+}
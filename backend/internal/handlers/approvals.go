@@ -1,7 +1,9 @@
 package handlers
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -9,39 +11,106 @@ import (
 	"gorm.io/gorm"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
-	"vista-backend/internal/services/amazon"
-	"vista-backend/pkg/crypto"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/budget"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/internal/services/visibility"
 	"vista-backend/pkg/response"
 )
 
 type ApprovalHandler struct {
 	db            *gorm.DB
-	amazonSvc     *amazon.AutomationService
-	encryptionSvc *crypto.EncryptionService
+	jobQueue      *jobs.Queue
+	chainSvc      *approval.ChainService
+	budgetTracker *budget.Tracker
+	converter     *currency.Converter
+	inventorySvc  *inventory.Service
+	events        *events.Dispatcher
 }
 
-func NewApprovalHandler(db *gorm.DB, amazonSvc *amazon.AutomationService, encryptionSvc *crypto.EncryptionService) *ApprovalHandler {
+func NewApprovalHandler(db *gorm.DB, jobQueue *jobs.Queue, chainSvc *approval.ChainService, budgetTracker *budget.Tracker, converter *currency.Converter, inventorySvc *inventory.Service, dispatcher *events.Dispatcher) *ApprovalHandler {
 	return &ApprovalHandler{
 		db:            db,
-		amazonSvc:     amazonSvc,
-		encryptionSvc: encryptionSvc,
+		jobQueue:      jobQueue,
+		chainSvc:      chainSvc,
+		budgetTracker: budgetTracker,
+		converter:     converter,
+		inventorySvc:  inventorySvc,
+		events:        dispatcher,
 	}
 }
 
 type ApprovalAction struct {
-	Comment string `json:"comment"`
+	Comment        string `json:"comment"`
+	OverrideBudget bool   `json:"override_budget"` // Approve even if the cost center budget is exceeded (requests.override_budget)
 }
 
-// ListPendingApprovals returns a list of pending requests for approval
+var errOverrideNotAllowed = errors.New("budget override not allowed")
+
+// currentUser loads the authenticated user from the database with the
+// permissions of their role and their scopes
+func currentUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
+	return visibility.LoadUser(db, middleware.GetUserID(c), middleware.GetUserPermissions(c))
+}
+
+// withDelegator adds the delegator a step was acted on for to an audit state
+func withDelegator(state gin.H, step *models.ApprovalStep) gin.H {
+	if step.OnBehalfOfID != nil {
+		state["on_behalf_of"] = *step.OnBehalfOfID
+	}
+	return state
+}
+
+// preloadApprovalSteps preloads a request's approval chain ordered by level
+func preloadApprovalSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("approval_steps.level ASC")
+}
+
+// loadRequestForDecision loads a request with its approval chain, starting the
+// chain for requests that predate it. Writes the error response on failure.
+func (h *ApprovalHandler) loadRequestForDecision(c *gin.Context, id uint64) (*models.PurchaseRequest, bool) {
+	var request models.PurchaseRequest
+	if err := h.db.Preload("ApprovalSteps", preloadApprovalSteps).First(&request, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Request not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch request")
+		}
+		return nil, false
+	}
+
+	if request.IsPending() || request.Status == models.StatusInfoRequested {
+		if err := h.chainSvc.EnsureChain(h.db, &request); err != nil {
+			response.InternalServerError(c, "Failed to resolve approval chain")
+			return nil, false
+		}
+	}
+
+	return &request, true
+}
+
+// ListPendingApprovals returns the pending requests whose current approval step
+// is assigned to the caller
 func (h *ApprovalHandler) ListPendingApprovals(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
 
 	offset := (page - 1) * perPage
 
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
+
 	query := h.db.Model(&models.PurchaseRequest{}).
 		Where("status = ?", models.StatusPending).
-		Preload("Requester")
+		Scopes(approval.AssignedTo(user)).
+		Preload("Requester").
+		Preload("ApprovalSteps", preloadApprovalSteps)
 
 	var total int64
 	query.Count(&total)
@@ -73,11 +142,23 @@ func (h *ApprovalHandler) GetApprovalDetails(c *gin.Context) {
 		return
 	}
 
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
+
 	var request models.PurchaseRequest
 	if err := h.db.
 		Preload("Requester").
+		Preload("Items", preloadRequestItems).
 		Preload("History").
 		Preload("History.User").
+		Preload("History.OnBehalfOf").
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		Preload("ApprovalSteps.Approver").
+		Preload("ApprovalSteps.ActedBy").
+		Preload("ApprovalSteps.OnBehalfOf").
 		First(&request, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
@@ -87,10 +168,16 @@ func (h *ApprovalHandler) GetApprovalDetails(c *gin.Context) {
 		return
 	}
 
+	if !visibility.CanView(user, &request) {
+		response.Forbidden(c, "Access denied")
+		return
+	}
+
 	response.Success(c, requestToResponse(request))
 }
 
-// ApproveRequest approves a purchase request
+// ApproveRequest approves the current step of a request's approval chain.
+// The request itself becomes approved once its final step is approved.
 func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -103,15 +190,14 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 		input.Comment = ""
 	}
 
-	userID := middleware.GetUserID(c)
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
 
-	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response.NotFound(c, "Request not found")
-		} else {
-			response.InternalServerError(c, "Failed to fetch request")
-		}
+	request, ok := h.loadRequestForDecision(c, id)
+	if !ok {
 		return
 	}
 
@@ -121,112 +207,138 @@ func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
 	}
 
 	oldStatus := request.Status
-	now := time.Now()
-	request.Status = models.StatusApproved
-	request.ApprovedByID = &userID
-	request.ApprovedAt = &now
-
-	// If it's an Amazon URL, try to add to cart
-	if request.IsAmazonURL && h.amazonSvc != nil {
-		go h.addToAmazonCart(&request)
-	}
+	before := requestAuditState(request, "")
+	var nextStep *models.ApprovalStep
+	var budgetCheck *budget.Check
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&request).Error; err != nil {
+		approvedStep, next, err := h.chainSvc.Approve(tx, request, user, input.Comment)
+		if err != nil {
 			return err
 		}
+		nextStep = next
 
 		comment := input.Comment
-		if comment == "" {
-			comment = "Request approved"
+		action := models.ActionStepApproved
+		if nextStep == nil {
+			now := time.Now()
+
+			// Commit the amount against the cost center budget
+			budgetCheck, err = h.budgetTracker.Check(tx, request, now)
+			if err != nil {
+				return err
+			}
+			if budgetCheck != nil {
+				if budgetCheck.Exceeded && !input.OverrideBudget {
+					return budget.ErrBudgetExceeded
+				}
+				if budgetCheck.Exceeded && !user.Can(models.PermRequestsOverrideBudget) {
+					return errOverrideNotAllowed
+				}
+				// Only an exceeded check was checked for the override permission
+				if err := h.budgetTracker.Commit(tx, request, budgetCheck, input.OverrideBudget && budgetCheck.Exceeded); err != nil {
+					return err
+				}
+			}
+
+			request.Status = models.StatusApproved
+			request.ApprovedByID = &user.ID
+			request.ApprovedAt = &now
+			action = models.ActionApproved
+			if comment == "" {
+				comment = "Request approved"
+			}
+			if request.BudgetOverride {
+				comment += " (budget exceeded, approved with override)"
+			}
+
+			// Hold catalog stock for the request
+			reservation, err := h.inventorySvc.Reserve(tx, request)
+			if err != nil {
+				return err
+			}
+			if reservation != nil {
+				comment += " (" + reservation.Message() + ")"
+			}
+		} else if comment == "" {
+			comment = fmt.Sprintf("Approval step %d (%s) approved", approvedStep.Level, approvedStep.Name)
+		}
+
+		if err := tx.Omit("ApprovalSteps").Save(request).Error; err != nil {
+			return err
+		}
+
+		history := models.NewStepHistory(request.ID, user.ID, action, oldStatus, request.Status, approvedStep, comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, c, models.AuditActionApprove, models.AuditResourceRequest, request.ID, before, withDelegator(requestAuditState(request, comment), approvedStep)); err != nil {
+			return err
 		}
-		history := models.NewHistory(request.ID, userID, models.ActionApproved, oldStatus, models.StatusApproved, comment)
-		return tx.Create(history).Error
+
+		// If it's an Amazon URL and the chain is complete, queue adding it to the cart
+		if nextStep == nil && request.IsAmazonURL {
+			if _, err := h.jobQueue.EnqueueAmazonCart(tx, request.ID); err != nil {
+				return err
+			}
+		}
+
+		eventType := events.RequestStepApproved
+		if nextStep == nil {
+			eventType = events.RequestApproved
+		}
+		return h.events.Dispatch(tx, events.Event{Type: eventType, Request: request, ActorID: user.ID, Step: approvedStep, Comment: input.Comment})
 	})
 
 	if err != nil {
-		response.InternalServerError(c, "Failed to approve request")
+		switch {
+		case errors.Is(err, approval.ErrNotAssigned):
+			response.Forbidden(c, "The current approval step is not assigned to you")
+		case errors.Is(err, approval.ErrNoPendingStep):
+			response.BadRequest(c, "Request has no pending approval step")
+		case errors.Is(err, approval.ErrConcurrentDecision):
+			response.Conflict(c, "The request was acted on by someone else in the meantime; reload it and try again")
+		case errors.Is(err, budget.ErrBudgetExceeded):
+			details := ""
+			if budgetCheck != nil {
+				details = budgetCheck.Message()
+			}
+			response.ErrorWithDetails(c, http.StatusConflict, "BUDGET_EXCEEDED",
+				"Request exceeds the remaining budget; approve with override_budget to proceed", details)
+		case errors.Is(err, errOverrideNotAllowed):
+			response.Forbidden(c, "Approving over budget requires the "+models.PermRequestsOverrideBudget+" permission")
+		case errors.Is(err, currency.ErrRateNotFound):
+			response.ValidationError(c, "No exchange rate is available from "+currency.Code(request.Currency)+" to "+
+				budgetCheck.Budget.Currency+"; ask an admin to add one before approving")
+		default:
+			response.InternalServerError(c, "Failed to approve request")
+		}
 		return
 	}
 
+	h.jobQueue.Wake()
+
 	// Reload with relations
 	h.db.
 		Preload("Requester").
 		Preload("History").
 		Preload("History.User").
+		Preload("History.OnBehalfOf").
 		Preload("ApprovedBy").
-		First(&request, request.ID)
-
-	response.SuccessWithMessage(c, "Request approved successfully", requestToResponse(request))
-}
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		Preload("ApprovalSteps.ActedBy").
+		Preload("ApprovalSteps.OnBehalfOf").
+		First(request, request.ID)
 
-// addToAmazonCart adds the product to Amazon cart asynchronously
-func (h *ApprovalHandler) addToAmazonCart(request *models.PurchaseRequest) {
-	// Check if Amazon is configured
-	var config models.AmazonConfig
-	if err := h.db.First(&config).Error; err != nil {
-		log.Printf("Amazon config not found, skipping cart automation for request %d", request.ID)
+	if nextStep != nil {
+		response.SuccessWithMessage(c, "Approval step completed, forwarded to next approver", requestToResponse(*request))
 		return
 	}
-
-	if !config.CanConnect() {
-		log.Printf("Amazon not configured or inactive, skipping cart automation for request %d", request.ID)
-		return
-	}
-
-	// Decrypt password
-	password, err := h.encryptionSvc.Decrypt(config.EncryptedPassword)
-	if err != nil {
-		log.Printf("Failed to decrypt Amazon password: %v", err)
-		h.updateCartError(request.ID, "Failed to decrypt credentials")
-		return
-	}
-
-	// Set credentials
-	h.amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
-
-	// Initialize browser if needed
-	if err := h.amazonSvc.Initialize(); err != nil {
-		log.Printf("Failed to initialize Amazon browser: %v", err)
-		h.updateCartError(request.ID, "Failed to initialize browser: "+err.Error())
-		return
-	}
-
-	// Login if needed
-	if !h.amazonSvc.IsLoggedIn() {
-		if err := h.amazonSvc.Login(); err != nil {
-			log.Printf("Failed to login to Amazon: %v", err)
-			h.updateCartError(request.ID, "Failed to login: "+err.Error())
-			return
-		}
-	}
-
-	// Add to cart
-	if err := h.amazonSvc.AddToCart(request.URL, request.Quantity); err != nil {
-		log.Printf("Failed to add to cart: %v", err)
-		h.updateCartError(request.ID, "Failed to add to cart: "+err.Error())
-		return
-	}
-
-	// Update request as successfully added to cart
-	now := time.Now()
-	h.db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
-		"added_to_cart":    true,
-		"added_to_cart_at": now,
-		"cart_error":       "",
-	})
-
-	log.Printf("Successfully added request %d to Amazon cart", request.ID)
-}
-
-// updateCartError updates the cart error for a request
-func (h *ApprovalHandler) updateCartError(requestID uint, errMsg string) {
-	h.db.Model(&models.PurchaseRequest{}).Where("id = ?", requestID).Updates(map[string]interface{}{
-		"cart_error": errMsg,
-	})
+	response.SuccessWithMessage(c, "Request approved successfully", requestToResponse(*request))
 }
 
-// RejectRequest rejects a purchase request
+// RejectRequest rejects a purchase request at its current approval step
 func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -245,15 +357,14 @@ func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 		return
 	}
 
-	userID := middleware.GetUserID(c)
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
 
-	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response.NotFound(c, "Request not found")
-		} else {
-			response.InternalServerError(c, "Failed to fetch request")
-		}
+	request, ok := h.loadRequestForDecision(c, id)
+	if !ok {
 		return
 	}
 
@@ -263,35 +374,64 @@ func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
 	}
 
 	oldStatus := request.Status
-	now := time.Now()
-	request.Status = models.StatusRejected
-	request.RejectedByID = &userID
-	request.RejectedAt = &now
-	request.RejectionReason = input.Comment
+	before := requestAuditState(request, "")
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&request).Error; err != nil {
+		rejectedStep, err := h.chainSvc.Reject(tx, request, user, input.Comment)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = models.StatusRejected
+		request.RejectedByID = &user.ID
+		request.RejectedAt = &now
+		request.RejectionReason = input.Comment
+
+		if err := tx.Omit("ApprovalSteps").Save(request).Error; err != nil {
 			return err
 		}
 
-		history := models.NewHistory(request.ID, userID, models.ActionRejected, oldStatus, models.StatusRejected, input.Comment)
-		return tx.Create(history).Error
+		history := models.NewStepHistory(request.ID, user.ID, models.ActionRejected, oldStatus, models.StatusRejected, rejectedStep, input.Comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, c, models.AuditActionReject, models.AuditResourceRequest, request.ID, before, withDelegator(requestAuditState(request, input.Comment), rejectedStep)); err != nil {
+			return err
+		}
+
+		return h.events.Dispatch(tx, events.Event{Type: events.RequestRejected, Request: request, ActorID: user.ID, Step: rejectedStep, Comment: input.Comment})
 	})
 
 	if err != nil {
-		response.InternalServerError(c, "Failed to reject request")
+		switch {
+		case errors.Is(err, approval.ErrNotAssigned):
+			response.Forbidden(c, "The current approval step is not assigned to you")
+		case errors.Is(err, approval.ErrNoPendingStep):
+			response.BadRequest(c, "Request has no pending approval step")
+		case errors.Is(err, approval.ErrConcurrentDecision):
+			response.Conflict(c, "The request was acted on by someone else in the meantime; reload it and try again")
+		default:
+			response.InternalServerError(c, "Failed to reject request")
+		}
 		return
 	}
+	h.jobQueue.Wake()
 
 	// Reload with relations
 	h.db.
 		Preload("Requester").
 		Preload("History").
 		Preload("History.User").
+		Preload("History.OnBehalfOf").
 		Preload("RejectedBy").
-		First(&request, request.ID)
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		Preload("ApprovalSteps.ActedBy").
+		Preload("ApprovalSteps.OnBehalfOf").
+		First(request, request.ID)
 
-	response.SuccessWithMessage(c, "Request rejected", requestToResponse(request))
+	response.SuccessWithMessage(c, "Request rejected", requestToResponse(*request))
 }
 
 // RequestInfo requests more information from the requester
@@ -313,15 +453,15 @@ func (h *ApprovalHandler) RequestInfo(c *gin.Context) {
 		return
 	}
 
-	userID := middleware.GetUserID(c)
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
+	userID := user.ID
 
-	var request models.PurchaseRequest
-	if err := h.db.First(&request, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response.NotFound(c, "Request not found")
-		} else {
-			response.InternalServerError(c, "Failed to fetch request")
-		}
+	request, ok := h.loadRequestForDecision(c, id)
+	if !ok {
 		return
 	}
 
@@ -330,38 +470,84 @@ func (h *ApprovalHandler) RequestInfo(c *gin.Context) {
 		return
 	}
 
+	step, err := h.chainSvc.PendingStepFor(h.db, request, user)
+	if err != nil {
+		if errors.Is(err, approval.ErrNotAssigned) || errors.Is(err, approval.ErrNoPendingStep) {
+			response.Forbidden(c, "The current approval step is not assigned to you")
+		} else {
+			response.InternalServerError(c, "Failed to check approval step")
+		}
+		return
+	}
+
 	oldStatus := request.Status
-	now := time.Now()
-	request.Status = models.StatusInfoRequested
-	request.InfoRequestedAt = &now
-	request.InfoRequestNote = input.Comment
+	before := requestAuditState(request, "")
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&request).Error; err != nil {
+		if err := approval.CheckUnchanged(tx, request); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = models.StatusInfoRequested
+		request.InfoRequestedAt = &now
+		request.InfoRequestNote = input.Comment
+		if err := tx.Omit("ApprovalSteps").Save(request).Error; err != nil {
 			return err
 		}
 
-		history := models.NewHistory(request.ID, userID, models.ActionReturned, oldStatus, models.StatusInfoRequested, input.Comment)
-		return tx.Create(history).Error
+		history := models.NewStepHistory(request.ID, userID, models.ActionReturned, oldStatus, models.StatusInfoRequested, step, input.Comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		// Each round of questions and answers is kept in the request's conversation
+		comment := models.RequestComment{RequestID: request.ID, AuthorID: userID, Kind: models.CommentInfoRequest, Body: input.Comment}
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, c, models.AuditActionRequestInfo, models.AuditResourceRequest, request.ID, before, withDelegator(requestAuditState(request, input.Comment), step)); err != nil {
+			return err
+		}
+
+		return h.events.Dispatch(tx, events.Event{Type: events.RequestInfoRequested, Request: request, ActorID: userID, Step: step, Comment: input.Comment})
 	})
 
 	if err != nil {
-		response.InternalServerError(c, "Failed to request more information")
+		if errors.Is(err, approval.ErrConcurrentDecision) {
+			response.Conflict(c, "The request was acted on by someone else in the meantime; reload it and try again")
+		} else {
+			response.InternalServerError(c, "Failed to request more information")
+		}
 		return
 	}
+	h.jobQueue.Wake()
 
 	// Reload with relations
 	h.db.
 		Preload("Requester").
 		Preload("History").
 		Preload("History.User").
-		First(&request, request.ID)
+		Preload("History.OnBehalfOf").
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		First(request, request.ID)
 
-	response.SuccessWithMessage(c, "Information requested from requester", requestToResponse(request))
+	response.SuccessWithMessage(c, "Information requested from requester", requestToResponse(*request))
 }
 
-// GetApprovalStats returns approval statistics
+// GetApprovalStats returns approval statistics for the requests the caller
+// covers with their scopes
 func (h *ApprovalHandler) GetApprovalStats(c *gin.Context) {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
+	requests := func() *gorm.DB {
+		return h.db.Model(&models.PurchaseRequest{}).Scopes(visibility.Covered(user))
+	}
+
 	var stats struct {
 		Pending      int64 `json:"pending"`
 		Approved     int64 `json:"approved"`
@@ -371,16 +557,25 @@ func (h *ApprovalHandler) GetApprovalStats(c *gin.Context) {
 		Total        int64 `json:"total"`
 		Urgent       int64 `json:"urgent"`
 		AmazonInCart int64 `json:"amazon_in_cart"`
+
+		// Totals in the base reporting currency
+		BaseCurrency   string  `json:"base_currency"`
+		PendingAmount  float64 `json:"pending_amount"`
+		ApprovedAmount float64 `json:"approved_amount"`
 	}
 
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPending).Count(&stats.Pending)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusApproved).Count(&stats.Approved)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.Rejected)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusInfoRequested).Count(&stats.InfoRequired)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ?", models.StatusPurchased).Count(&stats.Purchased)
-	h.db.Model(&models.PurchaseRequest{}).Count(&stats.Total)
-	h.db.Model(&models.PurchaseRequest{}).Where("status = ? AND urgency = ?", models.StatusPending, models.UrgencyUrgent).Count(&stats.Urgent)
-	h.db.Model(&models.PurchaseRequest{}).Where("added_to_cart = ?", true).Count(&stats.AmazonInCart)
+	requests().Where("status = ?", models.StatusPending).Count(&stats.Pending)
+	requests().Where("status = ?", models.StatusApproved).Count(&stats.Approved)
+	requests().Where("status = ?", models.StatusRejected).Count(&stats.Rejected)
+	requests().Where("status = ?", models.StatusInfoRequested).Count(&stats.InfoRequired)
+	requests().Where("status = ?", models.StatusPurchased).Count(&stats.Purchased)
+	requests().Count(&stats.Total)
+	requests().Where("status = ? AND urgency = ?", models.StatusPending, models.UrgencyUrgent).Count(&stats.Urgent)
+	requests().Where("added_to_cart = ?", true).Count(&stats.AmazonInCart)
+
+	stats.BaseCurrency = h.converter.Base()
+	stats.PendingAmount = sumNormalizedAmount(requests().Where("status = ?", models.StatusPending), stats.BaseCurrency)
+	stats.ApprovedAmount = sumNormalizedAmount(requests().Where("status = ?", models.StatusApproved), stats.BaseCurrency)
 
 	response.Success(c, stats)
 }
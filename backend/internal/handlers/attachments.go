@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/attachments"
+	"vista-backend/internal/services/visibility"
+	"vista-backend/pkg/response"
+)
+
+// MaxAttachmentsPerUpload is how many files can be posted at once
+const MaxAttachmentsPerUpload = 5
+
+type AttachmentHandler struct {
+	db    *gorm.DB
+	store *attachments.Store
+}
+
+func NewAttachmentHandler(db *gorm.DB, store *attachments.Store) *AttachmentHandler {
+	return &AttachmentHandler{db: db, store: store}
+}
+
+// AttachmentResponse is an attachment with what the caller can do with it
+type AttachmentResponse struct {
+	ID           uint          `json:"id"`
+	RequestID    uint          `json:"request_id"`
+	CommentID    *uint         `json:"comment_id,omitempty"` // Comment the file was posted with
+	Kind         string        `json:"kind"`
+	UploadedByID uint          `json:"uploaded_by_id"`
+	UploadedBy   *UserResponse `json:"uploaded_by,omitempty"`
+	FileName     string        `json:"file_name"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	CreatedAt    time.Time     `json:"created_at"`
+	CanDelete    bool          `json:"can_delete"`
+}
+
+func attachmentToResponse(attachment *models.RequestAttachment, canDelete bool) AttachmentResponse {
+	return AttachmentResponse{
+		ID:           attachment.ID,
+		RequestID:    attachment.RequestID,
+		CommentID:    attachment.CommentID,
+		Kind:         string(attachment.Kind),
+		UploadedByID: attachment.UploadedByID,
+		UploadedBy:   participantToResponse(attachment.UploadedBy),
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		CreatedAt:    attachment.CreatedAt,
+		CanDelete:    canDelete,
+	}
+}
+
+// participantToResponse is the part of a user shown to the other people
+// taking part in a request, or nil if the user is not loaded
+func participantToResponse(user *models.User) *UserResponse {
+	if user == nil || user.ID == 0 {
+		return nil
+	}
+	return &UserResponse{
+		ID:    user.ID,
+		Email: user.Email,
+		Name:  user.Name,
+		Role:  string(user.Role),
+	}
+}
+
+// ListAttachments returns a request's attachments, including those posted
+// with comments, oldest first
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	request, user, ok := loadRequest(c, h.db, visibility.CanViewOrder)
+	if !ok {
+		return
+	}
+
+	var list []models.RequestAttachment
+	if err := h.db.Preload("UploadedBy", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("request_id = ?", request.ID).
+		Order("created_at ASC, id ASC").
+		Find(&list).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch attachments")
+		return
+	}
+
+	result := make([]AttachmentResponse, len(list))
+	for i := range list {
+		result[i] = attachmentToResponse(&list[i], canDeleteAttachment(user, request, &list[i]))
+	}
+
+	response.Success(c, result)
+}
+
+// UploadAttachments attaches files of one kind to a request. Requesters add
+// quotes, spec sheets and other documents while the request is in review,
+// until an approval step is decided; order managers add invoices once it is
+// approved.
+func (h *AttachmentHandler) UploadAttachments(c *gin.Context) {
+	request, user, ok := loadRequest(c, h.db, visibility.CanViewOrder)
+	if !ok {
+		return
+	}
+
+	kind := models.AttachmentKind(c.DefaultPostForm("kind", string(models.AttachmentOther)))
+	if !kind.IsValid() {
+		response.BadRequest(c, "Invalid attachment kind")
+		return
+	}
+	if kind == models.AttachmentInvoice {
+		if !user.Can(models.PermOrdersManage) {
+			response.Forbidden(c, "Only order managers can attach invoices")
+			return
+		}
+		if request.Status != models.StatusApproved && request.Status != models.StatusPurchased {
+			response.BadRequest(c, "Invoices can only be attached to approved or purchased requests")
+			return
+		}
+	} else {
+		if request.RequesterID != user.ID {
+			response.Forbidden(c, "Only the requester can attach documents")
+			return
+		}
+		if request.Status != models.StatusPending && request.Status != models.StatusInfoRequested {
+			response.BadRequest(c, "Documents can only be attached while the request is in review")
+			return
+		}
+		if request.HasDecidedStep() {
+			response.BadRequest(c, "Documents can no longer be attached once an approval step has been decided; post them in the conversation instead")
+			return
+		}
+	}
+
+	files, ok := storeUploads(c, h.store, "files")
+	if !ok {
+		return
+	}
+	if len(files) == 0 {
+		response.BadRequest(c, "No files provided")
+		return
+	}
+
+	var created []models.RequestAttachment
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if created, err = createAttachments(tx, request, user.ID, kind, nil, files); err != nil {
+			return err
+		}
+		for i := range created {
+			if err := recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceAttachment, created[i].ID, nil, attachmentAuditState(&created[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeFiles(h.store, files)
+		response.InternalServerError(c, "Failed to save attachments")
+		return
+	}
+
+	result := make([]AttachmentResponse, len(created))
+	for i := range created {
+		created[i].UploadedBy = user
+		result[i] = attachmentToResponse(&created[i], canDeleteAttachment(user, request, &created[i]))
+	}
+	response.Created(c, result)
+}
+
+// DownloadAttachment sends a request attachment to a user who can view the
+// request, or handle it as an order manager
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	request, _, ok := loadRequest(c, h.db, visibility.CanViewOrder)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(c, request)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.FileAttachment(h.store.Path(attachment.StoragePath), attachment.FileName)
+}
+
+// DeleteAttachment removes an attachment and its file, following the rules of
+// models.RequestAttachment.CanBeDeleted
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	request, user, ok := loadRequest(c, h.db, visibility.CanViewOrder)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(c, request)
+	if !ok {
+		return
+	}
+	if !canDeleteAttachment(user, request, attachment) {
+		if attachment.CanBeDeleted(request) {
+			response.Forbidden(c, "You cannot delete this attachment")
+		} else {
+			response.BadRequest(c, "Attachment can no longer be deleted")
+		}
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(attachment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceAttachment, attachment.ID, attachmentAuditState(attachment), nil)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to delete attachment")
+		return
+	}
+	h.store.Remove(attachment.StoragePath)
+
+	response.SuccessWithMessage(c, "Attachment deleted", nil)
+}
+
+func (h *AttachmentHandler) loadAttachment(c *gin.Context, request *models.PurchaseRequest) (*models.RequestAttachment, bool) {
+	var attachment models.RequestAttachment
+	if err := h.db.Where("request_id = ?", request.ID).First(&attachment, c.Param("attachmentId")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Attachment not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch attachment")
+		}
+		return nil, false
+	}
+	return &attachment, true
+}
+
+// canDeleteAttachment checks if the user can delete the attachment: its
+// uploader, and for invoices any order manager, while the request's status
+// allows it
+func canDeleteAttachment(user *models.User, request *models.PurchaseRequest, attachment *models.RequestAttachment) bool {
+	if !attachment.CanBeDeleted(request) {
+		return false
+	}
+	if attachment.UploadedByID == user.ID {
+		return true
+	}
+	return attachment.Kind == models.AttachmentInvoice && user.Can(models.PermOrdersManage)
+}
+
+// loadRequest loads the request named by the id parameter with its
+// requester and approval chain, and the caller, writing the error response
+// when the request does not exist or canView denies the caller
+func loadRequest(c *gin.Context, db *gorm.DB, canView func(*models.User, *models.PurchaseRequest) bool) (*models.PurchaseRequest, *models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid request ID")
+		return nil, nil, false
+	}
+
+	var request models.PurchaseRequest
+	if err := db.Preload("Requester").Preload("ApprovalSteps", preloadApprovalSteps).First(&request, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Request not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch request")
+		}
+		return nil, nil, false
+	}
+
+	user, err := currentUser(c, db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return nil, nil, false
+	}
+	if !canView(user, &request) {
+		response.Forbidden(c, "Access denied")
+		return nil, nil, false
+	}
+	return &request, user, true
+}
+
+// storeUploads saves the files posted in the form field, writing the error
+// response and removing those already saved if one is rejected
+func storeUploads(c *gin.Context, store *attachments.Store, field string) ([]*attachments.File, bool) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File[field]) == 0 {
+		return nil, true
+	}
+	if len(form.File[field]) > MaxAttachmentsPerUpload {
+		response.BadRequest(c, "Too many files; the maximum is "+strconv.Itoa(MaxAttachmentsPerUpload))
+		return nil, false
+	}
+
+	var files []*attachments.File
+	for _, header := range form.File[field] {
+		file, err := store.Save(header)
+		if err != nil {
+			removeFiles(store, files)
+			switch {
+			case errors.Is(err, attachments.ErrFileType), errors.Is(err, attachments.ErrFileTooLarge), errors.Is(err, attachments.ErrEmptyFile):
+				response.ValidationError(c, err.Error())
+			default:
+				response.InternalServerError(c, "Failed to store attachment")
+			}
+			return nil, false
+		}
+		files = append(files, file)
+	}
+	return files, true
+}
+
+// createAttachments records stored files as attachments of the request
+func createAttachments(tx *gorm.DB, request *models.PurchaseRequest, userID uint, kind models.AttachmentKind, commentID *uint, files []*attachments.File) ([]models.RequestAttachment, error) {
+	created := make([]models.RequestAttachment, 0, len(files))
+	for _, file := range files {
+		attachment := models.RequestAttachment{
+			RequestID:    request.ID,
+			CommentID:    commentID,
+			Kind:         kind,
+			UploadedByID: userID,
+			FileName:     file.Name,
+			ContentType:  file.ContentType,
+			Size:         file.Size,
+			StoragePath:  file.Path,
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			return nil, err
+		}
+		created = append(created, attachment)
+	}
+	return created, nil
+}
+
+// removeFiles deletes stored files whose attachments were not saved
+func removeFiles(store *attachments.Store, files []*attachments.File) {
+	for _, file := range files {
+		store.Remove(file.Path)
+	}
+}
+
+// attachmentAuditState is an attachment as recorded in the audit log
+func attachmentAuditState(attachment *models.RequestAttachment) gin.H {
+	return gin.H{
+		"request_id": attachment.RequestID,
+		"kind":       attachment.Kind,
+		"file_name":  attachment.FileName,
+		"size":       attachment.Size,
+	}
+}
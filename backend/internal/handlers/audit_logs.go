@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+	"vista-backend/pkg/response"
+)
+
+type AuditLogHandler struct {
+	db *gorm.DB
+}
+
+func NewAuditLogHandler(db *gorm.DB) *AuditLogHandler {
+	return &AuditLogHandler{db: db}
+}
+
+// recordAudit records a change made by the authenticated user in the audit log,
+// taking the client IP and user agent from the request
+func recordAudit(tx *gorm.DB, c *gin.Context, action, resource string, resourceID uint, before, after interface{}) error {
+	return audit.Record(tx, audit.Entry{
+		UserID:     middleware.GetUserID(c),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Before:     before,
+		After:      after,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+}
+
+// requestAuditState is the workflow state of a purchase request recorded in the audit log
+func requestAuditState(request *models.PurchaseRequest, comment string) gin.H {
+	state := gin.H{
+		"request_number": request.RequestNumber,
+		"status":         request.Status,
+		"total_amount":   request.Amount(),
+		"currency":       request.Currency,
+	}
+	if step := request.PendingStep(); step != nil {
+		state["approval_level"] = step.Level
+	}
+	if request.BudgetOverride {
+		state["budget_override"] = true
+	}
+	if comment != "" {
+		state["comment"] = comment
+	}
+	return state
+}
+
+// ListAuditLogs returns audit log entries filtered by user_id, resource,
+// resource_id, action and a from/to date range (YYYY-MM-DD, inclusive)
+func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+
+	f, ok := parseAnalyticsFilter(c)
+	if !ok {
+		return
+	}
+
+	offset := (page - 1) * perPage
+
+	query := h.db.Model(&models.AuditLog{})
+
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if resource := c.Query("resource"); resource != "" {
+		query = query.Where("resource = ?", resource)
+	}
+	if resourceID := c.Query("resource_id"); resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	if err := query.Preload("User").Offset(offset).Limit(perPage).Order("created_at DESC, id DESC").Find(&logs).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch audit logs")
+		return
+	}
+
+	response.SuccessWithMeta(c, logs, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
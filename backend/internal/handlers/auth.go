@@ -1,19 +1,34 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/services"
+	"vista-backend/pkg/jwt"
 	"vista-backend/pkg/response"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService    *services.AuthService
+	mfaService     *services.MFAService
+	passwordTokens *services.PasswordTokenService
+	passwordPolicy *services.PasswordPolicy
+	ssoService     *services.SSOService
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, mfaService *services.MFAService, passwordTokens *services.PasswordTokenService, passwordPolicy *services.PasswordPolicy, ssoService *services.SSOService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:    authService,
+		mfaService:     mfaService,
+		passwordTokens: passwordTokens,
+		passwordPolicy: passwordPolicy,
+		ssoService:     ssoService,
 	}
 }
 
@@ -27,17 +42,23 @@ type LoginResponse struct {
 	AccessToken string       `json:"access_token"`
 	RefreshToken string      `json:"refresh_token"`
 	ExpiresIn   int64        `json:"expires_in"`
+	RecoveryCodes []string   `json:"recovery_codes,omitempty"` // Shown once after 2FA setup during login
 }
 
 type UserResponse struct {
-	ID          uint   `json:"id"`
-	Email       string `json:"email"`
-	Name        string `json:"name"`
-	Role        string `json:"role"`
-	CompanyCode string `json:"company_code"`
-	CostCenter  string `json:"cost_center"`
-	Department  string `json:"department"`
-	Status      string `json:"status"`
+	ID                     uint     `json:"id"`
+	Email                  string   `json:"email"`
+	Name                   string   `json:"name"`
+	Role                   string   `json:"role"`
+	CompanyCode            string   `json:"company_code"`
+	CostCenter             string   `json:"cost_center"`
+	Department             string   `json:"department"`
+	Status                 string   `json:"status"`
+	MFAEnabled             bool     `json:"mfa_enabled"`
+	PasswordChangeRequired bool     `json:"password_change_required"` // Only the password can be changed until it is
+	PasswordLoginDisabled  bool     `json:"password_login_disabled"`  // Signs in with SSO only
+	SSOLinked              bool     `json:"sso_linked"`
+	Permissions            []string `json:"permissions,omitempty"` // Set for the signed-in user
 }
 
 type RefreshRequest struct {
@@ -52,14 +73,16 @@ type RefreshResponse struct {
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticates a user and returns JWT tokens
+// @Description Authenticates a user and returns JWT tokens, or a challenge when two-factor authentication is needed
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
 // @Success 200 {object} LoginResponse
+// @Success 200 {object} MFAChallengeResponse
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -68,46 +91,86 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokens, user, err := h.authService.Login(req.Email, req.Password)
+	result, err := h.authService.Login(req.Email, req.Password, clientInfo(c))
 	if err != nil {
-		switch err {
-		case services.ErrInvalidCredentials:
-			response.Unauthorized(c, "Invalid email or password")
-		case services.ErrUserInactive:
-			response.Forbidden(c, "Account is inactive")
-		default:
-			response.InternalServerError(c, "Login failed")
+		respondLoginError(c, err)
+		return
+	}
+
+	respondLogin(c, result)
+}
+
+// respondLoginError writes the response for a failed login step
+func respondLoginError(c *gin.Context, err error) {
+	var blocked *services.LoginBlockedError
+	if errors.As(err, &blocked) {
+		c.Header("Retry-After", strconv.Itoa(blocked.RetryAfterSeconds()))
+		if blocked.Locked {
+			response.TooManyRequests(c, "Too many failed logins; try again later")
+		} else {
+			wait := time.Duration(blocked.RetryAfterSeconds()) * time.Second
+			response.TooManyRequests(c, fmt.Sprintf("Too many failed logins; try again in %s", wait))
 		}
 		return
 	}
 
+	switch err {
+	case services.ErrInvalidCredentials:
+		response.Unauthorized(c, "Invalid email or password")
+	case services.ErrInvalidMFACode:
+		response.Unauthorized(c, "Invalid authentication code")
+	case services.ErrUserInactive:
+		response.Forbidden(c, "Account is inactive")
+	case services.ErrPasswordLoginDisabled:
+		response.Forbidden(c, "Password sign-in is disabled for this account; use single sign-on")
+	case jwt.ErrInvalidToken, jwt.ErrExpiredToken, jwt.ErrInvalidClaim, services.ErrUserNotFound:
+		response.Unauthorized(c, "Invalid or expired challenge; log in again")
+	case services.ErrMFAAlreadyEnabled:
+		response.Conflict(c, "Two-factor authentication is already enabled")
+	case services.ErrMFANotEnrolled:
+		response.BadRequest(c, "Start two-factor setup first")
+	case services.ErrMFANotEnabled:
+		response.BadRequest(c, "Two-factor authentication is not enabled")
+	default:
+		response.InternalServerError(c, "Login failed")
+	}
+}
+
+// respondLogin writes the tokens of a completed login, or the challenge for its next step
+func respondLogin(c *gin.Context, result *services.LoginResult) {
+	if result.Challenge != nil {
+		response.Success(c, MFAChallengeResponse{
+			MFARequired:    true,
+			SetupRequired:  result.Challenge.SetupRequired,
+			ChallengeToken: result.Challenge.Token,
+			ExpiresIn:      result.Challenge.ExpiresIn,
+		})
+		return
+	}
+
 	response.Success(c, LoginResponse{
-		User: UserResponse{
-			ID:          user.ID,
-			Email:       user.Email,
-			Name:        user.Name,
-			Role:        string(user.Role),
-			CompanyCode: user.CompanyCode,
-			CostCenter:  user.CostCenter,
-			Department:  user.Department,
-			Status:      user.Status,
-		},
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-		ExpiresIn:    tokens.ExpiresIn,
+		User:          userToResponse(*result.User),
+		AccessToken:   result.Tokens.AccessToken,
+		RefreshToken:  result.Tokens.RefreshToken,
+		ExpiresIn:     result.Tokens.ExpiresIn,
+		RecoveryCodes: result.RecoveryCodes,
 	})
 }
 
 // Logout handles user logout
 // @Summary User logout
-// @Description Logs out the current user
+// @Description Logs out the current user, revoking the session's access and refresh tokens
 // @Tags Auth
 // @Security BearerAuth
 // @Success 200 {object} response.Response
 // @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side by removing tokens
-	// For additional security, you could implement a token blacklist here
+	err := h.authService.Logout(middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil && err != services.ErrSessionNotFound {
+		response.InternalServerError(c, "Logout failed")
+		return
+	}
+
 	response.SuccessWithMessage(c, "Logged out successfully", nil)
 }
 
@@ -129,9 +192,18 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshTokens(req.RefreshToken)
+	tokens, err := h.authService.RefreshTokens(req.RefreshToken, clientInfo(c))
 	if err != nil {
-		response.Unauthorized(c, "Invalid or expired refresh token")
+		switch err {
+		case services.ErrTokenReused:
+			response.Unauthorized(c, "Refresh token has already been used; the session has been revoked")
+		case services.ErrSessionRevoked, services.ErrSessionNotFound:
+			response.Unauthorized(c, "Session has been revoked")
+		case services.ErrMFASetupRequired:
+			response.Error(c, http.StatusUnauthorized, "MFA_SETUP_REQUIRED", "Two-factor authentication must be set up; log in again to enroll")
+		default:
+			response.Unauthorized(c, "Invalid or expired refresh token")
+		}
 		return
 	}
 
@@ -163,14 +235,5 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        string(user.Role),
-		CompanyCode: user.CompanyCode,
-		CostCenter:  user.CostCenter,
-		Department:  user.Department,
-		Status:      user.Status,
-	})
+	response.Success(c, userToResponse(*user))
 }
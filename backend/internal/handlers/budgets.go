@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+type BudgetHandler struct {
+	db *gorm.DB
+}
+
+func NewBudgetHandler(db *gorm.DB) *BudgetHandler {
+	return &BudgetHandler{db: db}
+}
+
+type BudgetRequest struct {
+	CostCenter  string  `json:"cost_center" binding:"required"`
+	CompanyCode string  `json:"company_code"`
+	Name        string  `json:"name"`
+	PeriodType  string  `json:"period_type" binding:"required,oneof=monthly quarterly yearly"`
+	PeriodDate  string  `json:"period_date" binding:"required"` // Any date within the period (YYYY-MM-DD)
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Currency    string  `json:"currency"`
+}
+
+type BudgetResponse struct {
+	models.Budget
+	Remaining   float64 `json:"remaining"`
+	Utilization float64 `json:"utilization"` // Percentage of the budget committed or spent
+}
+
+type BudgetRequestSummary struct {
+	ID            uint       `json:"id"`
+	RequestNumber string     `json:"request_number"`
+	ProductTitle  string     `json:"product_title"`
+	Status        string     `json:"status"`
+	BudgetAmount  float64    `json:"budget_amount"`
+	Override      bool       `json:"budget_override"`
+	ApprovedAt    *time.Time `json:"approved_at,omitempty"`
+	PurchasedAt   *time.Time `json:"purchased_at,omitempty"`
+}
+
+type BudgetUtilization struct {
+	Period      string  `json:"period"`
+	PeriodType  string  `json:"period_type"`
+	Currency    string  `json:"currency"`
+	Budgets     int     `json:"budgets"`
+	Amount      float64 `json:"amount"`
+	Committed   float64 `json:"committed"`
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	Utilization float64 `json:"utilization"`
+}
+
+func budgetToResponse(b models.Budget) BudgetResponse {
+	return BudgetResponse{
+		Budget:      b,
+		Remaining:   b.Remaining(),
+		Utilization: b.Utilization(),
+	}
+}
+
+// ListBudgets returns budgets with their utilization
+func (h *BudgetHandler) ListBudgets(c *gin.Context) {
+	query := h.db.Model(&models.Budget{})
+
+	if costCenter := c.Query("cost_center"); costCenter != "" {
+		query = query.Where("cost_center = ?", costCenter)
+	}
+	if companyCode := c.Query("company_code"); companyCode != "" {
+		query = query.Where("company_code = ?", companyCode)
+	}
+	if period := c.Query("period"); period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if periodType := c.Query("period_type"); periodType != "" {
+		query = query.Where("period_type = ?", periodType)
+	}
+	if c.Query("current") == "true" {
+		now := time.Now()
+		query = query.Where("period_start <= ? AND period_end > ?", now, now)
+	}
+
+	var budgets []models.Budget
+	if err := query.Order("period_start DESC, cost_center ASC").Find(&budgets).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch budgets")
+		return
+	}
+
+	budgetResponses := make([]BudgetResponse, len(budgets))
+	for i, b := range budgets {
+		budgetResponses[i] = budgetToResponse(b)
+	}
+
+	response.Success(c, budgetResponses)
+}
+
+// GetBudget returns a budget with the requests charged against it
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	b, ok := h.findBudget(c)
+	if !ok {
+		return
+	}
+
+	var requests []models.PurchaseRequest
+	if err := h.db.Where("budget_id = ?", b.ID).Order("approved_at DESC").Find(&requests).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch budget requests")
+		return
+	}
+
+	summaries := make([]BudgetRequestSummary, len(requests))
+	for i, r := range requests {
+		summaries[i] = BudgetRequestSummary{
+			ID:            r.ID,
+			RequestNumber: r.RequestNumber,
+			ProductTitle:  r.ProductTitle,
+			Status:        string(r.Status),
+			BudgetAmount:  r.BudgetAmount,
+			Override:      r.BudgetOverride,
+			ApprovedAt:    r.ApprovedAt,
+			PurchasedAt:   r.PurchasedAt,
+		}
+	}
+
+	response.Success(c, gin.H{
+		"budget":   budgetToResponse(*b),
+		"requests": summaries,
+	})
+}
+
+// GetUtilization returns budget totals grouped by period and currency
+func (h *BudgetHandler) GetUtilization(c *gin.Context) {
+	query := h.db.Model(&models.Budget{}).
+		Select("period, period_type, currency, COUNT(*) AS budgets, " +
+			"SUM(amount) AS amount, SUM(committed_amount) AS committed, SUM(spent_amount) AS spent")
+
+	if periodType := c.Query("period_type"); periodType != "" {
+		query = query.Where("period_type = ?", periodType)
+	}
+	if costCenter := c.Query("cost_center"); costCenter != "" {
+		query = query.Where("cost_center = ?", costCenter)
+	}
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			response.BadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		query = query.Where("period_end > ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			response.BadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		query = query.Where("period_start <= ?", date)
+	}
+
+	var rows []BudgetUtilization
+	if err := query.Group("period, period_type, currency").Order("MIN(period_start) DESC").Scan(&rows).Error; err != nil {
+		response.InternalServerError(c, "Failed to calculate budget utilization")
+		return
+	}
+
+	for i := range rows {
+		rows[i].Remaining = rows[i].Amount - rows[i].Committed - rows[i].Spent
+		if rows[i].Amount > 0 {
+			rows[i].Utilization = (rows[i].Committed + rows[i].Spent) / rows[i].Amount * 100
+		}
+	}
+
+	response.Success(c, rows)
+}
+
+// CreateBudget creates a budget for a cost center and period
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	b := models.Budget{
+		CreatedByID: middleware.GetUserID(c),
+	}
+	if msg := applyBudgetRequest(&b, &req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+	if h.overlaps(&b) {
+		response.Conflict(c, "A budget already exists for this cost center in an overlapping period")
+		return
+	}
+
+	if err := h.db.Create(&b).Error; err != nil {
+		response.InternalServerError(c, "Failed to create budget")
+		return
+	}
+
+	response.Created(c, budgetToResponse(b))
+}
+
+// UpdateBudget updates a budget. Currency and period are fixed once amounts are tracked.
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	b, ok := h.findBudget(c)
+	if !ok {
+		return
+	}
+
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	tracked := b.CommittedAmount != 0 || b.SpentAmount != 0
+	oldCurrency, oldPeriod := b.Currency, b.Period
+
+	if msg := applyBudgetRequest(b, &req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+	if tracked && (b.Currency != oldCurrency || b.Period != oldPeriod) {
+		response.ValidationError(c, "Currency and period cannot be changed once requests are charged to the budget")
+		return
+	}
+	if h.overlaps(b) {
+		response.Conflict(c, "A budget already exists for this cost center in an overlapping period")
+		return
+	}
+
+	if err := h.db.Save(b).Error; err != nil {
+		response.InternalServerError(c, "Failed to update budget")
+		return
+	}
+
+	response.Success(c, budgetToResponse(*b))
+}
+
+// DeleteBudget deletes a budget that has no requests charged against it
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	b, ok := h.findBudget(c)
+	if !ok {
+		return
+	}
+
+	var charged int64
+	h.db.Model(&models.PurchaseRequest{}).Where("budget_id = ?", b.ID).Count(&charged)
+	if charged > 0 {
+		response.Conflict(c, "Cannot delete a budget with requests charged against it")
+		return
+	}
+
+	if err := h.db.Delete(b).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete budget")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Budget deleted successfully", nil)
+}
+
+func (h *BudgetHandler) findBudget(c *gin.Context) (*models.Budget, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid budget ID")
+		return nil, false
+	}
+
+	var b models.Budget
+	if err := h.db.First(&b, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Budget not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch budget")
+		}
+		return nil, false
+	}
+	return &b, true
+}
+
+// overlaps checks if another budget covers the same cost center during the budget's period
+func (h *BudgetHandler) overlaps(b *models.Budget) bool {
+	var count int64
+	h.db.Model(&models.Budget{}).
+		Where("id <> ? AND cost_center = ?", b.ID, b.CostCenter).
+		Where("company_code = ? OR company_code = '' OR ? = ''", b.CompanyCode, b.CompanyCode).
+		Where("period_start < ? AND period_end > ?", b.PeriodEnd, b.PeriodStart).
+		Count(&count)
+	return count > 0
+}
+
+// applyBudgetRequest copies the request onto the budget and returns a validation message
+func applyBudgetRequest(b *models.Budget, req *BudgetRequest) string {
+	date, err := time.ParseInLocation("2006-01-02", req.PeriodDate, time.Local)
+	if err != nil {
+		return "Invalid period_date, expected YYYY-MM-DD"
+	}
+	if err := b.SetPeriod(models.BudgetPeriodType(req.PeriodType), date); err != nil {
+		return "Invalid period type"
+	}
+
+	b.CostCenter = strings.TrimSpace(req.CostCenter)
+	b.CompanyCode = strings.TrimSpace(req.CompanyCode)
+	b.Name = req.Name
+	b.Amount = req.Amount
+	b.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if b.Currency == "" {
+		b.Currency = "MXN"
+	}
+
+	if b.CostCenter == "" {
+		return "Cost center is required"
+	}
+	return ""
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/metadata"
+	"vista-backend/pkg/response"
+)
+
+type CartHandler struct {
+	db                *gorm.DB
+	metadataExtractor *metadata.Extractor
+}
+
+func NewCartHandler(db *gorm.DB) *CartHandler {
+	return &CartHandler{
+		db:                db,
+		metadataExtractor: metadata.NewExtractor(),
+	}
+}
+
+// AddCartItemInput represents the input for adding a catalog product or an external URL to the cart
+type AddCartItemInput struct {
+	ProductID       *uint    `json:"product_id"`
+	URL             string   `json:"url" binding:"omitempty,url"`
+	ProductTitle    string   `json:"product_title"`
+	ProductImageURL string   `json:"product_image_url"`
+	SiteName        string   `json:"site_name"`
+	UnitPrice       *float64 `json:"unit_price" binding:"omitempty,gte=0"`
+	Currency        string   `json:"currency"`
+	Quantity        int      `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// UpdateCartItemInput represents the input for editing a cart line
+type UpdateCartItemInput struct {
+	Quantity  int      `json:"quantity" binding:"omitempty,min=1"`
+	UnitPrice *float64 `json:"unit_price" binding:"omitempty,gte=0"`
+	Currency  string   `json:"currency"`
+}
+
+// GetCart returns the current user's cart with totals per currency
+func (h *CartHandler) GetCart(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var items []models.CartItem
+	if err := h.db.Preload("Product").Where("user_id = ?", userID).Order("id ASC").Find(&items).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch cart")
+		return
+	}
+
+	totals := make(map[string]float64)
+	for i := range items {
+		h.refreshCatalogItem(&items[i])
+		if items[i].UnitPrice != nil {
+			totals[items[i].Currency] += *items[i].UnitPrice * float64(items[i].Quantity)
+		}
+	}
+
+	response.Success(c, gin.H{
+		"items":  items,
+		"totals": totals,
+	})
+}
+
+// AddCartItem adds a product to the cart, merging it with an existing line for the same product
+func (h *CartHandler) AddCartItem(c *gin.Context) {
+	var input AddCartItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if (input.ProductID == nil) == (input.URL == "") {
+		response.BadRequest(c, "Provide either product_id or url")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	quantity := input.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	var existing models.CartItem
+	query := h.db.Where("user_id = ?", userID)
+	if input.ProductID != nil {
+		query = query.Where("product_id = ?", *input.ProductID)
+	} else {
+		query = query.Where("product_id IS NULL AND url = ?", input.URL)
+	}
+	err := query.First(&existing).Error
+	if err == nil {
+		existing.Quantity += quantity
+		if input.UnitPrice != nil && existing.ProductID == nil {
+			existing.UnitPrice = input.UnitPrice
+		}
+		if err := h.db.Save(&existing).Error; err != nil {
+			response.InternalServerError(c, "Failed to update cart")
+			return
+		}
+		response.Success(c, existing)
+		return
+	}
+	if err != gorm.ErrRecordNotFound {
+		response.InternalServerError(c, "Failed to fetch cart")
+		return
+	}
+
+	item := models.CartItem{
+		UserID:   userID,
+		Quantity: quantity,
+	}
+
+	if input.ProductID != nil {
+		var product models.Product
+		if err := h.db.First(&product, *input.ProductID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				response.NotFound(c, "Product not found")
+			} else {
+				response.InternalServerError(c, "Failed to fetch product")
+			}
+			return
+		}
+		if !product.IsActive {
+			response.BadRequest(c, "Product is not available")
+			return
+		}
+		item.ProductID = &product.ID
+		item.Product = &product
+		h.refreshCatalogItem(&item)
+	} else {
+		item.URL = input.URL
+		item.ProductTitle = input.ProductTitle
+		item.ProductImageURL = input.ProductImageURL
+		item.SiteName = input.SiteName
+		item.UnitPrice = input.UnitPrice
+		item.Currency = input.Currency
+
+		// Fill in missing details from the product page
+		if item.ProductTitle == "" || item.ProductImageURL == "" || item.UnitPrice == nil {
+			if meta, err := h.metadataExtractor.ExtractFromURL(input.URL); err == nil {
+				if item.ProductTitle == "" {
+					item.ProductTitle = meta.Title
+				}
+				if item.ProductImageURL == "" {
+					item.ProductImageURL = meta.ImageURL
+				}
+				if item.SiteName == "" {
+					item.SiteName = meta.SiteName
+				}
+				if item.UnitPrice == nil && meta.Price != nil {
+					item.UnitPrice = meta.Price
+					if item.Currency == "" {
+						item.Currency = meta.Currency
+					}
+				}
+			}
+		}
+		if item.SiteName == "" {
+			item.SiteName = metadata.SiteNameFromURL(input.URL)
+		}
+		if item.Currency == "" {
+			item.Currency = "MXN"
+		}
+		item.Currency = currency.Code(item.Currency)
+	}
+
+	if err := h.db.Create(&item).Error; err != nil {
+		response.InternalServerError(c, "Failed to add item to cart")
+		return
+	}
+
+	response.Created(c, item)
+}
+
+// UpdateCartItem changes the quantity or price of a cart line
+func (h *CartHandler) UpdateCartItem(c *gin.Context) {
+	item, ok := h.findCartItem(c)
+	if !ok {
+		return
+	}
+
+	var input UpdateCartItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if input.Quantity > 0 {
+		item.Quantity = input.Quantity
+	}
+
+	// Catalog prices always come from the product
+	if !item.IsCatalogItem() {
+		if input.UnitPrice != nil {
+			item.UnitPrice = input.UnitPrice
+		}
+		if input.Currency != "" {
+			item.Currency = currency.Code(input.Currency)
+		}
+	}
+
+	if err := h.db.Save(item).Error; err != nil {
+		response.InternalServerError(c, "Failed to update cart item")
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// RemoveCartItem removes a line from the cart
+func (h *CartHandler) RemoveCartItem(c *gin.Context) {
+	item, ok := h.findCartItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(item).Error; err != nil {
+		response.InternalServerError(c, "Failed to remove cart item")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Item removed from cart", nil)
+}
+
+// ClearCart removes every line from the current user's cart
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if err := h.db.Where("user_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+		response.InternalServerError(c, "Failed to clear cart")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Cart cleared", nil)
+}
+
+// findCartItem loads a cart line owned by the current user, writing the error response if it fails
+func (h *CartHandler) findCartItem(c *gin.Context) (*models.CartItem, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid cart item ID")
+		return nil, false
+	}
+
+	var item models.CartItem
+	if err := h.db.Where("user_id = ?", middleware.GetUserID(c)).First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Cart item not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch cart item")
+		}
+		return nil, false
+	}
+
+	return &item, true
+}
+
+// refreshCatalogItem copies the current catalog details onto a cart line
+func (h *CartHandler) refreshCatalogItem(item *models.CartItem) {
+	if item.Product == nil {
+		return
+	}
+	price := item.Product.Price
+	item.ProductTitle = item.Product.Name
+	item.ProductImageURL = item.Product.ImageURL
+	item.UnitPrice = &price
+	item.Currency = currency.Code(item.Product.Currency)
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/attachments"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/internal/services/visibility"
+	"vista-backend/pkg/response"
+)
+
+var errCannotMention = errors.New("mentioned users must be active and able to view the request")
+
+type CommentHandler struct {
+	db          *gorm.DB
+	roleService *services.RoleService
+	store       *attachments.Store
+	jobQueue    *jobs.Queue
+	events      *events.Dispatcher
+}
+
+func NewCommentHandler(db *gorm.DB, roleService *services.RoleService, store *attachments.Store, jobQueue *jobs.Queue, dispatcher *events.Dispatcher) *CommentHandler {
+	return &CommentHandler{
+		db:          db,
+		roleService: roleService,
+		store:       store,
+		jobQueue:    jobQueue,
+		events:      dispatcher,
+	}
+}
+
+// CreateCommentRequest is sent as JSON, or as a multipart form with the
+// attachments in "files"
+type CreateCommentRequest struct {
+	Body       string `json:"body" form:"body" binding:"required,max=5000"`
+	MentionIDs []uint `json:"mention_ids" form:"mention_ids"`
+	Resubmit   bool   `json:"resubmit" form:"resubmit"` // Answer an info request and send the request back to its approvers
+}
+
+// CommentResponse is a message in a request's conversation
+type CommentResponse struct {
+	ID          uint                 `json:"id"`
+	RequestID   uint                 `json:"request_id"`
+	AuthorID    uint                 `json:"author_id"`
+	Author      *UserResponse        `json:"author,omitempty"`
+	Kind        string               `json:"kind"`
+	Body        string               `json:"body"`
+	Mentions    []UserResponse       `json:"mentions"` // Users notified of the comment
+	Attachments []AttachmentResponse `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func commentToResponse(comment *models.RequestComment) CommentResponse {
+	resp := CommentResponse{
+		ID:          comment.ID,
+		RequestID:   comment.RequestID,
+		AuthorID:    comment.AuthorID,
+		Author:      participantToResponse(&comment.Author),
+		Kind:        string(comment.Kind),
+		Body:        comment.Body,
+		Mentions:    make([]UserResponse, 0, len(comment.Mentions)),
+		Attachments: make([]AttachmentResponse, 0, len(comment.Attachments)),
+		CreatedAt:   comment.CreatedAt,
+		UpdatedAt:   comment.UpdatedAt,
+	}
+	for i := range comment.Mentions {
+		resp.Mentions = append(resp.Mentions, *participantToResponse(&comment.Mentions[i]))
+	}
+	// Files posted with comments stay with the conversation
+	for i := range comment.Attachments {
+		resp.Attachments = append(resp.Attachments, attachmentToResponse(&comment.Attachments[i], false))
+	}
+	return resp
+}
+
+// MentionableUser is a user who can be mentioned on a request
+type MentionableUser struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// ListComments returns a request's conversation, oldest first
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	request, _, ok := loadRequest(c, h.db, visibility.CanView)
+	if !ok {
+		return
+	}
+
+	comments := []models.RequestComment{}
+	if err := h.preloadComment(h.db).
+		Where("request_id = ?", request.ID).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch comments")
+		return
+	}
+
+	result := make([]CommentResponse, len(comments))
+	for i := range comments {
+		result[i] = commentToResponse(&comments[i])
+	}
+
+	response.Success(c, result)
+}
+
+// CreateComment posts a comment on a request, notifying the users it
+// mentions. The requester answers an information request by posting with
+// resubmit, which sends the request back to its approvers.
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	request, user, ok := loadRequest(c, h.db, visibility.CanView)
+	if !ok {
+		return
+	}
+
+	var input CreateCommentRequest
+	if err := c.ShouldBind(&input); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		response.BadRequest(c, "Comment cannot be empty")
+		return
+	}
+
+	kind := models.CommentNote
+	if input.Resubmit {
+		if request.RequesterID != user.ID {
+			response.Forbidden(c, "Only the requester can answer an information request")
+			return
+		}
+		if request.Status != models.StatusInfoRequested {
+			response.BadRequest(c, "Request is not awaiting information")
+			return
+		}
+		kind = models.CommentInfoResponse
+	}
+
+	mentions, err := h.mentionedUsers(request, input.MentionIDs)
+	if err != nil {
+		if errors.Is(err, errCannotMention) {
+			response.ValidationError(c, err.Error())
+		} else {
+			response.InternalServerError(c, "Failed to check mentioned users")
+		}
+		return
+	}
+
+	files, ok := storeUploads(c, h.store, "files")
+	if !ok {
+		return
+	}
+
+	comment := models.RequestComment{
+		RequestID: request.ID,
+		AuthorID:  user.ID,
+		Kind:      kind,
+		Body:      body,
+		Mentions:  mentions,
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Mentioned users are only linked, never saved
+		if err := tx.Omit("Mentions.*").Create(&comment).Error; err != nil {
+			return err
+		}
+		if _, err := createAttachments(tx, request, user.ID, models.AttachmentOther, &comment.ID, files); err != nil {
+			return err
+		}
+
+		if input.Resubmit {
+			request.Status = models.StatusPending
+			if err := tx.Model(request).Update("status", request.Status).Error; err != nil {
+				return err
+			}
+			if err := recordResubmission(tx, h.events, request, user.ID, body); err != nil {
+				return err
+			}
+		}
+
+		comment.Author = *user
+		return h.events.Dispatch(tx, events.Event{Type: events.RequestCommented, Request: request, ActorID: user.ID, Comment: body, RequestComment: &comment})
+	})
+	if err != nil {
+		removeFiles(h.store, files)
+		response.InternalServerError(c, "Failed to post comment")
+		return
+	}
+	h.jobQueue.Wake()
+
+	h.preloadComment(h.db).First(&comment, comment.ID)
+	response.Created(c, commentToResponse(&comment))
+}
+
+// ListMentionableUsers returns the users who can be mentioned on a request:
+// its requester, approvers and the users who took part in it
+func (h *CommentHandler) ListMentionableUsers(c *gin.Context) {
+	request, user, ok := loadRequest(c, h.db, visibility.CanView)
+	if !ok {
+		return
+	}
+
+	ids := []uint{request.RequesterID}
+	for _, step := range request.ApprovalSteps {
+		for _, id := range []*uint{step.ApproverID, step.ActedByID, step.OnBehalfOfID} {
+			if id != nil {
+				ids = append(ids, *id)
+			}
+		}
+	}
+	if step := request.PendingStep(); step != nil {
+		approvers, err := approval.Approvers(h.db, step, &request.Requester)
+		if err != nil {
+			response.InternalServerError(c, "Failed to fetch approvers")
+			return
+		}
+		for _, approver := range approvers {
+			ids = append(ids, approver.ID)
+		}
+	}
+	var participants []uint
+	if err := h.db.Model(&models.RequestHistory{}).Where("request_id = ?", request.ID).Distinct().Pluck("user_id", &participants).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch participants")
+		return
+	}
+	ids = append(ids, participants...)
+	if err := h.db.Model(&models.RequestComment{}).Where("request_id = ?", request.ID).Distinct().Pluck("author_id", &participants).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch participants")
+		return
+	}
+	ids = append(ids, participants...)
+
+	candidates, err := h.mentionedUsers(request, ids)
+	if err != nil && !errors.Is(err, errCannotMention) {
+		response.InternalServerError(c, "Failed to check users")
+		return
+	}
+	users := []MentionableUser{}
+	for _, candidate := range candidates {
+		if candidate.ID != user.ID {
+			users = append(users, MentionableUser{ID: candidate.ID, Name: candidate.Name, Email: candidate.Email})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	response.Success(c, users)
+}
+
+// mentionedUsers loads the users with the given IDs, skipping duplicates.
+// Along with the users who can be mentioned it returns errCannotMention if
+// any of them is inactive or cannot view the request.
+func (h *CommentHandler) mentionedUsers(request *models.PurchaseRequest, ids []uint) ([]models.User, error) {
+	users := []models.User{}
+	var rejected error
+	seen := make(map[uint]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		user, err := visibility.LoadUser(h.db, id, nil)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				rejected = errCannotMention
+				continue
+			}
+			return nil, err
+		}
+		if user.Permissions, err = h.roleService.Permissions(user.Role); err != nil {
+			return nil, err
+		}
+		if !user.IsActive() || !visibility.CanView(user, request) {
+			rejected = errCannotMention
+			continue
+		}
+		users = append(users, *user)
+	}
+	return users, rejected
+}
+
+func (h *CommentHandler) preloadComment(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Author", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Mentions", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") })
+}
+
+// recordResubmission logs that the requester answered an information request
+// and sent the request back to its approvers
+func recordResubmission(tx *gorm.DB, dispatcher *events.Dispatcher, request *models.PurchaseRequest, userID uint, answer string) error {
+	history := models.NewHistory(request.ID, userID, models.ActionResubmitted, models.StatusInfoRequested, models.StatusPending, answer)
+	if err := tx.Create(history).Error; err != nil {
+		return err
+	}
+	return dispatcher.Dispatch(tx, events.Event{Type: events.RequestResubmitted, Request: request, ActorID: userID, Comment: answer})
+}
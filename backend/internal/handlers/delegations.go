@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/approval"
+	"vista-backend/pkg/response"
+)
+
+type DelegationHandler struct {
+	db *gorm.DB
+}
+
+func NewDelegationHandler(db *gorm.DB) *DelegationHandler {
+	return &DelegationHandler{db: db}
+}
+
+type CreateDelegationRequest struct {
+	DelegatorID *uint      `json:"delegator_id"` // Defaults to the caller; delegating for others requires users.manage
+	DelegateID  uint       `json:"delegate_id" binding:"required"`
+	StartsAt    *time.Time `json:"starts_at"` // Defaults to now
+	EndsAt      time.Time  `json:"ends_at" binding:"required"`
+	Department  string     `json:"department"`  // Limits the delegation to requesters in the department
+	CostCenter  string     `json:"cost_center"` // Limits the delegation to requesters in the cost center
+	Reason      string     `json:"reason" binding:"max=255"`
+}
+
+type DelegationResponse struct {
+	ID         uint          `json:"id"`
+	Delegator  *UserResponse `json:"delegator"`
+	Delegate   *UserResponse `json:"delegate"`
+	StartsAt   time.Time     `json:"starts_at"`
+	EndsAt     time.Time     `json:"ends_at"`
+	Department string        `json:"department"`
+	CostCenter string        `json:"cost_center"`
+	Reason     string        `json:"reason"`
+	Active     bool          `json:"active"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// DelegateOption is a user who can be picked as a delegate. Any active user
+// can be one, so only what is needed to pick them is shown.
+type DelegateOption struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func delegationToResponse(d *models.ApprovalDelegation) DelegationResponse {
+	resp := DelegationResponse{
+		ID:         d.ID,
+		StartsAt:   d.StartsAt,
+		EndsAt:     d.EndsAt,
+		Department: d.Department,
+		CostCenter: d.CostCenter,
+		Reason:     d.Reason,
+		Active:     d.IsActive(time.Now()),
+		CreatedAt:  d.CreatedAt,
+	}
+	if d.Delegator.ID != 0 {
+		resp.Delegator = &UserResponse{
+			ID:    d.Delegator.ID,
+			Email: d.Delegator.Email,
+			Name:  d.Delegator.Name,
+			Role:  string(d.Delegator.Role),
+		}
+	}
+	if d.Delegate.ID != 0 {
+		resp.Delegate = &UserResponse{
+			ID:    d.Delegate.ID,
+			Email: d.Delegate.Email,
+			Name:  d.Delegate.Name,
+			Role:  string(d.Delegate.Role),
+		}
+	}
+	return resp
+}
+
+// ListDelegations returns the caller's delegations, both given and received.
+// Users with users.manage can list everyone's with all=true. Expired
+// delegations are included with include_expired=true.
+func (h *DelegationHandler) ListDelegations(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if c.Query("all") == "true" && middleware.HasPermission(c, models.PermUsersManage) {
+		userID = 0
+	}
+
+	delegations, err := approval.ListDelegations(h.db, userID, c.Query("include_expired") == "true")
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch delegations")
+		return
+	}
+
+	delegationResponses := make([]DelegationResponse, len(delegations))
+	for i := range delegations {
+		delegationResponses[i] = delegationToResponse(&delegations[i])
+	}
+
+	response.Success(c, delegationResponses)
+}
+
+// ListDelegates returns the active users the caller can delegate to
+func (h *DelegationHandler) ListDelegates(c *gin.Context) {
+	delegates := []DelegateOption{}
+	if err := h.db.Model(&models.User{}).Select("id", "name").
+		Where("status = ? AND id <> ?", "active", middleware.GetUserID(c)).
+		Order("name ASC").Find(&delegates).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch users")
+		return
+	}
+
+	response.Success(c, delegates)
+}
+
+// CreateDelegation lets a delegate approve on the delegator's behalf for a
+// period, such as while the delegator is out of office
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	var req CreateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	delegation := models.ApprovalDelegation{
+		DelegatorID: userID,
+		DelegateID:  req.DelegateID,
+		StartsAt:    time.Now(),
+		EndsAt:      req.EndsAt,
+		Department:  req.Department,
+		CostCenter:  req.CostCenter,
+		Reason:      req.Reason,
+		CreatedByID: userID,
+	}
+	if req.DelegatorID != nil && *req.DelegatorID != userID {
+		if !middleware.HasPermission(c, models.PermUsersManage) {
+			response.Forbidden(c, "You can only delegate your own approvals")
+			return
+		}
+		delegation.DelegatorID = *req.DelegatorID
+	}
+	if req.StartsAt != nil {
+		delegation.StartsAt = *req.StartsAt
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := approval.CreateDelegation(tx, &delegation); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceDelegation, delegation.ID, nil, delegationToResponse(&delegation))
+	})
+	if err != nil {
+		respondDelegationError(c, err, "Failed to create delegation")
+		return
+	}
+
+	response.Created(c, delegationToResponse(&delegation))
+}
+
+// DeleteDelegation ends a delegation. Only its delegator, whoever created it,
+// and users with users.manage can delete it; decisions already made under it
+// are kept.
+func (h *DelegationHandler) DeleteDelegation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid delegation ID")
+		return
+	}
+
+	delegation, err := approval.GetDelegation(h.db, uint(id))
+	if err != nil {
+		respondDelegationError(c, err, "Failed to fetch delegation")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if delegation.DelegatorID != userID && delegation.CreatedByID != userID && !middleware.HasPermission(c, models.PermUsersManage) {
+		response.Forbidden(c, "Access denied")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(delegation).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceDelegation, delegation.ID, delegationToResponse(delegation), nil)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to delete delegation")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Delegation deleted", nil)
+}
+
+// respondDelegationError writes the response for a failed delegation change
+func respondDelegationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, approval.ErrDelegationNotFound):
+		response.NotFound(c, "Delegation not found")
+	case errors.Is(err, approval.ErrSelfDelegation), errors.Is(err, approval.ErrDelegatorNotFound),
+		errors.Is(err, approval.ErrDelegateNotFound), errors.Is(err, approval.ErrInvalidDelegationEnd):
+		response.ValidationError(c, err.Error())
+	default:
+		response.InternalServerError(c, fallback)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+	"vista-backend/pkg/response"
+)
+
+type ExchangeRateHandler struct {
+	db        *gorm.DB
+	converter *currency.Converter
+}
+
+func NewExchangeRateHandler(db *gorm.DB, converter *currency.Converter) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		db:        db,
+		converter: converter,
+	}
+}
+
+type ExchangeRateRequest struct {
+	Currency      string  `json:"currency" binding:"required"`
+	Rate          float64 `json:"rate" binding:"required,gt=0"`
+	EffectiveDate string  `json:"effective_date"` // YYYY-MM-DD, defaults to today
+}
+
+// ListExchangeRates returns exchange rates into the base currency, newest first
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	query := h.db.Model(&models.ExchangeRate{}).Where("base_currency = ?", h.converter.Base())
+
+	if code := c.Query("currency"); code != "" {
+		query = query.Where("currency = ?", currency.Code(code))
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Order("effective_date DESC, currency ASC").Find(&rates).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch exchange rates")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"base_currency": h.converter.Base(),
+		"rates":         rates,
+	})
+}
+
+// CreateExchangeRate records a manually maintained exchange rate
+func (h *ExchangeRateHandler) CreateExchangeRate(c *gin.Context) {
+	var req ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	code := currency.Code(req.Currency)
+	if code == h.converter.Base() {
+		response.ValidationError(c, "The base currency does not need an exchange rate")
+		return
+	}
+
+	effectiveDate := time.Now().Truncate(24 * time.Hour)
+	if req.EffectiveDate != "" {
+		date, err := time.Parse("2006-01-02", req.EffectiveDate)
+		if err != nil {
+			response.ValidationError(c, "Invalid effective_date, expected YYYY-MM-DD")
+			return
+		}
+		effectiveDate = date
+	}
+
+	userID := middleware.GetUserID(c)
+	rate := models.ExchangeRate{
+		Currency:      code,
+		BaseCurrency:  h.converter.Base(),
+		Rate:          req.Rate,
+		EffectiveDate: effectiveDate,
+		Source:        models.RateSourceManual,
+		CreatedByID:   &userID,
+	}
+	if err := h.db.Create(&rate).Error; err != nil {
+		response.InternalServerError(c, "Failed to create exchange rate")
+		return
+	}
+
+	h.normalizeRequests()
+	response.Created(c, rate)
+}
+
+// DeleteExchangeRate deletes an exchange rate. Requests keep the rate they were normalized with.
+func (h *ExchangeRateHandler) DeleteExchangeRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid exchange rate ID")
+		return
+	}
+
+	var rate models.ExchangeRate
+	if err := h.db.First(&rate, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Exchange rate not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch exchange rate")
+		}
+		return
+	}
+
+	if err := h.db.Delete(&rate).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete exchange rate")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Exchange rate deleted successfully", nil)
+}
+
+// ImportExchangeRates imports exchange rates from an uploaded CSV file
+func (h *ExchangeRateHandler) ImportExchangeRates(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided")
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.InternalServerError(c, "Failed to read file")
+		return
+	}
+	defer f.Close()
+
+	userID := middleware.GetUserID(c)
+	imported, err := h.converter.ImportCSV(f, &userID)
+	if err != nil {
+		response.ValidationError(c, "Invalid exchange rate file: "+err.Error())
+		return
+	}
+
+	normalized := h.normalizeRequests()
+	response.SuccessWithMessage(c, "Exchange rates imported", gin.H{
+		"imported":            imported,
+		"requests_normalized": normalized,
+	})
+}
+
+// normalizeRequests fills in base currency amounts that new rates made available
+func (h *ExchangeRateHandler) normalizeRequests() int {
+	updated, err := h.converter.NormalizeMissing()
+	if err != nil {
+		log.Printf("Failed to normalize request amounts: %v", err)
+	}
+	return updated
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+type FilterRuleHandler struct {
+	db *gorm.DB
+}
+
+func NewFilterRuleHandler(db *gorm.DB) *FilterRuleHandler {
+	return &FilterRuleHandler{db: db}
+}
+
+type FilterRuleRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	RuleType    string `json:"rule_type" binding:"required,oneof=price_max price_min category_allow category_block supplier_block keyword_block"`
+	Value       string `json:"value" binding:"required"`
+	Action      string `json:"action" binding:"omitempty,oneof=block flag"`
+	Priority    int    `json:"priority"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// ListFilterRules returns all filter rules ordered by priority
+func (h *FilterRuleHandler) ListFilterRules(c *gin.Context) {
+	var rules []models.FilterRule
+	if err := h.db.Order("priority DESC, id ASC").Find(&rules).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch filter rules")
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// CreateFilterRule creates a new filter rule
+func (h *FilterRuleHandler) CreateFilterRule(c *gin.Context) {
+	var req FilterRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	rule := models.FilterRule{
+		IsActive:    true,
+		CreatedByID: middleware.GetUserID(c),
+	}
+	if msg := applyFilterRuleRequest(&rule, &req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+
+	if err := h.db.Create(&rule).Error; err != nil {
+		response.InternalServerError(c, "Failed to create filter rule")
+		return
+	}
+	// GORM skips zero values for columns with defaults on insert
+	if !rule.IsActive {
+		h.db.Model(&rule).Update("is_active", false)
+	}
+
+	response.Created(c, rule)
+}
+
+// UpdateFilterRule updates an existing filter rule
+func (h *FilterRuleHandler) UpdateFilterRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	var req FilterRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if msg := applyFilterRuleRequest(rule, &req); msg != "" {
+		response.ValidationError(c, msg)
+		return
+	}
+
+	if err := h.db.Save(rule).Error; err != nil {
+		response.InternalServerError(c, "Failed to update filter rule")
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// ToggleFilterRule toggles a filter rule active/inactive
+func (h *FilterRuleHandler) ToggleFilterRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	rule.IsActive = !rule.IsActive
+	if err := h.db.Model(rule).Update("is_active", rule.IsActive).Error; err != nil {
+		response.InternalServerError(c, "Failed to update filter rule")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Filter rule updated", rule)
+}
+
+// DeleteFilterRule deletes a filter rule
+func (h *FilterRuleHandler) DeleteFilterRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(rule).Error; err != nil {
+		response.InternalServerError(c, "Failed to delete filter rule")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Filter rule deleted successfully", nil)
+}
+
+func (h *FilterRuleHandler) findRule(c *gin.Context) (*models.FilterRule, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID")
+		return nil, false
+	}
+
+	var rule models.FilterRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Filter rule not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch filter rule")
+		}
+		return nil, false
+	}
+	return &rule, true
+}
+
+// applyFilterRuleRequest copies the request onto the rule and returns a validation message
+func applyFilterRuleRequest(rule *models.FilterRule, req *FilterRuleRequest) string {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.RuleType = models.FilterRuleType(req.RuleType)
+	rule.Value = strings.TrimSpace(req.Value)
+	rule.Priority = req.Priority
+
+	rule.Action = models.FilterActionBlock
+	if req.Action != "" {
+		rule.Action = models.FilterAction(req.Action)
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if rule.Value == "" {
+		return "Value is required"
+	}
+	if rule.IsPriceRule() {
+		if price, err := strconv.ParseFloat(rule.Value, 64); err != nil || price < 0 {
+			return "Price rules require a non-negative numeric value"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/handlers"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/attachments"
+	"vista-backend/internal/services/budget"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/internal/testutil"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// env wires the handlers under test to one database, as main does
+type env struct {
+	db        *gorm.DB
+	roles     *services.RoleService
+	requests  *handlers.RequestHandler
+	approvals *handlers.ApprovalHandler
+	admin     *handlers.AdminHandler
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	db := testutil.NewDB(t)
+	converter := currency.NewConverter(db, "MXN")
+	chainSvc := approval.NewChainService(db)
+	budgetTracker := budget.NewTracker(db, converter)
+	inventorySvc := inventory.NewService(db)
+	jobQueue := jobs.NewQueue(db, jobs.Config{})
+	dispatcher := events.NewDispatcher()
+	store, err := attachments.NewStore(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	return &env{
+		db:        db,
+		roles:     services.NewRoleService(db),
+		requests:  handlers.NewRequestHandler(db, chainSvc, converter, jobQueue, dispatcher),
+		approvals: handlers.NewApprovalHandler(db, jobQueue, chainSvc, budgetTracker, converter, inventorySvc, dispatcher),
+		admin:     handlers.NewAdminHandler(db, nil, nil, jobQueue, budgetTracker, converter, inventorySvc, store, dispatcher),
+	}
+}
+
+func (e *env) createUser(t *testing.T, name string, role models.UserRole) *models.User {
+	t.Helper()
+	user := models.User{
+		Email:        name + "@example.com",
+		PasswordHash: "x",
+		Name:         name,
+		Role:         role,
+		CostCenter:   "CC-100",
+		Status:       "active",
+	}
+	if err := e.db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &user
+}
+
+// createBudget gives cost center CC-100 a budget for the current month
+func (e *env) createBudget(t *testing.T, amount float64) *models.Budget {
+	t.Helper()
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	b := models.Budget{
+		CostCenter:  "CC-100",
+		PeriodType:  models.BudgetMonthly,
+		Period:      start.Format("2006-01"),
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Amount:      amount,
+		Currency:    "MXN",
+	}
+	if err := e.db.Create(&b).Error; err != nil {
+		t.Fatalf("create budget: %v", err)
+	}
+	return &b
+}
+
+// submitRequest creates a pending single line request bought from a URL and
+// starts its approval chain
+func (e *env) submitRequest(t *testing.T, requester *models.User, amount float64) *models.PurchaseRequest {
+	t.Helper()
+	request := models.PurchaseRequest{
+		RequestNumber:    models.GenerateRequestNumber(e.db),
+		URL:              "https://example.com/item",
+		ProductTitle:     "Item",
+		Justification:    "Needed",
+		EstimatedPrice:   &amount,
+		Currency:         "MXN",
+		TotalAmount:      amount,
+		NormalizedAmount: &amount,
+		BaseCurrency:     "MXN",
+		Quantity:         1,
+		RequesterID:      requester.ID,
+		Status:           models.StatusPending,
+	}
+	if err := e.db.Create(&request).Error; err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	item := models.RequestItem{
+		RequestID:    request.ID,
+		LineNumber:   1,
+		URL:          request.URL,
+		ProductTitle: request.ProductTitle,
+		Quantity:     1,
+		UnitPrice:    &amount,
+		LineTotal:    amount,
+	}
+	if err := e.db.Create(&item).Error; err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+	if err := approval.NewChainService(e.db).StartChain(e.db, &request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+	return &request
+}
+
+// call runs a handler as the user on the request with the given id, with body
+// sent as JSON, and returns the response
+func (e *env) call(t *testing.T, handler gin.HandlerFunc, user *models.User, id uint, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	permissions, err := e.roles.Permissions(user.Role)
+	if err != nil {
+		t.Fatalf("Permissions: %v", err)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal body: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(id), 10)}}
+	c.Set(middleware.UserIDKey, user.ID)
+	c.Set(middleware.UserEmailKey, user.Email)
+	c.Set(middleware.UserRoleKey, string(user.Role))
+	c.Set(middleware.UserPermissionsKey, permissions)
+
+	handler(c)
+	return w
+}
+
+// afterFirstLoad runs fn once, right after the next query that loads purchase
+// requests. It stands in for a concurrent call that completes between a
+// handler loading a request and writing it.
+func afterFirstLoad(t *testing.T, db *gorm.DB, fn func()) {
+	t.Helper()
+	fired := false
+	err := db.Callback().Query().After("gorm:query").Register("test:after_first_load", func(tx *gorm.DB) {
+		if fired || tx.Statement.Table != "purchase_requests" {
+			return
+		}
+		fired = true
+		fn()
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+}
+
+func loadRequest(t *testing.T, db *gorm.DB, id uint) *models.PurchaseRequest {
+	t.Helper()
+	var request models.PurchaseRequest
+	if err := db.First(&request, id).Error; err != nil {
+		t.Fatalf("load request: %v", err)
+	}
+	return &request
+}
+
+func loadBudget(t *testing.T, db *gorm.DB, id uint) *models.Budget {
+	t.Helper()
+	var b models.Budget
+	if err := db.First(&b, id).Error; err != nil {
+		t.Fatalf("load budget: %v", err)
+	}
+	return &b
+}
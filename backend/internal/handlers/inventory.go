@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/pkg/response"
+)
+
+type InventoryHandler struct {
+	db      *gorm.DB
+	monitor *inventory.Monitor
+}
+
+func NewInventoryHandler(db *gorm.DB, monitor *inventory.Monitor) *InventoryHandler {
+	return &InventoryHandler{
+		db:      db,
+		monitor: monitor,
+	}
+}
+
+// ListStockAlerts returns low stock alerts, newest first
+func (h *InventoryHandler) ListStockAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Model(&models.StockAlert{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var alerts []models.StockAlert
+	if err := query.
+		Preload("Product").
+		Preload("ReplenishmentRequest").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&alerts).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch stock alerts")
+		return
+	}
+
+	response.SuccessWithMeta(c, alerts, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// CheckStock runs the low stock check immediately
+func (h *InventoryHandler) CheckStock(c *gin.Context) {
+	result, err := h.monitor.Check()
+	if err != nil {
+		response.InternalServerError(c, "Failed to check stock levels")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ReplenishStockAlert raises a replenishment request for an open alert
+func (h *InventoryHandler) ReplenishStockAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid alert ID")
+		return
+	}
+
+	request, err := h.monitor.Replenish(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.NotFound(c, "Stock alert not found")
+		case errors.Is(err, inventory.ErrAlertResolved):
+			response.BadRequest(c, "Stock alert is already resolved")
+		case errors.Is(err, inventory.ErrAlreadyRequested):
+			response.Conflict(c, "Replenishment has already been requested for this alert")
+		case errors.Is(err, inventory.ErrNothingToReorder):
+			response.ValidationError(c, "Set a maximum stock above the available stock to replenish")
+		case errors.Is(err, currency.ErrRateNotFound):
+			response.ValidationError(c, "No exchange rate is available for the product's currency; ask an admin to add one")
+		case errors.Is(err, inventory.ErrNoRequester):
+			response.InternalServerError(c, "No active admin or supply chain manager to raise the request")
+		default:
+			response.InternalServerError(c, "Failed to create replenishment request")
+		}
+		return
+	}
+
+	h.db.
+		Preload("Requester").
+		Preload("Items", preloadRequestItems).
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		First(request, request.ID)
+
+	response.Created(c, requestToResponse(*request))
+}
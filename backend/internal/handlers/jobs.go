@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/pkg/response"
+)
+
+type JobHandler struct {
+	db       *gorm.DB
+	jobQueue *jobs.Queue
+}
+
+func NewJobHandler(db *gorm.DB, jobQueue *jobs.Queue) *JobHandler {
+	return &JobHandler{
+		db:       db,
+		jobQueue: jobQueue,
+	}
+}
+
+// ListJobs returns background jobs with optional status, type and request filters
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	status := c.Query("status")
+	jobType := c.Query("type")
+	requestID := c.Query("request_id")
+
+	offset := (page - 1) * perPage
+
+	query := h.db.Model(&models.Job{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var jobList []models.Job
+	if err := query.Offset(offset).Limit(perPage).Order("created_at DESC").Find(&jobList).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch jobs")
+		return
+	}
+
+	response.SuccessWithMeta(c, jobList, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// GetJob returns a job with its attempt history
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid job ID")
+		return
+	}
+
+	var job models.Job
+	err = h.db.
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("job_attempts.attempt ASC, job_attempts.id ASC")
+		}).
+		First(&job, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Job not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch job")
+		}
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// RetryJob requeues a failed or cancelled job
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobQueue.Retry(uint(id))
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "Job queued for retry", job)
+}
+
+// CancelJob cancels a queued job
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobQueue.Cancel(uint(id))
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "Job cancelled", job)
+}
+
+func (h *JobHandler) handleJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.NotFound(c, "Job not found")
+	case errors.Is(err, jobs.ErrJobNotRetryable):
+		response.BadRequest(c, "Only failed or cancelled jobs can be retried")
+	case errors.Is(err, jobs.ErrJobNotCancellable):
+		response.BadRequest(c, "Only queued jobs can be cancelled")
+	case errors.Is(err, jobs.ErrJobActive):
+		response.Conflict(c, "Another job of this type is already queued or running for the request")
+	default:
+		response.InternalServerError(c, "Failed to update job")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type LoginLockoutHandler struct {
+	db         *gorm.DB
+	loginGuard *services.LoginGuard
+}
+
+func NewLoginLockoutHandler(db *gorm.DB, loginGuard *services.LoginGuard) *LoginLockoutHandler {
+	return &LoginLockoutHandler{db: db, loginGuard: loginGuard}
+}
+
+// ListLoginLockouts returns the emails and IPs with recent failed logins.
+// Pass locked=true for only those currently locked out, and kind=account|ip to filter.
+func (h *LoginLockoutHandler) ListLoginLockouts(c *gin.Context) {
+	query := h.db.Model(&models.LoginThrottle{})
+
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if c.Query("locked") == "true" {
+		query = query.Where("locked_until > ?", time.Now())
+	}
+
+	var throttles []models.LoginThrottle
+	if err := query.Order("updated_at DESC").Find(&throttles).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch login lockouts")
+		return
+	}
+
+	response.Success(c, throttles)
+}
+
+// UnlockLogin clears the failed logins and lockout of an email or IP
+func (h *LoginLockoutHandler) UnlockLogin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid lockout ID")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		throttle, err := h.loginGuard.Unlock(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionUnlock, models.AuditResourceLoginThrottle, throttle.ID, throttle, nil)
+	})
+	if err != nil {
+		if err == services.ErrThrottleNotFound {
+			response.NotFound(c, "Login lockout not found")
+		} else {
+			response.InternalServerError(c, "Failed to unlock login")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Login unlocked", nil)
+}
+
+// UnlockUser clears the failed logins and lockout of a user's account
+func (h *LoginLockoutHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		throttle, err := h.loginGuard.UnlockAccount(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionUnlock, models.AuditResourceLoginThrottle, throttle.ID, throttle, nil)
+	})
+	if err != nil {
+		if err == services.ErrThrottleNotFound {
+			response.NotFound(c, "User has no failed logins to clear")
+		} else {
+			response.InternalServerError(c, "Failed to unlock user")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "User unlocked", nil)
+}
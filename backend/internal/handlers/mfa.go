@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+// MFAChallengeResponse is returned by login instead of tokens when a second factor is needed
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	SetupRequired  bool   `json:"setup_required"` // Enroll with /auth/mfa/setup before verifying
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+type MFAStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // Mandatory for the user's role
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type VerifyMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type MFASetupRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type ConfirmMFASetupRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// VerifyMFA completes a login with a TOTP code or a recovery code
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.authService.VerifyMFA(req.ChallengeToken, req.Code, req.RecoveryCode, clientInfo(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	respondLogin(c, result)
+}
+
+// BeginMFASetup returns a new TOTP secret for a login that must enroll before continuing
+func (h *AuthHandler) BeginMFASetup(c *gin.Context) {
+	var req MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	enrollment, err := h.authService.BeginMFASetup(req.ChallengeToken, clientInfo(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// ConfirmMFASetup enables 2FA with a code from the new authenticator and completes the login
+func (h *AuthHandler) ConfirmMFASetup(c *gin.Context) {
+	var req ConfirmMFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.authService.CompleteMFASetup(req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	respondLogin(c, result)
+}
+
+// GetMFAStatus returns the current user's two-factor authentication status
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	remaining, err := h.mfaService.RecoveryCodesRemaining(user.ID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch recovery codes")
+		return
+	}
+
+	response.Success(c, MFAStatusResponse{
+		Enabled:                user.MFAEnabled,
+		Required:               h.mfaService.Required(user),
+		EnabledAt:              user.MFAEnabledAt,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// EnrollMFA returns a new TOTP secret for the current user to confirm with EnableMFA
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(user)
+	if err != nil {
+		if err == services.ErrMFAAlreadyEnabled {
+			response.Conflict(c, "Two-factor authentication is already enabled")
+		} else {
+			response.InternalServerError(c, "Failed to start two-factor setup")
+		}
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// EnableMFA confirms the current user's enrollment and returns their recovery codes
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	codes, err := h.mfaService.Enable(user, req.Code, clientInfo(c))
+	if err != nil {
+		respondMFAError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Two-factor authentication enabled", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA turns off two-factor authentication for the current user
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	if err := h.mfaService.Disable(user, req.Password, req.Code, clientInfo(c)); err != nil {
+		respondMFAError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.authService.GetUserByID(middleware.GetUserID(c))
+	if err != nil {
+		response.NotFound(c, "User not found")
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(user, req.Code, clientInfo(c))
+	if err != nil {
+		respondMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Recovery codes regenerated", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// respondMFAError writes the response for a failed 2FA management request
+func respondMFAError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrInvalidMFACode:
+		response.BadRequest(c, "Invalid authentication code")
+	case services.ErrInvalidCredentials:
+		response.BadRequest(c, "Current password is incorrect")
+	case services.ErrMFAAlreadyEnabled:
+		response.Conflict(c, "Two-factor authentication is already enabled")
+	case services.ErrMFANotEnrolled:
+		response.BadRequest(c, "Start two-factor setup first")
+	case services.ErrMFANotEnabled:
+		response.BadRequest(c, "Two-factor authentication is not enabled")
+	case services.ErrMFAMandatory:
+		response.Forbidden(c, "Two-factor authentication is mandatory for your role")
+	default:
+		response.InternalServerError(c, message)
+	}
+}
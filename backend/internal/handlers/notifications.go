@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/pkg/response"
+)
+
+type NotificationHandler struct {
+	db *gorm.DB
+}
+
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+type NotificationPreferenceInput struct {
+	Type  models.NotificationType `json:"type" binding:"required"`
+	InApp bool                    `json:"in_app"`
+	Email bool                    `json:"email"`
+}
+
+// UpdateNotificationPreferencesRequest sets the channels of some
+// notification types; types left out keep their current channels
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceInput `json:"preferences" binding:"required,dive"`
+}
+
+// ListNotifications returns the caller's notifications, newest first.
+// unread=true returns only unread ones.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+
+	offset := (page - 1) * perPage
+
+	query := h.db.Model(&models.Notification{}).Where("user_id = ?", middleware.GetUserID(c))
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	notifications := []models.Notification{}
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(perPage).Find(&notifications).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch notifications")
+		return
+	}
+
+	response.SuccessWithMeta(c, notifications, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// GetUnreadCount returns how many of the caller's notifications are unread
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	var count int64
+	if err := h.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", middleware.GetUserID(c)).
+		Count(&count).Error; err != nil {
+		response.InternalServerError(c, "Failed to count notifications")
+		return
+	}
+
+	response.Success(c, gin.H{"count": count})
+}
+
+// MarkNotificationRead marks one of the caller's notifications as read
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid notification ID")
+		return
+	}
+
+	var notification models.Notification
+	if err := h.db.Where("id = ? AND user_id = ?", id, middleware.GetUserID(c)).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Notification not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch notification")
+		}
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := h.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			response.InternalServerError(c, "Failed to update notification")
+			return
+		}
+	}
+
+	response.Success(c, notification)
+}
+
+// MarkAllNotificationsRead marks all of the caller's notifications as read
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	result := h.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", middleware.GetUserID(c)).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		response.InternalServerError(c, "Failed to update notifications")
+		return
+	}
+
+	response.Success(c, gin.H{"updated": result.RowsAffected})
+}
+
+// GetNotificationPreferences returns the caller's channels for every
+// notification type, including the defaults of types they have not set
+func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	preferences, err := h.preferences(middleware.GetUserID(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch notification preferences")
+		return
+	}
+
+	response.Success(c, preferences)
+}
+
+// UpdateNotificationPreferences sets the caller's channels for notification types
+func (h *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	known := make(map[models.NotificationType]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		known[notificationType] = true
+	}
+
+	userID := middleware.GetUserID(c)
+	preferences := make([]models.NotificationPreference, len(req.Preferences))
+	for i, input := range req.Preferences {
+		if !known[input.Type] {
+			response.ValidationError(c, "Unknown notification type: "+string(input.Type))
+			return
+		}
+		preferences[i] = models.NotificationPreference{
+			UserID: userID,
+			Type:   input.Type,
+			InApp:  input.InApp,
+			Email:  input.Email,
+		}
+	}
+
+	if len(preferences) > 0 {
+		err := h.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+		}).Create(&preferences).Error
+		if err != nil {
+			response.InternalServerError(c, "Failed to update notification preferences")
+			return
+		}
+	}
+
+	updated, err := h.preferences(userID)
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch notification preferences")
+		return
+	}
+
+	response.Success(c, updated)
+}
+
+// preferences returns the user's preference for every notification type
+func (h *NotificationHandler) preferences(userID uint) ([]models.NotificationPreference, error) {
+	var saved []models.NotificationPreference
+	if err := h.db.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	savedByType := make(map[models.NotificationType]models.NotificationPreference, len(saved))
+	for _, preference := range saved {
+		savedByType[preference.Type] = preference
+	}
+
+	preferences := make([]models.NotificationPreference, len(models.NotificationTypes))
+	for i, notificationType := range models.NotificationTypes {
+		preference, ok := savedByType[notificationType]
+		if !ok {
+			preference = models.NotificationPreference{UserID: userID, Type: notificationType, InApp: true, Email: true}
+		}
+		preferences[i] = preference
+	}
+	return preferences, nil
+}
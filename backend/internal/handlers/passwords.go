@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
+}
+
+// GetPasswordPolicy returns the rules new passwords must follow
+func (h *AuthHandler) GetPasswordPolicy(c *gin.Context) {
+	response.Success(c, h.passwordPolicy.Rules())
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not an account exists for the email.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.passwordTokens.RequestReset(req.Email); err != nil {
+		response.InternalServerError(c, "Failed to request password reset")
+		return
+	}
+
+	response.SuccessWithMessage(c, "If an account exists for this email, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a reset or invitation link's token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.passwordTokens.ResetPassword(req.Token, req.Password, clientInfo(c))
+	if err != nil {
+		if services.IsPasswordPolicyError(err) {
+			response.ValidationError(c, err.Error())
+			return
+		}
+		switch err {
+		case services.ErrInvalidPasswordToken:
+			response.BadRequest(c, "This link is invalid, has expired or has already been used")
+		case services.ErrUserInactive:
+			response.Forbidden(c, "Account is inactive")
+		default:
+			response.InternalServerError(c, "Failed to reset password")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Password has been set; you can now sign in", gin.H{"email": user.Email})
+}
@@ -1,21 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
+	"vista-backend/internal/services/inventory"
 	"vista-backend/pkg/response"
 )
 
 type ProductHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	inventorySvc *inventory.Service
 }
 
-func NewProductHandler(db *gorm.DB) *ProductHandler {
-	return &ProductHandler{db: db}
+func NewProductHandler(db *gorm.DB, inventorySvc *inventory.Service) *ProductHandler {
+	return &ProductHandler{db: db, inventorySvc: inventorySvc}
 }
 
 type ProductResponse struct {
@@ -35,6 +39,7 @@ type ProductResponse struct {
 	Price         float64               `json:"price"`
 	Currency      string                `json:"currency"`
 	Stock         int                   `json:"stock"`
+	ReservedStock int                   `json:"reserved_stock"`
 	MinStock      int                   `json:"min_stock"`
 	MaxStock      int                   `json:"max_stock"`
 	Location      string                `json:"location"`
@@ -104,6 +109,7 @@ type UpdateProductRequest struct {
 	Stock         *int                `json:"stock" binding:"omitempty,gte=0"`
 	MinStock      *int                `json:"min_stock"`
 	MaxStock      *int                `json:"max_stock"`
+	StockReason   string              `json:"stock_reason"` // Recorded with the stock adjustment
 	Location      string              `json:"location"`
 	ImageURL      string              `json:"image_url"`
 	ImageEmoji    string              `json:"image_emoji"`
@@ -141,6 +147,7 @@ func productToResponse(p models.Product) ProductResponse {
 		Price:         p.Price,
 		Currency:      p.Currency,
 		Stock:         p.Stock,
+		ReservedStock: p.ReservedStock,
 		MinStock:      p.MinStock,
 		MaxStock:      p.MaxStock,
 		Location:      p.Location,
@@ -296,7 +303,6 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		SupplierCode:  req.SupplierCode,
 		Price:         req.Price,
 		Currency:      currency,
-		Stock:         req.Stock,
 		MinStock:      req.MinStock,
 		MaxStock:      req.MaxStock,
 		Location:      req.Location,
@@ -307,7 +313,29 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		IsActive:      true,
 	}
 
-	if err := h.db.Create(&product).Error; err != nil {
+	userID := middleware.GetUserID(c)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		// Initial stock enters through the ledger
+		if req.Stock > 0 {
+			movement, err := h.inventorySvc.Record(tx, inventory.Movement{
+				ProductID: product.ID,
+				Type:      models.MovementReceipt,
+				Quantity:  req.Stock,
+				Reason:    "Initial stock",
+				UserID:    &userID,
+			})
+			if err != nil {
+				return err
+			}
+			product.Stock = movement.StockAfter
+		}
+		return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceProduct, product.ID, nil, productToResponse(product))
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to create product")
 		return
 	}
@@ -355,6 +383,8 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	before := productToResponse(product)
+
 	if req.Name != "" {
 		product.Name = req.Name
 	}
@@ -394,9 +424,6 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Currency != "" {
 		product.Currency = req.Currency
 	}
-	if req.Stock != nil {
-		product.Stock = *req.Stock
-	}
 	if req.MinStock != nil {
 		product.MinStock = *req.MinStock
 	}
@@ -419,8 +446,35 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		product.IsActive = *req.IsActive
 	}
 
-	if err := h.db.Save(&product).Error; err != nil {
-		response.InternalServerError(c, "Failed to update product")
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Stock only changes through the movement ledger
+		if err := tx.Omit("Stock", "ReservedStock").Save(&product).Error; err != nil {
+			return err
+		}
+
+		after := productToResponse(product)
+		if req.Stock != nil {
+			reason := req.StockReason
+			if reason == "" {
+				reason = "Stock updated from product edit"
+			}
+			userID := middleware.GetUserID(c)
+			movement, err := h.inventorySvc.SetStock(tx, product.ID, *req.Stock, reason, &userID)
+			if err != nil {
+				return err
+			}
+			if movement != nil {
+				after.Stock = movement.StockAfter
+			}
+		}
+		return recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceProduct, product.ID, before, after)
+	})
+	if err != nil {
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			response.Conflict(c, "Stock cannot go below the reserved quantity")
+		} else {
+			response.InternalServerError(c, "Failed to update product")
+		}
 		return
 	}
 
@@ -464,7 +518,13 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Delete(&product).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceProduct, product.ID, productToResponse(product), nil)
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to delete product")
 		return
 	}
@@ -472,7 +532,18 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	response.SuccessWithMessage(c, "Product deleted successfully", nil)
 }
 
-// UpdateStock updates product stock
+// StockMovementRequest records a stock movement. Sending only stock records the
+// adjustment to that counted level.
+type StockMovementRequest struct {
+	Type      string `json:"type" binding:"omitempty,oneof=receipt issue adjustment transfer return"`
+	Quantity  int    `json:"quantity"`                        // Signed for adjustments
+	Stock     *int   `json:"stock" binding:"omitempty,gte=0"` // Counted stock level
+	Location  string `json:"location"`                        // Transfer destination
+	Reason    string `json:"reason"`
+	RequestID *uint  `json:"request_id"`
+}
+
+// UpdateStock records a stock movement for a product
 func (h *ProductHandler) UpdateStock(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -480,13 +551,15 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Stock int `json:"stock" binding:"required,gte=0"`
-	}
+	var req StockMovementRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.Type == "" && req.Stock == nil {
+		response.BadRequest(c, "Provide either type and quantity or stock")
+		return
+	}
 
 	var product models.Product
 	if err := h.db.First(&product, id).Error; err != nil {
@@ -498,11 +571,158 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	product.Stock = req.Stock
-	if err := h.db.Save(&product).Error; err != nil {
-		response.InternalServerError(c, "Failed to update stock")
+	if req.RequestID != nil {
+		var count int64
+		h.db.Model(&models.PurchaseRequest{}).Where("id = ?", *req.RequestID).Count(&count)
+		if count == 0 {
+			response.ValidationError(c, "Referenced request not found")
+			return
+		}
+	}
+
+	userID := middleware.GetUserID(c)
+	var movement *models.StockMovement
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if req.Type == "" {
+			reason := req.Reason
+			if reason == "" {
+				reason = "Stock count"
+			}
+			movement, err = h.inventorySvc.SetStock(tx, product.ID, *req.Stock, reason, &userID)
+		} else {
+			movement, err = h.inventorySvc.Record(tx, inventory.Movement{
+				ProductID: product.ID,
+				Type:      models.StockMovementType(req.Type),
+				Quantity:  req.Quantity,
+				Reason:    req.Reason,
+				Location:  req.Location,
+				UserID:    &userID,
+				RequestID: req.RequestID,
+			})
+		}
+		if err != nil || movement == nil {
+			return err
+		}
+
+		return recordAudit(tx, c, models.AuditActionStockChange, models.AuditResourceProduct, product.ID,
+			gin.H{"stock": product.Stock, "location": product.Location}, movement)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrInvalidMovement):
+			response.ValidationError(c, err.Error())
+		case errors.Is(err, inventory.ErrInsufficientStock):
+			response.Conflict(c, "Stock cannot go below the reserved quantity")
+		default:
+			response.InternalServerError(c, "Failed to update stock")
+		}
 		return
 	}
 
-	response.Success(c, productToResponse(product))
+	h.db.Preload("Images").First(&product, product.ID)
+	response.Success(c, gin.H{
+		"product":  productToResponse(product),
+		"movement": movement,
+	})
+}
+
+// ListStockMovements returns a product's movement history, newest first
+func (h *ProductHandler) ListStockMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID")
+		return
+	}
+
+	var product models.Product
+	if err := h.db.Unscoped().First(&product, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Product not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch product")
+		}
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := h.db.Model(&models.StockMovement{}).Where("product_id = ?", product.ID)
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if requestID := c.Query("request_id"); requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var movements []models.StockMovement
+	if err := query.Preload("User").Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).Find(&movements).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch stock movements")
+		return
+	}
+
+	response.SuccessWithMeta(c, movements, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// ReconcileStock compares a product's stock with its ledger and resets it to the ledger level
+func (h *ProductHandler) ReconcileStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID")
+		return
+	}
+
+	var product models.Product
+	if err := h.db.First(&product, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Product not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch product")
+		}
+		return
+	}
+
+	previousStock := product.Stock
+	var ledgerStock int
+	var drifted bool
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ledgerStock, drifted, err = h.inventorySvc.Reconcile(tx, product.ID)
+		if err != nil || !drifted {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionStockChange, models.AuditResourceProduct, product.ID,
+			gin.H{"stock": previousStock}, gin.H{"stock": ledgerStock, "reason": "Reconciled with ledger"})
+	})
+	if err != nil {
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			response.Conflict(c, "Ledger stock is below the reserved quantity; record the missing movements before reconciling")
+		} else {
+			response.InternalServerError(c, "Failed to reconcile stock")
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"product_id":     product.ID,
+		"previous_stock": previousStock,
+		"ledger_stock":   ledgerStock,
+		"corrected":      drifted,
+	})
 }
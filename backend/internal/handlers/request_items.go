@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/filter"
+	"vista-backend/pkg/response"
+)
+
+type RequestItemResponse struct {
+	ID              uint     `json:"id"`
+	LineNumber      int      `json:"line_number"`
+	ProductID       *uint    `json:"product_id,omitempty"`
+	SKU             string   `json:"sku,omitempty"`
+	URL             string   `json:"url,omitempty"`
+	ProductTitle    string   `json:"product_title"`
+	ProductImageURL string   `json:"product_image_url"`
+	SiteName        string   `json:"site_name,omitempty"`
+	Quantity        int      `json:"quantity"`
+	UnitPrice       *float64 `json:"unit_price,omitempty"`
+	Currency        string   `json:"currency"`
+	LineTotal       float64  `json:"line_total"`
+
+	FulfillmentStatus string `json:"fulfillment_status,omitempty"`
+	ReservedQuantity  int    `json:"reserved_quantity,omitempty"`
+
+	IsAmazonURL   bool       `json:"is_amazon_url"`
+	AddedToCart   bool       `json:"added_to_cart"`
+	AddedToCartAt *time.Time `json:"added_to_cart_at,omitempty"`
+}
+
+// CheckoutInput represents the input for turning the cart into a purchase request
+type CheckoutInput struct {
+	Justification string `json:"justification" binding:"required"`
+	Urgency       string `json:"urgency" binding:"omitempty,oneof=normal urgent"`
+}
+
+func requestItemToResponse(item models.RequestItem) RequestItemResponse {
+	return RequestItemResponse{
+		ID:                item.ID,
+		LineNumber:        item.LineNumber,
+		ProductID:         item.ProductID,
+		SKU:               item.SKU,
+		URL:               item.URL,
+		ProductTitle:      item.ProductTitle,
+		ProductImageURL:   item.ProductImageURL,
+		SiteName:          item.SiteName,
+		Quantity:          item.Quantity,
+		UnitPrice:         item.UnitPrice,
+		Currency:          item.Currency,
+		LineTotal:         item.LineTotal,
+		FulfillmentStatus: string(item.FulfillmentStatus),
+		ReservedQuantity:  item.ReservedQuantity,
+		IsAmazonURL:       item.IsAmazonURL,
+		AddedToCart:       item.AddedToCart,
+		AddedToCartAt:     item.AddedToCartAt,
+	}
+}
+
+// preloadRequestItems orders request items by line number
+func preloadRequestItems(db *gorm.DB) *gorm.DB {
+	return db.Order("request_items.line_number ASC")
+}
+
+// newURLItem builds a request line for an external product URL
+func newURLItem(url, title, imageURL, siteName string, unitPrice *float64, currencyCode string, quantity int) models.RequestItem {
+	item := models.RequestItem{
+		URL:             url,
+		ProductTitle:    title,
+		ProductImageURL: imageURL,
+		SiteName:        siteName,
+		Quantity:        quantity,
+		UnitPrice:       unitPrice,
+		Currency:        currency.Code(currencyCode),
+		IsAmazonURL:     amazon.IsAmazonURL(url),
+	}
+	if item.IsAmazonURL {
+		item.AmazonASIN = amazon.ExtractASIN(url)
+	}
+	return item
+}
+
+// newCatalogItem builds a request line for a catalog product at its current price
+func newCatalogItem(product *models.Product, quantity int) models.RequestItem {
+	price := product.Price
+	return models.RequestItem{
+		ProductID:       &product.ID,
+		SKU:             product.SKU,
+		ProductTitle:    product.Name,
+		ProductImageURL: product.ImageURL,
+		Quantity:        quantity,
+		UnitPrice:       &price,
+		Currency:        currency.Code(product.Currency),
+	}
+}
+
+// priceItems numbers the request lines, converts their totals to the request
+// currency and updates the request total
+func (h *RequestHandler) priceItems(tx *gorm.DB, request *models.PurchaseRequest) error {
+	now := time.Now()
+	for i := range request.Items {
+		item := &request.Items[i]
+		item.LineNumber = i + 1
+
+		total, err := h.converter.Convert(tx, item.Subtotal(), item.Currency, request.Currency, now)
+		if err != nil {
+			return err
+		}
+		item.LineTotal = total
+	}
+
+	request.RecalculateTotal()
+	return nil
+}
+
+// mirrorFirstItem copies the first line onto the request's product fields so
+// single-product views and Amazon automation keep working
+func mirrorFirstItem(request *models.PurchaseRequest) {
+	first := request.Items[0]
+	request.URL = first.URL
+	request.ProductTitle = first.ProductTitle
+	request.ProductImageURL = first.ProductImageURL
+	request.SiteName = first.SiteName
+	request.Quantity = first.Quantity
+	request.EstimatedPrice = nil
+	if first.UnitPrice != nil && first.Quantity > 0 {
+		unitPrice := first.LineTotal / float64(first.Quantity)
+		request.EstimatedPrice = &unitPrice
+	}
+
+	request.IsAmazonURL = false
+	request.AmazonASIN = ""
+	for _, item := range request.Items {
+		if item.IsAmazonURL {
+			request.IsAmazonURL = true
+			if request.AmazonASIN == "" {
+				request.AmazonASIN = item.AmazonASIN
+			}
+		}
+	}
+}
+
+// CheckoutCart turns the user's cart into a single multi-line purchase request
+func (h *RequestHandler) CheckoutCart(c *gin.Context) {
+	var input CheckoutInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+
+	var cartItems []models.CartItem
+	if err := h.db.Preload("Product").Where("user_id = ?", userID).Order("id ASC").Find(&cartItems).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch cart")
+		return
+	}
+	if len(cartItems) == 0 {
+		response.BadRequest(c, "Cart is empty")
+		return
+	}
+
+	items := make([]models.RequestItem, 0, len(cartItems))
+	currencies := make(map[string]bool)
+	for _, cartItem := range cartItems {
+		var item models.RequestItem
+		if cartItem.IsCatalogItem() {
+			if cartItem.Product == nil || !cartItem.Product.IsActive {
+				response.ValidationError(c, fmt.Sprintf("Product %q is no longer available", cartItem.ProductTitle))
+				return
+			}
+			item = newCatalogItem(cartItem.Product, cartItem.Quantity)
+		} else {
+			item = newURLItem(cartItem.URL, cartItem.ProductTitle, cartItem.ProductImageURL, cartItem.SiteName,
+				cartItem.UnitPrice, cartItem.Currency, cartItem.Quantity)
+		}
+		currencies[item.Currency] = true
+		items = append(items, item)
+	}
+
+	// Mixed currency carts are totalled in the base currency
+	requestCurrency := h.converter.Base()
+	if len(currencies) == 1 {
+		requestCurrency = items[0].Currency
+	}
+
+	// Evaluate filter rules against each external line
+	var flags []string
+	for i, item := range items {
+		if item.IsCatalogItem() {
+			continue
+		}
+		result, err := h.filterEvaluator.Evaluate(filter.Subject{
+			URL:      item.URL,
+			Title:    item.ProductTitle,
+			SiteName: item.SiteName,
+			Price:    item.UnitPrice,
+			Currency: item.Currency,
+		})
+		if err != nil {
+			response.InternalServerError(c, "Failed to evaluate filter rules")
+			return
+		}
+		if result.Blocked {
+			response.ErrorWithDetails(c, http.StatusUnprocessableEntity, "FILTER_RULE_VIOLATION",
+				fmt.Sprintf("Line %d blocked by purchasing filter rules", i+1), strings.Join(result.Messages(), "; "))
+			return
+		}
+		for _, msg := range result.Messages() {
+			flags = append(flags, fmt.Sprintf("Line %d: %s", i+1, msg))
+		}
+	}
+
+	urgency := models.UrgencyNormal
+	if input.Urgency == "urgent" {
+		urgency = models.UrgencyUrgent
+	}
+
+	request := models.PurchaseRequest{
+		RequestNumber: models.GenerateRequestNumber(h.db),
+		Currency:      requestCurrency,
+		Justification: input.Justification,
+		Urgency:       urgency,
+		RequesterID:   userID,
+		Status:        models.StatusPending,
+		Items:         items,
+		IsFlagged:     len(flags) > 0,
+		FlagReason:    strings.Join(flags, "; "),
+	}
+
+	if err := h.priceItems(h.db, &request); err != nil {
+		if errors.Is(err, currency.ErrRateNotFound) {
+			response.ValidationError(c, "Cart items cannot be totalled: "+err.Error())
+		} else {
+			response.InternalServerError(c, "Failed to price cart items")
+		}
+		return
+	}
+	mirrorFirstItem(&request)
+
+	comment := fmt.Sprintf("Purchase request created from cart (%d items)", len(request.Items))
+	if request.IsFlagged {
+		comment += " (flagged: " + request.FlagReason + ")"
+	}
+	h.submitRequest(c, &request, comment, func(tx *gorm.DB) error {
+		return tx.Where("user_id = ?", userID).Delete(&models.CartItem{}).Error
+	})
+}
+
+// createCatalogRequest creates a requisition for a single catalog product
+func (h *RequestHandler) createCatalogRequest(c *gin.Context, input CreateRequestInput) {
+	var product models.Product
+	if err := h.db.First(&product, *input.ProductID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "Product not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch product")
+		}
+		return
+	}
+	if !product.IsActive {
+		response.BadRequest(c, "Product is not available")
+		return
+	}
+
+	urgency := models.UrgencyNormal
+	if input.Urgency == "urgent" {
+		urgency = models.UrgencyUrgent
+	}
+
+	request := models.PurchaseRequest{
+		RequestNumber:      models.GenerateRequestNumber(h.db),
+		ProductDescription: product.Description,
+		Currency:           currency.Code(product.Currency),
+		Justification:      input.Justification,
+		Urgency:            urgency,
+		RequesterID:        middleware.GetUserID(c),
+		Status:             models.StatusPending,
+		Items:              []models.RequestItem{newCatalogItem(&product, input.Quantity)},
+	}
+	if err := h.priceItems(h.db, &request); err != nil {
+		response.InternalServerError(c, "Failed to price request")
+		return
+	}
+	mirrorFirstItem(&request)
+
+	h.submitRequest(c, &request, "Requisition created for catalog item "+product.SKU, nil)
+}
+
+// submitRequest stores a new request with its items, records its creation and
+// starts the approval chain, then writes the created request to the response.
+// extra runs in the same transaction.
+func (h *RequestHandler) submitRequest(c *gin.Context, request *models.PurchaseRequest, comment string, extra func(tx *gorm.DB) error) {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Normalize before starting the chain so rules compare base currency amounts
+		if err := h.converter.NormalizeRequired(tx, request, time.Now()); err != nil {
+			return err
+		}
+
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+
+		history := models.NewHistory(request.ID, request.RequesterID, models.ActionCreated, "", models.StatusPending, comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := h.chainSvc.StartChain(tx, request); err != nil {
+			return err
+		}
+
+		if err := h.events.Dispatch(tx, events.Event{Type: events.RequestCreated, Request: request, ActorID: request.RequesterID}); err != nil {
+			return err
+		}
+
+		if extra != nil {
+			return extra(tx)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, currency.ErrRateNotFound) {
+			response.ValidationError(c, "No exchange rate is available for "+request.Currency+"; ask an admin to add one before submitting")
+		} else {
+			response.InternalServerError(c, "Failed to create request")
+		}
+		return
+	}
+	h.jobQueue.Wake()
+
+	// Reload with relations
+	h.db.
+		Preload("Requester").
+		Preload("Items", preloadRequestItems).
+		Preload("History").
+		Preload("History.User").
+		Preload("History.OnBehalfOf").
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		Preload("ApprovalSteps.Approver").
+		First(request, request.ID)
+
+	response.Created(c, requestToResponse(*request))
+}
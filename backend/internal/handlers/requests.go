@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,25 +12,42 @@ import (
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/filter"
+	"vista-backend/internal/services/jobs"
 	"vista-backend/internal/services/metadata"
+	"vista-backend/internal/services/visibility"
 	"vista-backend/pkg/response"
 )
 
 type RequestHandler struct {
 	db                *gorm.DB
 	metadataExtractor *metadata.Extractor
+	chainSvc          *approval.ChainService
+	filterEvaluator   *filter.Evaluator
+	converter         *currency.Converter
+	jobQueue          *jobs.Queue
+	events            *events.Dispatcher
 }
 
-func NewRequestHandler(db *gorm.DB) *RequestHandler {
+func NewRequestHandler(db *gorm.DB, chainSvc *approval.ChainService, converter *currency.Converter, jobQueue *jobs.Queue, dispatcher *events.Dispatcher) *RequestHandler {
 	return &RequestHandler{
 		db:                db,
 		metadataExtractor: metadata.NewExtractor(),
+		chainSvc:          chainSvc,
+		filterEvaluator:   filter.NewEvaluator(db, converter),
+		converter:         converter,
+		jobQueue:          jobQueue,
+		events:            dispatcher,
 	}
 }
 
 // CreateRequestInput represents the input for creating a new purchase request
 type CreateRequestInput struct {
-	URL           string   `json:"url" binding:"required,url"`
+	URL           string   `json:"url" binding:"omitempty,url"`
+	ProductID     *uint    `json:"product_id"` // Catalog product, instead of a URL
 	Quantity      int      `json:"quantity" binding:"required,gte=1"`
 	Justification string   `json:"justification" binding:"required"`
 	Urgency       string   `json:"urgency" binding:"omitempty,oneof=normal urgent"`
@@ -38,6 +58,7 @@ type CreateRequestInput struct {
 	ProductDescription string   `json:"product_description"`
 	EstimatedPrice     *float64 `json:"estimated_price"`
 	Currency           string   `json:"currency"`
+	SiteName           string   `json:"site_name"`
 }
 
 // ExtractMetadataInput represents the input for metadata extraction
@@ -53,6 +74,7 @@ type RequestResponse struct {
 	ProductTitle       string        `json:"product_title"`
 	ProductImageURL    string        `json:"product_image_url"`
 	ProductDescription string        `json:"product_description"`
+	SiteName           string        `json:"site_name,omitempty"`
 	EstimatedPrice     *float64      `json:"estimated_price,omitempty"`
 	Currency           string        `json:"currency"`
 	Quantity           int           `json:"quantity"`
@@ -62,6 +84,30 @@ type RequestResponse struct {
 	Requester          *UserResponse `json:"requester,omitempty"`
 	Status             string        `json:"status"`
 
+	IsReplenishment bool `json:"is_replenishment"`
+
+	// Line items
+	Items       []RequestItemResponse `json:"items,omitempty"`
+	TotalAmount float64               `json:"total_amount"`
+
+	// Amount in the base reporting currency
+	NormalizedAmount *float64 `json:"normalized_amount,omitempty"`
+	BaseCurrency     string   `json:"base_currency,omitempty"`
+	ExchangeRate     *float64 `json:"exchange_rate,omitempty"`
+
+	// Filter rules
+	IsFlagged  bool   `json:"is_flagged"`
+	FlagReason string `json:"flag_reason,omitempty"`
+
+	// Approval chain
+	CurrentStep   int                    `json:"current_step"`
+	ApprovalSteps []ApprovalStepResponse `json:"approval_steps,omitempty"`
+
+	// Budget
+	BudgetID       *uint   `json:"budget_id,omitempty"`
+	BudgetAmount   float64 `json:"budget_amount,omitempty"`
+	BudgetOverride bool    `json:"budget_override"`
+
 	// Amazon specific
 	IsAmazonURL   bool       `json:"is_amazon_url"`
 	AddedToCart   bool       `json:"added_to_cart"`
@@ -94,14 +140,30 @@ type RequestResponse struct {
 }
 
 type RequestHistoryResponse struct {
-	ID        uint          `json:"id"`
-	UserID    uint          `json:"user_id"`
-	User      *UserResponse `json:"user,omitempty"`
-	Action    string        `json:"action"`
-	Comment   string        `json:"comment"`
-	OldStatus string        `json:"old_status"`
-	NewStatus string        `json:"new_status"`
-	CreatedAt time.Time     `json:"created_at"`
+	ID         uint          `json:"id"`
+	UserID     uint          `json:"user_id"`
+	User       *UserResponse `json:"user,omitempty"`
+	Action     string        `json:"action"`
+	Comment    string        `json:"comment"`
+	OldStatus  string        `json:"old_status"`
+	NewStatus  string        `json:"new_status"`
+	StepLevel  int           `json:"step_level,omitempty"`
+	StepStatus string        `json:"step_status,omitempty"`
+	OnBehalfOf *UserResponse `json:"on_behalf_of,omitempty"` // Delegator the user acted for
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ApprovalStepResponse struct {
+	ID           uint          `json:"id"`
+	Level        int           `json:"level"`
+	Name         string        `json:"name"`
+	ApproverRole string        `json:"approver_role,omitempty"`
+	Approver     *UserResponse `json:"approver,omitempty"`
+	Status       string        `json:"status"`
+	ActedBy      *UserResponse `json:"acted_by,omitempty"`
+	ActedAt      *time.Time    `json:"acted_at,omitempty"`
+	OnBehalfOf   *UserResponse `json:"on_behalf_of,omitempty"` // Delegator ActedBy approved for
+	Comment      string        `json:"comment,omitempty"`
 }
 
 func requestToResponse(r models.PurchaseRequest) RequestResponse {
@@ -112,6 +174,7 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		ProductTitle:       r.ProductTitle,
 		ProductImageURL:    r.ProductImageURL,
 		ProductDescription: r.ProductDescription,
+		SiteName:           r.SiteName,
 		EstimatedPrice:     r.EstimatedPrice,
 		Currency:           r.Currency,
 		Quantity:           r.Quantity,
@@ -119,6 +182,17 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		Urgency:            string(r.Urgency),
 		RequesterID:        r.RequesterID,
 		Status:             string(r.Status),
+		IsReplenishment:    r.IsReplenishment,
+		TotalAmount:        r.Amount(),
+		NormalizedAmount:   r.NormalizedAmount,
+		BaseCurrency:       r.BaseCurrency,
+		ExchangeRate:       r.ExchangeRate,
+		IsFlagged:          r.IsFlagged,
+		FlagReason:         r.FlagReason,
+		CurrentStep:        r.CurrentStep,
+		BudgetID:           r.BudgetID,
+		BudgetAmount:       r.BudgetAmount,
+		BudgetOverride:     r.BudgetOverride,
 		IsAmazonURL:        r.IsAmazonURL,
 		AddedToCart:        r.AddedToCart,
 		AddedToCartAt:      r.AddedToCartAt,
@@ -135,6 +209,9 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		UpdatedAt:          r.UpdatedAt,
 	}
 
+	for _, item := range r.Items {
+		resp.Items = append(resp.Items, requestItemToResponse(item))
+	}
 	if r.Requester.ID != 0 {
 		resp.Requester = &UserResponse{
 			ID:          r.Requester.ID,
@@ -175,15 +252,54 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 		}
 	}
 
+	for _, step := range r.ApprovalSteps {
+		stepResp := ApprovalStepResponse{
+			ID:           step.ID,
+			Level:        step.Level,
+			Name:         step.Name,
+			ApproverRole: string(step.ApproverRole),
+			Status:       string(step.Status),
+			ActedAt:      step.ActedAt,
+			Comment:      step.Comment,
+		}
+		if step.Approver != nil && step.Approver.ID != 0 {
+			stepResp.Approver = &UserResponse{
+				ID:    step.Approver.ID,
+				Email: step.Approver.Email,
+				Name:  step.Approver.Name,
+				Role:  string(step.Approver.Role),
+			}
+		}
+		if step.ActedBy != nil && step.ActedBy.ID != 0 {
+			stepResp.ActedBy = &UserResponse{
+				ID:    step.ActedBy.ID,
+				Email: step.ActedBy.Email,
+				Name:  step.ActedBy.Name,
+				Role:  string(step.ActedBy.Role),
+			}
+		}
+		if step.OnBehalfOf != nil && step.OnBehalfOf.ID != 0 {
+			stepResp.OnBehalfOf = &UserResponse{
+				ID:    step.OnBehalfOf.ID,
+				Email: step.OnBehalfOf.Email,
+				Name:  step.OnBehalfOf.Name,
+				Role:  string(step.OnBehalfOf.Role),
+			}
+		}
+		resp.ApprovalSteps = append(resp.ApprovalSteps, stepResp)
+	}
+
 	for _, h := range r.History {
 		historyResp := RequestHistoryResponse{
-			ID:        h.ID,
-			UserID:    h.UserID,
-			Action:    string(h.Action),
-			Comment:   h.Comment,
-			OldStatus: string(h.OldStatus),
-			NewStatus: string(h.NewStatus),
-			CreatedAt: h.CreatedAt,
+			ID:         h.ID,
+			UserID:     h.UserID,
+			Action:     string(h.Action),
+			Comment:    h.Comment,
+			OldStatus:  string(h.OldStatus),
+			NewStatus:  string(h.NewStatus),
+			StepLevel:  h.StepLevel,
+			StepStatus: string(h.StepStatus),
+			CreatedAt:  h.CreatedAt,
 		}
 		if h.User.ID != 0 {
 			historyResp.User = &UserResponse{
@@ -193,6 +309,14 @@ func requestToResponse(r models.PurchaseRequest) RequestResponse {
 				Role:  string(h.User.Role),
 			}
 		}
+		if h.OnBehalfOf != nil && h.OnBehalfOf.ID != 0 {
+			historyResp.OnBehalfOf = &UserResponse{
+				ID:    h.OnBehalfOf.ID,
+				Email: h.OnBehalfOf.Email,
+				Name:  h.OnBehalfOf.Name,
+				Role:  string(h.OnBehalfOf.Role),
+			}
+		}
 		resp.History = append(resp.History, historyResp)
 	}
 
@@ -210,6 +334,7 @@ func (h *RequestHandler) ExtractMetadata(c *gin.Context) {
 	meta, err := h.metadataExtractor.ExtractFromURL(input.URL)
 	if err != nil {
 		// Return partial response even on error
+		filterResult, _ := h.filterEvaluator.Evaluate(filter.Subject{URL: input.URL})
 		response.Success(c, gin.H{
 			"url":          input.URL,
 			"title":        "",
@@ -218,13 +343,29 @@ func (h *RequestHandler) ExtractMetadata(c *gin.Context) {
 			"price":        nil,
 			"currency":     "MXN",
 			"site_name":    "",
+			"category":     "",
 			"is_amazon":    amazon.IsAmazonURL(input.URL),
 			"amazon_asin":  amazon.ExtractASIN(input.URL),
+			"filter":       filterResult,
 			"error":        err.Error(),
 		})
 		return
 	}
 
+	filterResult, err := h.filterEvaluator.Evaluate(filter.Subject{
+		URL:         input.URL,
+		Title:       meta.Title,
+		Description: meta.Description,
+		SiteName:    meta.SiteName,
+		Category:    meta.Category,
+		Price:       meta.Price,
+		Currency:    meta.Currency,
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to evaluate filter rules")
+		return
+	}
+
 	response.Success(c, gin.H{
 		"url":          input.URL,
 		"title":        meta.Title,
@@ -233,8 +374,10 @@ func (h *RequestHandler) ExtractMetadata(c *gin.Context) {
 		"price":        meta.Price,
 		"currency":     meta.Currency,
 		"site_name":    meta.SiteName,
+		"category":     meta.Category,
 		"is_amazon":    amazon.IsAmazonURL(input.URL),
 		"amazon_asin":  amazon.ExtractASIN(input.URL),
+		"filter":       filterResult,
 		"error":        nil,
 	})
 }
@@ -247,6 +390,15 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		return
 	}
 
+	if input.ProductID != nil {
+		h.createCatalogRequest(c, input)
+		return
+	}
+	if input.URL == "" {
+		response.BadRequest(c, "Provide either url or product_id")
+		return
+	}
+
 	userID := middleware.GetUserID(c)
 
 	// Try to extract metadata if not provided
@@ -255,10 +407,16 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 	productDescription := input.ProductDescription
 	estimatedPrice := input.EstimatedPrice
 	currency := input.Currency
+	siteName := input.SiteName
+	category := ""
 
 	if productTitle == "" || productImageURL == "" {
 		meta, err := h.metadataExtractor.ExtractFromURL(input.URL)
 		if err == nil {
+			if siteName == "" {
+				siteName = meta.SiteName
+			}
+			category = meta.Category
 			if productTitle == "" {
 				productTitle = meta.Title
 			}
@@ -280,12 +438,35 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 	if currency == "" {
 		currency = "MXN"
 	}
+	if siteName == "" {
+		siteName = metadata.SiteNameFromURL(input.URL)
+	}
 
 	urgency := models.UrgencyNormal
 	if input.Urgency == "urgent" {
 		urgency = models.UrgencyUrgent
 	}
 
+	// Evaluate filter rules against the final product details
+	filterResult, err := h.filterEvaluator.Evaluate(filter.Subject{
+		URL:         input.URL,
+		Title:       productTitle,
+		Description: productDescription,
+		SiteName:    siteName,
+		Category:    category,
+		Price:       estimatedPrice,
+		Currency:    currency,
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to evaluate filter rules")
+		return
+	}
+	if filterResult.Blocked {
+		response.ErrorWithDetails(c, http.StatusUnprocessableEntity, "FILTER_RULE_VIOLATION",
+			"Request blocked by purchasing filter rules", strings.Join(filterResult.Messages(), "; "))
+		return
+	}
+
 	// Check if it's an Amazon URL
 	isAmazonURL := amazon.IsAmazonURL(input.URL)
 	amazonASIN := ""
@@ -299,6 +480,7 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		ProductTitle:       productTitle,
 		ProductImageURL:    productImageURL,
 		ProductDescription: productDescription,
+		SiteName:           siteName,
 		EstimatedPrice:     estimatedPrice,
 		Currency:           currency,
 		Quantity:           input.Quantity,
@@ -308,48 +490,42 @@ func (h *RequestHandler) CreateRequest(c *gin.Context) {
 		Status:             models.StatusPending,
 		IsAmazonURL:        isAmazonURL,
 		AmazonASIN:         amazonASIN,
-	}
-
-	err := h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(&request).Error; err != nil {
-			return err
-		}
-
-		history := models.NewHistory(request.ID, userID, models.ActionCreated, "", models.StatusPending, "Purchase request created")
-		return tx.Create(history).Error
-	})
-
-	if err != nil {
-		response.InternalServerError(c, "Failed to create request")
+		IsFlagged:          filterResult.Flagged,
+		FlagReason:         strings.Join(filterResult.Messages(), "; "),
+		Items: []models.RequestItem{
+			newURLItem(input.URL, productTitle, productImageURL, siteName, estimatedPrice, currency, input.Quantity),
+		},
+	}
+	if err := h.priceItems(h.db, &request); err != nil {
+		response.InternalServerError(c, "Failed to price request")
 		return
 	}
 
-	// Reload with relations
-	h.db.
-		Preload("Requester").
-		Preload("History").
-		Preload("History.User").
-		First(&request, request.ID)
-
-	response.Created(c, requestToResponse(request))
+	comment := "Purchase request created"
+	if request.IsFlagged {
+		comment += " (flagged: " + request.FlagReason + ")"
+	}
+	h.submitRequest(c, &request, comment, nil)
 }
 
-// ListRequests returns a list of requests
+// ListRequests returns the requests the caller can see: everyone's with
+// requests.view_all, limited to their scopes if they have any, otherwise
+// their own
 func (h *RequestHandler) ListRequests(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
 	status := c.Query("status")
 
 	offset := (page - 1) * perPage
-	userID := middleware.GetUserID(c)
-	canViewAll := middleware.CanViewAll(c)
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
 
 	query := h.db.Model(&models.PurchaseRequest{}).
-		Preload("Requester")
-
-	if !canViewAll {
-		query = query.Where("requester_id = ?", userID)
-	}
+		Preload("Requester").
+		Scopes(visibility.Requests(user))
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -388,11 +564,17 @@ func (h *RequestHandler) GetRequest(c *gin.Context) {
 	var req models.PurchaseRequest
 	if err := h.db.
 		Preload("Requester").
+		Preload("Items", preloadRequestItems).
 		Preload("History").
 		Preload("History.User").
+		Preload("History.OnBehalfOf").
 		Preload("ApprovedBy").
 		Preload("RejectedBy").
 		Preload("PurchasedBy").
+		Preload("ApprovalSteps", preloadApprovalSteps).
+		Preload("ApprovalSteps.Approver").
+		Preload("ApprovalSteps.ActedBy").
+		Preload("ApprovalSteps.OnBehalfOf").
 		First(&req, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.NotFound(c, "Request not found")
@@ -402,9 +584,12 @@ func (h *RequestHandler) GetRequest(c *gin.Context) {
 		return
 	}
 
-	userID := middleware.GetUserID(c)
-	canViewAll := middleware.CanViewAll(c)
-	if !canViewAll && req.RequesterID != userID {
+	user, err := currentUser(c, h.db)
+	if err != nil {
+		response.Unauthorized(c, "User not found")
+		return
+	}
+	if !visibility.CanView(user, &req) {
 		response.Forbidden(c, "Access denied")
 		return
 	}
@@ -484,18 +669,28 @@ func (h *RequestHandler) CancelRequest(c *gin.Context) {
 	request.Status = models.StatusRejected
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&request).Error; err != nil {
+		// An approval since loading the request has committed budget and
+		// reserved stock, so the request can no longer be cancelled
+		if err := approval.SaveUnchanged(tx, &request, oldStatus, request.CurrentStep); err != nil {
 			return err
 		}
 
 		history := models.NewHistory(request.ID, userID, models.ActionCancelled, oldStatus, models.StatusRejected, "Request cancelled by requester")
-		return tx.Create(history).Error
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		return h.events.Dispatch(tx, events.Event{Type: events.RequestCancelled, Request: &request, ActorID: userID})
 	})
 
+	if errors.Is(err, approval.ErrConcurrentDecision) {
+		response.Conflict(c, "The request was acted on by someone else in the meantime; reload it and try again")
+		return
+	}
 	if err != nil {
 		response.InternalServerError(c, "Failed to cancel request")
 		return
 	}
+	h.jobQueue.Wake()
 
 	response.SuccessWithMessage(c, "Request cancelled successfully", nil)
 }
@@ -538,6 +733,7 @@ func (h *RequestHandler) UpdateRequest(c *gin.Context) {
 		ProductTitle       string   `json:"product_title"`
 		ProductDescription string   `json:"product_description"`
 		EstimatedPrice     *float64 `json:"estimated_price"`
+		Response           string   `json:"response"` // Answer to an information request, added to the request's conversation
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -545,6 +741,29 @@ func (h *RequestHandler) UpdateRequest(c *gin.Context) {
 		return
 	}
 
+	var items []models.RequestItem
+	if err := h.db.Where("request_id = ?", request.ID).Order("line_number ASC").Find(&items).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch request items")
+		return
+	}
+
+	// Quantity and price of multi-line requests are edited per line
+	if len(items) > 1 && (input.Quantity > 0 || input.EstimatedPrice != nil) {
+		response.BadRequest(c, "Quantity and price cannot be changed on a multi-line request")
+		return
+	}
+
+	// Catalog lines keep the catalog price they were requested at
+	catalogLine := len(items) == 1 && items[0].IsCatalogItem()
+	if catalogLine && input.EstimatedPrice != nil {
+		response.BadRequest(c, "The price of a catalog item cannot be changed")
+		return
+	}
+
+	// Approval rules match on amount and urgency
+	previousAmount, previousUrgency := request.TotalAmount, request.Urgency
+	loadedStatus := request.Status
+
 	// Update fields if provided
 	if input.Quantity > 0 {
 		request.Quantity = input.Quantity
@@ -569,21 +788,115 @@ func (h *RequestHandler) UpdateRequest(c *gin.Context) {
 		request.EstimatedPrice = input.EstimatedPrice
 	}
 
+	// Re-evaluate filter rules against the edited product details. Like at
+	// submission, they only apply to products bought from a URL.
+	if request.URL != "" {
+		filterResult, err := h.filterEvaluator.Evaluate(filter.Subject{
+			URL:         request.URL,
+			Title:       request.ProductTitle,
+			Description: request.ProductDescription,
+			SiteName:    request.SiteName,
+			Price:       request.EstimatedPrice,
+			Currency:    request.Currency,
+		})
+		if err != nil {
+			response.InternalServerError(c, "Failed to evaluate filter rules")
+			return
+		}
+		if filterResult.Blocked {
+			response.ErrorWithDetails(c, http.StatusUnprocessableEntity, "FILTER_RULE_VIOLATION",
+				"Request blocked by purchasing filter rules", strings.Join(filterResult.Messages(), "; "))
+			return
+		}
+		request.IsFlagged = filterResult.Flagged
+		request.FlagReason = strings.Join(filterResult.Messages(), "; ")
+	}
+
 	// If status was info_requested, change back to pending
-	if request.Status == models.StatusInfoRequested {
+	resubmitted := request.Status == models.StatusInfoRequested
+	if resubmitted {
 		request.Status = models.StatusPending
 	}
 
-	if err := h.db.Save(&request).Error; err != nil {
+	// Keep the single line in step with the edited header
+	if len(items) == 1 {
+		item := &items[0]
+		item.ProductTitle = request.ProductTitle
+		item.Quantity = request.Quantity
+		if !catalogLine {
+			item.UnitPrice = request.EstimatedPrice
+		}
+		item.LineTotal = item.Subtotal()
+		request.TotalAmount = item.LineTotal
+	} else if len(items) == 0 {
+		request.TotalAmount = 0
+	}
+
+	// A changed amount or urgency can match a different approval rule, so the
+	// chain is rebuilt and the amount must be convertible to route it
+	rechain := request.TotalAmount != previousAmount || request.Urgency != previousUrgency
+	if rechain {
+		err = h.converter.NormalizeRequired(h.db, &request, time.Now())
+	} else {
+		err = h.converter.Normalize(h.db, &request, time.Now())
+	}
+	if errors.Is(err, currency.ErrRateNotFound) {
+		response.ValidationError(c, "No exchange rate is available for "+request.Currency+"; ask an admin to add one before changing the amount")
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, "Failed to convert request amount")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Not over a decision made since loading the request
+		if err := approval.SaveUnchanged(tx, &request, loadedStatus, request.CurrentStep); err != nil {
+			return err
+		}
+		if len(items) == 1 {
+			if err := tx.Save(&items[0]).Error; err != nil {
+				return err
+			}
+		}
+		if rechain {
+			if err := h.chainSvc.RestartChain(tx, &request); err != nil {
+				return err
+			}
+		}
+		if !resubmitted {
+			return nil
+		}
+		answer := strings.TrimSpace(input.Response)
+		if answer != "" {
+			comment := models.RequestComment{RequestID: request.ID, AuthorID: userID, Kind: models.CommentInfoResponse, Body: answer}
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+		}
+		return recordResubmission(tx, h.events, &request, userID, answer)
+	})
+	if errors.Is(err, approval.ErrChainDecided) {
+		response.BadRequest(c, "Quantity, price and urgency cannot be changed once an approval step has been decided")
+		return
+	}
+	if errors.Is(err, approval.ErrConcurrentDecision) {
+		response.Conflict(c, "The request was acted on by someone else in the meantime; reload it and try again")
+		return
+	}
+	if err != nil {
 		response.InternalServerError(c, "Failed to update request")
 		return
 	}
+	h.jobQueue.Wake()
 
 	// Reload with relations
 	h.db.
 		Preload("Requester").
+		Preload("Items", preloadRequestItems).
 		Preload("History").
 		Preload("History.User").
+		Preload("History.OnBehalfOf").
 		First(&request, request.ID)
 
 	response.Success(c, requestToResponse(request))
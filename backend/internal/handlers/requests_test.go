@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/models"
+)
+
+func TestCancelRequestLosesToConcurrentApproval(t *testing.T) {
+	e := newEnv(t)
+	b := e.createBudget(t, 10000)
+	requester := e.createUser(t, "requester", models.RoleEmployee)
+	gm := e.createUser(t, "gm", models.RoleGeneralManager)
+	request := e.submitRequest(t, requester, 500)
+
+	var approved int
+	afterFirstLoad(t, e.db, func() {
+		approved = e.call(t, e.approvals.ApproveRequest, gm, request.ID, gin.H{}).Code
+	})
+	w := e.call(t, e.requests.CancelRequest, requester, request.ID, nil)
+
+	if approved != http.StatusOK {
+		t.Fatalf("concurrent approval: got %d, want 200", approved)
+	}
+	if w.Code != http.StatusConflict {
+		t.Errorf("cancel: got %d %s, want 409", w.Code, w.Body)
+	}
+	if got := loadRequest(t, e.db, request.ID); got.Status != models.StatusApproved || got.BudgetID == nil {
+		t.Errorf("got request %s with budget %v, want it approved against the budget", got.Status, got.BudgetID)
+	}
+	if got := loadBudget(t, e.db, b.ID).CommittedAmount; got != 500 {
+		t.Errorf("got committed %.2f, want 500", got)
+	}
+}
+
+func TestUpdateRequestLosesToConcurrentApproval(t *testing.T) {
+	e := newEnv(t)
+	b := e.createBudget(t, 10000)
+	requester := e.createUser(t, "requester", models.RoleEmployee)
+	gm := e.createUser(t, "gm", models.RoleGeneralManager)
+	request := e.submitRequest(t, requester, 500)
+
+	var approved int
+	afterFirstLoad(t, e.db, func() {
+		approved = e.call(t, e.approvals.ApproveRequest, gm, request.ID, gin.H{}).Code
+	})
+	w := e.call(t, e.requests.UpdateRequest, requester, request.ID, gin.H{"justification": "Needed sooner"})
+
+	if approved != http.StatusOK {
+		t.Fatalf("concurrent approval: got %d, want 200", approved)
+	}
+	if w.Code != http.StatusConflict {
+		t.Errorf("update: got %d %s, want 409", w.Code, w.Body)
+	}
+	got := loadRequest(t, e.db, request.ID)
+	if got.Status != models.StatusApproved || got.BudgetID == nil || got.Justification != "Needed" {
+		t.Errorf("got request %s with budget %v and justification %q, want it approved unchanged",
+			got.Status, got.BudgetID, got.Justification)
+	}
+	if got := loadBudget(t, e.db, b.ID).CommittedAmount; got != 500 {
+		t.Errorf("got committed %.2f, want 500", got)
+	}
+}
+
+func TestUpdateRequestWithoutConcurrentDecision(t *testing.T) {
+	e := newEnv(t)
+	requester := e.createUser(t, "requester", models.RoleEmployee)
+	request := e.submitRequest(t, requester, 500)
+
+	w := e.call(t, e.requests.UpdateRequest, requester, request.ID, gin.H{"justification": "Needed sooner"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: got %d %s, want 200", w.Code, w.Body)
+	}
+	if got := loadRequest(t, e.db, request.ID); got.Justification != "Needed sooner" || got.Status != models.StatusPending {
+		t.Errorf("got request %s with justification %q, want it pending and updated", got.Status, got.Justification)
+	}
+}
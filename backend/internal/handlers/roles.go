@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type RoleHandler struct {
+	db    *gorm.DB
+	roles *services.RoleService
+}
+
+func NewRoleHandler(db *gorm.DB, roles *services.RoleService) *RoleHandler {
+	return &RoleHandler{db: db, roles: roles}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"` // Stored on users; cannot be changed later
+	DisplayName string   `json:"display_name" binding:"required"`
+	Description string   `json:"description"`
+	RequireMFA  bool     `json:"require_mfa"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	DisplayName string   `json:"display_name" binding:"required"`
+	Description string   `json:"description"`
+	RequireMFA  bool     `json:"require_mfa"`
+	Permissions []string `json:"permissions"` // Replaces the role's permissions
+}
+
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description"`
+	RequireMFA  bool     `json:"require_mfa"`
+	BuiltIn     bool     `json:"built_in"`
+	Permissions []string `json:"permissions"`
+	UserCount   int64    `json:"user_count"`
+}
+
+func roleToResponse(role *models.Role) RoleResponse {
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		RequireMFA:  role.RequireMFA,
+		BuiltIn:     role.BuiltIn,
+		Permissions: role.PermissionNames(),
+	}
+}
+
+// ListPermissions returns every permission a role can grant
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roles.ListPermissions()
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch permissions")
+		return
+	}
+
+	response.Success(c, permissions)
+}
+
+// ListRoles returns all roles with their permissions and how many users have them
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roles.List()
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch roles")
+		return
+	}
+
+	var counts []struct {
+		Role  string
+		Count int64
+	}
+	h.db.Model(&models.User{}).Select("role, COUNT(*) AS count").Group("role").Scan(&counts)
+	userCounts := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		userCounts[count.Role] = count.Count
+	}
+
+	roleResponses := make([]RoleResponse, len(roles))
+	for i := range roles {
+		roleResponses[i] = roleToResponse(&roles[i])
+		roleResponses[i].UserCount = userCounts[roles[i].Name]
+	}
+
+	response.Success(c, roleResponses)
+}
+
+// CreateRole creates a custom role with a set of permissions
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if !services.HoldsPermissions(middleware.GetUserPermissions(c), req.Permissions) {
+		response.Forbidden(c, "You cannot grant permissions you do not have")
+		return
+	}
+
+	var role *models.Role
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		role, err = h.roles.Create(tx, req.Name, services.RoleInput{
+			DisplayName: req.DisplayName,
+			Description: req.Description,
+			RequireMFA:  req.RequireMFA,
+			Permissions: req.Permissions,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceRole, role.ID, nil, roleToResponse(role))
+	})
+	if err != nil {
+		respondRoleError(c, err, "Failed to create role")
+		return
+	}
+	h.roles.Invalidate()
+
+	response.Created(c, roleToResponse(role))
+}
+
+// UpdateRole changes a role's details and replaces its permissions. Users
+// with the role get the new permissions on their next request.
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	role, err := h.roles.Get(uint(id))
+	if err != nil {
+		respondRoleError(c, err, "Failed to fetch role")
+		return
+	}
+
+	// Nobody edits their own role or a role holding more access than they do,
+	// and the role cannot be given permissions the caller lacks
+	if role.Name == middleware.GetUserRole(c) {
+		response.Forbidden(c, "You cannot edit your own role")
+		return
+	}
+	held := middleware.GetUserPermissions(c)
+	granted, err := h.roles.Grants(held, models.UserRole(role.Name))
+	if err != nil {
+		response.InternalServerError(c, "Failed to check role permissions")
+		return
+	}
+	if !granted {
+		response.Forbidden(c, "You cannot edit a role with permissions you do not have")
+		return
+	}
+	if !services.HoldsPermissions(held, req.Permissions) {
+		response.Forbidden(c, "You cannot grant permissions you do not have")
+		return
+	}
+
+	before := roleToResponse(role)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := h.roles.Update(tx, role, services.RoleInput{
+			DisplayName: req.DisplayName,
+			Description: req.Description,
+			RequireMFA:  req.RequireMFA,
+			Permissions: req.Permissions,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceRole, role.ID, before, roleToResponse(role))
+	})
+	if err != nil {
+		respondRoleError(c, err, "Failed to update role")
+		return
+	}
+	h.roles.Invalidate()
+
+	response.Success(c, roleToResponse(role))
+}
+
+// DeleteRole deletes a custom role that is not assigned to any user or approval step
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return
+	}
+
+	role, err := h.roles.Get(uint(id))
+	if err != nil {
+		respondRoleError(c, err, "Failed to fetch role")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.roles.Delete(tx, role); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceRole, role.ID, roleToResponse(role), nil)
+	})
+	if err != nil {
+		respondRoleError(c, err, "Failed to delete role")
+		return
+	}
+	h.roles.Invalidate()
+
+	response.SuccessWithMessage(c, "Role deleted", nil)
+}
+
+// respondRoleError writes the response for a failed role change
+func respondRoleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		response.NotFound(c, "Role not found")
+	case errors.Is(err, services.ErrRoleExists), errors.Is(err, services.ErrRoleInUse):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, services.ErrBuiltInRole), errors.Is(err, services.ErrAdminRoleLocked):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, services.ErrInvalidRoleName), errors.Is(err, services.ErrUnknownPermission):
+		response.ValidationError(c, err.Error())
+	default:
+		response.InternalServerError(c, fallback)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/response"
+)
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"` // Session of the token making the request
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// clientInfo identifies the client making the request
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// ListSessions returns the current user's active sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.authService.ListSessions(middleware.GetUserID(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to fetch sessions")
+		return
+	}
+
+	currentID := middleware.GetSessionID(c)
+	sessionResponses := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		sessionResponses[i] = SessionResponse{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Current:    session.ID == currentID,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			CreatedAt:  session.CreatedAt,
+		}
+	}
+
+	response.Success(c, sessionResponses)
+}
+
+// RevokeSession signs out one of the current user's sessions
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeSession(middleware.GetUserID(c), uint(id), models.SessionRevokedByUser); err != nil {
+		if err == services.ErrSessionNotFound {
+			response.NotFound(c, "Session not found")
+		} else {
+			response.InternalServerError(c, "Failed to revoke session")
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "Session revoked", nil)
+}
+
+// RevokeOtherSessions signs out every session of the current user except the current one
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	revoked, err := h.authService.RevokeOtherSessions(middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to revoke sessions")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Other sessions revoked", gin.H{"revoked": revoked})
+}
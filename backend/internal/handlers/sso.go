@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/services"
+	"vista-backend/pkg/oidc"
+	"vista-backend/pkg/response"
+)
+
+type SSOStatusResponse struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+type SSOCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	BrowserKey string `json:"browser_key" binding:"required"` // Returned by /auth/sso/authorize to the browser that began the login
+}
+
+// GetSSOStatus tells the login page whether to offer single sign-on
+func (h *AuthHandler) GetSSOStatus(c *gin.Context) {
+	if !h.ssoService.Enabled() {
+		response.Success(c, SSOStatusResponse{Enabled: false})
+		return
+	}
+	response.Success(c, SSOStatusResponse{Enabled: true, ProviderName: h.ssoService.ProviderName()})
+}
+
+// BeginSSO returns the identity provider URL that starts a single sign-on
+// login, and the key the browser keeps to send with the callback
+func (h *AuthHandler) BeginSSO(c *gin.Context) {
+	authURL, browserKey, err := h.ssoService.Begin(c.Request.Context())
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"authorization_url": authURL, "browser_key": browserKey})
+}
+
+// CompleteSSO finishes a single sign-on login with the code and state the
+// identity provider redirected back with
+func (h *AuthHandler) CompleteSSO(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.ssoService.Complete(c.Request.Context(), req.Code, req.State, req.BrowserKey, clientInfo(c))
+	if err != nil {
+		respondSSOError(c, err)
+		return
+	}
+
+	respondLogin(c, result)
+}
+
+// respondSSOError writes the response for a failed single sign-on step
+func respondSSOError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSSODisabled):
+		response.NotFound(c, "Single sign-on is not configured")
+	case errors.Is(err, services.ErrInvalidSSOState):
+		response.BadRequest(c, "Single sign-on login has expired; try again")
+	case errors.Is(err, services.ErrSSONotProvisioned):
+		response.Forbidden(c, "There is no account for this user; ask an administrator for access")
+	case errors.Is(err, services.ErrSSOEmailUnverified):
+		response.Forbidden(c, "Your identity provider has not verified your email")
+	case errors.Is(err, services.ErrSSOAccountConflict):
+		response.Conflict(c, "This account is linked to another single sign-on user")
+	case errors.Is(err, oidc.ErrInvalidIDToken), errors.Is(err, oidc.ErrNonceMismatch):
+		log.Printf("Rejected single sign-on login: %v", err)
+		response.Unauthorized(c, "Single sign-on login could not be verified")
+	case errors.Is(err, services.ErrUserInactive), errors.As(err, new(*services.LoginBlockedError)):
+		respondLoginError(c, err)
+	default:
+		log.Printf("Single sign-on failed: %v", err)
+		response.Error(c, http.StatusBadGateway, "SSO_ERROR", "Single sign-on failed; try again later")
+	}
+}
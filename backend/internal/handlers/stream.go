@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/services/stream"
+	"vista-backend/pkg/response"
+)
+
+const (
+	streamHeartbeat = 25 * time.Second // Keeps proxies from closing idle streams
+	streamRetry     = 5 * time.Second  // How long clients wait before reconnecting
+)
+
+type StreamHandler struct {
+	broker   *stream.Broker
+	sessions middleware.SessionValidator
+}
+
+func NewStreamHandler(broker *stream.Broker, sessions middleware.SessionValidator) *StreamHandler {
+	return &StreamHandler{broker: broker, sessions: sessions}
+}
+
+// StreamEvents streams the request events the caller can see as Server-Sent
+// Events. Clients resume with the Last-Event-ID header after reconnecting.
+// The stream ends when the access token expires or the session is revoked,
+// so clients reconnect with a fresh token.
+func (h *StreamHandler) StreamEvents(c *gin.Context) {
+	var lastEventID uint64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(header, 10, 32); err != nil {
+			response.BadRequest(c, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	userID := middleware.GetUserID(c)
+	sessionID := middleware.GetSessionID(c)
+
+	sub, err := h.broker.Subscribe(userID, middleware.GetUserPermissions(c), uint(lastEventID))
+	if err != nil {
+		response.InternalServerError(c, "Failed to subscribe to events")
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if expiresAt := middleware.GetTokenExpiresAt(c); !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if err := h.sessions.ValidateSession(sessionID, userID); err != nil {
+				return
+			}
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -1,47 +1,101 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"vista-backend/internal/middleware"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services"
+	"vista-backend/internal/services/visibility"
 	"vista-backend/pkg/response"
 )
 
 type UserHandler struct {
-	db *gorm.DB
+	db             *gorm.DB
+	authService    *services.AuthService
+	mfaService     *services.MFAService
+	passwordTokens *services.PasswordTokenService
+	passwordPolicy *services.PasswordPolicy
+	roles          *services.RoleService
 }
 
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
+func NewUserHandler(db *gorm.DB, authService *services.AuthService, mfaService *services.MFAService, passwordTokens *services.PasswordTokenService, passwordPolicy *services.PasswordPolicy, roles *services.RoleService) *UserHandler {
+	return &UserHandler{db: db, authService: authService, mfaService: mfaService, passwordTokens: passwordTokens, passwordPolicy: passwordPolicy, roles: roles}
 }
 
 type CreateUserRequest struct {
-	Email       string `json:"email" binding:"required,email"`
-	Password    string `json:"password" binding:"required,min=6"`
-	Name        string `json:"name" binding:"required"`
-	Role        string `json:"role" binding:"required,oneof=admin supply_chain_manager general_manager employee"`
-	CompanyCode string `json:"company_code"`
-	CostCenter  string `json:"cost_center"`
-	Department  string `json:"department"`
+	Email                 string `json:"email" binding:"required,email"`
+	Password              string `json:"password"` // Omit to email the user an invitation to set it; the user must change it at first login
+	Name                  string `json:"name" binding:"required"`
+	Role                  string `json:"role" binding:"required"` // Name of an existing role
+	CompanyCode           string `json:"company_code"`
+	CostCenter            string `json:"cost_center"`
+	Department            string `json:"department"`
+	PasswordLoginDisabled bool   `json:"password_login_disabled"` // Sign in with SSO only; no invitation is sent
 }
 
 type UpdateUserRequest struct {
-	Email       string `json:"email" binding:"omitempty,email"`
-	Name        string `json:"name"`
-	Role        string `json:"role" binding:"omitempty,oneof=admin supply_chain_manager general_manager employee"`
-	CompanyCode string `json:"company_code"`
-	CostCenter  string `json:"cost_center"`
-	Department  string `json:"department"`
-	Status      string `json:"status" binding:"omitempty,oneof=active inactive"`
+	Email                 string `json:"email" binding:"omitempty,email"`
+	Name                  string `json:"name"`
+	Role                  string `json:"role"`
+	CompanyCode           string `json:"company_code"`
+	CostCenter            string `json:"cost_center"`
+	Department            string `json:"department"`
+	Status                string `json:"status" binding:"omitempty,oneof=active inactive"`
+	PasswordLoginDisabled *bool  `json:"password_login_disabled"`
+}
+
+// UserScopesRequest replaces the departments and cost centers a user manages
+type UserScopesRequest struct {
+	Departments []string `json:"departments"`
+	CostCenters []string `json:"cost_centers"`
+}
+
+type UserScopesResponse struct {
+	Departments []string `json:"departments"`
+	CostCenters []string `json:"cost_centers"`
+}
+
+func scopesToResponse(scopes []models.UserScope) UserScopesResponse {
+	user := models.User{Scopes: scopes}
+	departments, costCenters := user.ScopeValues()
+	if departments == nil {
+		departments = []string{}
+	}
+	if costCenters == nil {
+		costCenters = []string{}
+	}
+	return UserScopesResponse{Departments: departments, CostCenters: costCenters}
 }
 
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
+// userToResponse builds the API representation of a user
+func userToResponse(user models.User) UserResponse {
+	return UserResponse{
+		ID:                     user.ID,
+		Email:                  user.Email,
+		Name:                   user.Name,
+		Role:                   string(user.Role),
+		CompanyCode:            user.CompanyCode,
+		CostCenter:             user.CostCenter,
+		Department:             user.Department,
+		Status:                 user.Status,
+		MFAEnabled:             user.MFAEnabled,
+		PasswordChangeRequired: user.MustChangePassword && !user.PasswordLoginDisabled,
+		PasswordLoginDisabled:  user.PasswordLoginDisabled,
+		SSOLinked:              user.OIDCSubject != nil,
+		Permissions:            user.Permissions,
+	}
 }
 
 // ListUsers returns a list of all users
@@ -77,16 +131,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 
 	userResponses := make([]UserResponse, len(users))
 	for i, user := range users {
-		userResponses[i] = UserResponse{
-			ID:          user.ID,
-			Email:       user.Email,
-			Name:        user.Name,
-			Role:        string(user.Role),
-			CompanyCode: user.CompanyCode,
-			CostCenter:  user.CostCenter,
-			Department:  user.Department,
-			Status:      user.Status,
-		}
+		userResponses[i] = userToResponse(user)
 	}
 
 	response.SuccessWithMeta(c, userResponses, &response.Meta{
@@ -115,16 +160,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        string(user.Role),
-		CompanyCode: user.CompanyCode,
-		CostCenter:  user.CostCenter,
-		Department:  user.Department,
-		Status:      user.Status,
-	})
+	response.Success(c, userToResponse(user))
 }
 
 // CreateUser creates a new user
@@ -134,6 +170,13 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if !h.roles.Exists(models.UserRole(req.Role)) {
+		response.ValidationError(c, "Unknown role: "+req.Role)
+		return
+	}
+	if !h.checkRoleGranted(c, models.UserRole(req.Role), "You cannot assign a role with permissions you do not have") {
+		return
+	}
 
 	// Check if email already exists
 	var existingUser models.User
@@ -142,39 +185,99 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	user := models.User{
+		Email:                 req.Email,
+		Name:                  req.Name,
+		Role:                  models.UserRole(req.Role),
+		CompanyCode:           req.CompanyCode,
+		CostCenter:            req.CostCenter,
+		Department:            req.Department,
+		Status:                "active",
+		PasswordLoginDisabled: req.PasswordLoginDisabled,
+	}
+
+	// Invited users get a random password until they set their own, and SSO
+	// only users keep it. A password chosen by the admin has to be changed at
+	// the first login.
+	password := req.Password
+	invite := password == "" && !req.PasswordLoginDisabled
+	if password == "" {
+		password = uuid.NewString()
+	} else {
+		if err := h.passwordPolicy.Validate(password, &user); err != nil {
+			response.ValidationError(c, err.Error())
+			return
+		}
+		user.MustChangePassword = true
+	}
+
 	// Hash password
-	hashedPassword, err := services.HashPassword(req.Password)
+	hashedPassword, err := services.HashPassword(password)
 	if err != nil {
 		response.InternalServerError(c, "Failed to hash password")
 		return
 	}
+	now := time.Now()
+	user.PasswordHash = hashedPassword
+	user.PasswordChangedAt = &now
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceUser, user.ID, nil, userToResponse(user))
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to create user")
+		return
+	}
 
-	user := models.User{
-		Email:        req.Email,
-		PasswordHash: hashedPassword,
-		Name:         req.Name,
-		Role:         models.UserRole(req.Role),
-		CompanyCode:  req.CompanyCode,
-		CostCenter:   req.CostCenter,
-		Department:   req.Department,
-		Status:       "active",
+	if invite {
+		if err := h.passwordTokens.Invite(&user, middleware.GetUserID(c)); err != nil {
+			log.Printf("Failed to send invitation to %s: %v", user.Email, err)
+			response.CreatedWithMessage(c, "User created, but the invitation email could not be sent; resend it later", userToResponse(user))
+			return
+		}
+		response.CreatedWithMessage(c, "User created and invited by email", userToResponse(user))
+		return
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
-		response.InternalServerError(c, "Failed to create user")
+	response.Created(c, userToResponse(user))
+}
+
+// InviteUser emails the user a link to set their password, replacing any
+// earlier invitation
+func (h *UserHandler) InviteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
 		return
 	}
 
-	response.Created(c, UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        string(user.Role),
-		CompanyCode: user.CompanyCode,
-		CostCenter:  user.CostCenter,
-		Department:  user.Department,
-		Status:      user.Status,
-	})
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+	if !user.IsActive() {
+		response.BadRequest(c, "Cannot invite an inactive user")
+		return
+	}
+	if !h.checkRoleGranted(c, user.Role, "You cannot invite a user whose role has permissions you do not have") {
+		return
+	}
+
+	if err := h.passwordTokens.Invite(&user, middleware.GetUserID(c)); err != nil {
+		log.Printf("Failed to send invitation to %s: %v", user.Email, err)
+		response.InternalServerError(c, "Failed to send invitation email")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Invitation sent", nil)
 }
 
 // UpdateUser updates an existing user
@@ -200,6 +303,27 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.Role != "" && !h.roles.Exists(models.UserRole(req.Role)) {
+		response.ValidationError(c, "Unknown role: "+req.Role)
+		return
+	}
+
+	// Users holding more access than the caller are out of their reach, and
+	// nobody changes their own role
+	if !h.checkRoleGranted(c, user.Role, "You cannot edit a user whose role has permissions you do not have") {
+		return
+	}
+	if req.Role != "" && models.UserRole(req.Role) != user.Role {
+		if user.ID == middleware.GetUserID(c) {
+			response.BadRequest(c, "Cannot change your own role")
+			return
+		}
+		if !h.checkRoleGranted(c, models.UserRole(req.Role), "You cannot assign a role with permissions you do not have") {
+			return
+		}
+	}
+
+	before := userToResponse(user)
 
 	// Check if email already exists (if being changed)
 	if req.Email != "" && req.Email != user.Email {
@@ -229,22 +353,69 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	if req.Status != "" {
 		user.Status = req.Status
 	}
+	if req.PasswordLoginDisabled != nil {
+		user.PasswordLoginDisabled = *req.PasswordLoginDisabled
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		after := userToResponse(user)
+		if err := recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceUser, user.ID, before, after); err != nil {
+			return err
+		}
+
+		// Role and status changes are also recorded on their own so they are easy to find
+		if before.Role != after.Role {
+			err := recordAudit(tx, c, models.AuditActionRoleChange, models.AuditResourceUser, user.ID,
+				gin.H{"role": before.Role}, gin.H{"role": after.Role})
+			if err != nil {
+				return err
+			}
+		}
+		if before.Status != after.Status {
+			err := recordAudit(tx, c, models.AuditActionStatusChange, models.AuditResourceUser, user.ID,
+				gin.H{"status": before.Status}, gin.H{"status": after.Status})
+			if err != nil {
+				return err
+			}
+		}
 
-	if err := h.db.Save(&user).Error; err != nil {
+		// Sessions carry the role they were opened with, and the new role may
+		// require two-factor authentication the user has not set up
+		if !user.IsActive() {
+			_, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedUserDeactivated, 0)
+			return err
+		}
+		if before.Role != after.Role {
+			_, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedRoleChanged, 0)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to update user")
 		return
 	}
 
-	response.Success(c, UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        string(user.Role),
-		CompanyCode: user.CompanyCode,
-		CostCenter:  user.CostCenter,
-		Department:  user.Department,
-		Status:      user.Status,
-	})
+	response.Success(c, userToResponse(user))
+}
+
+// checkRoleGranted writes a forbidden response with the message and returns
+// false unless the caller holds every permission of the role
+func (h *UserHandler) checkRoleGranted(c *gin.Context, role models.UserRole, message string) bool {
+	granted, err := h.roles.Grants(middleware.GetUserPermissions(c), role)
+	if err != nil {
+		response.InternalServerError(c, "Failed to check role permissions")
+		return false
+	}
+	if !granted {
+		response.Forbidden(c, message)
+		return false
+	}
+	return true
 }
 
 // DeleteUser deletes a user
@@ -271,8 +442,20 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		}
 		return
 	}
+	if !h.checkRoleGranted(c, user.Role, "You cannot delete a user whose role has permissions you do not have") {
+		return
+	}
 
-	if err := h.db.Delete(&user).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		if _, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedUserDeleted, 0); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceUser, user.ID, userToResponse(user), nil)
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to delete user")
 		return
 	}
@@ -304,28 +487,38 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 		}
 		return
 	}
+	if !h.checkRoleGranted(c, user.Role, "You cannot change the status of a user whose role has permissions you do not have") {
+		return
+	}
 
+	oldStatus := user.Status
 	if user.Status == "active" {
 		user.Status = "inactive"
 	} else {
 		user.Status = "active"
 	}
 
-	if err := h.db.Save(&user).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		// Deactivated users are signed out everywhere
+		if !user.IsActive() {
+			if _, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedUserDeactivated, 0); err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(tx, c, models.AuditActionStatusChange, models.AuditResourceUser, user.ID,
+			gin.H{"status": oldStatus}, gin.H{"status": user.Status})
+	})
+	if err != nil {
 		response.InternalServerError(c, "Failed to update user status")
 		return
 	}
 
-	response.SuccessWithMessage(c, "User status updated", UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Name:        user.Name,
-		Role:        string(user.Role),
-		CompanyCode: user.CompanyCode,
-		CostCenter:  user.CostCenter,
-		Department:  user.Department,
-		Status:      user.Status,
-	})
+	response.SuccessWithMessage(c, "User status updated", userToResponse(user))
 }
 
 // ChangePassword changes the user's password
@@ -350,22 +543,154 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	// Verify current password
 	if !services.VerifyPassword(req.CurrentPassword, user.PasswordHash) {
-		response.Unauthorized(c, "Current password is incorrect")
+		response.BadRequest(c, "Current password is incorrect")
 		return
 	}
 
-	// Hash new password
-	hashedPassword, err := services.HashPassword(req.NewPassword)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.passwordPolicy.SetPassword(tx, &user, req.NewPassword, false); err != nil {
+			return err
+		}
+
+		// Sign out other sessions that may have been opened with the old password
+		if _, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedPasswordChanged, middleware.GetSessionID(c)); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionPasswordChange, models.AuditResourceUser, user.ID, nil, nil)
+	})
 	if err != nil {
-		response.InternalServerError(c, "Failed to hash password")
+		if services.IsPasswordPolicyError(err) {
+			response.ValidationError(c, err.Error())
+		} else {
+			response.InternalServerError(c, "Failed to update password")
+		}
 		return
 	}
 
-	user.PasswordHash = hashedPassword
-	if err := h.db.Save(&user).Error; err != nil {
-		response.InternalServerError(c, "Failed to update password")
+	response.SuccessWithMessage(c, "Password changed successfully", nil)
+}
+
+// ResetUserMFA turns off a user's two-factor authentication, e.g. after they
+// lost their authenticator, and signs them out everywhere. Users the policy
+// requires it for enroll again at their next login.
+func (h *UserHandler) ResetUserMFA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
 		return
 	}
 
-	response.SuccessWithMessage(c, "Password changed successfully", nil)
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+	if !user.MFAEnabled && user.MFASecret == "" {
+		response.BadRequest(c, "User has not set up two-factor authentication")
+		return
+	}
+	if !h.checkRoleGranted(c, user.Role, "You cannot reset two-factor authentication for a user whose role has permissions you do not have") {
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.mfaService.Reset(tx, user.ID); err != nil {
+			return err
+		}
+		if _, err := h.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedMFAReset, 0); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionMFAReset, models.AuditResourceUser, user.ID,
+			gin.H{"mfa_enabled": user.MFAEnabled}, gin.H{"mfa_enabled": false})
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to reset two-factor authentication")
+		return
+	}
+
+	user.MFAEnabled = false
+	response.SuccessWithMessage(c, "Two-factor authentication reset", userToResponse(user))
+}
+
+// GetUserScopes returns the departments and cost centers a user manages
+func (h *UserHandler) GetUserScopes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Preload("Scopes").First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+
+	response.Success(c, scopesToResponse(user.Scopes))
+}
+
+// SetUserScopes replaces the departments and cost centers a user manages.
+// With any assigned, the user only sees and approves requests from them;
+// with none, requests.view_all covers the whole company.
+func (h *UserHandler) SetUserScopes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	// Prevent widening one's own visibility
+	if uint(id) == middleware.GetUserID(c) {
+		response.BadRequest(c, "Cannot change your own scopes")
+		return
+	}
+
+	var req UserScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	var user models.User
+	if err := h.db.Preload("Scopes").First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.NotFound(c, "User not found")
+		} else {
+			response.InternalServerError(c, "Failed to fetch user")
+		}
+		return
+	}
+	if !h.checkRoleGranted(c, user.Role, "You cannot change the scopes of a user whose role has permissions you do not have") {
+		return
+	}
+	before := scopesToResponse(user.Scopes)
+
+	var scopes []models.UserScope
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		scopes, err = visibility.SetScopes(tx, user.ID, req.Departments, req.CostCenters)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceUser, user.ID,
+			gin.H{"scopes": before}, gin.H{"scopes": scopesToResponse(scopes)})
+	})
+	if err != nil {
+		if errors.Is(err, visibility.ErrInvalidScope) {
+			response.ValidationError(c, "Departments and cost centers cannot be empty")
+		} else {
+			response.InternalServerError(c, "Failed to update scopes")
+		}
+		return
+	}
+
+	response.Success(c, scopesToResponse(scopes))
 }
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/webhook"
+	"vista-backend/pkg/response"
+)
+
+type WebhookHandler struct {
+	db       *gorm.DB
+	webhooks *webhook.Service
+}
+
+func NewWebhookHandler(db *gorm.DB, webhooks *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{db: db, webhooks: webhooks}
+}
+
+type CreateWebhookRequest struct {
+	Name     string   `json:"name" binding:"required,max=100"`
+	URL      string   `json:"url" binding:"required"`
+	Secret   string   `json:"secret"` // Generated when empty
+	Events   []string `json:"events" binding:"required"`
+	IsActive *bool    `json:"is_active"` // Defaults to true
+}
+
+type UpdateWebhookRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	URL          string   `json:"url" binding:"required"`
+	Events       []string `json:"events" binding:"required"`
+	IsActive     bool     `json:"is_active"`
+	Secret       string   `json:"secret"`        // Replaces the secret when set
+	RotateSecret bool     `json:"rotate_secret"` // Generates a new secret
+}
+
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when it is set or generated
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	LastDelivery *models.WebhookDelivery `json:"last_delivery,omitempty"`
+}
+
+func webhookToResponse(w *models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		URL:       w.URL,
+		Events:    w.EventTypes(),
+		IsActive:  w.IsActive,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// webhookAuditState is the webhook recorded in the audit log. The secret is
+// never recorded, only whether it changed.
+type webhookAuditState struct {
+	Name          string   `json:"name"`
+	URL           string   `json:"url"`
+	Events        []string `json:"events"`
+	IsActive      bool     `json:"is_active"`
+	SecretChanged bool     `json:"secret_changed,omitempty"`
+}
+
+func webhookAuditSnapshot(w *models.Webhook) webhookAuditState {
+	return webhookAuditState{
+		Name:     w.Name,
+		URL:      w.URL,
+		Events:   w.EventTypes(),
+		IsActive: w.IsActive,
+	}
+}
+
+// ListWebhookEvents returns the event types webhooks can subscribe to
+func (h *WebhookHandler) ListWebhookEvents(c *gin.Context) {
+	response.Success(c, events.Types)
+}
+
+// ListWebhooks returns all webhooks with their latest delivery
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	var webhooks []models.Webhook
+	if err := h.db.Order("name ASC").Find(&webhooks).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch webhooks")
+		return
+	}
+
+	webhookResponses := make([]WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		webhookResponses[i] = webhookToResponse(&webhooks[i])
+
+		var last models.WebhookDelivery
+		if err := h.db.Where("webhook_id = ?", webhooks[i].ID).Order("id DESC").Omit("payload").First(&last).Error; err == nil {
+			webhookResponses[i].LastDelivery = &last
+		}
+	}
+
+	response.Success(c, webhookResponses)
+}
+
+// CreateWebhook subscribes a URL to event types. The signing secret is only
+// returned in this response.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	active := req.IsActive == nil || *req.IsActive
+	hook := models.Webhook{
+		Name:        strings.TrimSpace(req.Name),
+		URL:         strings.TrimSpace(req.URL),
+		IsActive:    active,
+		CreatedByID: middleware.GetUserID(c),
+	}
+	hook.SetEventTypes(req.Events)
+	if err := h.webhooks.Validate(hook.URL, req.Events); err != nil {
+		response.ValidationError(c, err.Error())
+		return
+	}
+
+	secret, err := h.setSecret(&hook, req.Secret, req.Secret == "")
+	if err != nil {
+		respondWebhookError(c, err, "Failed to create webhook secret")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hook).Error; err != nil {
+			return err
+		}
+		// GORM skips zero values for columns with defaults on insert and
+		// reads the default back into the struct
+		if !active {
+			if err := tx.Model(&hook).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, c, models.AuditActionCreate, models.AuditResourceWebhook, hook.ID, nil, webhookAuditSnapshot(&hook))
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to create webhook")
+		return
+	}
+
+	resp := webhookToResponse(&hook)
+	resp.Secret = secret
+	response.Created(c, resp)
+}
+
+// UpdateWebhook changes a webhook. The secret is only returned when it changes.
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := h.webhooks.Validate(strings.TrimSpace(req.URL), req.Events); err != nil {
+		response.ValidationError(c, err.Error())
+		return
+	}
+
+	before := webhookAuditSnapshot(hook)
+	hook.Name = strings.TrimSpace(req.Name)
+	hook.URL = strings.TrimSpace(req.URL)
+	hook.IsActive = req.IsActive
+	hook.SetEventTypes(req.Events)
+
+	secret, err := h.setSecret(hook, req.Secret, req.RotateSecret)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to update webhook secret")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(hook).Select("name", "url", "events", "is_active", "encrypted_secret").Updates(hook).Error; err != nil {
+			return err
+		}
+		after := webhookAuditSnapshot(hook)
+		after.SecretChanged = secret != ""
+		return recordAudit(tx, c, models.AuditActionUpdate, models.AuditResourceWebhook, hook.ID, before, after)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to update webhook")
+		return
+	}
+
+	resp := webhookToResponse(hook)
+	resp.Secret = secret
+	response.Success(c, resp)
+}
+
+// DeleteWebhook deletes a webhook. Its queued deliveries fail without being sent.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(hook).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, models.AuditActionDelete, models.AuditResourceWebhook, hook.ID, webhookAuditSnapshot(hook), nil)
+	})
+	if err != nil {
+		response.InternalServerError(c, "Failed to delete webhook")
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook deleted", nil)
+}
+
+// ListWebhookDeliveries returns a webhook's delivery log, newest first, with
+// optional status and event type filters
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	offset := (page - 1) * perPage
+
+	query := h.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Order("id DESC").Offset(offset).Limit(perPage).Find(&deliveries).Error; err != nil {
+		response.InternalServerError(c, "Failed to fetch deliveries")
+		return
+	}
+
+	response.SuccessWithMeta(c, deliveries, &response.Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: response.CalculateTotalPages(total, perPage),
+	})
+}
+
+// TestWebhook sends a test event to the webhook right away and returns the
+// delivery, whether or not the receiver accepted it
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooks.SendTest(c.Request.Context(), hook)
+	if err != nil {
+		response.InternalServerError(c, "Failed to send test event")
+		return
+	}
+
+	if delivery.Status != models.DeliverySucceeded {
+		response.SuccessWithMessage(c, "Test event failed: "+delivery.Error, delivery)
+		return
+	}
+	response.SuccessWithMessage(c, "Test event delivered", delivery)
+}
+
+// loadWebhook fetches the webhook named by the id parameter, writing the
+// error response when it cannot
+func (h *WebhookHandler) loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid webhook ID")
+		return nil, false
+	}
+
+	hook, err := h.webhooks.Get(uint(id))
+	if err != nil {
+		respondWebhookError(c, err, "Failed to fetch webhook")
+		return nil, false
+	}
+	return hook, true
+}
+
+// setSecret stores the given secret on the webhook, or a generated one when
+// generate is set, and returns the new plaintext secret
+func (h *WebhookHandler) setSecret(hook *models.Webhook, secret string, generate bool) (string, error) {
+	if generate {
+		var err error
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			return "", err
+		}
+	}
+	if secret == "" {
+		return "", nil
+	}
+	if err := h.webhooks.SetSecret(hook, secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// respondWebhookError writes the response for a failed webhook change
+func respondWebhookError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, webhook.ErrWebhookNotFound):
+		response.NotFound(c, "Webhook not found")
+	case errors.Is(err, webhook.ErrSecretTooShort):
+		response.ValidationError(c, err.Error())
+	default:
+		response.InternalServerError(c, fallback)
+	}
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"vista-backend/pkg/jwt"
@@ -14,10 +16,36 @@ const (
 	UserIDKey           = "user_id"
 	UserEmailKey        = "user_email"
 	UserRoleKey         = "user_role"
+	UserPermissionsKey  = "user_permissions"
+	SessionIDKey        = "session_id"
+	TokenExpiresAtKey   = "token_expires_at"
 )
 
-// Auth returns an authentication middleware
-func Auth(jwtService *jwt.JWTService) gin.HandlerFunc {
+// SessionValidator checks that the session an access token was issued for has
+// not been revoked, whether its user has to change their password or set up
+// two-factor authentication first, and which permissions their role grants
+type SessionValidator interface {
+	ValidateSession(sessionID, userID uint) error
+	PasswordChangeRequired(userID uint) (bool, error)
+	MFASetupRequired(userID uint) (bool, error)
+	RolePermissions(role string) ([]string, error)
+}
+
+// Auth returns an authentication middleware. Tokens of revoked sessions are
+// rejected even before they expire, users who must change their password are
+// refused until they do, and users who must set up two-factor authentication
+// have to log in again to enroll.
+func Auth(jwtService *jwt.JWTService, sessions SessionValidator) gin.HandlerFunc {
+	return authenticate(jwtService, sessions, false)
+}
+
+// AuthAllowingPasswordChange is Auth for the routes a user who must change
+// their password can still use, such as changing it
+func AuthAllowingPasswordChange(jwtService *jwt.JWTService, sessions SessionValidator) gin.HandlerFunc {
+	return authenticate(jwtService, sessions, true)
+}
+
+func authenticate(jwtService *jwt.JWTService, sessions SessionValidator, allowPasswordChange bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
@@ -46,10 +74,54 @@ func Auth(jwtService *jwt.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		if err := sessions.ValidateSession(claims.SessionID, claims.UserID); err != nil {
+			response.Unauthorized(c, "Session has been revoked")
+			c.Abort()
+			return
+		}
+
+		mfaRequired, err := sessions.MFASetupRequired(claims.UserID)
+		if err != nil {
+			response.Unauthorized(c, "Invalid token")
+			c.Abort()
+			return
+		}
+		if mfaRequired {
+			response.Error(c, http.StatusUnauthorized, "MFA_SETUP_REQUIRED", "Two-factor authentication must be set up; log in again to enroll")
+			c.Abort()
+			return
+		}
+
+		if !allowPasswordChange {
+			required, err := sessions.PasswordChangeRequired(claims.UserID)
+			if err != nil {
+				response.Unauthorized(c, "Invalid token")
+				c.Abort()
+				return
+			}
+			if required {
+				response.Error(c, http.StatusForbidden, "PASSWORD_CHANGE_REQUIRED", "You must change your password before continuing")
+				c.Abort()
+				return
+			}
+		}
+
+		permissions, err := sessions.RolePermissions(claims.Role)
+		if err != nil {
+			response.InternalServerError(c, "Failed to load permissions")
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
 		c.Set(UserIDKey, claims.UserID)
 		c.Set(UserEmailKey, claims.Email)
 		c.Set(UserRoleKey, claims.Role)
+		c.Set(UserPermissionsKey, permissions)
+		c.Set(SessionIDKey, claims.SessionID)
+		if claims.ExpiresAt != nil {
+			c.Set(TokenExpiresAtKey, claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
@@ -63,6 +135,23 @@ func GetUserID(c *gin.Context) uint {
 	return 0
 }
 
+// GetSessionID extracts the session ID from context
+func GetSessionID(c *gin.Context) uint {
+	if sessionID, exists := c.Get(SessionIDKey); exists {
+		return sessionID.(uint)
+	}
+	return 0
+}
+
+// GetTokenExpiresAt extracts when the access token expires from context, or
+// the zero time if it does not
+func GetTokenExpiresAt(c *gin.Context) time.Time {
+	if expiresAt, exists := c.Get(TokenExpiresAtKey); exists {
+		return expiresAt.(time.Time)
+	}
+	return time.Time{}
+}
+
 // GetUserEmail extracts user email from context
 func GetUserEmail(c *gin.Context) string {
 	if email, exists := c.Get(UserEmailKey); exists {
@@ -80,7 +169,7 @@ func GetUserRole(c *gin.Context) string {
 }
 
 // OptionalAuth is middleware that doesn't require auth but sets user info if present
-func OptionalAuth(jwtService *jwt.JWTService) gin.HandlerFunc {
+func OptionalAuth(jwtService *jwt.JWTService, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
@@ -96,10 +185,13 @@ func OptionalAuth(jwtService *jwt.JWTService) gin.HandlerFunc {
 
 		token := parts[1]
 		claims, err := jwtService.ValidateAccessToken(token)
-		if err == nil {
+		if err == nil && sessions.ValidateSession(claims.SessionID, claims.UserID) == nil {
+			permissions, _ := sessions.RolePermissions(claims.Role)
 			c.Set(UserIDKey, claims.UserID)
 			c.Set(UserEmailKey, claims.Email)
 			c.Set(UserRoleKey, claims.Role)
+			c.Set(UserPermissionsKey, permissions)
+			c.Set(SessionIDKey, claims.SessionID)
 		}
 
 		c.Next()
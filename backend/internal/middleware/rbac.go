@@ -2,23 +2,21 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
-	"vista-backend/internal/models"
 	"vista-backend/pkg/response"
 )
 
-// RequireRole returns middleware that requires specific roles
-func RequireRole(allowedRoles ...models.UserRole) gin.HandlerFunc {
+// RequirePermission returns middleware that requires the user's role to grant
+// at least one of the permissions
+func RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := GetUserRole(c)
-		if userRole == "" {
+		if GetUserID(c) == 0 {
 			response.Unauthorized(c, "Authentication required")
 			c.Abort()
 			return
 		}
 
-		// Check if user's role is in allowed roles
-		for _, role := range allowedRoles {
-			if models.UserRole(userRole) == role {
+		for _, permission := range permissions {
+			if HasPermission(c, permission) {
 				c.Next()
 				return
 			}
@@ -29,51 +27,20 @@ func RequireRole(allowedRoles ...models.UserRole) gin.HandlerFunc {
 	}
 }
 
-// RequireAdmin requires admin role
-func RequireAdmin() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin)
-}
-
-// RequireAdminOrSupplyChain requires admin or supply chain manager role
-func RequireAdminOrSupplyChain() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleSupplyChainManager)
-}
-
-// RequireApprover requires a role that can approve requests
-func RequireApprover() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleGeneralManager)
-}
-
-// RequireManager requires a manager-level role
-func RequireManager() gin.HandlerFunc {
-	return RequireRole(models.RoleAdmin, models.RoleSupplyChainManager, models.RoleGeneralManager)
-}
-
-// CanViewAllRequests checks if user can view all requests
-func CanViewAllRequests() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userRole := GetUserRole(c)
-		if userRole == "" {
-			response.Unauthorized(c, "Authentication required")
-			c.Abort()
-			return
-		}
-
-		role := models.UserRole(userRole)
-		if role == models.RoleAdmin || role == models.RoleSupplyChainManager || role == models.RoleGeneralManager {
-			c.Set("can_view_all", true)
-		} else {
-			c.Set("can_view_all", false)
+// HasPermission reports whether the authenticated user's role grants the permission
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, p := range GetUserPermissions(c) {
+		if p == permission {
+			return true
 		}
-
-		c.Next()
 	}
+	return false
 }
 
-// CanViewAll extracts the can_view_all flag from context
-func CanViewAll(c *gin.Context) bool {
-	if canView, exists := c.Get("can_view_all"); exists {
-		return canView.(bool)
+// GetUserPermissions extracts the permissions of the user's role from context
+func GetUserPermissions(c *gin.Context) []string {
+	if permissions, exists := c.Get(UserPermissionsKey); exists {
+		return permissions.([]string)
 	}
-	return false
+	return nil
 }
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApprovalStepStatus string
+
+const (
+	StepWaiting  ApprovalStepStatus = "waiting" // Not yet reached in the chain
+	StepPending  ApprovalStepStatus = "pending" // Current step, awaiting a decision
+	StepApproved ApprovalStepStatus = "approved"
+	StepRejected ApprovalStepStatus = "rejected"
+)
+
+// ApprovalRule defines an approval chain and the requests it applies to.
+// Empty matching fields act as wildcards; the active rule with the highest
+// priority that matches a request determines its chain.
+type ApprovalRule struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"not null;size:255" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Matching criteria
+	MinAmount  *float64 `json:"min_amount,omitempty"` // Inclusive, compared against the request amount in the base currency
+	MaxAmount  *float64 `json:"max_amount,omitempty"` // Exclusive
+	CostCenter string   `gorm:"size:50" json:"cost_center"`
+	Department string   `gorm:"size:100" json:"department"`
+	Urgency    Urgency  `gorm:"size:20" json:"urgency"`
+
+	Priority int  `gorm:"default:0" json:"priority"`
+	IsActive bool `gorm:"default:true" json:"is_active"`
+
+	Steps []ApprovalRuleStep `gorm:"foreignKey:RuleID" json:"steps"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ApprovalRuleStep is one level of an approval chain. A step is assigned
+// either to a specific user or, when ApproverID is nil, to every user
+// holding ApproverRole.
+type ApprovalRuleStep struct {
+	ID           uint     `gorm:"primaryKey" json:"id"`
+	RuleID       uint     `gorm:"not null;index" json:"rule_id"`
+	Level        int      `gorm:"not null" json:"level"`
+	Name         string   `gorm:"size:255" json:"name"`
+	ApproverRole UserRole `gorm:"size:50" json:"approver_role"`
+	ApproverID   *uint    `json:"approver_id,omitempty"`
+	Approver     *User    `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
+}
+
+// ApprovalStep is the instantiated chain step for a single purchase request
+type ApprovalStep struct {
+	ID           uint               `gorm:"primaryKey" json:"id"`
+	RequestID    uint               `gorm:"not null;index" json:"request_id"`
+	Level        int                `gorm:"not null" json:"level"`
+	Name         string             `gorm:"size:255" json:"name"`
+	ApproverRole UserRole           `gorm:"size:50;index" json:"approver_role"`
+	ApproverID   *uint              `gorm:"index" json:"approver_id,omitempty"`
+	Approver     *User              `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
+	Status       ApprovalStepStatus `gorm:"default:'waiting';size:20;index" json:"status"`
+	ActedByID    *uint              `json:"acted_by_id,omitempty"`
+	ActedBy      *User              `gorm:"foreignKey:ActedByID" json:"acted_by,omitempty"`
+	ActedAt      *time.Time         `json:"acted_at,omitempty"`
+	OnBehalfOfID *uint              `json:"on_behalf_of_id,omitempty"` // Delegator of the user who acted as their delegate
+	OnBehalfOf   *User              `gorm:"foreignKey:OnBehalfOfID" json:"on_behalf_of,omitempty"`
+	Comment      string             `gorm:"type:text" json:"comment"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// Matches checks whether the rule applies to a request with the given amount and requester
+func (r *ApprovalRule) Matches(amount float64, requester *User, urgency Urgency) bool {
+	if r.MinAmount != nil && amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && amount >= *r.MaxAmount {
+		return false
+	}
+	if r.CostCenter != "" && r.CostCenter != requester.CostCenter {
+		return false
+	}
+	if r.Department != "" && r.Department != requester.Department {
+		return false
+	}
+	if r.Urgency != "" && r.Urgency != urgency {
+		return false
+	}
+	return true
+}
+
+// IsAssignedTo checks if the step can be acted on by the given user on a
+// request by the requester. Nobody acts on their own request. Steps naming
+// the user are theirs; steps for their role, or any step for users allowed
+// to approve any request, only if the requester is within the user's scopes.
+func (s *ApprovalStep) IsAssignedTo(user, requester *User) bool {
+	if requester.ID == user.ID {
+		return false
+	}
+	if s.ApproverID != nil && *s.ApproverID == user.ID {
+		return true
+	}
+	if !user.Covers(requester) {
+		return false
+	}
+	if user.Can(PermRequestsApproveAny) {
+		return true
+	}
+	return s.ApproverID == nil && s.ApproverRole == user.Role
+}
+
+// DelegationFor returns the delegation under which the user can act on the
+// step on behalf of another user, or nil. Only the user's loaded Delegations
+// are considered. A delegation covers the steps naming its delegator or for
+// the delegator's role within the delegator's scopes, but never the
+// delegator's requests.approve_any, nor the delegate's own requests.
+func (s *ApprovalStep) DelegationFor(user, requester *User) *ApprovalDelegation {
+	if requester.ID == user.ID {
+		return nil
+	}
+	for i := range user.Delegations {
+		d := &user.Delegations[i]
+		if d.Delegator.IsActive() && d.Covers(requester) && s.IsAssignedTo(&d.Delegator, requester) {
+			return d
+		}
+	}
+	return nil
+}
@@ -4,6 +4,44 @@ import (
 	"time"
 )
 
+// Audit log actions
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionRoleChange     = "role_change"
+	AuditActionStatusChange   = "status_change"
+	AuditActionPasswordChange = "password_change"
+	AuditActionStockChange    = "stock_change"
+	AuditActionApprove        = "approve"
+	AuditActionReject         = "reject"
+	AuditActionRequestInfo    = "request_info"
+	AuditActionPurchase       = "purchase"
+	AuditActionTokenReuse     = "token_reuse"
+	AuditActionLockout        = "lockout"
+	AuditActionUnlock         = "unlock"
+	AuditActionMFAEnable      = "mfa_enable"
+	AuditActionMFADisable     = "mfa_disable"
+	AuditActionMFAReset       = "mfa_reset"
+	AuditActionRecoveryCodes  = "recovery_codes"
+	AuditActionRecoveryLogin  = "recovery_login"
+	AuditActionSSOLink        = "sso_link"
+)
+
+// Audit log resources
+const (
+	AuditResourceUser          = "user"
+	AuditResourceProduct       = "product"
+	AuditResourceAmazonConfig  = "amazon_config"
+	AuditResourceRequest       = "purchase_request"
+	AuditResourceSession       = "session"
+	AuditResourceLoginThrottle = "login_throttle"
+	AuditResourceRole          = "role"
+	AuditResourceDelegation    = "delegation"
+	AuditResourceWebhook       = "webhook"
+	AuditResourceAttachment    = "request_attachment"
+)
+
 type AuditLog struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
 	UserID     uint      `gorm:"index" json:"user_id"`
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BudgetPeriodType string
+
+const (
+	BudgetMonthly   BudgetPeriodType = "monthly"
+	BudgetQuarterly BudgetPeriodType = "quarterly"
+	BudgetYearly    BudgetPeriodType = "yearly"
+)
+
+// Budget is the spending limit of a cost center for one period.
+// CommittedAmount holds approved requests not yet purchased; SpentAmount
+// holds purchased requests. Both are in the budget currency.
+type Budget struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	CostCenter  string           `gorm:"not null;size:50;index" json:"cost_center"`
+	CompanyCode string           `gorm:"size:50" json:"company_code"`
+	Name        string           `gorm:"size:255" json:"name"`
+	PeriodType  BudgetPeriodType `gorm:"not null;size:20" json:"period_type"`
+	Period      string           `gorm:"not null;size:20;index" json:"period"` // e.g. 2026-10, 2026-Q4, 2026
+	PeriodStart time.Time        `gorm:"not null;index" json:"period_start"`
+	PeriodEnd   time.Time        `gorm:"not null;index" json:"period_end"` // Exclusive
+
+	Amount          float64 `gorm:"not null" json:"amount"`
+	Currency        string  `gorm:"default:'MXN';size:10" json:"currency"`
+	CommittedAmount float64 `gorm:"default:0" json:"committed_amount"`
+	SpentAmount     float64 `gorm:"default:0" json:"spent_amount"`
+
+	CreatedByID uint           `json:"created_by_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Remaining returns the amount still available for new approvals
+func (b *Budget) Remaining() float64 {
+	return b.Amount - b.CommittedAmount - b.SpentAmount
+}
+
+// Utilization returns committed plus spent as a percentage of the budget
+func (b *Budget) Utilization() float64 {
+	if b.Amount <= 0 {
+		return 0
+	}
+	return (b.CommittedAmount + b.SpentAmount) / b.Amount * 100
+}
+
+// SetPeriod sets the period bounds and label for the period containing date
+func (b *Budget) SetPeriod(periodType BudgetPeriodType, date time.Time) error {
+	year, month, _ := date.Date()
+	loc := date.Location()
+
+	switch periodType {
+	case BudgetMonthly:
+		b.PeriodStart = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		b.PeriodEnd = b.PeriodStart.AddDate(0, 1, 0)
+		b.Period = b.PeriodStart.Format("2006-01")
+	case BudgetQuarterly:
+		quarter := (int(month)-1)/3 + 1
+		b.PeriodStart = time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, loc)
+		b.PeriodEnd = b.PeriodStart.AddDate(0, 3, 0)
+		b.Period = fmt.Sprintf("%d-Q%d", year, quarter)
+	case BudgetYearly:
+		b.PeriodStart = time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		b.PeriodEnd = b.PeriodStart.AddDate(1, 0, 0)
+		b.Period = fmt.Sprintf("%d", year)
+	default:
+		return fmt.Errorf("unknown budget period type %q", periodType)
+	}
+
+	b.PeriodType = periodType
+	return nil
+}
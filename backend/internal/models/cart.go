@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// CartItem is a product a user intends to request, persisted until checkout
+type CartItem struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"user_id"`
+
+	// Catalog product (nil for external URLs)
+	ProductID *uint    `gorm:"index" json:"product_id,omitempty"`
+	Product   *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+
+	// External product details
+	URL             string   `gorm:"size:2000" json:"url,omitempty"`
+	ProductTitle    string   `gorm:"size:500" json:"product_title"`
+	ProductImageURL string   `gorm:"size:2000" json:"product_image_url"`
+	SiteName        string   `gorm:"size:255" json:"site_name,omitempty"`
+	UnitPrice       *float64 `json:"unit_price,omitempty"`
+	Currency        string   `gorm:"size:10" json:"currency"`
+
+	Quantity int `gorm:"not null;default:1" json:"quantity"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsCatalogItem checks if the cart item refers to an internal catalog product
+func (i *CartItem) IsCatalogItem() bool {
+	return i.ProductID != nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalDelegation lets the delegate act on the delegator's approval steps
+// while the delegator is away, from StartsAt until EndsAt. A department or
+// cost center limits it to requests from requesters in them; empty fields
+// act as wildcards.
+type ApprovalDelegation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	DelegatorID uint      `gorm:"not null;index" json:"delegator_id"`
+	Delegator   User      `gorm:"foreignKey:DelegatorID" json:"-"`
+	DelegateID  uint      `gorm:"not null;index" json:"delegate_id"`
+	Delegate    User      `gorm:"foreignKey:DelegateID" json:"-"`
+	StartsAt    time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt      time.Time `gorm:"not null;index" json:"ends_at"` // Exclusive
+
+	// Scope
+	Department string `gorm:"size:100" json:"department"`
+	CostCenter string `gorm:"size:50" json:"cost_center"`
+
+	Reason      string `gorm:"size:255" json:"reason"`
+	CreatedByID uint   `json:"created_by_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsActive checks if the delegation is in effect at the given time
+func (d *ApprovalDelegation) IsActive(at time.Time) bool {
+	return !at.Before(d.StartsAt) && at.Before(d.EndsAt)
+}
+
+// Covers checks if the requester is within the delegation's scope
+func (d *ApprovalDelegation) Covers(requester *User) bool {
+	if d.Department != "" && d.Department != requester.Department {
+		return false
+	}
+	if d.CostCenter != "" && d.CostCenter != requester.CostCenter {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExchangeRateSource string
+
+const (
+	RateSourceManual ExchangeRateSource = "manual"
+	RateSourceCSV    ExchangeRateSource = "csv"
+)
+
+// ExchangeRate is the value of one unit of Currency in BaseCurrency from
+// EffectiveDate until a newer rate for the same pair takes effect
+type ExchangeRate struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	Currency      string             `gorm:"not null;size:10;index:idx_exchange_rate_pair" json:"currency"`
+	BaseCurrency  string             `gorm:"not null;size:10;index:idx_exchange_rate_pair" json:"base_currency"`
+	Rate          float64            `gorm:"not null" json:"rate"`
+	EffectiveDate time.Time          `gorm:"not null;index" json:"effective_date"`
+	Source        ExchangeRateSource `gorm:"default:'manual';size:20" json:"source"`
+	CreatedByID   *uint              `json:"created_by_id,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt     `gorm:"index" json:"-"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FilterRuleType string
+
+const (
+	FilterPriceMax      FilterRuleType = "price_max"
+	FilterPriceMin      FilterRuleType = "price_min"
+	FilterCategoryAllow FilterRuleType = "category_allow"
+	FilterCategoryBlock FilterRuleType = "category_block"
+	FilterSupplierBlock FilterRuleType = "supplier_block"
+	FilterKeywordBlock  FilterRuleType = "keyword_block"
+)
+
+type FilterAction string
+
+const (
+	FilterActionBlock FilterAction = "block" // Request is rejected at submission
+	FilterActionFlag  FilterAction = "flag"  // Request is accepted but flagged for approvers
+)
+
+// FilterRule restricts which products can be requested
+type FilterRule struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"not null;size:255" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	RuleType    FilterRuleType `gorm:"not null;size:30;index" json:"rule_type"`
+	Value       string         `gorm:"not null;size:500" json:"value"`
+	Action      FilterAction   `gorm:"default:'block';size:20" json:"action"`
+	Priority    int            `gorm:"default:0" json:"priority"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedByID uint           `json:"created_by_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsPriceRule checks if the rule compares against the product price
+func (r *FilterRule) IsPriceRule() bool {
+	return r.RuleType == FilterPriceMax || r.RuleType == FilterPriceMin
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+const (
+	JobTypeAmazonCart = "amazon_add_to_cart"
+	JobTypeEmail      = "send_email"
+	JobTypeWebhook    = "deliver_webhook"
+)
+
+// Job is a persisted unit of background work processed by the job queue
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"not null;size:50;index" json:"type"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	Status      JobStatus  `gorm:"default:'queued';size:20;index" json:"status"`
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"default:5" json:"max_attempts"`
+	RunAt       time.Time  `gorm:"index" json:"run_at"` // Earliest time the next attempt may start
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	RequestID   *uint      `gorm:"index" json:"request_id,omitempty"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+
+	AttemptLog []JobAttempt `gorm:"foreignKey:JobID" json:"attempt_log,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobAttempt records the outcome of a single execution of a job
+type JobAttempt struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JobID      uint       `gorm:"not null;index" json:"job_id"`
+	Attempt    int        `gorm:"not null" json:"attempt"`
+	Status     JobStatus  `gorm:"size:20" json:"status"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// DecodePayload unmarshals the job payload into v
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// IsActive checks if the job is waiting to run or running
+func (j *Job) IsActive() bool {
+	return j.Status == JobQueued || j.Status == JobRunning
+}
+
+// CanBeRetried checks if the job can be manually retried
+func (j *Job) CanBeRetried() bool {
+	return j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// CanBeCancelled checks if the job can be cancelled
+func (j *Job) CanBeCancelled() bool {
+	return j.Status == JobQueued
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type LoginThrottleKind string
+
+const (
+	LoginThrottleAccount LoginThrottleKind = "account"
+	LoginThrottleIP      LoginThrottleKind = "ip"
+)
+
+// LoginThrottle counts recent failed logins for an email address or a client
+// IP, and locks it out once too many have failed
+type LoginThrottle struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	Kind       LoginThrottleKind `gorm:"not null;size:20;uniqueIndex:idx_login_throttle_key" json:"kind"`
+	Identifier string            `gorm:"not null;size:255;uniqueIndex:idx_login_throttle_key" json:"identifier"` // Email (lowercase) or IP address
+	UserID     *uint             `gorm:"index" json:"user_id,omitempty"`                                         // Account the email belongs to, if any
+
+	Failures      int        `gorm:"default:0" json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LockedUntil   *time.Time `gorm:"index" json:"locked_until,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsLocked checks if the lockout is still in effect
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// MFARecoveryCode is a single-use code that replaces a TOTP code when the
+// user has lost their authenticator. Only a hash of the code is stored.
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsed reports whether the code has been redeemed
+func (c *MFARecoveryCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationType is what a notification tells its user about; preferences
+// are set per type
+type NotificationType string
+
+const (
+	NotifyApprovalPending  NotificationType = "approval_pending" // A request awaits the user's approval
+	NotifyStepApproved     NotificationType = "request_step_approved"
+	NotifyRequestApproved  NotificationType = "request_approved"
+	NotifyRequestRejected  NotificationType = "request_rejected"
+	NotifyInfoRequested    NotificationType = "request_info_requested"
+	NotifyRequestPurchased NotificationType = "request_purchased"
+	NotifyRequestComment   NotificationType = "request_comment" // Someone commented on the user's request or mentioned them
+)
+
+// NotificationTypes lists every notification type
+var NotificationTypes = []NotificationType{
+	NotifyApprovalPending,
+	NotifyStepApproved,
+	NotifyRequestApproved,
+	NotifyRequestRejected,
+	NotifyInfoRequested,
+	NotifyRequestPurchased,
+	NotifyRequestComment,
+}
+
+// Notification is an in-app message to a user about a purchase request
+type Notification struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"not null;index" json:"-"`
+	Type      NotificationType `gorm:"not null;size:50" json:"type"`
+	RequestID *uint            `gorm:"index" json:"request_id,omitempty"`
+	Title     string           `gorm:"not null;size:255" json:"title"`
+	Body      string           `gorm:"type:text" json:"body"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+	CreatedAt time.Time        `gorm:"index" json:"created_at"`
+}
+
+// NotificationPreference turns the in-app and email channels of a
+// notification type on or off for a user. Without one both are on.
+type NotificationPreference struct {
+	ID        uint             `gorm:"primaryKey" json:"-"`
+	UserID    uint             `gorm:"not null;uniqueIndex:idx_notification_preference" json:"-"`
+	Type      NotificationType `gorm:"not null;size:50;uniqueIndex:idx_notification_preference" json:"type"`
+	InApp     bool             `gorm:"not null" json:"in_app"`
+	Email     bool             `gorm:"not null" json:"email"`
+	UpdatedAt time.Time        `json:"-"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordHistory keeps a user's previous password hashes so they cannot be reused
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"index;not null" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"` // When the password was replaced
+}
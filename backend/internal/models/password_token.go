@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+type PasswordTokenPurpose string
+
+const (
+	PasswordTokenReset  PasswordTokenPurpose = "reset"  // Forgotten password
+	PasswordTokenInvite PasswordTokenPurpose = "invite" // New user setting their first password
+)
+
+// PasswordToken records a signed link sent by email to set a password, so it
+// can be used once. Only the token's ID is stored, not the token.
+type PasswordToken struct {
+	ID          uint                 `gorm:"primaryKey" json:"id"`
+	UserID      uint                 `gorm:"index;not null" json:"user_id"`
+	Purpose     PasswordTokenPurpose `gorm:"size:20;not null" json:"purpose"`
+	TokenID     string               `gorm:"uniqueIndex;size:36;not null" json:"-"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+	UsedAt      *time.Time           `json:"used_at"`
+	CreatedByID *uint                `json:"created_by_id"` // Admin who sent an invitation
+	CreatedAt   time.Time            `json:"created_at"`
+}
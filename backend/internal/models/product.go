@@ -32,6 +32,7 @@ type Product struct {
 	Price         float64        `gorm:"not null" json:"price"`
 	Currency      string         `gorm:"default:'USD';size:10" json:"currency"`
 	Stock         int            `gorm:"default:0" json:"stock"`
+	ReservedStock int            `gorm:"default:0" json:"reserved_stock"` // Held for approved requests until issued
 	MinStock      int            `gorm:"default:0" json:"min_stock"`      // Minimum stock level for alerts
 	MaxStock      int            `gorm:"default:0" json:"max_stock"`      // Maximum stock level
 	Location      string         `gorm:"size:100" json:"location"`        // Warehouse/shelf location
@@ -57,6 +58,11 @@ type ProductImage struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// AvailableStock returns the stock not reserved for approved requests
+func (p *Product) AvailableStock() int {
+	return p.Stock - p.ReservedStock
+}
+
 // GetLocalizedName returns the product name in the specified language
 func (p *Product) GetLocalizedName(lang string) string {
 	switch lang {
@@ -91,8 +97,13 @@ func (p *Product) GetLocalizedSpec(lang string) string {
 func (p *Product) StockStatus() string {
 	if p.Stock == 0 {
 		return "out_of_stock"
-	} else if p.Stock < 100 {
+	} else if p.IsLowStock() {
 		return "limited"
 	}
 	return "in_stock"
 }
+
+// IsLowStock checks if the available stock is below the product's minimum
+func (p *Product) IsLowStock() bool {
+	return p.MinStock > 0 && p.AvailableStock() < p.MinStock
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -34,9 +35,22 @@ type PurchaseRequest struct {
 	ProductTitle       string   `gorm:"size:500" json:"product_title"`
 	ProductImageURL    string   `gorm:"size:2000" json:"product_image_url"`
 	ProductDescription string   `gorm:"type:text" json:"product_description"`
+	SiteName           string   `gorm:"size:255;index" json:"site_name"`
 	EstimatedPrice     *float64 `json:"estimated_price,omitempty"`
 	Currency           string   `gorm:"default:'MXN';size:10" json:"currency"`
 
+	// Currency normalization (request amount in the base reporting currency)
+	NormalizedAmount *float64 `gorm:"index" json:"normalized_amount,omitempty"`
+	BaseCurrency     string   `gorm:"size:10" json:"base_currency,omitempty"`
+	ExchangeRate     *float64 `json:"exchange_rate,omitempty"` // Rate applied when normalizing
+
+	// Replenishment requests restock catalog products instead of issuing them
+	IsReplenishment bool `gorm:"default:false;index" json:"is_replenishment"`
+
+	// Line items (the product fields above mirror the first line)
+	Items       []RequestItem `gorm:"foreignKey:RequestID" json:"items,omitempty"`
+	TotalAmount float64       `gorm:"default:0" json:"total_amount"` // Sum of line totals, in Currency
+
 	// Request details
 	Quantity      int     `gorm:"not null;default:1" json:"quantity"`
 	Justification string  `gorm:"type:text" json:"justification"`
@@ -49,6 +63,19 @@ type PurchaseRequest struct {
 	// Status
 	Status RequestStatus `gorm:"default:'pending';size:20;index" json:"status"`
 
+	// Filter rules (request accepted but violating a "flag" rule)
+	IsFlagged  bool   `gorm:"default:false;index" json:"is_flagged"`
+	FlagReason string `gorm:"type:text" json:"flag_reason,omitempty"`
+
+	// Approval chain
+	CurrentStep   int            `gorm:"default:0" json:"current_step"` // Level of the pending approval step
+	ApprovalSteps []ApprovalStep `gorm:"foreignKey:RequestID" json:"approval_steps,omitempty"`
+
+	// Budget tracking (amount committed against the cost center budget on approval)
+	BudgetID       *uint   `gorm:"index" json:"budget_id,omitempty"`
+	BudgetAmount   float64 `gorm:"default:0" json:"budget_amount"`
+	BudgetOverride bool    `gorm:"default:false" json:"budget_override"` // Approved despite exceeding the budget
+
 	// Approval flow
 	ApprovedByID    *uint      `json:"approved_by_id,omitempty"`
 	ApprovedBy      *User      `gorm:"foreignKey:ApprovedByID" json:"approved_by,omitempty"`
@@ -92,6 +119,58 @@ func GenerateRequestNumber(db *gorm.DB) string {
 	return fmt.Sprintf("REQ-%d-%04d", year, count+1)
 }
 
+// Amount returns the total of the request in its currency. Requests without a
+// computed total fall back to EstimatedPrice * Quantity.
+func (pr *PurchaseRequest) Amount() float64 {
+	if pr.TotalAmount != 0 {
+		return pr.TotalAmount
+	}
+	if pr.EstimatedPrice == nil {
+		return 0
+	}
+	return *pr.EstimatedPrice * float64(pr.Quantity)
+}
+
+// RecalculateTotal sums the line totals of the loaded items
+func (pr *PurchaseRequest) RecalculateTotal() {
+	total := 0.0
+	for _, item := range pr.Items {
+		total += item.LineTotal
+	}
+	pr.TotalAmount = total
+}
+
+// ReportingAmount returns the amount in the base reporting currency. Amounts
+// that could not be converted are reported as +Inf, so amount based rules
+// treat them like the largest requests instead of comparing the raw amount.
+func (pr *PurchaseRequest) ReportingAmount() float64 {
+	if pr.NormalizedAmount != nil {
+		return *pr.NormalizedAmount
+	}
+	return math.Inf(1)
+}
+
+// PendingStep returns the approval step currently awaiting a decision, if any
+func (pr *PurchaseRequest) PendingStep() *ApprovalStep {
+	for i := range pr.ApprovalSteps {
+		step := &pr.ApprovalSteps[i]
+		if step.Level == pr.CurrentStep && step.Status == StepPending {
+			return step
+		}
+	}
+	return nil
+}
+
+// HasDecidedStep checks if any loaded approval step has been approved or rejected
+func (pr *PurchaseRequest) HasDecidedStep() bool {
+	for _, step := range pr.ApprovalSteps {
+		if step.Status != StepWaiting && step.Status != StepPending {
+			return true
+		}
+	}
+	return false
+}
+
 // CanBeCancelled checks if the request can be cancelled
 func (pr *PurchaseRequest) CanBeCancelled() bool {
 	return pr.Status == StatusPending || pr.Status == StatusInfoRequested
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// AttachmentKind tells what a request attachment is for
+type AttachmentKind string
+
+const (
+	AttachmentQuote   AttachmentKind = "quote"
+	AttachmentSpec    AttachmentKind = "spec"    // Spec sheet or other product documentation
+	AttachmentInvoice AttachmentKind = "invoice" // Attached by buyers when the order is purchased
+	AttachmentOther   AttachmentKind = "other"
+)
+
+// IsValid checks if the attachment kind is known
+func (k AttachmentKind) IsValid() bool {
+	switch k {
+	case AttachmentQuote, AttachmentSpec, AttachmentInvoice, AttachmentOther:
+		return true
+	}
+	return false
+}
+
+// RequestAttachment is a file attached to a purchase request. Files are kept
+// outside the public uploads directory and only served to users who can view
+// the request.
+type RequestAttachment struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	RequestID    uint           `gorm:"not null;index" json:"request_id"`
+	CommentID    *uint          `gorm:"index" json:"comment_id,omitempty"` // Comment the file was posted with
+	Kind         AttachmentKind `gorm:"default:'other';size:20" json:"kind"`
+	UploadedByID uint           `gorm:"not null" json:"uploaded_by_id"`
+	UploadedBy   *User          `gorm:"foreignKey:UploadedByID" json:"uploaded_by,omitempty"`
+	FileName     string         `gorm:"not null;size:255" json:"file_name"` // Name of the file as uploaded
+	ContentType  string         `gorm:"not null;size:100" json:"content_type"`
+	Size         int64          `json:"size"`
+	StoragePath  string         `gorm:"not null;size:500" json:"-"` // Relative to the attachments directory
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// CanBeDeleted checks if the attachment can still be removed from the
+// request, whose approval steps must be loaded. Files posted with comments
+// stay with the conversation. Requesters' documents are part of what
+// approvers decide on, so they are kept once any approval step has been
+// decided or the request leaves review; invoices stay removable while the
+// order is handled.
+func (a *RequestAttachment) CanBeDeleted(request *PurchaseRequest) bool {
+	if a.CommentID != nil {
+		return false
+	}
+	if a.Kind == AttachmentInvoice {
+		return request.Status == StatusApproved || request.Status == StatusPurchased
+	}
+	return (request.Status == StatusPending || request.Status == StatusInfoRequested) && !request.HasDecidedStep()
+}
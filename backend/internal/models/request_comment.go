@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// CommentKind tells plain comments apart from the clarification rounds of
+// an info request
+type CommentKind string
+
+const (
+	CommentNote         CommentKind = "comment"
+	CommentInfoRequest  CommentKind = "info_request"  // An approver asked the requester for more information
+	CommentInfoResponse CommentKind = "info_response" // The requester answered and resubmitted the request
+)
+
+// RequestComment is a message in a purchase request's conversation
+type RequestComment struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	RequestID   uint                `gorm:"not null;index" json:"request_id"`
+	AuthorID    uint                `gorm:"not null" json:"author_id"`
+	Author      User                `gorm:"foreignKey:AuthorID" json:"author"`
+	Kind        CommentKind         `gorm:"default:'comment';size:20" json:"kind"`
+	Body        string              `gorm:"type:text;not null" json:"body"`
+	Mentions    []User              `gorm:"many2many:request_comment_mentions" json:"mentions"` // Users notified of the comment
+	Attachments []RequestAttachment `gorm:"foreignKey:CommentID" json:"attachments"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
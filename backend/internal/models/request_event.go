@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// RequestEvent records a purchase request event for live update streams.
+// Streams pick up new rows after the transaction that wrote them commits,
+// and clients that reconnect catch up from the last ID they received. Rows
+// are pruned once they are older than a day.
+type RequestEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Type      string    `gorm:"not null;size:50" json:"type"`
+	RequestID uint      `gorm:"not null;index" json:"request_id"`
+	ActorID   uint      `json:"actor_id,omitempty"` // 0 for events raised by background jobs
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
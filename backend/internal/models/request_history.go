@@ -15,6 +15,9 @@ const (
 	ActionCancelled HistoryAction = "cancelled"
 	ActionProcessed HistoryAction = "processed"
 	ActionCompleted HistoryAction = "completed"
+
+	ActionStepApproved HistoryAction = "step_approved"
+	ActionResubmitted  HistoryAction = "resubmitted" // The requester answered an info request
 )
 
 type RequestHistory struct {
@@ -26,7 +29,17 @@ type RequestHistory struct {
 	Comment   string        `gorm:"type:text" json:"comment"`
 	OldStatus RequestStatus `gorm:"size:20" json:"old_status"`
 	NewStatus RequestStatus `gorm:"size:20" json:"new_status"`
-	CreatedAt time.Time     `json:"created_at"`
+
+	// Approval chain step this entry refers to (nil for request-level events)
+	StepID     *uint              `json:"step_id,omitempty"`
+	StepLevel  int                `gorm:"default:0" json:"step_level,omitempty"`
+	StepStatus ApprovalStepStatus `gorm:"size:20" json:"step_status,omitempty"`
+
+	// Delegator the user acted for as their delegate (nil when acting for themselves)
+	OnBehalfOfID *uint `json:"on_behalf_of_id,omitempty"`
+	OnBehalfOf   *User `gorm:"foreignKey:OnBehalfOfID" json:"on_behalf_of,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // NewHistory creates a new history entry
@@ -41,3 +54,14 @@ func NewHistory(requestID uint, userID uint, action HistoryAction, oldStatus, ne
 		CreatedAt: time.Now(),
 	}
 }
+
+// NewStepHistory creates a history entry for a decision on an approval step,
+// including the delegator it was decided for
+func NewStepHistory(requestID uint, userID uint, action HistoryAction, oldStatus, newStatus RequestStatus, step *ApprovalStep, comment string) *RequestHistory {
+	history := NewHistory(requestID, userID, action, oldStatus, newStatus, comment)
+	history.StepID = &step.ID
+	history.StepLevel = step.Level
+	history.StepStatus = step.Status
+	history.OnBehalfOfID = step.OnBehalfOfID
+	return history
+}
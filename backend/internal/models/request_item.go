@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// ItemFulfillment tracks how a catalog line is supplied once the request is approved
+type ItemFulfillment string
+
+const (
+	FulfillmentReserved ItemFulfillment = "reserved"          // Stock held for the line
+	FulfillmentExternal ItemFulfillment = "external_purchase" // Not enough stock, bought from the supplier
+	FulfillmentIssued   ItemFulfillment = "issued"            // Reserved stock handed out
+	FulfillmentReceived ItemFulfillment = "received"          // Replenishment received into stock
+)
+
+// RequestItem is one line of a purchase request: either a catalog product or
+// an external product URL
+type RequestItem struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	RequestID  uint `gorm:"not null;index" json:"request_id"`
+	LineNumber int  `gorm:"not null" json:"line_number"`
+
+	// Catalog product (nil for external URLs)
+	ProductID *uint    `gorm:"index" json:"product_id,omitempty"`
+	Product   *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	SKU       string   `gorm:"size:100" json:"sku,omitempty"`
+
+	// Product details, copied from the catalog or extracted from the URL
+	URL             string `gorm:"size:2000" json:"url,omitempty"`
+	ProductTitle    string `gorm:"size:500" json:"product_title"`
+	ProductImageURL string `gorm:"size:2000" json:"product_image_url"`
+	SiteName        string `gorm:"size:255" json:"site_name,omitempty"`
+
+	// Pricing (LineTotal is in the request currency)
+	Quantity  int      `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice *float64 `json:"unit_price,omitempty"`
+	Currency  string   `gorm:"size:10" json:"currency"`
+	LineTotal float64  `gorm:"default:0" json:"line_total"`
+
+	// Stock fulfillment (catalog lines only)
+	FulfillmentStatus ItemFulfillment `gorm:"size:20" json:"fulfillment_status,omitempty"`
+	ReservedQuantity  int             `gorm:"default:0" json:"reserved_quantity,omitempty"`
+
+	// Amazon automation status
+	IsAmazonURL   bool       `gorm:"default:false" json:"is_amazon_url"`
+	AmazonASIN    string     `gorm:"size:20" json:"amazon_asin,omitempty"`
+	AddedToCart   bool       `gorm:"default:false" json:"added_to_cart"`
+	AddedToCartAt *time.Time `json:"added_to_cart_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsCatalogItem checks if the line refers to an internal catalog product
+func (i *RequestItem) IsCatalogItem() bool {
+	return i.ProductID != nil
+}
+
+// Subtotal returns unit price times quantity in the line currency
+func (i *RequestItem) Subtotal() float64 {
+	if i.UnitPrice == nil {
+		return 0
+	}
+	return *i.UnitPrice * float64(i.Quantity)
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+)
+
+// Permissions checked by the API. Roles grant a set of them.
+const (
+	PermRequestsViewAll        = "requests.view_all"
+	PermRequestsApproveAny     = "requests.approve_any"
+	PermRequestsOverrideBudget = "requests.override_budget"
+	PermRequestsViewStats      = "requests.view_stats"
+	PermProductsWrite          = "products.write"
+	PermInventoryManage        = "inventory.manage"
+	PermBudgetsView            = "budgets.view"
+	PermBudgetsManage          = "budgets.manage"
+	PermAnalyticsView          = "analytics.view"
+	PermOrdersManage           = "orders.manage"
+	PermAmazonConfigure        = "amazon.configure"
+	PermUsersManage            = "users.manage"
+	PermRolesManage            = "roles.manage"
+	PermApprovalRulesManage    = "approval_rules.manage"
+	PermFilterRulesManage      = "filter_rules.manage"
+	PermExchangeRatesManage    = "exchange_rates.manage"
+	PermJobsManage             = "jobs.manage"
+	PermWebhooksManage         = "webhooks.manage"
+	PermAuditView              = "audit.view"
+	PermAdminDashboard         = "admin.dashboard"
+)
+
+// PermissionDefinitions lists every permission with its description, in the
+// order they are shown to admins
+var PermissionDefinitions = []Permission{
+	{Name: PermRequestsViewAll, Description: "View all purchase requests"},
+	{Name: PermRequestsApproveAny, Description: "Act on any approval step, not only assigned ones"},
+	{Name: PermRequestsOverrideBudget, Description: "Approve requests that exceed the cost center budget"},
+	{Name: PermRequestsViewStats, Description: "View approval statistics"},
+	{Name: PermProductsWrite, Description: "Create, edit and delete products and upload product images"},
+	{Name: PermInventoryManage, Description: "Adjust stock, view stock movements and handle low stock alerts"},
+	{Name: PermBudgetsView, Description: "View budgets and their utilization"},
+	{Name: PermBudgetsManage, Description: "Create, edit and delete budgets"},
+	{Name: PermAnalyticsView, Description: "View spend analytics"},
+	{Name: PermOrdersManage, Description: "Manage approved orders and mark them purchased"},
+	{Name: PermAmazonConfigure, Description: "Configure the Amazon Business integration"},
+	{Name: PermUsersManage, Description: "Manage users, invitations, 2FA resets and lockouts"},
+	{Name: PermRolesManage, Description: "Manage roles and their permissions"},
+	{Name: PermApprovalRulesManage, Description: "Manage approval chain rules"},
+	{Name: PermFilterRulesManage, Description: "Manage product filter rules"},
+	{Name: PermExchangeRatesManage, Description: "Manage currency exchange rates"},
+	{Name: PermJobsManage, Description: "View, retry and cancel background jobs"},
+	{Name: PermWebhooksManage, Description: "Manage outbound webhooks and view their deliveries"},
+	{Name: PermAuditView, Description: "View the audit log"},
+	{Name: PermAdminDashboard, Description: "View the admin dashboard"},
+}
+
+// BuiltInRoles are the roles seeded on first start with their default
+// permissions. Admins can change them, except that admin always has every
+// permission.
+var BuiltInRoles = []Role{
+	{
+		Name:        string(RoleAdmin),
+		DisplayName: "Admin",
+		Description: "Full access to every feature",
+		RequireMFA:  true,
+	},
+	{
+		Name:        string(RoleGeneralManager),
+		DisplayName: "General Manager",
+		Description: "Approves requests and reviews spend",
+		RequireMFA:  true,
+		Permissions: permissions(PermRequestsViewAll, PermRequestsOverrideBudget, PermRequestsViewStats,
+			PermBudgetsView, PermAnalyticsView),
+	},
+	{
+		Name:        string(RoleSupplyChainManager),
+		DisplayName: "Supply Chain Manager",
+		Description: "Manages the catalog and inventory",
+		Permissions: permissions(PermRequestsViewAll, PermProductsWrite, PermInventoryManage,
+			PermBudgetsView, PermAnalyticsView),
+	},
+	{
+		Name:        string(RoleEmployee),
+		DisplayName: "Employee",
+		Description: "Requests products",
+	},
+}
+
+func permissions(names ...string) []Permission {
+	perms := make([]Permission, len(names))
+	for i, name := range names {
+		perms[i] = Permission{Name: name}
+	}
+	return perms
+}
+
+// Permission is an action a role can be allowed to perform
+type Permission struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	Name        string `gorm:"uniqueIndex;not null;size:100" json:"name"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// Role is a named set of permissions assigned to users. Users reference it by
+// name in User.Role.
+type Role struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"uniqueIndex;not null;size:50" json:"name"`
+	DisplayName string       `gorm:"not null;size:100" json:"display_name"`
+	Description string       `gorm:"size:255" json:"description"`
+	RequireMFA  bool         `gorm:"default:false" json:"require_mfa"` // Users must use two-factor authentication when MFA_ENFORCE is on
+	BuiltIn     bool         `gorm:"default:false" json:"built_in"`    // Seeded roles cannot be deleted
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"-"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// PermissionNames returns the names of the role's permissions
+func (r *Role) PermissionNames() []string {
+	names := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		names[i] = p.Name
+	}
+	return names
+}
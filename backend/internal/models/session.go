@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Session reasons for revocation
+const (
+	SessionRevokedLogout          = "logout"
+	SessionRevokedByUser          = "revoked by user"
+	SessionRevokedTokenReuse      = "refresh token reuse detected"
+	SessionRevokedUserDeactivated = "user deactivated"
+	SessionRevokedUserDeleted     = "user deleted"
+	SessionRevokedPasswordChanged = "password changed"
+	SessionRevokedRoleChanged     = "role changed"
+	SessionRevokedMFAReset        = "two-factor authentication reset"
+	SessionRevokedMFARequired     = "two-factor setup required"
+)
+
+// Session is a login session. Its tokens carry the session ID, and each refresh
+// rotates the refresh token, so presenting an older refresh token reveals that
+// it was stolen and revokes the session.
+type Session struct {
+	ID     uint  `gorm:"primaryKey" json:"id"`
+	UserID uint  `gorm:"not null;index" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	RefreshTokenID string `gorm:"size:36" json:"-"` // jti of the only refresh token that may be used
+
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	UserAgent  string    `gorm:"size:500" json:"user_agent"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+
+	RevokedAt     *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokedReason string     `gorm:"size:100" json:"revoked_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsActive checks if the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
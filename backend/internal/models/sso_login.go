@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// SSOLogin is a single sign-on login waiting for the identity provider to
+// redirect back. The state in the redirect finds it, the browser that started
+// it proves it with its key, and it is deleted when used.
+type SSOLogin struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	State          string    `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Nonce          string    `gorm:"size:64;not null" json:"-"`
+	CodeVerifier   string    `gorm:"size:64;not null" json:"-"` // PKCE verifier, never sent to the browser
+	BrowserKeyHash string    `gorm:"size:64" json:"-"`          // SHA-256 of the key kept by the browser that began the login
+	ExpiresAt      time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
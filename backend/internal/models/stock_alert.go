@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type StockAlertStatus string
+
+const (
+	StockAlertOpen     StockAlertStatus = "open"
+	StockAlertResolved StockAlertStatus = "resolved"
+)
+
+// StockAlert records a product whose available stock fell below its minimum
+type StockAlert struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	ProductID uint             `gorm:"not null;index" json:"product_id"`
+	Product   *Product         `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Status    StockAlertStatus `gorm:"not null;size:20;index;default:'open'" json:"status"`
+
+	// Levels when the alert was raised
+	AvailableStock int `json:"available_stock"`
+	MinStock       int `json:"min_stock"`
+	MaxStock       int `json:"max_stock"`
+
+	// Replenishment request raised for the alert, if any
+	ReplenishmentRequestID *uint            `gorm:"index" json:"replenishment_request_id,omitempty"`
+	ReplenishmentRequest   *PurchaseRequest `gorm:"foreignKey:ReplenishmentRequestID" json:"replenishment_request,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// IsOpen checks if the alert is still open
+func (a *StockAlert) IsOpen() bool {
+	return a.Status == StockAlertOpen
+}
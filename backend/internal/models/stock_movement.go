@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type StockMovementType string
+
+const (
+	MovementReceipt    StockMovementType = "receipt"    // Goods received into stock
+	MovementIssue      StockMovementType = "issue"      // Goods handed out, e.g. for a request
+	MovementAdjustment StockMovementType = "adjustment" // Count correction, either direction
+	MovementTransfer   StockMovementType = "transfer"   // Relocation, stock level unchanged
+	MovementReturn     StockMovementType = "return"     // Goods returned into stock
+)
+
+// IsValid checks if the movement type is known
+func (t StockMovementType) IsValid() bool {
+	switch t {
+	case MovementReceipt, MovementIssue, MovementAdjustment, MovementTransfer, MovementReturn:
+		return true
+	}
+	return false
+}
+
+// StockMovement is an entry in the inventory ledger. A product's stock is the
+// sum of the StockChange of its movements.
+type StockMovement struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	ProductID   uint              `gorm:"not null;index" json:"product_id"`
+	Product     *Product          `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Type        StockMovementType `gorm:"not null;size:20;index" json:"type"`
+	Quantity    int               `gorm:"not null" json:"quantity"`     // Units moved
+	StockChange int               `gorm:"not null" json:"stock_change"` // Signed effect on stock
+	StockBefore int               `json:"stock_before"`
+	StockAfter  int               `json:"stock_after"`
+	Reason      string            `gorm:"size:500" json:"reason"`
+
+	// Transfers
+	FromLocation string `gorm:"size:100" json:"from_location,omitempty"`
+	ToLocation   string `gorm:"size:100" json:"to_location,omitempty"`
+
+	// Who moved the stock and why
+	UserID    *uint            `gorm:"index" json:"user_id,omitempty"`
+	User      *User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	RequestID *uint            `gorm:"index" json:"request_id,omitempty"`
+	Request   *PurchaseRequest `gorm:"foreignKey:RequestID" json:"-"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -6,8 +6,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// UserRole is the name of the user's Role
 type UserRole string
 
+// Built-in roles
 const (
 	RoleAdmin              UserRole = "admin"
 	RoleSupplyChainManager UserRole = "supply_chain_manager"
@@ -16,48 +18,72 @@ const (
 )
 
 type User struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Email        string         `gorm:"uniqueIndex;not null;size:255" json:"email"`
-	PasswordHash string         `gorm:"not null" json:"-"`
-	Name         string         `gorm:"not null;size:255" json:"name"`
-	Role         UserRole       `gorm:"not null;size:50" json:"role"`
-	CompanyCode  string         `gorm:"size:50" json:"company_code"`
-	CostCenter   string         `gorm:"size:50" json:"cost_center"`
-	Department   string         `gorm:"size:100" json:"department"`
-	Status       string         `gorm:"default:'active';size:20" json:"status"` // active, inactive
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                    uint                 `gorm:"primaryKey" json:"id"`
+	Email                 string               `gorm:"uniqueIndex;not null;size:255" json:"email"`
+	PasswordHash          string               `gorm:"not null" json:"-"`
+	Name                  string               `gorm:"not null;size:255" json:"name"`
+	Role                  UserRole             `gorm:"not null;size:50" json:"role"`
+	CompanyCode           string               `gorm:"size:50" json:"company_code"`
+	CostCenter            string               `gorm:"size:50" json:"cost_center"`
+	Department            string               `gorm:"size:100" json:"department"`
+	Status                string               `gorm:"default:'active';size:20" json:"status"` // active, inactive
+	MFAEnabled            bool                 `gorm:"default:false" json:"mfa_enabled"`
+	MFASecret             string               `gorm:"size:255" json:"-"` // TOTP secret, encrypted
+	MFALastStep           int64                `json:"-"`                 // Time step of the last accepted code, so codes cannot be replayed
+	MFAEnabledAt          *time.Time           `json:"mfa_enabled_at,omitempty"`
+	MustChangePassword    bool                 `gorm:"default:false" json:"must_change_password"` // Set by an admin or expired; must be changed before using the API
+	PasswordChangedAt     *time.Time           `json:"password_changed_at,omitempty"`
+	OIDCSubject           *string              `gorm:"column:oidc_subject;uniqueIndex;size:255" json:"-"` // Identity provider subject of a user who signs in with SSO
+	PasswordLoginDisabled bool                 `gorm:"default:false" json:"password_login_disabled"`
+	Permissions           []string             `gorm:"-" json:"-"`                     // Granted by the role; see Can
+	Scopes                []UserScope          `gorm:"foreignKey:UserID" json:"-"`     // Departments and cost centers the user manages; see Covers
+	Delegations           []ApprovalDelegation `gorm:"foreignKey:DelegateID" json:"-"` // Active delegations to the user; see ApprovalStep.DelegationFor
+	CreatedAt             time.Time            `json:"created_at"`
+	UpdatedAt             time.Time            `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt       `gorm:"index" json:"-"`
 }
 
 func (u *User) IsActive() bool {
 	return u.Status == "active"
 }
 
-func (u *User) IsAdmin() bool {
-	return u.Role == RoleAdmin
+// Can reports whether the user's role grants the permission. Permissions are
+// only loaded for the authenticated user of a request.
+func (u *User) Can(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
 
-func (u *User) IsGeneralManager() bool {
-	return u.Role == RoleGeneralManager
+// ScopeValues returns the departments and cost centers of the user's scopes
+func (u *User) ScopeValues() (departments, costCenters []string) {
+	for _, scope := range u.Scopes {
+		switch scope.Type {
+		case ScopeDepartment:
+			departments = append(departments, scope.Value)
+		case ScopeCostCenter:
+			costCenters = append(costCenters, scope.Value)
+		}
+	}
+	return departments, costCenters
 }
 
-func (u *User) IsSupplyChainManager() bool {
-	return u.Role == RoleSupplyChainManager
-}
-
-func (u *User) CanApprove() bool {
-	return u.Role == RoleGeneralManager || u.Role == RoleAdmin
-}
-
-func (u *User) CanManageUsers() bool {
-	return u.Role == RoleAdmin
-}
-
-func (u *User) CanConfigureAmazon() bool {
-	return u.Role == RoleAdmin
-}
-
-func (u *User) CanViewAllRequests() bool {
-	return u.Role == RoleAdmin || u.Role == RoleSupplyChainManager || u.Role == RoleGeneralManager
+// Covers reports whether the requester is in the department or cost center
+// of one of the user's scopes. Users without scopes cover every requester.
+func (u *User) Covers(requester *User) bool {
+	if len(u.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range u.Scopes {
+		switch {
+		case scope.Type == ScopeDepartment && scope.Value == requester.Department:
+			return true
+		case scope.Type == ScopeCostCenter && scope.Value == requester.CostCenter:
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// ScopeType is the requester attribute a scope matches
+type ScopeType string
+
+const (
+	ScopeDepartment ScopeType = "department"
+	ScopeCostCenter ScopeType = "cost_center"
+)
+
+// UserScope assigns a manager to the requests of a department or cost
+// center. Users with scopes see and approve only requests from requesters in
+// one of them; users without any are not limited.
+type UserScope struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_scope" json:"-"`
+	Type      ScopeType `gorm:"not null;size:20;uniqueIndex:idx_user_scope" json:"type"`
+	Value     string    `gorm:"not null;size:100;uniqueIndex:idx_user_scope" json:"value"` // Department name or cost center code
+	CreatedAt time.Time `json:"created_at"`
+}
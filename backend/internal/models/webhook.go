@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook is an admin-managed subscription that posts signed JSON to an
+// external URL when subscribed events happen
+type Webhook struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"not null;size:100" json:"name"`
+	URL             string         `gorm:"not null;size:2000" json:"url"`
+	EncryptedSecret string         `gorm:"not null" json:"-"`             // AES-256 encrypted signing secret
+	Events          string         `gorm:"type:text;not null" json:"-"`   // Comma-separated event types
+	IsActive        bool           `gorm:"default:true" json:"is_active"` // Inactive webhooks receive no deliveries
+	CreatedByID     uint           `json:"created_by_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// EventTypes returns the event types the webhook is subscribed to
+func (w *Webhook) EventTypes() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// SetEventTypes sets the event types the webhook is subscribed to
+func (w *Webhook) SetEventTypes(eventTypes []string) {
+	w.Events = strings.Join(eventTypes, ",")
+}
+
+// Subscribes checks if the webhook receives the event type
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, t := range w.EventTypes() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending" // Not attempted yet, or failed and waiting to be retried
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed" // Gave up after the last attempt
+)
+
+// WebhookDelivery is one event sent to one webhook. Retries update it; the
+// individual attempts are recorded on its job.
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	WebhookID      uint                  `gorm:"not null;index" json:"webhook_id"`
+	EventID        string                `gorm:"not null;size:36;index" json:"event_id"` // Shared by the deliveries of one event
+	EventType      string                `gorm:"not null;size:50;index" json:"event_type"`
+	RequestID      *uint                 `gorm:"index" json:"request_id,omitempty"`
+	Payload        string                `gorm:"type:text" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"default:'pending';size:20;index" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `gorm:"type:text" json:"response_body,omitempty"` // Truncated
+	Error          string                `gorm:"type:text" json:"error,omitempty"`
+	DurationMs     int64                 `json:"duration_ms"`
+	JobID          *uint                 `gorm:"index" json:"job_id,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
@@ -31,8 +31,20 @@ func NewAutomationService() *AutomationService {
 	}
 }
 
+// operation derives the context of one browser operation from the browser's.
+// It ends after timeout or once the caller's ctx is done, so a caller giving
+// up also stops the page it was waiting on.
+func (s *AutomationService) operation(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	opCtx, cancel := context.WithTimeout(s.ctx, timeout)
+	stop := context.AfterFunc(ctx, cancel)
+	return opCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
 // Initialize starts the browser and prepares for automation
-func (s *AutomationService) Initialize() error {
+func (s *AutomationService) Initialize(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -51,13 +63,22 @@ func (s *AutomationService) Initialize() error {
 	)
 
 	allocCtx, _ := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	browserCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
 
-	s.ctx = ctx
+	s.ctx = browserCtx
 	s.cancel = cancel
 
+	// Start the browser on its own context so it outlives this call
+	if err := chromedp.Run(browserCtx); err != nil {
+		s.cancel()
+		s.ctx = nil
+		return fmt.Errorf("failed to initialize browser: %w", err)
+	}
+
 	// Navigate to Amazon to initialize session
-	if err := chromedp.Run(ctx,
+	opCtx, stop := s.operation(ctx, 60*time.Second)
+	defer stop()
+	if err := chromedp.Run(opCtx,
 		chromedp.Navigate(s.baseURL),
 		chromedp.WaitVisible(`body`, chromedp.ByQuery),
 	); err != nil {
@@ -82,7 +103,7 @@ func (s *AutomationService) SetCredentials(email, password, marketplace string)
 }
 
 // Login performs Amazon Business login
-func (s *AutomationService) Login() error {
+func (s *AutomationService) Login(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -94,7 +115,7 @@ func (s *AutomationService) Login() error {
 		return fmt.Errorf("browser not initialized")
 	}
 
-	ctx, cancel := context.WithTimeout(s.ctx, 60*time.Second)
+	ctx, cancel := s.operation(ctx, 60*time.Second)
 	defer cancel()
 
 	// Navigate to login page
@@ -138,7 +159,7 @@ func (s *AutomationService) Login() error {
 }
 
 // AddToCart adds a product to the Amazon cart
-func (s *AutomationService) AddToCart(productURL string, quantity int) error {
+func (s *AutomationService) AddToCart(ctx context.Context, productURL string, quantity int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -150,7 +171,7 @@ func (s *AutomationService) AddToCart(productURL string, quantity int) error {
 		return fmt.Errorf("not logged in to Amazon")
 	}
 
-	ctx, cancel := context.WithTimeout(s.ctx, 45*time.Second)
+	ctx, cancel := s.operation(ctx, 45*time.Second)
 	defer cancel()
 
 	// Navigate to product page
@@ -162,7 +183,9 @@ func (s *AutomationService) AddToCart(productURL string, quantity int) error {
 	}
 
 	// Wait for the page to fully load
-	time.Sleep(2 * time.Second)
+	if err := chromedp.Run(ctx, chromedp.Sleep(2*time.Second)); err != nil {
+		return err
+	}
 
 	// Set quantity if greater than 1
 	if quantity > 1 {
@@ -207,7 +230,9 @@ func (s *AutomationService) AddToCart(productURL string, quantity int) error {
 	}
 
 	// Wait for cart confirmation
-	time.Sleep(3 * time.Second)
+	if err := chromedp.Run(ctx, chromedp.Sleep(3*time.Second)); err != nil {
+		return err
+	}
 
 	// Check for success indicators
 	successSelectors := []string{
@@ -0,0 +1,399 @@
+package approval
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/visibility"
+)
+
+var (
+	ErrNoPendingStep      = errors.New("request has no pending approval step")
+	ErrNotAssigned        = errors.New("current approval step is not assigned to this user")
+	ErrChainDecided       = errors.New("an approval step of the request has already been decided")
+	ErrConcurrentDecision = errors.New("the request was acted on by someone else in the meantime")
+)
+
+// DefaultStep is used when no approval rule matches a request, preserving the
+// original single general manager approval.
+var DefaultStep = models.ApprovalRuleStep{
+	Level:        1,
+	Name:         "General Manager approval",
+	ApproverRole: models.RoleGeneralManager,
+}
+
+// ChainService resolves approval chains and moves requests through their steps
+type ChainService struct {
+	db *gorm.DB
+}
+
+// NewChainService creates a new approval chain service
+func NewChainService(db *gorm.DB) *ChainService {
+	return &ChainService{db: db}
+}
+
+// MatchRule returns the highest priority active rule matching the request, or nil
+func (s *ChainService) MatchRule(tx *gorm.DB, request *models.PurchaseRequest, requester *models.User) (*models.ApprovalRule, error) {
+	var rules []models.ApprovalRule
+	if err := tx.Preload("Steps").
+		Where("is_active = ?", true).
+		Order("priority DESC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	amount := request.ReportingAmount()
+	for i := range rules {
+		if len(rules[i].Steps) > 0 && rules[i].Matches(amount, requester, request.Urgency) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// StartChain instantiates the approval steps for a newly submitted request.
+// The first step becomes pending and the request's CurrentStep points to it.
+func (s *ChainService) StartChain(tx *gorm.DB, request *models.PurchaseRequest) error {
+	var requester models.User
+	if err := tx.First(&requester, request.RequesterID).Error; err != nil {
+		return err
+	}
+
+	rule, err := s.MatchRule(tx, request, &requester)
+	if err != nil {
+		return err
+	}
+
+	ruleSteps := []models.ApprovalRuleStep{DefaultStep}
+	if rule != nil {
+		ruleSteps = rule.Steps
+	}
+
+	return s.StartChainWithSteps(tx, request, ruleSteps)
+}
+
+// StartChainWithSteps creates the approval chain of a newly submitted request
+// from the given steps, bypassing rule matching
+func (s *ChainService) StartChainWithSteps(tx *gorm.DB, request *models.PurchaseRequest, ruleSteps []models.ApprovalRuleStep) error {
+	sort.SliceStable(ruleSteps, func(i, j int) bool {
+		return ruleSteps[i].Level < ruleSteps[j].Level
+	})
+
+	steps := make([]models.ApprovalStep, len(ruleSteps))
+	for i, rs := range ruleSteps {
+		steps[i] = models.ApprovalStep{
+			RequestID:    request.ID,
+			Level:        i + 1,
+			Name:         rs.Name,
+			ApproverRole: rs.ApproverRole,
+			ApproverID:   rs.ApproverID,
+			Status:       models.StepWaiting,
+		}
+	}
+	steps[0].Status = models.StepPending
+
+	if err := tx.Create(&steps).Error; err != nil {
+		return err
+	}
+
+	request.CurrentStep = steps[0].Level
+	request.ApprovalSteps = steps
+	return tx.Model(request).Update("current_step", request.CurrentStep).Error
+}
+
+// RestartChain re-matches the approval rule of a request whose amount or
+// urgency changed and replaces its chain with the matching steps. It fails
+// with ErrChainDecided once any step has been approved or rejected.
+func (s *ChainService) RestartChain(tx *gorm.DB, request *models.PurchaseRequest) error {
+	var decided int64
+	if err := tx.Model(&models.ApprovalStep{}).
+		Where("request_id = ? AND status NOT IN ?", request.ID, []models.ApprovalStepStatus{models.StepWaiting, models.StepPending}).
+		Count(&decided).Error; err != nil {
+		return err
+	}
+	if decided > 0 {
+		return ErrChainDecided
+	}
+
+	if err := tx.Where("request_id = ?", request.ID).Delete(&models.ApprovalStep{}).Error; err != nil {
+		return err
+	}
+	return s.StartChain(tx, request)
+}
+
+// EnsureChain starts the chain for requests created before approval chains existed
+func (s *ChainService) EnsureChain(tx *gorm.DB, request *models.PurchaseRequest) error {
+	if len(request.ApprovalSteps) > 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.ApprovalStep{}).Where("request_id = ?", request.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return tx.Where("request_id = ?", request.ID).Order("level ASC").Find(&request.ApprovalSteps).Error
+	}
+	return s.StartChain(tx, request)
+}
+
+// Approve records the user's approval of the request's pending step and
+// activates the next one. It returns the approved step and the newly pending
+// step; next is nil when the chain is complete and the request is fully approved.
+func (s *ChainService) Approve(tx *gorm.DB, request *models.PurchaseRequest, user *models.User, comment string) (*models.ApprovalStep, *models.ApprovalStep, error) {
+	if err := CheckUnchanged(tx, request); err != nil {
+		return nil, nil, err
+	}
+	step, err := s.PendingStepFor(tx, request, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.decide(tx, step, user.ID, models.StepApproved, comment); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range request.ApprovalSteps {
+		next := &request.ApprovalSteps[i]
+		if next.Level == step.Level+1 {
+			next.Status = models.StepPending
+			if err := tx.Model(next).Update("status", next.Status).Error; err != nil {
+				return nil, nil, err
+			}
+			request.CurrentStep = next.Level
+			return step, next, nil
+		}
+	}
+
+	return step, nil, nil
+}
+
+// Reject records the user's rejection of the request's pending step
+func (s *ChainService) Reject(tx *gorm.DB, request *models.PurchaseRequest, user *models.User, comment string) (*models.ApprovalStep, error) {
+	if err := CheckUnchanged(tx, request); err != nil {
+		return nil, err
+	}
+	step, err := s.PendingStepFor(tx, request, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.decide(tx, step, user.ID, models.StepRejected, comment); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// PendingStepFor returns the request's pending step if it is assigned to the
+// user, checking the requester against the user's scopes, or delegated to
+// them. When the user acts as a delegate, the step's OnBehalfOfID is set to
+// the delegator; it is saved with the decision.
+func (s *ChainService) PendingStepFor(tx *gorm.DB, request *models.PurchaseRequest, user *models.User) (*models.ApprovalStep, error) {
+	step := request.PendingStep()
+	if step == nil {
+		return nil, ErrNoPendingStep
+	}
+
+	requester := &request.Requester
+	if requester.ID != request.RequesterID {
+		requester = &models.User{}
+		if err := tx.Unscoped().First(requester, request.RequesterID).Error; err != nil {
+			return nil, err
+		}
+	}
+	if step.IsAssignedTo(user, requester) {
+		step.OnBehalfOfID = nil
+		return step, nil
+	}
+	if delegation := step.DelegationFor(user, requester); delegation != nil {
+		step.OnBehalfOfID = &delegation.DelegatorID
+		return step, nil
+	}
+	return nil, ErrNotAssigned
+}
+
+// Approvers returns the active users who can act on the step of a request
+// by the requester: the user it names, or the users with its role whose
+// scopes cover the requester, followed by their active delegates. Users who
+// could only act with requests.approve_any are not included.
+func Approvers(tx *gorm.DB, step *models.ApprovalStep, requester *models.User) ([]models.User, error) {
+	query := tx.Preload("Scopes").Where("status = ?", "active")
+	if step.ApproverID != nil {
+		query = query.Where("id = ?", *step.ApproverID)
+	} else {
+		query = query.Where("role = ?", step.ApproverRole)
+	}
+	var candidates []models.User
+	if err := query.Order("id ASC").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	approvers := []models.User{}
+	seen := make(map[uint]bool)
+	for i := range candidates {
+		if step.IsAssignedTo(&candidates[i], requester) {
+			approvers = append(approvers, candidates[i])
+			seen[candidates[i].ID] = true
+		}
+	}
+	if len(approvers) == 0 {
+		return approvers, nil
+	}
+
+	delegatorIDs := make([]uint, len(approvers))
+	for i := range approvers {
+		delegatorIDs[i] = approvers[i].ID
+	}
+	now := time.Now()
+	var delegations []models.ApprovalDelegation
+	if err := tx.Preload("Delegate").
+		Where("delegator_id IN ? AND starts_at <= ? AND ends_at > ?", delegatorIDs, now, now).
+		Order("id ASC").
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range delegations {
+		if !seen[d.DelegateID] && d.DelegateID != requester.ID && d.Delegate.IsActive() && d.Covers(requester) {
+			approvers = append(approvers, d.Delegate)
+			seen[d.DelegateID] = true
+		}
+	}
+	return approvers, nil
+}
+
+// CheckUnchanged re-reads the status and current step of a request loaded
+// before the transaction and fails with ErrConcurrentDecision when another
+// decision changed them since
+func CheckUnchanged(tx *gorm.DB, request *models.PurchaseRequest) error {
+	var current models.PurchaseRequest
+	if err := tx.Select("status", "current_step").First(&current, request.ID).Error; err != nil {
+		return err
+	}
+	if current.Status != request.Status || current.CurrentStep != request.CurrentStep {
+		return ErrConcurrentDecision
+	}
+	return nil
+}
+
+// SaveUnchanged saves a request loaded before the transaction only while its
+// stored status and current step are still the ones it was loaded with,
+// failing with ErrConcurrentDecision otherwise. Unlike CheckUnchanged, the
+// check and the write are one statement, so of two concurrent actions on a
+// request only one is saved.
+func SaveUnchanged(tx *gorm.DB, request *models.PurchaseRequest, status models.RequestStatus, currentStep int) error {
+	result := tx.Model(request).
+		Where("status = ? AND current_step = ?", status, currentStep).
+		Select("*").
+		Updates(request)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentDecision
+	}
+	return nil
+}
+
+// decide records the decision on a pending step. The update only applies
+// while the step is still pending, so of two concurrent decisions the second
+// fails with ErrConcurrentDecision.
+func (s *ChainService) decide(tx *gorm.DB, step *models.ApprovalStep, userID uint, status models.ApprovalStepStatus, comment string) error {
+	now := time.Now()
+	result := tx.Model(&models.ApprovalStep{}).
+		Where("id = ? AND status = ?", step.ID, models.StepPending).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"acted_by_id":     &userID,
+			"acted_at":        &now,
+			"on_behalf_of_id": step.OnBehalfOfID,
+			"comment":         comment,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConcurrentDecision
+	}
+
+	step.Status = status
+	step.ActedByID = &userID
+	step.ActedAt = &now
+	step.Comment = comment
+	return nil
+}
+
+// AssignedTo returns a query scope limiting purchase requests to those whose
+// current approval step can be acted on by the user, directly or through the
+// user's loaded Delegations, mirroring ApprovalStep.IsAssignedTo. Steps for
+// the user's role, and any step for users allowed to approve any request,
+// only count for requesters in the user's scopes.
+func AssignedTo(user *models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("purchase_requests.requester_id <> ?", user.ID)
+		if user.Can(models.PermRequestsApproveAny) && len(user.Scopes) == 0 {
+			return db
+		}
+
+		cond := assignedCondition(db, user)
+		for i := range user.Delegations {
+			d := &user.Delegations[i]
+			if d.Delegator.IsActive() {
+				cond = cond.Or(delegatedCondition(db, user, d))
+			}
+		}
+		return db.Where(cond)
+	}
+}
+
+// newCondition starts a condition group or subquery independent of db
+func newCondition(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+// assignedCondition matches purchase requests whose current approval step
+// is assigned to the approver
+func assignedCondition(db *gorm.DB, approver *models.User) *gorm.DB {
+	currentStep := func() *gorm.DB {
+		return newCondition(db).
+			Model(&models.ApprovalStep{}).
+			Select("1").
+			Where("approval_steps.request_id = purchase_requests.id").
+			Where("approval_steps.level = purchase_requests.current_step").
+			Where("approval_steps.status = ?", models.StepPending)
+	}
+	named := currentStep().Where("approval_steps.approver_id = ?", approver.ID)
+	cond := newCondition(db).Where("EXISTS (?)", named)
+
+	if approver.Can(models.PermRequestsApproveAny) {
+		return cond.Or("purchase_requests.requester_id IN (?)", visibility.ScopedRequesters(db, approver))
+	}
+
+	forRole := currentStep().Where("approval_steps.approver_id IS NULL AND approval_steps.approver_role = ?", approver.Role)
+	if len(approver.Scopes) == 0 {
+		return cond.Or("EXISTS (?)", forRole)
+	}
+	return cond.Or("EXISTS (?) AND purchase_requests.requester_id IN (?)",
+		forRole, visibility.ScopedRequesters(db, approver))
+}
+
+// delegatedCondition matches purchase requests the delegate can act on
+// under the delegation, mirroring ApprovalStep.DelegationFor
+func delegatedCondition(db *gorm.DB, delegate *models.User, d *models.ApprovalDelegation) *gorm.DB {
+	cond := newCondition(db).
+		Where(assignedCondition(db, &d.Delegator)).
+		Where("purchase_requests.requester_id <> ?", delegate.ID)
+	if d.Department == "" && d.CostCenter == "" {
+		return cond
+	}
+
+	requesters := newCondition(db).Unscoped().Model(&models.User{}).Select("id")
+	if d.Department != "" {
+		requesters = requesters.Where("department = ?", d.Department)
+	}
+	if d.CostCenter != "" {
+		requesters = requesters.Where("cost_center = ?", d.CostCenter)
+	}
+	return cond.Where("purchase_requests.requester_id IN (?)", requesters)
+}
@@ -0,0 +1,249 @@
+package approval_test
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/testutil"
+)
+
+func createUser(t *testing.T, db *gorm.DB, name string, role models.UserRole) *models.User {
+	t.Helper()
+	user := models.User{
+		Email:        name + "@example.com",
+		PasswordHash: "x",
+		Name:         name,
+		Role:         role,
+		CostCenter:   "CC-100",
+		Status:       "active",
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &user
+}
+
+func createRequest(t *testing.T, db *gorm.DB, requester *models.User, amount float64) *models.PurchaseRequest {
+	t.Helper()
+	request := models.PurchaseRequest{
+		RequestNumber:    models.GenerateRequestNumber(db),
+		URL:              "https://example.com/item",
+		Currency:         "MXN",
+		TotalAmount:      amount,
+		NormalizedAmount: &amount,
+		Quantity:         1,
+		RequesterID:      requester.ID,
+		Status:           models.StatusPending,
+	}
+	if err := db.Create(&request).Error; err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	return &request
+}
+
+// reload loads the request with its chain as a handler would before deciding
+func reload(t *testing.T, db *gorm.DB, id uint) *models.PurchaseRequest {
+	t.Helper()
+	var request models.PurchaseRequest
+	if err := db.Preload("ApprovalSteps", func(db *gorm.DB) *gorm.DB { return db.Order("level ASC") }).
+		First(&request, id).Error; err != nil {
+		t.Fatalf("reload request: %v", err)
+	}
+	return &request
+}
+
+func TestStartChainUsesDefaultStepWithoutMatchingRule(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	requester := createUser(t, db, "requester", models.RoleEmployee)
+	request := createRequest(t, db, requester, 500)
+
+	if err := chain.StartChain(db, request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+
+	request = reload(t, db, request.ID)
+	if len(request.ApprovalSteps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(request.ApprovalSteps))
+	}
+	step := request.ApprovalSteps[0]
+	if step.ApproverRole != models.RoleGeneralManager || step.Status != models.StepPending {
+		t.Errorf("got step for %s in %s, want pending general manager step", step.ApproverRole, step.Status)
+	}
+	if request.CurrentStep != 1 {
+		t.Errorf("got current step %d, want 1", request.CurrentStep)
+	}
+}
+
+func TestApproveWalksMatchingRuleChain(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	requester := createUser(t, db, "requester", models.RoleEmployee)
+	scm := createUser(t, db, "scm", models.RoleSupplyChainManager)
+	gm := createUser(t, db, "gm", models.RoleGeneralManager)
+
+	minAmount := 1000.0
+	rule := models.ApprovalRule{
+		Name:      "Large purchases",
+		MinAmount: &minAmount,
+		IsActive:  true,
+		Steps: []models.ApprovalRuleStep{
+			{Level: 2, Name: "General Manager", ApproverRole: models.RoleGeneralManager},
+			{Level: 1, Name: "Supply Chain", ApproverRole: models.RoleSupplyChainManager},
+		},
+	}
+	if err := db.Create(&rule).Error; err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+
+	request := createRequest(t, db, requester, 5000)
+	if err := chain.StartChain(db, request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+
+	request = reload(t, db, request.ID)
+	if _, _, err := chain.Approve(db, request, gm, ""); !errors.Is(err, approval.ErrNotAssigned) {
+		t.Fatalf("approve by general manager at level 1: got %v, want ErrNotAssigned", err)
+	}
+
+	request = reload(t, db, request.ID)
+	step, next, err := chain.Approve(db, request, scm, "ok")
+	if err != nil {
+		t.Fatalf("approve level 1: %v", err)
+	}
+	if step.Level != 1 || step.Status != models.StepApproved || next == nil || next.Level != 2 {
+		t.Fatalf("got step %d %s and next %v, want level 1 approved and level 2 next", step.Level, step.Status, next)
+	}
+	if err := db.Model(request).Update("current_step", request.CurrentStep).Error; err != nil {
+		t.Fatalf("save current step: %v", err)
+	}
+
+	request = reload(t, db, request.ID)
+	step, next, err = chain.Approve(db, request, gm, "")
+	if err != nil {
+		t.Fatalf("approve level 2: %v", err)
+	}
+	if step.Level != 2 || next != nil {
+		t.Errorf("got step %d and next %v, want level 2 completing the chain", step.Level, next)
+	}
+}
+
+func TestConcurrentDecisionsOnlyOneSucceeds(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	requester := createUser(t, db, "requester", models.RoleEmployee)
+	gm := createUser(t, db, "gm", models.RoleGeneralManager)
+	otherGM := createUser(t, db, "gm2", models.RoleGeneralManager)
+
+	request := createRequest(t, db, requester, 500)
+	if err := chain.StartChain(db, request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+
+	// Both approvers loaded the request before either decided
+	first := reload(t, db, request.ID)
+	second := reload(t, db, request.ID)
+
+	if _, _, err := chain.Approve(db, first, gm, ""); err != nil {
+		t.Fatalf("first approval: %v", err)
+	}
+	if _, err := chain.Reject(db, second, otherGM, "no"); !errors.Is(err, approval.ErrConcurrentDecision) {
+		t.Fatalf("second decision: got %v, want ErrConcurrentDecision", err)
+	}
+
+	var step models.ApprovalStep
+	if err := db.Where("request_id = ?", request.ID).First(&step).Error; err != nil {
+		t.Fatalf("load step: %v", err)
+	}
+	if step.Status != models.StepApproved || step.ActedByID == nil || *step.ActedByID != gm.ID {
+		t.Errorf("got step %s by %v, want approved by the first approver", step.Status, step.ActedByID)
+	}
+}
+
+func TestCheckUnchangedDetectsStatusChange(t *testing.T) {
+	db := testutil.NewDB(t)
+	requester := createUser(t, db, "requester", models.RoleEmployee)
+	request := createRequest(t, db, requester, 500)
+
+	if err := approval.CheckUnchanged(db, request); err != nil {
+		t.Fatalf("unchanged request: %v", err)
+	}
+	if err := db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Update("status", models.StatusInfoRequested).Error; err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if err := approval.CheckUnchanged(db, request); !errors.Is(err, approval.ErrConcurrentDecision) {
+		t.Errorf("changed request: got %v, want ErrConcurrentDecision", err)
+	}
+}
+
+func TestRestartChainRefusedOnceDecided(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	requester := createUser(t, db, "requester", models.RoleEmployee)
+	gm := createUser(t, db, "gm", models.RoleGeneralManager)
+
+	request := createRequest(t, db, requester, 500)
+	if err := chain.StartChain(db, request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+	if err := chain.RestartChain(db, reload(t, db, request.ID)); err != nil {
+		t.Fatalf("restart undecided chain: %v", err)
+	}
+
+	request = reload(t, db, request.ID)
+	if _, err := chain.Reject(db, request, gm, "no"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if err := chain.RestartChain(db, request); !errors.Is(err, approval.ErrChainDecided) {
+		t.Errorf("restart decided chain: got %v, want ErrChainDecided", err)
+	}
+}
+
+func TestReplenishmentRequesterCannotApprove(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	admin.Permissions = []string{models.PermRequestsApproveAny}
+
+	request := createRequest(t, db, admin, 500)
+	request.IsReplenishment = true
+	steps := []models.ApprovalRuleStep{{Level: 1, Name: "Supply Chain", ApproverRole: models.RoleSupplyChainManager}}
+	if err := chain.StartChainWithSteps(db, request, steps); err != nil {
+		t.Fatalf("StartChainWithSteps: %v", err)
+	}
+
+	if _, err := chain.PendingStepFor(db, request, admin); !errors.Is(err, approval.ErrNotAssigned) {
+		t.Errorf("got %v, want ErrNotAssigned", err)
+	}
+}
+
+func TestRequesterCannotApproveOwnRequest(t *testing.T) {
+	db := testutil.NewDB(t)
+	chain := approval.NewChainService(db)
+	gm := createUser(t, db, "gm", models.RoleGeneralManager)
+	otherGM := createUser(t, db, "gm2", models.RoleGeneralManager)
+
+	request := createRequest(t, db, gm, 500)
+	if err := chain.StartChain(db, request); err != nil {
+		t.Fatalf("StartChain: %v", err)
+	}
+
+	request = reload(t, db, request.ID)
+	if _, err := chain.PendingStepFor(db, request, gm); !errors.Is(err, approval.ErrNotAssigned) {
+		t.Errorf("requester: got %v, want ErrNotAssigned", err)
+	}
+	if _, err := chain.PendingStepFor(db, request, otherGM); err != nil {
+		t.Errorf("other general manager: %v", err)
+	}
+
+	var assigned int64
+	if err := db.Model(&models.PurchaseRequest{}).Scopes(approval.AssignedTo(gm)).Count(&assigned).Error; err != nil {
+		t.Fatalf("count assigned: %v", err)
+	}
+	if assigned != 0 {
+		t.Errorf("got %d requests awaiting the requester, want 0", assigned)
+	}
+}
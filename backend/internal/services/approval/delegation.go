@@ -0,0 +1,85 @@
+package approval
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var (
+	ErrDelegationNotFound   = errors.New("delegation not found")
+	ErrSelfDelegation       = errors.New("users cannot delegate to themselves")
+	ErrDelegatorNotFound    = errors.New("delegator not found")
+	ErrDelegateNotFound     = errors.New("delegate not found or inactive")
+	ErrInvalidDelegationEnd = errors.New("delegation must end after it starts and in the future")
+)
+
+// CreateDelegation validates and saves a delegation. The delegate must be an
+// active user other than the delegator.
+func CreateDelegation(tx *gorm.DB, delegation *models.ApprovalDelegation) error {
+	if delegation.DelegateID == delegation.DelegatorID {
+		return ErrSelfDelegation
+	}
+	if !delegation.EndsAt.After(delegation.StartsAt) || !delegation.EndsAt.After(time.Now()) {
+		return ErrInvalidDelegationEnd
+	}
+
+	if err := tx.First(&delegation.Delegator, delegation.DelegatorID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDelegatorNotFound
+		}
+		return err
+	}
+	if err := tx.First(&delegation.Delegate, delegation.DelegateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDelegateNotFound
+		}
+		return err
+	}
+	if !delegation.Delegate.IsActive() {
+		return ErrDelegateNotFound
+	}
+
+	delegation.Department = strings.TrimSpace(delegation.Department)
+	delegation.CostCenter = strings.TrimSpace(delegation.CostCenter)
+	return tx.Omit("Delegator", "Delegate").Create(delegation).Error
+}
+
+// GetDelegation returns a delegation with its delegator and delegate
+func GetDelegation(db *gorm.DB, id uint) (*models.ApprovalDelegation, error) {
+	var delegation models.ApprovalDelegation
+	if err := preloadDelegationUsers(db).First(&delegation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDelegationNotFound
+		}
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// ListDelegations returns the delegations from or to the user, or every
+// delegation when userID is 0, newest first. Expired delegations are only
+// included with includeExpired.
+func ListDelegations(db *gorm.DB, userID uint, includeExpired bool) ([]models.ApprovalDelegation, error) {
+	query := preloadDelegationUsers(db).Order("starts_at DESC, id DESC")
+	if userID != 0 {
+		query = query.Where("delegator_id = ? OR delegate_id = ?", userID, userID)
+	}
+	if !includeExpired {
+		query = query.Where("ends_at > ?", time.Now())
+	}
+
+	var delegations []models.ApprovalDelegation
+	err := query.Find(&delegations).Error
+	return delegations, err
+}
+
+// preloadDelegationUsers preloads the delegator and delegate, including
+// deleted users so old delegations still name them
+func preloadDelegationUsers(db *gorm.DB) *gorm.DB {
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	return db.Preload("Delegator", unscoped).Preload("Delegate", unscoped)
+}
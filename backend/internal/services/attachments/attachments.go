@@ -0,0 +1,194 @@
+// Package attachments stores the files attached to purchase requests: quotes,
+// spec sheets, invoices and the files posted with comments. Files are checked
+// by their content, not only their extension, renamed so uploaded names never
+// reach the file system, and kept in a directory that is not served publicly.
+package attachments
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFileTooLarge = errors.New("file is too large")
+	ErrFileType     = errors.New("file type not allowed")
+	ErrEmptyFile    = errors.New("file is empty")
+)
+
+// compoundFileSignature starts the legacy Office formats (.doc, .xls, .ppt)
+var compoundFileSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// fileType is an allowed kind of file: the content type it is served with and
+// how its content is recognized
+type fileType struct {
+	contentType string
+	matches     func(head []byte, file multipart.File, size int64) bool
+}
+
+// fileTypes maps each allowed extension to its file type
+var fileTypes = map[string]fileType{
+	".pdf":  {"application/pdf", detected("application/pdf")},
+	".png":  {"image/png", detected("image/png")},
+	".jpg":  {"image/jpeg", detected("image/jpeg")},
+	".jpeg": {"image/jpeg", detected("image/jpeg")},
+	".gif":  {"image/gif", detected("image/gif")},
+	".webp": {"image/webp", detected("image/webp")},
+	".doc":  {"application/msword", compoundFile},
+	".xls":  {"application/vnd.ms-excel", compoundFile},
+	".ppt":  {"application/vnd.ms-powerpoint", compoundFile},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", officeDocument("word/")},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", officeDocument("xl/")},
+	".pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", officeDocument("ppt/")},
+}
+
+// Extensions returns the extensions of the files that can be stored
+func Extensions() []string {
+	extensions := make([]string, 0, len(fileTypes))
+	for ext := range fileTypes {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// detected matches files whose content type, as detected from their first
+// bytes, is the given one
+func detected(contentType string) func([]byte, multipart.File, int64) bool {
+	return func(head []byte, _ multipart.File, _ int64) bool {
+		return http.DetectContentType(head) == contentType
+	}
+}
+
+func compoundFile(head []byte, _ multipart.File, _ int64) bool {
+	return bytes.HasPrefix(head, compoundFileSignature)
+}
+
+// officeDocument matches Office Open XML files: zip archives with a content
+// types part and the parts of the application under dir
+func officeDocument(dir string) func([]byte, multipart.File, int64) bool {
+	return func(head []byte, file multipart.File, size int64) bool {
+		if http.DetectContentType(head) != "application/zip" {
+			return false
+		}
+		archive, err := zip.NewReader(file, size)
+		if err != nil {
+			return false
+		}
+		var hasContentTypes, hasParts bool
+		for _, f := range archive.File {
+			hasContentTypes = hasContentTypes || f.Name == "[Content_Types].xml"
+			hasParts = hasParts || strings.HasPrefix(f.Name, dir)
+		}
+		return hasContentTypes && hasParts
+	}
+}
+
+// Store saves attachment files under a directory
+type Store struct {
+	dir     string
+	maxSize int64
+}
+
+// NewStore creates a new attachment store, creating its directory
+func NewStore(dir string, maxSize int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, maxSize: maxSize}, nil
+}
+
+// File is a stored attachment
+type File struct {
+	Name        string // Name of the file as uploaded, without any directories
+	ContentType string
+	Size        int64
+	Path        string // Relative to the store's directory
+}
+
+// Save checks an uploaded file and stores it
+func (s *Store) Save(header *multipart.FileHeader) (*File, error) {
+	if header.Size > s.maxSize {
+		return nil, fmt.Errorf("%w: the maximum is %d MB", ErrFileTooLarge, s.maxSize/(1024*1024))
+	}
+	if header.Size == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	fileType, ok := fileTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFileType, header.Filename)
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// The content must match the extension, so a renamed executable or
+	// HTML page is not served as a PDF, image or document
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if !fileType.matches(buffer[:n], src, header.Size) {
+		return nil, fmt.Errorf("%w: %s is not a valid %s file", ErrFileType, header.Filename, strings.TrimPrefix(ext, "."))
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(time.Now().Format("2006/01"), uuid.New().String()+ext)
+	fullPath := filepath.Join(s.dir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+		return nil, err
+	}
+	dst, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, err
+	}
+
+	return &File{
+		Name:        filepath.Base(header.Filename),
+		ContentType: fileType.contentType,
+		Size:        size,
+		Path:        path,
+	}, nil
+}
+
+// Path returns where a stored file is on disk
+func (s *Store) Path(path string) string {
+	return filepath.Join(s.dir, filepath.Clean("/"+path))
+}
+
+// Remove deletes stored files, ignoring those already gone
+func (s *Store) Remove(paths ...string) error {
+	for _, path := range paths {
+		if err := os.Remove(s.Path(path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
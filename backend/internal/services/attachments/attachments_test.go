@@ -0,0 +1,168 @@
+package attachments_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vista-backend/internal/services/attachments"
+)
+
+var (
+	pdfContent = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")
+	pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+)
+
+// upload returns the header of a file posted in a multipart form, as a
+// handler would receive it
+func upload(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("read form: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// officeFile returns a zip archive with the given entries
+func officeFile(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		w.Write([]byte("<xml/>"))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func newStore(t *testing.T, maxSize int64) (*attachments.Store, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "attachments")
+	store, err := attachments.NewStore(dir, maxSize)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return store, dir
+}
+
+func TestSaveStoresFileUnderGeneratedName(t *testing.T) {
+	store, dir := newStore(t, 1<<20)
+
+	file, err := store.Save(upload(t, "Quote March.PDF", pdfContent))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if file.Name != "Quote March.PDF" || file.ContentType != "application/pdf" || file.Size != int64(len(pdfContent)) {
+		t.Errorf("got %+v, want the uploaded PDF", file)
+	}
+	if strings.Contains(file.Path, "Quote") || filepath.Ext(file.Path) != ".pdf" {
+		t.Errorf("got path %s, want a generated name with the .pdf extension", file.Path)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(dir, file.Path))
+	if err != nil {
+		t.Fatalf("read stored file: %v", err)
+	}
+	if !bytes.Equal(stored, pdfContent) {
+		t.Error("stored content differs from the upload")
+	}
+	if store.Path(file.Path) != filepath.Join(dir, file.Path) {
+		t.Errorf("got path %s, want it under the store directory", store.Path(file.Path))
+	}
+}
+
+func TestSaveAcceptsAllowedTypes(t *testing.T) {
+	store, _ := newStore(t, 1<<20)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+	}{
+		{"photo.png", pngContent, "image/png"},
+		{"specs.doc", append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 64)...), "application/msword"},
+		{"specs.docx", officeFile(t, "[Content_Types].xml", "word/document.xml"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"prices.xlsx", officeFile(t, "[Content_Types].xml", "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := store.Save(upload(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if file.ContentType != tt.contentType {
+				t.Errorf("got content type %s, want %s", file.ContentType, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestSaveRejectsInvalidFiles(t *testing.T) {
+	store, dir := newStore(t, 1024)
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    error
+	}{
+		{"large.pdf", append(pdfContent, make([]byte, 1024)...), attachments.ErrFileTooLarge},
+		{"empty.pdf", nil, attachments.ErrEmptyFile},
+		{"setup.exe", []byte("MZ\x90\x00"), attachments.ErrFileType},
+		{"page.pdf", []byte("<html><script>alert(1)</script></html>"), attachments.ErrFileType},
+		{"photo.png", pdfContent, attachments.ErrFileType},
+		{"archive.docx", officeFile(t, "payload.bin"), attachments.ErrFileType},
+		{"sheet.docx", officeFile(t, "[Content_Types].xml", "xl/workbook.xml"), attachments.ErrFileType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Save(upload(t, tt.name, tt.content)); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read store directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries in the store, want rejected files not stored", len(entries))
+	}
+}
+
+func TestRemoveIgnoresMissingFiles(t *testing.T) {
+	store, dir := newStore(t, 1<<20)
+
+	file, err := store.Save(upload(t, "quote.pdf", pdfContent))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.Remove(file.Path, "2020/01/missing.pdf"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, file.Path)); !os.IsNotExist(err) {
+		t.Errorf("got %v, want the file removed", err)
+	}
+}
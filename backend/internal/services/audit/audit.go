@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// Entry describes a change to record in the audit log
+type Entry struct {
+	UserID     uint // User who made the change; 0 for anonymous actions
+	Action     string
+	Resource   string
+	ResourceID uint
+	Before     interface{} // State before the change; nil for creations
+	After      interface{} // State after the change; nil for deletions
+	IPAddress  string
+	UserAgent  string
+}
+
+// Record writes an audit log entry, storing the before and after states as JSON.
+// Pass the transaction that applies the change so both commit together.
+func Record(tx *gorm.DB, e Entry) error {
+	oldValue, err := snapshot(e.Before)
+	if err != nil {
+		return err
+	}
+	newValue, err := snapshot(e.After)
+	if err != nil {
+		return err
+	}
+
+	userAgent := e.UserAgent
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	log := models.NewAuditLog(e.UserID, e.Action, e.Resource, e.ResourceID, oldValue, newValue, e.IPAddress, userAgent)
+	return tx.Create(log).Error
+}
+
+// snapshot serializes a state to JSON, returning an empty string for nil
+func snapshot(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -10,75 +10,275 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserInactive       = errors.New("user account is inactive")
-	ErrEmailExists        = errors.New("email already exists")
+	ErrInvalidCredentials    = errors.New("invalid email or password")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrUserInactive          = errors.New("user account is inactive")
+	ErrEmailExists           = errors.New("email already exists")
+	ErrPasswordLoginDisabled = errors.New("password login is disabled for this user")
 )
 
 type AuthService struct {
 	db         *gorm.DB
 	jwtService *jwt.JWTService
+	loginGuard *LoginGuard
+	mfa        *MFAService
+	passwords  *PasswordPolicy
+	roles      *RoleService
 }
 
-func NewAuthService(db *gorm.DB, jwtService *jwt.JWTService) *AuthService {
+func NewAuthService(db *gorm.DB, jwtService *jwt.JWTService, loginGuard *LoginGuard, mfa *MFAService, passwords *PasswordPolicy, roles *RoleService) *AuthService {
 	return &AuthService{
 		db:         db,
 		jwtService: jwtService,
+		loginGuard: loginGuard,
+		mfa:        mfa,
+		passwords:  passwords,
+		roles:      roles,
 	}
 }
 
-// Login authenticates a user and returns tokens
-func (as *AuthService) Login(email, password string) (*jwt.TokenPair, *models.User, error) {
+// MFAChallenge is returned instead of tokens when a login needs a second factor
+type MFAChallenge struct {
+	Token         string
+	SetupRequired bool // The policy requires 2FA but the user has not enrolled yet
+	ExpiresIn     int64
+}
+
+// LoginResult is the outcome of a login step: either the session's tokens or
+// the challenge for the next step
+type LoginResult struct {
+	User          *models.User
+	Tokens        *jwt.TokenPair
+	Challenge     *MFAChallenge
+	RecoveryCodes []string // Issued when the login completed 2FA enrollment
+}
+
+// Login authenticates a user, opens a session for the client and returns its tokens.
+// Users with two-factor authentication get a challenge to complete with VerifyMFA,
+// and users the policy requires it for get one to enroll with CompleteMFASetup.
+// Repeated failures are slowed down and then locked out with a LoginBlockedError.
+func (as *AuthService) Login(email, password string, client ClientInfo) (*LoginResult, error) {
+	// The attempt counts as failed until the password is verified
+	if err := as.loginGuard.Begin(email, client); err != nil {
+		return nil, err
+	}
+
 	var user models.User
 	if err := as.db.Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, ErrInvalidCredentials
+			return nil, as.failLogin(email, nil, client)
 		}
-		return nil, nil, err
+		return nil, as.cancelAttempt(email, client, err)
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
-		return nil, nil, ErrUserInactive
+		return nil, as.cancelAttempt(email, client, ErrUserInactive)
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, nil, ErrInvalidCredentials
+		return nil, as.failLogin(email, &user.ID, client)
+	}
+	if err := as.cancelAttempt(email, client, nil); err != nil {
+		return nil, err
+	}
+	if user.PasswordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
+
+	// Expired passwords still log in, but the session can only be used to
+	// change the password
+	if !user.MustChangePassword && as.passwords.Expired(&user) {
+		if err := as.db.Model(&models.User{}).Where("id = ?", user.ID).Update("must_change_password", true).Error; err != nil {
+			return nil, err
+		}
+		user.MustChangePassword = true
 	}
 
-	// Generate tokens
-	tokens, err := as.jwtService.GenerateTokenPair(user.ID, user.Email, string(user.Role))
+	return as.finishLogin(&user, client)
+}
+
+// finishLogin continues the login of an authenticated user: users with two-
+// factor authentication, or who the policy requires it for, get a challenge
+// and everyone else a session
+func (as *AuthService) finishLogin(user *models.User, client ClientInfo) (*LoginResult, error) {
+	// Failures are only cleared once the second factor passes, so codes
+	// cannot be guessed by logging in again between attempts
+	switch {
+	case user.MFAEnabled:
+		return as.challenge(user, jwt.MFAChallengeToken)
+	case as.mfa.Required(user):
+		return as.challenge(user, jwt.MFASetupToken)
+	}
+
+	return as.completeLogin(user, client)
+}
+
+// VerifyMFA completes a login challenged for its second factor with a TOTP
+// code or a recovery code
+func (as *AuthService) VerifyMFA(challengeToken, code, recoveryCode string, client ClientInfo) (*LoginResult, error) {
+	user, err := as.challengedUser(challengeToken, jwt.MFAChallengeToken, client)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	// The attempt counts as failed until the code is verified
+	if err := as.loginGuard.Begin(user.Email, client); err != nil {
+		return nil, err
+	}
+	if err := as.mfa.Verify(user, code, recoveryCode, client); err != nil {
+		return nil, as.failMFA(user, err, client)
+	}
+	if err := as.cancelAttempt(user.Email, client, nil); err != nil {
+		return nil, err
 	}
 
-	return tokens, &user, nil
+	return as.completeLogin(user, client)
 }
 
-// RefreshTokens refreshes the access token using a valid refresh token
-func (as *AuthService) RefreshTokens(refreshToken string) (*jwt.TokenPair, error) {
-	claims, err := as.jwtService.ValidateRefreshToken(refreshToken)
+// BeginMFASetup starts enrollment for a login that must set up 2FA first
+func (as *AuthService) BeginMFASetup(challengeToken string, client ClientInfo) (*MFAEnrollment, error) {
+	user, err := as.challengedUser(challengeToken, jwt.MFASetupToken, client)
 	if err != nil {
 		return nil, err
 	}
+	return as.mfa.Enroll(user)
+}
 
-	// Verify user still exists and is active
-	var user models.User
-	if err := as.db.First(&user, claims.UserID).Error; err != nil {
-		return nil, ErrUserNotFound
+// CompleteMFASetup confirms the enrollment started by BeginMFASetup and
+// completes the login, returning the new recovery codes with the tokens
+func (as *AuthService) CompleteMFASetup(challengeToken, code string, client ClientInfo) (*LoginResult, error) {
+	user, err := as.challengedUser(challengeToken, jwt.MFASetupToken, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.loginGuard.Begin(user.Email, client); err != nil {
+		return nil, err
+	}
+	codes, err := as.mfa.Enable(user, code, client)
+	if err != nil {
+		return nil, as.failMFA(user, err, client)
+	}
+	if err := as.cancelAttempt(user.Email, client, nil); err != nil {
+		return nil, err
+	}
+
+	result, err := as.completeLogin(user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = codes
+	return result, nil
+}
+
+// challenge issues a challenge token for the next step of the user's login
+func (as *AuthService) challenge(user *models.User, tokenType jwt.TokenType) (*LoginResult, error) {
+	token, err := as.jwtService.GenerateChallengeToken(user.ID, user.Email, string(user.Role), tokenType, as.mfa.cfg.ChallengeExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		User: user,
+		Challenge: &MFAChallenge{
+			Token:         token,
+			SetupRequired: tokenType == jwt.MFASetupToken,
+			ExpiresIn:     int64(as.mfa.cfg.ChallengeExpiry.Seconds()),
+		},
+	}, nil
+}
+
+// challengedUser loads the user a challenge token was issued to, checking the
+// user can still log in
+func (as *AuthService) challengedUser(challengeToken string, tokenType jwt.TokenType, client ClientInfo) (*models.User, error) {
+	claims, err := as.jwtService.ValidateChallengeToken(challengeToken, tokenType)
+	if err != nil {
+		return nil, err
 	}
 
+	user, err := as.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
 	if !user.IsActive() {
 		return nil, ErrUserInactive
 	}
+	if err := as.loginGuard.Check(user.Email, client); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// failMFA records a wrong second factor as a failed login. Other errors do
+// not count against the user.
+func (as *AuthService) failMFA(user *models.User, err error, client ClientInfo) error {
+	if !errors.Is(err, ErrInvalidMFACode) {
+		return as.cancelAttempt(user.Email, client, err)
+	}
+	if err := as.loginGuard.RecordFailure(user.Email, &user.ID, client); err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+// completeLogin clears the user's failed logins and opens a session
+func (as *AuthService) completeLogin(user *models.User, client ClientInfo) (*LoginResult, error) {
+	if err := as.loginGuard.RecordSuccess(user.Email); err != nil {
+		return nil, err
+	}
 
-	// Generate new tokens
-	return as.jwtService.GenerateTokenPair(user.ID, user.Email, string(user.Role))
+	tokens, err := as.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	if user.Permissions, err = as.roles.Permissions(user.Role); err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// cancelAttempt takes back the failure counted for a login attempt that did
+// not fail on its credentials, returning err unless cancelling failed
+func (as *AuthService) cancelAttempt(email string, client ClientInfo, err error) error {
+	if cancelErr := as.loginGuard.Cancel(email, client); cancelErr != nil {
+		return cancelErr
+	}
+	return err
 }
 
-// GetUserByID retrieves a user by ID
+// failLogin records a failed login, returning the error to report for it
+func (as *AuthService) failLogin(email string, userID *uint, client ClientInfo) error {
+	if err := as.loginGuard.RecordFailure(email, userID, client); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// RefreshTokens exchanges a valid refresh token for a new token pair, rotating
+// the session's refresh token
+func (as *AuthService) RefreshTokens(refreshToken string, client ClientInfo) (*jwt.TokenPair, error) {
+	claims, err := as.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before sessions were tracked cannot be rotated
+	if claims.SessionID == 0 || claims.ID == "" {
+		return nil, jwt.ErrInvalidClaim
+	}
+
+	return as.rotateSession(claims, client)
+}
+
+// Logout revokes the user's current session
+func (as *AuthService) Logout(userID, sessionID uint) error {
+	return as.RevokeSession(userID, sessionID, models.SessionRevokedLogout)
+}
+
+// GetUserByID retrieves a user by ID with the permissions of their role
 func (as *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	var user models.User
 	if err := as.db.First(&user, userID).Error; err != nil {
@@ -87,9 +287,41 @@ func (as *AuthService) GetUserByID(userID uint) (*models.User, error) {
 		}
 		return nil, err
 	}
+	permissions, err := as.roles.Permissions(user.Role)
+	if err != nil {
+		return nil, err
+	}
+	user.Permissions = permissions
 	return &user, nil
 }
 
+// RolePermissions returns the permissions the role grants
+func (as *AuthService) RolePermissions(role string) ([]string, error) {
+	return as.roles.Permissions(models.UserRole(role))
+}
+
+// PasswordChangeRequired reports whether the user must change their password
+// before using anything but their profile. Users who only sign in with SSO
+// never have to.
+func (as *AuthService) PasswordChangeRequired(userID uint) (bool, error) {
+	var user models.User
+	if err := as.db.Select("id", "must_change_password", "password_login_disabled").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	return user.MustChangePassword && !user.PasswordLoginDisabled, nil
+}
+
+// MFASetupRequired reports whether the policy requires two-factor
+// authentication for the user but they have not set it up, as when their
+// role was changed or started requiring it after they logged in
+func (as *AuthService) MFASetupRequired(userID uint) (bool, error) {
+	var user models.User
+	if err := as.db.Select("id", "role", "mfa_enabled").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	return as.mfa.Required(&user) && !user.MFAEnabled, nil
+}
+
 // HashPassword hashes a password using bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
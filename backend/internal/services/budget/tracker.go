@@ -0,0 +1,143 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+)
+
+var ErrBudgetExceeded = errors.New("request exceeds the remaining budget")
+
+// Check is the budget impact of approving a request
+type Check struct {
+	Budget    *models.Budget
+	Amount    float64 // Request amount in the budget currency
+	Remaining float64 // Remaining budget before this request
+	Exceeded  bool
+
+	// Set when no exchange rate converts the request amount to the budget
+	// currency. The amount is then unknown, so the request cannot be committed
+	// against the budget, not even with an override, until a rate is added.
+	RateMissing error
+}
+
+// Message describes the check for approvers
+func (c *Check) Message() string {
+	if c.RateMissing != nil {
+		return fmt.Sprintf("Request amount cannot be checked against the budget for cost center %s (%s): %v",
+			c.Budget.CostCenter, c.Budget.Period, c.RateMissing)
+	}
+	return fmt.Sprintf("Request amount %.2f %s exceeds the remaining budget of %.2f %s for cost center %s (%s)",
+		c.Amount, c.Budget.Currency, c.Remaining, c.Budget.Currency, c.Budget.CostCenter, c.Budget.Period)
+}
+
+// Tracker keeps budget committed and spent amounts in sync with request approvals and purchases
+type Tracker struct {
+	db        *gorm.DB
+	converter *currency.Converter
+}
+
+// NewTracker creates a new budget tracker
+func NewTracker(db *gorm.DB, converter *currency.Converter) *Tracker {
+	return &Tracker{db: db, converter: converter}
+}
+
+// FindBudget returns the budget covering the requester's cost center at the given time, or nil
+func (t *Tracker) FindBudget(tx *gorm.DB, requester *models.User, at time.Time) (*models.Budget, error) {
+	if requester.CostCenter == "" {
+		return nil, nil
+	}
+
+	var budget models.Budget
+	err := tx.
+		Where("cost_center = ? AND period_start <= ? AND period_end > ?", requester.CostCenter, at, at).
+		Where("company_code = '' OR company_code = ?", requester.CompanyCode).
+		Order("period_start DESC").
+		First(&budget).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Check evaluates the request against its requester's budget, converting the
+// request amount to the budget currency. It returns nil when no budget applies.
+// A missing exchange rate is reported in RateMissing rather than as an error.
+func (t *Tracker) Check(tx *gorm.DB, request *models.PurchaseRequest, at time.Time) (*Check, error) {
+	var requester models.User
+	if err := tx.First(&requester, request.RequesterID).Error; err != nil {
+		return nil, err
+	}
+
+	budget, err := t.FindBudget(tx, &requester, at)
+	if err != nil || budget == nil {
+		return nil, err
+	}
+
+	remaining := budget.Remaining()
+	amount, err := t.converter.Convert(tx, request.Amount(), request.Currency, budget.Currency, at)
+	if errors.Is(err, currency.ErrRateNotFound) {
+		return &Check{
+			Budget:      budget,
+			Remaining:   remaining,
+			RateMissing: fmt.Errorf("%w: %s to %s", currency.ErrRateNotFound, currency.Code(request.Currency), budget.Currency),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Check{
+		Budget:    budget,
+		Amount:    amount,
+		Remaining: remaining,
+		Exceeded:  amount > remaining,
+	}, nil
+}
+
+// Commit reserves the checked amount on the budget. Unless override is set,
+// the reservation fails with ErrBudgetExceeded if the remaining budget is
+// insufficient at the time of the update. A check whose rate was missing
+// fails with its RateMissing error.
+func (t *Tracker) Commit(tx *gorm.DB, request *models.PurchaseRequest, check *Check, override bool) error {
+	if check.RateMissing != nil {
+		return check.RateMissing
+	}
+
+	query := tx.Model(&models.Budget{}).Where("id = ?", check.Budget.ID)
+	if !override {
+		query = query.Where("amount - committed_amount - spent_amount >= ?", check.Amount)
+	}
+
+	result := query.Update("committed_amount", gorm.Expr("committed_amount + ?", check.Amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBudgetExceeded
+	}
+
+	request.BudgetID = &check.Budget.ID
+	request.BudgetAmount = check.Amount
+	request.BudgetOverride = check.Exceeded
+	return nil
+}
+
+// Spend moves the request's committed amount to spent once it is purchased
+func (t *Tracker) Spend(tx *gorm.DB, request *models.PurchaseRequest) error {
+	if request.BudgetID == nil {
+		return nil
+	}
+
+	return tx.Model(&models.Budget{}).Where("id = ?", *request.BudgetID).Updates(map[string]interface{}{
+		"committed_amount": gorm.Expr("committed_amount - ?", request.BudgetAmount),
+		"spent_amount":     gorm.Expr("spent_amount + ?", request.BudgetAmount),
+	}).Error
+}
@@ -0,0 +1,222 @@
+package budget_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/budget"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/testutil"
+)
+
+// setup creates a requester in cost center CC-100 with a 10,000 MXN budget
+// for the current month
+func setup(t *testing.T) (*gorm.DB, *budget.Tracker, *models.User, *models.Budget) {
+	t.Helper()
+	db := testutil.NewDB(t)
+
+	requester := models.User{
+		Email:        "requester@example.com",
+		PasswordHash: "x",
+		Name:         "Requester",
+		Role:         models.RoleEmployee,
+		CostCenter:   "CC-100",
+		Status:       "active",
+	}
+	if err := db.Create(&requester).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	b := models.Budget{
+		CostCenter:  "CC-100",
+		PeriodType:  models.BudgetMonthly,
+		Period:      start.Format("2006-01"),
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Amount:      10000,
+		Currency:    "MXN",
+	}
+	if err := db.Create(&b).Error; err != nil {
+		t.Fatalf("create budget: %v", err)
+	}
+
+	return db, budget.NewTracker(db, currency.NewConverter(db, "MXN")), &requester, &b
+}
+
+func newRequest(requester *models.User, amount float64, code string) *models.PurchaseRequest {
+	return &models.PurchaseRequest{
+		ID:          1,
+		Currency:    code,
+		TotalAmount: amount,
+		RequesterID: requester.ID,
+	}
+}
+
+func loadBudget(t *testing.T, db *gorm.DB, id uint) *models.Budget {
+	t.Helper()
+	var b models.Budget
+	if err := db.First(&b, id).Error; err != nil {
+		t.Fatalf("load budget: %v", err)
+	}
+	return &b
+}
+
+func TestCheckWithoutBudget(t *testing.T) {
+	db, tracker, requester, _ := setup(t)
+	if err := db.Model(requester).Update("cost_center", "CC-999").Error; err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	check, err := tracker.Check(db, newRequest(requester, 500, "MXN"), time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if check != nil {
+		t.Errorf("got check against budget %d, want none", check.Budget.ID)
+	}
+}
+
+func TestCommitWithinBudget(t *testing.T) {
+	db, tracker, requester, b := setup(t)
+	request := newRequest(requester, 4000, "MXN")
+
+	check, err := tracker.Check(db, request, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if check == nil || check.Exceeded || check.Remaining != 10000 {
+		t.Fatalf("got %+v, want a check within the full budget", check)
+	}
+	if err := tracker.Commit(db, request, check, false); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if got := loadBudget(t, db, b.ID).CommittedAmount; got != 4000 {
+		t.Errorf("got committed %.2f, want 4000", got)
+	}
+	if request.BudgetID == nil || *request.BudgetID != b.ID || request.BudgetAmount != 4000 || request.BudgetOverride {
+		t.Errorf("got request budget %v amount %.2f override %v, want the budget, 4000 and no override",
+			request.BudgetID, request.BudgetAmount, request.BudgetOverride)
+	}
+}
+
+func TestCommitOverBudgetNeedsOverride(t *testing.T) {
+	db, tracker, requester, b := setup(t)
+	request := newRequest(requester, 12000, "MXN")
+
+	check, err := tracker.Check(db, request, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !check.Exceeded {
+		t.Fatalf("got check within budget, want exceeded")
+	}
+	if err := tracker.Commit(db, request, check, false); !errors.Is(err, budget.ErrBudgetExceeded) {
+		t.Fatalf("commit without override: got %v, want ErrBudgetExceeded", err)
+	}
+	if got := loadBudget(t, db, b.ID).CommittedAmount; got != 0 {
+		t.Fatalf("got committed %.2f after refused commit, want 0", got)
+	}
+
+	if err := tracker.Commit(db, request, check, true); err != nil {
+		t.Fatalf("commit with override: %v", err)
+	}
+	if got := loadBudget(t, db, b.ID).CommittedAmount; got != 12000 {
+		t.Errorf("got committed %.2f, want 12000", got)
+	}
+	if !request.BudgetOverride {
+		t.Error("request not marked as approved with override")
+	}
+}
+
+func TestCommitRechecksRemainingBudget(t *testing.T) {
+	db, tracker, requester, b := setup(t)
+
+	// Both requests were checked before either was committed
+	first := newRequest(requester, 6000, "MXN")
+	second := newRequest(requester, 6000, "MXN")
+	firstCheck, err := tracker.Check(db, first, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	secondCheck, err := tracker.Check(db, second, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	if err := tracker.Commit(db, first, firstCheck, false); err != nil {
+		t.Fatalf("first commit: %v", err)
+	}
+	if err := tracker.Commit(db, second, secondCheck, false); !errors.Is(err, budget.ErrBudgetExceeded) {
+		t.Errorf("second commit: got %v, want ErrBudgetExceeded", err)
+	}
+	if got := loadBudget(t, db, b.ID).CommittedAmount; got != 6000 {
+		t.Errorf("got committed %.2f, want 6000", got)
+	}
+}
+
+func TestCheckConvertsToBudgetCurrency(t *testing.T) {
+	db, tracker, requester, _ := setup(t)
+	rate := models.ExchangeRate{Currency: "USD", BaseCurrency: "MXN", Rate: 20, EffectiveDate: time.Now().AddDate(0, 0, -1)}
+	if err := db.Create(&rate).Error; err != nil {
+		t.Fatalf("create rate: %v", err)
+	}
+
+	check, err := tracker.Check(db, newRequest(requester, 600, "USD"), time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if check.Amount != 12000 || !check.Exceeded {
+		t.Errorf("got amount %.2f exceeded %v, want 12000 exceeding the budget", check.Amount, check.Exceeded)
+	}
+}
+
+func TestCommitWithoutRateRefusedEvenWithOverride(t *testing.T) {
+	db, tracker, requester, b := setup(t)
+	request := newRequest(requester, 10, "EUR")
+
+	check, err := tracker.Check(db, request, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !errors.Is(check.RateMissing, currency.ErrRateNotFound) || !strings.Contains(check.RateMissing.Error(), "EUR to MXN") {
+		t.Fatalf("got missing rate %v, want the EUR to MXN rate reported", check.RateMissing)
+	}
+
+	if err := tracker.Commit(db, request, check, true); !errors.Is(err, currency.ErrRateNotFound) {
+		t.Errorf("commit with override: got %v, want ErrRateNotFound", err)
+	}
+	if got := loadBudget(t, db, b.ID).CommittedAmount; got != 0 {
+		t.Errorf("got committed %.2f, want 0", got)
+	}
+	if request.BudgetID != nil {
+		t.Errorf("got request committed against budget %d, want none", *request.BudgetID)
+	}
+}
+
+func TestSpendMovesCommittedToSpent(t *testing.T) {
+	db, tracker, requester, b := setup(t)
+	request := newRequest(requester, 3000, "MXN")
+
+	check, err := tracker.Check(db, request, time.Now())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if err := tracker.Commit(db, request, check, false); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := tracker.Spend(db, request); err != nil {
+		t.Fatalf("Spend: %v", err)
+	}
+
+	got := loadBudget(t, db, b.ID)
+	if got.CommittedAmount != 0 || got.SpentAmount != 3000 {
+		t.Errorf("got committed %.2f spent %.2f, want 0 and 3000", got.CommittedAmount, got.SpentAmount)
+	}
+}
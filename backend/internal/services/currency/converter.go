@@ -0,0 +1,224 @@
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Converter converts amounts between currencies using the exchange rate table
+type Converter struct {
+	db   *gorm.DB
+	base string
+}
+
+// NewConverter creates a converter normalizing to the given base currency
+func NewConverter(db *gorm.DB, base string) *Converter {
+	return &Converter{db: db, base: Code(base)}
+}
+
+// Code normalizes a currency code
+func Code(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// Base returns the base reporting currency
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Rate returns the value of one unit of currency in the base currency at the
+// given time. When no rate was effective yet, the earliest known rate is used.
+func (c *Converter) Rate(tx *gorm.DB, currency string, at time.Time) (float64, error) {
+	currency = Code(currency)
+	if currency == c.base {
+		return 1, nil
+	}
+
+	var rate models.ExchangeRate
+	err := tx.Where("currency = ? AND base_currency = ? AND effective_date <= ?", currency, c.base, at).
+		Order("effective_date DESC, id DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("currency = ? AND base_currency = ?", currency, c.base).
+			Order("effective_date ASC, id ASC").
+			First(&rate).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("%w: %s to %s", ErrRateNotFound, currency, c.base)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+// Convert converts an amount between two currencies through the base currency
+func (c *Converter) Convert(tx *gorm.DB, amount float64, from, to string, at time.Time) (float64, error) {
+	if Code(from) == Code(to) {
+		return amount, nil
+	}
+
+	fromRate, err := c.Rate(tx, from, at)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := c.Rate(tx, to, at)
+	if err != nil {
+		return 0, err
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// Normalize stores the request amount in the base currency. When no rate is
+// available the normalized fields are cleared and no error is returned.
+func (c *Converter) Normalize(tx *gorm.DB, request *models.PurchaseRequest, at time.Time) error {
+	err := c.NormalizeRequired(tx, request, at)
+	if errors.Is(err, ErrRateNotFound) {
+		request.NormalizedAmount = nil
+		request.BaseCurrency = ""
+		request.ExchangeRate = nil
+		return nil
+	}
+	return err
+}
+
+// NormalizeRequired stores the request amount in the base currency, failing
+// with ErrRateNotFound when no rate is available. Submitted requests must be
+// normalized, as approval rules compare base currency amounts.
+func (c *Converter) NormalizeRequired(tx *gorm.DB, request *models.PurchaseRequest, at time.Time) error {
+	rate, err := c.Rate(tx, request.Currency, at)
+	if err != nil {
+		return err
+	}
+
+	amount := request.Amount() * rate
+	request.NormalizedAmount = &amount
+	request.BaseCurrency = c.base
+	request.ExchangeRate = &rate
+	return nil
+}
+
+// NormalizeMissing normalizes requests that have no amount in the current base
+// currency, e.g. after rates were imported or the base currency changed
+func (c *Converter) NormalizeMissing() (int, error) {
+	var requests []models.PurchaseRequest
+	if err := c.db.Where("normalized_amount IS NULL OR base_currency <> ?", c.base).Find(&requests).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for i := range requests {
+		request := &requests[i]
+		if err := c.Normalize(c.db, request, request.CreatedAt); err != nil {
+			return updated, err
+		}
+		if request.NormalizedAmount == nil {
+			continue
+		}
+
+		if err := c.db.Model(request).Updates(map[string]interface{}{
+			"normalized_amount": request.NormalizedAmount,
+			"base_currency":     request.BaseCurrency,
+			"exchange_rate":     request.ExchangeRate,
+		}).Error; err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ImportCSV imports rates from CSV rows of currency,rate,effective_date
+// (YYYY-MM-DD) with an optional fourth base_currency column. A header row is
+// skipped. Rates are quoted as base currency units per unit of currency.
+func (c *Converter) ImportCSV(r io.Reader, userID *uint) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var rates []models.ExchangeRate
+	for i, record := range records {
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+		if len(record) < 3 {
+			return 0, fmt.Errorf("line %d: expected currency,rate,effective_date", i+1)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil || rate <= 0 {
+			return 0, fmt.Errorf("line %d: invalid rate %q", i+1, record[1])
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[2]))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid effective date %q", i+1, record[2])
+		}
+
+		base := c.base
+		if len(record) > 3 && Code(record[3]) != "" {
+			base = Code(record[3])
+		}
+
+		rates = append(rates, models.ExchangeRate{
+			Currency:      Code(record[0]),
+			BaseCurrency:  base,
+			Rate:          rate,
+			EffectiveDate: date,
+			Source:        models.RateSourceCSV,
+			CreatedByID:   userID,
+		})
+	}
+
+	if len(rates) == 0 {
+		return 0, nil
+	}
+
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			// Re-importing a file replaces the rate for the same pair and date
+			if err := tx.Where("currency = ? AND base_currency = ? AND effective_date = ?",
+				rates[i].Currency, rates[i].BaseCurrency, rates[i].EffectiveDate).
+				Delete(&models.ExchangeRate{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&rates[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// ImportFile imports rates from a CSV file on disk
+func (c *Converter) ImportFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return c.ImportCSV(f, nil)
+}
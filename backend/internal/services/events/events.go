@@ -0,0 +1,85 @@
+// Package events dispatches purchase request lifecycle events to the parts
+// of the system that react to them, such as notifications. Events are
+// dispatched inside the transaction that caused them, so listeners that
+// persist work commit or roll back with the change.
+package events
+
+import (
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// Type identifies what happened to a request
+type Type string
+
+const (
+	RequestCreated       Type = "request.created"
+	RequestStepApproved  Type = "request.step_approved" // An approval step was approved and the next one is pending
+	RequestApproved      Type = "request.approved"      // The final approval step was approved
+	RequestRejected      Type = "request.rejected"
+	RequestInfoRequested Type = "request.info_requested"
+	RequestResubmitted   Type = "request.resubmitted" // The requester answered an info request and the request is pending again
+	RequestCancelled     Type = "request.cancelled"
+	RequestCommented     Type = "request.commented"
+	OrderPurchased       Type = "order.purchased"
+	CartAdded            Type = "cart.added"  // Amazon products of an approved request were added to the cart
+	CartFailed           Type = "cart.failed" // Adding Amazon products to the cart failed for good
+)
+
+// Types lists every event type
+var Types = []Type{
+	RequestCreated,
+	RequestStepApproved,
+	RequestApproved,
+	RequestRejected,
+	RequestInfoRequested,
+	RequestResubmitted,
+	RequestCancelled,
+	RequestCommented,
+	OrderPurchased,
+	CartAdded,
+	CartFailed,
+}
+
+// Event is something that happened to a purchase request
+type Event struct {
+	Type    Type
+	Request *models.PurchaseRequest
+	ActorID uint                 // User who caused the event, 0 for background jobs
+	Step    *models.ApprovalStep // Step decided on, for approval events
+	Comment string               // Approver comment, purchase notes, requester answer, comment body or cart error
+
+	// Comment posted, with its mentions, for comment events
+	RequestComment *models.RequestComment
+}
+
+// Listener reacts to events inside the transaction that caused them.
+// Returning an error rolls the transaction back.
+type Listener interface {
+	Handle(tx *gorm.DB, event *Event) error
+}
+
+// Dispatcher delivers events to its listeners in the order they subscribed
+type Dispatcher struct {
+	listeners []Listener
+}
+
+// NewDispatcher creates a new event dispatcher
+func NewDispatcher(listeners ...Listener) *Dispatcher {
+	return &Dispatcher{listeners: listeners}
+}
+
+// Subscribe adds a listener. Must be called before events are dispatched.
+func (d *Dispatcher) Subscribe(listener Listener) {
+	d.listeners = append(d.listeners, listener)
+}
+
+// Dispatch delivers the event to every listener using tx
+func (d *Dispatcher) Dispatch(tx *gorm.DB, event Event) error {
+	for _, listener := range d.listeners {
+		if err := listener.Handle(tx, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
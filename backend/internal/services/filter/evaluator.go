@@ -0,0 +1,201 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/currency"
+)
+
+// Subject is the product information a purchase request is evaluated against
+type Subject struct {
+	URL         string
+	Title       string
+	Description string
+	SiteName    string
+	Category    string
+	Price       *float64
+	Currency    string
+}
+
+// Violation describes a filter rule the subject does not satisfy
+type Violation struct {
+	RuleID   uint                  `json:"rule_id"`
+	RuleName string                `json:"rule_name"`
+	RuleType models.FilterRuleType `json:"rule_type"`
+	Action   models.FilterAction   `json:"action"`
+	Message  string                `json:"message"`
+}
+
+// Result is the outcome of evaluating all active rules
+type Result struct {
+	Blocked    bool        `json:"blocked"`
+	Flagged    bool        `json:"flagged"`
+	Violations []Violation `json:"violations"`
+}
+
+// Messages returns the violation messages in evaluation order
+func (r *Result) Messages() []string {
+	messages := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		messages[i] = v.Message
+	}
+	return messages
+}
+
+// Evaluator checks product metadata against the admin-defined filter rules
+type Evaluator struct {
+	db        *gorm.DB
+	converter *currency.Converter
+}
+
+// NewEvaluator creates a new filter rule evaluator
+func NewEvaluator(db *gorm.DB, converter *currency.Converter) *Evaluator {
+	return &Evaluator{db: db, converter: converter}
+}
+
+// Evaluate checks the subject against all active rules in priority order
+func (e *Evaluator) Evaluate(subject Subject) (*Result, error) {
+	var rules []models.FilterRule
+	if err := e.db.Where("is_active = ?", true).Order("priority DESC, id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	price, err := e.basePrice(&subject, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Violations: []Violation{}}
+	var allowedCategories []*models.FilterRule
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.RuleType == models.FilterCategoryAllow {
+			allowedCategories = append(allowedCategories, rule)
+			continue
+		}
+		if msg := e.check(rule, &subject, price); msg != "" {
+			result.add(rule, msg)
+		}
+	}
+
+	// Allow rules form a whitelist: the category must match at least one.
+	// Products whose category could not be extracted are not restricted.
+	if len(allowedCategories) > 0 && subject.Category != "" {
+		allowed := false
+		for _, rule := range allowedCategories {
+			if containsFold(subject.Category, rule.Value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			result.add(allowedCategories[0], fmt.Sprintf("Category %q is not in the allowed categories", subject.Category))
+		}
+	}
+
+	return result, nil
+}
+
+func (r *Result) add(rule *models.FilterRule, message string) {
+	action := rule.Action
+	if action == "" {
+		action = models.FilterActionBlock
+	}
+
+	r.Violations = append(r.Violations, Violation{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		RuleType: rule.RuleType,
+		Action:   action,
+		Message:  message,
+	})
+
+	if action == models.FilterActionBlock {
+		r.Blocked = true
+	} else {
+		r.Flagged = true
+	}
+}
+
+// basePrice returns the subject's price in the base currency when a price
+// rule is active, or nil when the price is unknown or cannot be converted
+func (e *Evaluator) basePrice(subject *Subject, rules []models.FilterRule) (*float64, error) {
+	if subject.Price == nil {
+		return nil, nil
+	}
+	for i := range rules {
+		if rules[i].RuleType != models.FilterPriceMax && rules[i].RuleType != models.FilterPriceMin {
+			continue
+		}
+		rate, err := e.converter.Rate(e.db, subject.Currency, time.Now())
+		if errors.Is(err, currency.ErrRateNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		price := *subject.Price * rate
+		return &price, nil
+	}
+	return nil, nil
+}
+
+// check returns a violation message if the subject breaks the rule. Price
+// limits are in the base currency and compared with price, the subject's
+// price converted to it; a price that cannot be converted breaks them.
+func (e *Evaluator) check(rule *models.FilterRule, subject *Subject, price *float64) string {
+	switch rule.RuleType {
+	case models.FilterPriceMax, models.FilterPriceMin:
+		limit, err := strconv.ParseFloat(strings.TrimSpace(rule.Value), 64)
+		if err != nil || subject.Price == nil {
+			return ""
+		}
+		base := e.converter.Base()
+		if price == nil {
+			return fmt.Sprintf("No exchange rate is available to compare the price in %s with the limit in %s", currency.Code(subject.Currency), base)
+		}
+		if rule.RuleType == models.FilterPriceMax && *price > limit {
+			return fmt.Sprintf("Price %.2f %s exceeds the maximum of %.2f %s", *price, base, limit, base)
+		}
+		if rule.RuleType == models.FilterPriceMin && *price < limit {
+			return fmt.Sprintf("Price %.2f %s is below the minimum of %.2f %s", *price, base, limit, base)
+		}
+	case models.FilterCategoryBlock:
+		if subject.Category != "" && containsFold(subject.Category, rule.Value) {
+			return fmt.Sprintf("Category %q is blocked", rule.Value)
+		}
+	case models.FilterSupplierBlock:
+		if containsFold(subject.SiteName, rule.Value) || containsFold(hostOf(subject.URL), rule.Value) {
+			return fmt.Sprintf("Supplier %q is blocked", rule.Value)
+		}
+	case models.FilterKeywordBlock:
+		if containsFold(subject.Title, rule.Value) || containsFold(subject.Description, rule.Value) {
+			return fmt.Sprintf("Keyword %q is blocked", rule.Value)
+		}
+	}
+	return ""
+}
+
+func containsFold(s, substr string) bool {
+	substr = strings.TrimSpace(substr)
+	if s == "" || substr == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
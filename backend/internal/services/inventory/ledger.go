@@ -0,0 +1,199 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var (
+	ErrInvalidMovement   = errors.New("invalid stock movement")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// Movement describes a stock change to record in the ledger
+type Movement struct {
+	ProductID uint
+	Type      models.StockMovementType
+	Quantity  int // Units moved; signed for adjustments
+	Reason    string
+	Location  string // Destination of a transfer
+	UserID    *uint
+	RequestID *uint
+}
+
+// stockChange returns the signed effect of the movement on stock
+func (m Movement) stockChange() (int, error) {
+	switch m.Type {
+	case models.MovementReceipt, models.MovementReturn:
+		if m.Quantity <= 0 {
+			return 0, fmt.Errorf("%w: %s quantity must be positive", ErrInvalidMovement, m.Type)
+		}
+		return m.Quantity, nil
+	case models.MovementIssue:
+		if m.Quantity <= 0 {
+			return 0, fmt.Errorf("%w: issue quantity must be positive", ErrInvalidMovement)
+		}
+		return -m.Quantity, nil
+	case models.MovementAdjustment:
+		if m.Quantity == 0 {
+			return 0, fmt.Errorf("%w: adjustment quantity cannot be zero", ErrInvalidMovement)
+		}
+		return m.Quantity, nil
+	case models.MovementTransfer:
+		if m.Location == "" {
+			return 0, fmt.Errorf("%w: transfer needs a destination location", ErrInvalidMovement)
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%w: unknown type %q", ErrInvalidMovement, m.Type)
+}
+
+// Record applies a movement to the product's stock and appends it to the
+// ledger. Stock cannot be taken below what is reserved for approved requests.
+func (s *Service) Record(tx *gorm.DB, m Movement) (*models.StockMovement, error) {
+	change, err := m.stockChange()
+	if err != nil {
+		return nil, err
+	}
+
+	// Unscoped so stock reserved before a product was deleted can still be issued
+	var product models.Product
+	if err := tx.Unscoped().First(&product, m.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	movement := models.StockMovement{
+		ProductID:   product.ID,
+		Type:        m.Type,
+		Quantity:    m.Quantity,
+		StockChange: change,
+		Reason:      m.Reason,
+		UserID:      m.UserID,
+		RequestID:   m.RequestID,
+	}
+	if movement.Quantity < 0 {
+		movement.Quantity = -movement.Quantity
+	}
+
+	if m.Type == models.MovementTransfer {
+		movement.Quantity = product.Stock
+		movement.FromLocation = product.Location
+		movement.ToLocation = m.Location
+		if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", product.ID).Update("location", m.Location).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		// Conditional update so concurrent movements cannot take reserved stock
+		result := tx.Unscoped().Model(&models.Product{}).
+			Where("id = ? AND stock + ? >= reserved_stock", product.ID, change).
+			Update("stock", gorm.Expr("stock + ?", change))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrInsufficientStock
+		}
+	}
+
+	if err := tx.Unscoped().Select("stock").First(&product, product.ID).Error; err != nil {
+		return nil, err
+	}
+	movement.StockAfter = product.Stock
+	movement.StockBefore = product.Stock - change
+
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// SetStock records the adjustment that brings a product to a counted stock level.
+// Returns nil if the stock is already at that level.
+func (s *Service) SetStock(tx *gorm.DB, productID uint, stock int, reason string, userID *uint) (*models.StockMovement, error) {
+	var product models.Product
+	if err := tx.Select("id", "stock").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	if product.Stock == stock {
+		return nil, nil
+	}
+
+	return s.Record(tx, Movement{
+		ProductID: productID,
+		Type:      models.MovementAdjustment,
+		Quantity:  stock - product.Stock,
+		Reason:    reason,
+		UserID:    userID,
+	})
+}
+
+// LedgerStock returns the stock level derived from a product's movements
+func (s *Service) LedgerStock(tx *gorm.DB, productID uint) (int, error) {
+	var total int
+	err := tx.Model(&models.StockMovement{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(stock_change), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// Reconcile resets a product's stock to the level derived from its ledger.
+// Returns the ledger stock and whether the product had drifted from it, or
+// ErrInsufficientStock when the ledger stock is below the reserved stock.
+func (s *Service) Reconcile(tx *gorm.DB, productID uint) (int, bool, error) {
+	var product models.Product
+	if err := tx.Select("id", "stock").First(&product, productID).Error; err != nil {
+		return 0, false, err
+	}
+
+	ledgerStock, err := s.LedgerStock(tx, productID)
+	if err != nil {
+		return 0, false, err
+	}
+	if ledgerStock == product.Stock {
+		return ledgerStock, false, nil
+	}
+
+	// Conditional update so stock reserved for approved requests is never lost
+	result := tx.Model(&models.Product{}).
+		Where("id = ? AND reserved_stock <= ?", productID, ledgerStock).
+		Update("stock", ledgerStock)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ledgerStock, false, ErrInsufficientStock
+	}
+	return ledgerStock, true, nil
+}
+
+// OpenBalances records an opening balance adjustment for products whose stock
+// predates the ledger, so the ledger accounts for all existing stock
+func OpenBalances(db *gorm.DB) (int, error) {
+	var products []models.Product
+	err := db.Unscoped().
+		Select("id", "stock").
+		Where("stock <> 0 AND id NOT IN (?)", db.Model(&models.StockMovement{}).Select("product_id")).
+		Find(&products).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, product := range products {
+		movement := models.StockMovement{
+			ProductID:   product.ID,
+			Type:        models.MovementAdjustment,
+			Quantity:    product.Stock,
+			StockChange: product.Stock,
+			StockAfter:  product.Stock,
+			Reason:      "Opening balance",
+		}
+		if err := db.Create(&movement).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(products), nil
+}
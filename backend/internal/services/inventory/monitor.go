@@ -0,0 +1,306 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/jobs"
+)
+
+var (
+	ErrAlertResolved    = errors.New("stock alert is resolved")
+	ErrAlreadyRequested = errors.New("replenishment already requested")
+	ErrNothingToReorder = errors.New("product is at or above its maximum stock")
+	ErrNoRequester      = errors.New("no active admin or supply chain manager to raise the request")
+)
+
+// ReplenishmentStep routes replenishment requests to the supply chain manager
+var ReplenishmentStep = models.ApprovalRuleStep{
+	Level:        1,
+	Name:         "Supply Chain Manager approval",
+	ApproverRole: models.RoleSupplyChainManager,
+}
+
+// MonitorConfig configures the low stock monitor
+type MonitorConfig struct {
+	Interval      time.Duration
+	AutoReplenish bool // Raise replenishment requests up to MaxStock for new alerts
+}
+
+// CheckResult summarizes one low stock check
+type CheckResult struct {
+	Raised    int `json:"raised"`
+	Resolved  int `json:"resolved"`
+	Requested int `json:"requested"`
+}
+
+// Monitor periodically compares available stock with each product's minimum,
+// recording alerts and optionally raising replenishment requests
+type Monitor struct {
+	db        *gorm.DB
+	chainSvc  *approval.ChainService
+	converter *currency.Converter
+	jobQueue  *jobs.Queue
+	events    *events.Dispatcher
+	cfg       MonitorConfig
+
+	mu     sync.Mutex // Serializes checks
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor creates a new low stock monitor
+func NewMonitor(db *gorm.DB, chainSvc *approval.ChainService, converter *currency.Converter, jobQueue *jobs.Queue, dispatcher *events.Dispatcher, cfg MonitorConfig) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	return &Monitor{
+		db:        db,
+		chainSvc:  chainSvc,
+		converter: converter,
+		jobQueue:  jobQueue,
+		events:    dispatcher,
+		cfg:       cfg,
+	}
+}
+
+// Start runs a check immediately and then on every interval
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if result, err := m.Check(); err != nil {
+				log.Printf("Low stock check failed: %v", err)
+			} else if result.Raised > 0 || result.Resolved > 0 || result.Requested > 0 {
+				log.Printf("Low stock check: %d alerts raised, %d resolved, %d replenishment requests", result.Raised, result.Resolved, result.Requested)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("Low stock monitor started (every %s)", m.cfg.Interval)
+}
+
+// Stop stops the monitor and waits for a running check to finish
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+// Check raises alerts for products that fell below their minimum stock and
+// resolves alerts for products that recovered
+func (m *Monitor) Check() (*CheckResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := &CheckResult{}
+
+	var openAlerts []models.StockAlert
+	if err := m.db.Where("status = ?", models.StockAlertOpen).Find(&openAlerts).Error; err != nil {
+		return nil, err
+	}
+	alerted := make(map[uint]*models.StockAlert, len(openAlerts))
+	for i := range openAlerts {
+		alerted[openAlerts[i].ProductID] = &openAlerts[i]
+	}
+
+	var lowProducts []models.Product
+	err := m.db.
+		Where("is_active = ? AND min_stock > 0 AND stock - reserved_stock < min_stock", true).
+		Find(&lowProducts).Error
+	if err != nil {
+		return nil, err
+	}
+	low := make(map[uint]bool, len(lowProducts))
+
+	for i := range lowProducts {
+		product := &lowProducts[i]
+		low[product.ID] = true
+
+		// Open alerts whose replenishment could not be raised are retried
+		alert := alerted[product.ID]
+		if alert == nil {
+			alert = &models.StockAlert{
+				ProductID:      product.ID,
+				Status:         models.StockAlertOpen,
+				AvailableStock: product.AvailableStock(),
+				MinStock:       product.MinStock,
+				MaxStock:       product.MaxStock,
+			}
+			if err := m.db.Create(alert).Error; err != nil {
+				return result, err
+			}
+			result.Raised++
+		}
+
+		if m.cfg.AutoReplenish && alert.ReplenishmentRequestID == nil && product.MaxStock > product.AvailableStock() {
+			if _, err := m.Replenish(alert.ID); err != nil {
+				log.Printf("Failed to raise replenishment request for %s: %v", product.SKU, err)
+				continue
+			}
+			result.Requested++
+		}
+	}
+
+	// Resolve alerts whose product recovered, was deactivated or deleted
+	now := time.Now()
+	for _, alert := range openAlerts {
+		if low[alert.ProductID] {
+			continue
+		}
+		err := m.db.Model(&models.StockAlert{}).Where("id = ?", alert.ID).Updates(map[string]interface{}{
+			"status":      models.StockAlertResolved,
+			"resolved_at": now,
+		}).Error
+		if err != nil {
+			return result, err
+		}
+		result.Resolved++
+	}
+
+	return result, nil
+}
+
+// Replenish raises a purchase request restocking the alerted product up to its
+// maximum stock. The request is approved by the supply chain manager.
+func (m *Monitor) Replenish(alertID uint) (*models.PurchaseRequest, error) {
+	var alert models.StockAlert
+	if err := m.db.Preload("Product").First(&alert, alertID).Error; err != nil {
+		return nil, err
+	}
+	if !alert.IsOpen() {
+		return nil, ErrAlertResolved
+	}
+	if alert.ReplenishmentRequestID != nil {
+		return nil, ErrAlreadyRequested
+	}
+	if alert.Product == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	product := alert.Product
+	quantity := product.MaxStock - product.AvailableStock()
+	if quantity <= 0 {
+		return nil, ErrNothingToReorder
+	}
+
+	requester, err := m.replenishmentRequester()
+	if err != nil {
+		return nil, err
+	}
+
+	price := product.Price
+	code := currency.Code(product.Currency)
+	item := models.RequestItem{
+		LineNumber:      1,
+		ProductID:       &product.ID,
+		SKU:             product.SKU,
+		ProductTitle:    product.Name,
+		ProductImageURL: product.ImageURL,
+		SiteName:        product.Supplier,
+		Quantity:        quantity,
+		UnitPrice:       &price,
+		Currency:        code,
+	}
+	item.LineTotal = item.Subtotal()
+
+	request := models.PurchaseRequest{
+		RequestNumber:      models.GenerateRequestNumber(m.db),
+		ProductTitle:       product.Name,
+		ProductImageURL:    product.ImageURL,
+		ProductDescription: product.Description,
+		SiteName:           product.Supplier,
+		EstimatedPrice:     &price,
+		Currency:           code,
+		Quantity:           quantity,
+		Justification: fmt.Sprintf("Automatic replenishment: %s (%s) has %d available, minimum %d. Restocking to %d.",
+			product.Name, product.SKU, product.AvailableStock(), product.MinStock, product.MaxStock),
+		RequesterID:     requester.ID,
+		Status:          models.StatusPending,
+		IsReplenishment: true,
+		Items:           []models.RequestItem{item},
+	}
+	request.RecalculateTotal()
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := m.converter.NormalizeRequired(tx, &request, time.Now()); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+
+		comment := fmt.Sprintf("Replenishment request created for low stock of %s", product.SKU)
+		history := models.NewHistory(request.ID, requester.ID, models.ActionCreated, "", models.StatusPending, comment)
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := m.chainSvc.StartChainWithSteps(tx, &request, []models.ApprovalRuleStep{ReplenishmentStep}); err != nil {
+			return err
+		}
+
+		if err := m.events.Dispatch(tx, events.Event{Type: events.RequestCreated, Request: &request, ActorID: requester.ID}); err != nil {
+			return err
+		}
+
+		// Conditional update so concurrent calls cannot raise two requests
+		result := tx.Model(&models.StockAlert{}).
+			Where("id = ? AND replenishment_request_id IS NULL", alert.ID).
+			Update("replenishment_request_id", request.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyRequested
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.jobQueue.Wake()
+
+	return &request, nil
+}
+
+// replenishmentRequester returns the user replenishment requests are raised on
+// behalf of: the first active admin, so that any supply chain manager can
+// approve them, or else the first supply chain manager, leaving them to the
+// others
+func (m *Monitor) replenishmentRequester() (*models.User, error) {
+	for _, role := range []models.UserRole{models.RoleAdmin, models.RoleSupplyChainManager} {
+		var user models.User
+		err := m.db.Where("role = ? AND status = ?", role, "active").Order("id ASC").First(&user).Error
+		if err == nil {
+			return &user, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, ErrNoRequester
+}
@@ -0,0 +1,171 @@
+package inventory
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// Service reserves and issues catalog stock for purchase requests
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new inventory service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Reservation summarizes how the catalog lines of a request are supplied
+type Reservation struct {
+	Reserved int // Lines supplied from stock
+	External int // Lines that must be bought because stock is insufficient
+}
+
+// Message describes the reservation for the request history
+func (r *Reservation) Message() string {
+	if r.External == 0 {
+		return fmt.Sprintf("%d catalog line(s) reserved from stock", r.Reserved)
+	}
+	return fmt.Sprintf("%d catalog line(s) reserved from stock, %d to be purchased externally", r.Reserved, r.External)
+}
+
+// catalogItems loads the catalog lines of a request
+func catalogItems(tx *gorm.DB, requestID uint) ([]models.RequestItem, error) {
+	var items []models.RequestItem
+	err := tx.Where("request_id = ? AND product_id IS NOT NULL", requestID).Order("line_number ASC").Find(&items).Error
+	return items, err
+}
+
+// Reserve holds stock for each catalog line of an approved request. Lines whose
+// full quantity is not available fall back to an external purchase. Returns nil
+// if the request has no catalog lines.
+func (s *Service) Reserve(tx *gorm.DB, request *models.PurchaseRequest) (*Reservation, error) {
+	if request.IsReplenishment {
+		return nil, nil
+	}
+
+	items, err := catalogItems(tx, request.ID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	reservation := &Reservation{}
+	for _, item := range items {
+		if item.FulfillmentStatus != "" {
+			continue
+		}
+
+		// Conditional update so concurrent approvals cannot oversell
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND stock - reserved_stock >= ?", *item.ProductID, item.Quantity).
+			Update("reserved_stock", gorm.Expr("reserved_stock + ?", item.Quantity))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		updates := map[string]interface{}{"fulfillment_status": models.FulfillmentExternal}
+		if result.RowsAffected == 1 {
+			updates = map[string]interface{}{
+				"fulfillment_status": models.FulfillmentReserved,
+				"reserved_quantity":  item.Quantity,
+			}
+			reservation.Reserved++
+		} else {
+			reservation.External++
+		}
+
+		if err := tx.Model(&models.RequestItem{}).Where("id = ?", item.ID).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return reservation, nil
+}
+
+// Issue hands out the reserved stock of a fulfilled request, recording a stock
+// movement per line. Returns the number of lines issued.
+func (s *Service) Issue(tx *gorm.DB, request *models.PurchaseRequest, userID uint) (int, error) {
+	items, err := catalogItems(tx, request.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	issued := 0
+	for _, item := range items {
+		if item.FulfillmentStatus != models.FulfillmentReserved {
+			continue
+		}
+
+		// Release the reservation, then issue the stock it held
+		quantity := item.ReservedQuantity
+		err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", *item.ProductID).
+			Update("reserved_stock", gorm.Expr("reserved_stock - ?", quantity)).Error
+		if err != nil {
+			return issued, err
+		}
+
+		_, err = s.Record(tx, Movement{
+			ProductID: *item.ProductID,
+			Type:      models.MovementIssue,
+			Quantity:  quantity,
+			Reason:    fmt.Sprintf("Issued for request %s", request.RequestNumber),
+			UserID:    &userID,
+			RequestID: &request.ID,
+		})
+		if err != nil {
+			return issued, err
+		}
+
+		err = tx.Model(&models.RequestItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"fulfillment_status": models.FulfillmentIssued,
+			"reserved_quantity":  0,
+		}).Error
+		if err != nil {
+			return issued, err
+		}
+		issued++
+	}
+
+	return issued, nil
+}
+
+// Receive books the catalog lines of a purchased replenishment request into
+// stock, recording a receipt movement per line. Returns the number of lines received.
+func (s *Service) Receive(tx *gorm.DB, request *models.PurchaseRequest, userID uint) (int, error) {
+	if !request.IsReplenishment {
+		return 0, nil
+	}
+
+	items, err := catalogItems(tx, request.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	received := 0
+	for _, item := range items {
+		if item.FulfillmentStatus == models.FulfillmentReceived {
+			continue
+		}
+
+		_, err := s.Record(tx, Movement{
+			ProductID: *item.ProductID,
+			Type:      models.MovementReceipt,
+			Quantity:  item.Quantity,
+			Reason:    fmt.Sprintf("Received for replenishment request %s", request.RequestNumber),
+			UserID:    &userID,
+			RequestID: &request.ID,
+		})
+		if err != nil {
+			return received, err
+		}
+
+		if err := tx.Model(&models.RequestItem{}).Where("id = ?", item.ID).Update("fulfillment_status", models.FulfillmentReceived).Error; err != nil {
+			return received, err
+		}
+		received++
+	}
+
+	return received, nil
+}
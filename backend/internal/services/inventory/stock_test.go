@@ -0,0 +1,259 @@
+package inventory_test
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/testutil"
+)
+
+func createProduct(t *testing.T, db *gorm.DB, sku string, stock int) *models.Product {
+	t.Helper()
+	product := models.Product{SKU: sku, Name: sku, Price: 10, Currency: "MXN", IsActive: true}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if stock != 0 {
+		_, err := inventory.NewService(db).Record(db, inventory.Movement{
+			ProductID: product.ID,
+			Type:      models.MovementReceipt,
+			Quantity:  stock,
+			Reason:    "Initial stock",
+		})
+		if err != nil {
+			t.Fatalf("receive initial stock: %v", err)
+		}
+	}
+	return &product
+}
+
+// createRequest creates an approved request with a catalog line per product
+// for the given quantities
+func createRequest(t *testing.T, db *gorm.DB, quantities map[*models.Product]int) *models.PurchaseRequest {
+	t.Helper()
+	requester := models.User{Email: "requester@example.com", PasswordHash: "x", Name: "Requester", Role: models.RoleEmployee, Status: "active"}
+	if err := db.FirstOrCreate(&requester, models.User{Email: requester.Email}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	request := models.PurchaseRequest{
+		RequestNumber: models.GenerateRequestNumber(db),
+		Currency:      "MXN",
+		Quantity:      1,
+		RequesterID:   requester.ID,
+		Status:        models.StatusApproved,
+	}
+	if err := db.Create(&request).Error; err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	line := 0
+	for product, quantity := range quantities {
+		line++
+		price := product.Price
+		item := models.RequestItem{
+			RequestID:  request.ID,
+			LineNumber: line,
+			ProductID:  &product.ID,
+			Quantity:   quantity,
+			UnitPrice:  &price,
+		}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("create item: %v", err)
+		}
+	}
+	return &request
+}
+
+func loadProduct(t *testing.T, db *gorm.DB, id uint) *models.Product {
+	t.Helper()
+	var product models.Product
+	if err := db.Unscoped().First(&product, id).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	return &product
+}
+
+func TestReserveFallsBackToExternalPurchase(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	stocked := createProduct(t, db, "PEN-01", 10)
+	scarce := createProduct(t, db, "PAD-01", 2)
+	request := createRequest(t, db, map[*models.Product]int{stocked: 4, scarce: 3})
+
+	reservation, err := svc.Reserve(db, request)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reservation.Reserved != 1 || reservation.External != 1 {
+		t.Errorf("got %d reserved and %d external, want 1 and 1", reservation.Reserved, reservation.External)
+	}
+	if got := loadProduct(t, db, stocked.ID).ReservedStock; got != 4 {
+		t.Errorf("got %d reserved of stocked product, want 4", got)
+	}
+	if got := loadProduct(t, db, scarce.ID).ReservedStock; got != 0 {
+		t.Errorf("got %d reserved of scarce product, want 0", got)
+	}
+
+	var items []models.RequestItem
+	if err := db.Where("request_id = ?", request.ID).Find(&items).Error; err != nil {
+		t.Fatalf("load items: %v", err)
+	}
+	for _, item := range items {
+		want := models.FulfillmentReserved
+		if *item.ProductID == scarce.ID {
+			want = models.FulfillmentExternal
+		}
+		if item.FulfillmentStatus != want {
+			t.Errorf("got line %d %s, want %s", item.LineNumber, item.FulfillmentStatus, want)
+		}
+	}
+}
+
+func TestReserveDoesNotOversell(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 5)
+	first := createRequest(t, db, map[*models.Product]int{product: 3})
+	second := createRequest(t, db, map[*models.Product]int{product: 3})
+
+	if _, err := svc.Reserve(db, first); err != nil {
+		t.Fatalf("first Reserve: %v", err)
+	}
+	reservation, err := svc.Reserve(db, second)
+	if err != nil {
+		t.Fatalf("second Reserve: %v", err)
+	}
+	if reservation.Reserved != 0 || reservation.External != 1 {
+		t.Errorf("got %d reserved and %d external, want the line bought externally", reservation.Reserved, reservation.External)
+	}
+	if got := loadProduct(t, db, product.ID).ReservedStock; got != 3 {
+		t.Errorf("got %d reserved, want 3", got)
+	}
+}
+
+func TestReserveSkipsReplenishment(t *testing.T) {
+	db := testutil.NewDB(t)
+	product := createProduct(t, db, "PEN-01", 5)
+	request := createRequest(t, db, map[*models.Product]int{product: 3})
+	request.IsReplenishment = true
+
+	reservation, err := inventory.NewService(db).Reserve(db, request)
+	if err != nil || reservation != nil {
+		t.Errorf("got %v, %v, want no reservation", reservation, err)
+	}
+}
+
+func TestIssueReleasesReservationIntoLedger(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 10)
+	request := createRequest(t, db, map[*models.Product]int{product: 4})
+
+	if _, err := svc.Reserve(db, request); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	issued, err := svc.Issue(db, request, request.RequesterID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if issued != 1 {
+		t.Fatalf("got %d lines issued, want 1", issued)
+	}
+
+	got := loadProduct(t, db, product.ID)
+	if got.Stock != 6 || got.ReservedStock != 0 {
+		t.Errorf("got stock %d reserved %d, want 6 and 0", got.Stock, got.ReservedStock)
+	}
+	ledger, err := svc.LedgerStock(db, product.ID)
+	if err != nil {
+		t.Fatalf("LedgerStock: %v", err)
+	}
+	if ledger != got.Stock {
+		t.Errorf("got ledger stock %d, want %d", ledger, got.Stock)
+	}
+
+	// Issuing again must not hand out the stock twice
+	if issued, err := svc.Issue(db, request, request.RequesterID); err != nil || issued != 0 {
+		t.Errorf("second Issue: got %d, %v, want nothing issued", issued, err)
+	}
+}
+
+func TestRecordCannotTakeReservedStock(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 10)
+	request := createRequest(t, db, map[*models.Product]int{product: 8})
+
+	if _, err := svc.Reserve(db, request); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	_, err := svc.Record(db, inventory.Movement{ProductID: product.ID, Type: models.MovementIssue, Quantity: 3})
+	if !errors.Is(err, inventory.ErrInsufficientStock) {
+		t.Errorf("issue into reserved stock: got %v, want ErrInsufficientStock", err)
+	}
+	if _, err := svc.SetStock(db, product.ID, 7, "Count", nil); !errors.Is(err, inventory.ErrInsufficientStock) {
+		t.Errorf("count below reserved stock: got %v, want ErrInsufficientStock", err)
+	}
+	if got := loadProduct(t, db, product.ID).Stock; got != 10 {
+		t.Errorf("got stock %d, want 10", got)
+	}
+}
+
+func TestRecordRejectsInvalidMovements(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 10)
+
+	movements := []inventory.Movement{
+		{ProductID: product.ID, Type: models.MovementReceipt, Quantity: 0},
+		{ProductID: product.ID, Type: models.MovementIssue, Quantity: -1},
+		{ProductID: product.ID, Type: models.MovementAdjustment, Quantity: 0},
+		{ProductID: product.ID, Type: models.MovementTransfer},
+		{ProductID: product.ID, Type: "unknown", Quantity: 1},
+	}
+	for _, m := range movements {
+		if _, err := svc.Record(db, m); !errors.Is(err, inventory.ErrInvalidMovement) {
+			t.Errorf("%s of %d: got %v, want ErrInvalidMovement", m.Type, m.Quantity, err)
+		}
+	}
+}
+
+func TestReconcileRestoresLedgerStock(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 10)
+
+	if err := db.Model(product).Update("stock", 15).Error; err != nil {
+		t.Fatalf("drift stock: %v", err)
+	}
+	stock, drifted, err := svc.Reconcile(db, product.ID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if stock != 10 || !drifted {
+		t.Errorf("got stock %d drifted %v, want 10 and drifted", stock, drifted)
+	}
+	if got := loadProduct(t, db, product.ID).Stock; got != 10 {
+		t.Errorf("got product stock %d, want 10", got)
+	}
+}
+
+func TestReconcileKeepsReservedStock(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := inventory.NewService(db)
+	product := createProduct(t, db, "PEN-01", 10)
+
+	if err := db.Model(product).Updates(map[string]interface{}{"stock": 20, "reserved_stock": 15}).Error; err != nil {
+		t.Fatalf("drift stock: %v", err)
+	}
+	if _, _, err := svc.Reconcile(db, product.ID); !errors.Is(err, inventory.ErrInsufficientStock) {
+		t.Errorf("got %v, want ErrInsufficientStock", err)
+	}
+	if got := loadProduct(t, db, product.ID).Stock; got != 20 {
+		t.Errorf("got product stock %d, want 20", got)
+	}
+}
@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/events"
+	"vista-backend/pkg/crypto"
+)
+
+// AmazonCartPayload is the payload of an Amazon add-to-cart job
+type AmazonCartPayload struct {
+	RequestID uint `json:"request_id"`
+}
+
+// EnqueueAmazonCart queues adding an approved request's Amazon products to the Amazon cart
+func (q *Queue) EnqueueAmazonCart(tx *gorm.DB, requestID uint) (*models.Job, error) {
+	return q.Enqueue(tx, models.JobTypeAmazonCart, &requestID, AmazonCartPayload{RequestID: requestID})
+}
+
+// ActiveJobForRequest returns the queued or running job of the given type for a request, if any
+func (q *Queue) ActiveJobForRequest(jobType string, requestID uint) (*models.Job, error) {
+	var job models.Job
+	found := q.db.
+		Where("type = ? AND request_id = ? AND status IN ?", jobType, requestID, []models.JobStatus{models.JobQueued, models.JobRunning}).
+		Limit(1).
+		Find(&job)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// NewAmazonCartHandler returns the job handler that adds a request's Amazon
+// products to the Amazon cart. It dispatches cart.added once they are all in
+// the cart and cart.failed when the job gives up.
+func NewAmazonCartHandler(db *gorm.DB, amazonSvc *amazon.AutomationService, encryptionSvc *crypto.EncryptionService, dispatcher *events.Dispatcher) HandlerFunc {
+	return func(ctx context.Context, job *models.Job) error {
+		var payload AmazonCartPayload
+		if err := job.DecodePayload(&payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+
+		var request models.PurchaseRequest
+		if err := db.First(&request, payload.RequestID).Error; err != nil {
+			return Permanent(fmt.Errorf("request %d not found: %w", payload.RequestID, err))
+		}
+
+		if request.AddedToCart {
+			return nil
+		}
+		if request.Status != models.StatusApproved {
+			return Permanent(fmt.Errorf("request %d is %s, only approved requests can be added to cart", request.ID, request.Status))
+		}
+
+		var items []models.RequestItem
+		if err := db.Where("request_id = ?", request.ID).Order("line_number ASC").Find(&items).Error; err != nil {
+			return err
+		}
+
+		err := addToCart(ctx, db, amazonSvc, encryptionSvc, &request, items)
+		if err != nil {
+			setCartError(db, request.ID, err.Error())
+			request.CartError = err.Error()
+			if IsFinalAttempt(job, err) {
+				dispatchCartEvent(db, dispatcher, events.CartFailed, &request, items)
+			}
+			return err
+		}
+
+		now := time.Now()
+		db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
+			"added_to_cart":    true,
+			"added_to_cart_at": now,
+			"cart_error":       "",
+		})
+		request.AddedToCart = true
+		request.AddedToCartAt = &now
+		request.CartError = ""
+		dispatchCartEvent(db, dispatcher, events.CartAdded, &request, items)
+
+		log.Printf("Successfully added request %d to Amazon cart", request.ID)
+		return nil
+	}
+}
+
+// dispatchCartEvent dispatches the outcome of adding a request to the cart.
+// The cart change is already saved, so a failing listener is only logged.
+func dispatchCartEvent(db *gorm.DB, dispatcher *events.Dispatcher, eventType events.Type, request *models.PurchaseRequest, items []models.RequestItem) {
+	request.Items = items
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return dispatcher.Dispatch(tx, events.Event{Type: eventType, Request: request, Comment: request.CartError})
+	})
+	if err != nil {
+		log.Printf("Failed to dispatch %s for request %d: %v", eventType, request.ID, err)
+	}
+}
+
+// addToCart adds every Amazon line of the request that is not in the cart yet.
+// Lines already added are skipped so retries resume where the last attempt stopped.
+func addToCart(ctx context.Context, db *gorm.DB, amazonSvc *amazon.AutomationService, encryptionSvc *crypto.EncryptionService, request *models.PurchaseRequest, items []models.RequestItem) error {
+	// Check if Amazon is configured
+	var config models.AmazonConfig
+	if err := db.First(&config).Error; err != nil || !config.CanConnect() {
+		return Permanent(errors.New("Amazon is not configured or inactive"))
+	}
+
+	// Decrypt password
+	password, err := encryptionSvc.Decrypt(config.EncryptedPassword)
+	if err != nil {
+		return Permanent(errors.New("Failed to decrypt credentials"))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	amazonSvc.SetCredentials(config.Email, password, config.Marketplace)
+
+	// Initialize browser if needed
+	if err := amazonSvc.Initialize(ctx); err != nil {
+		return fmt.Errorf("Failed to initialize browser: %w", err)
+	}
+
+	// Login if needed
+	if !amazonSvc.IsLoggedIn() {
+		if err := amazonSvc.Login(ctx); err != nil {
+			return fmt.Errorf("Failed to login: %w", err)
+		}
+	}
+
+	// Requests created before line items existed only have the header product
+	if len(items) == 0 {
+		if err := amazonSvc.AddToCart(ctx, request.URL, request.Quantity); err != nil {
+			return fmt.Errorf("Failed to add to cart: %w", err)
+		}
+		return nil
+	}
+
+	for _, item := range items {
+		if !item.IsAmazonURL || item.AddedToCart {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := amazonSvc.AddToCart(ctx, item.URL, item.Quantity); err != nil {
+			return fmt.Errorf("Failed to add line %d to cart: %w", item.LineNumber, err)
+		}
+
+		now := time.Now()
+		db.Model(&models.RequestItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"added_to_cart":    true,
+			"added_to_cart_at": now,
+		})
+	}
+	return nil
+}
+
+// setCartError updates the cart error shown on the request
+func setCartError(db *gorm.DB, requestID uint, errMsg string) {
+	db.Model(&models.PurchaseRequest{}).Where("id = ?", requestID).Updates(map[string]interface{}{
+		"cart_error": errMsg,
+	})
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/mail"
+)
+
+// EmailPayload is the payload of an email job
+type EmailPayload struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// EnqueueEmail queues sending an email, optionally about a purchase request
+func (q *Queue) EnqueueEmail(tx *gorm.DB, requestID *uint, msg mail.Message) (*models.Job, error) {
+	return q.Enqueue(tx, models.JobTypeEmail, requestID, EmailPayload{To: msg.To, Subject: msg.Subject, Body: msg.Body})
+}
+
+// NewEmailHandler returns the job handler that sends queued email
+func NewEmailHandler(mailer mail.Mailer) HandlerFunc {
+	return func(ctx context.Context, job *models.Job) error {
+		var payload EmailPayload
+		if err := job.DecodePayload(&payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		if len(payload.To) == 0 {
+			return Permanent(fmt.Errorf("email has no recipients"))
+		}
+		return mailer.Send(mail.Message{To: payload.To, Subject: payload.Subject, Body: payload.Body})
+	}
+}
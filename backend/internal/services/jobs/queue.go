@@ -0,0 +1,368 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var (
+	ErrJobNotRetryable   = errors.New("only failed or cancelled jobs can be retried")
+	ErrJobNotCancellable = errors.New("only queued jobs can be cancelled")
+	ErrJobActive         = errors.New("a job of this type is already queued or running for the request")
+)
+
+// exclusiveTypes are the job types a request may only have one active job of,
+// because running two at once would repeat their side effects
+var exclusiveTypes = map[string]bool{
+	models.JobTypeAmazonCart: true,
+}
+
+// HandlerFunc executes a job. Returning an error schedules a retry unless the
+// error is wrapped with Permanent or the job has used all its attempts.
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// Config controls the worker pool and retry behaviour
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	JobTimeout   time.Duration
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsFinalAttempt checks if a job failing with err will not be retried,
+// because err is permanent or the job has used all its attempts
+func IsFinalAttempt(job *models.Job, err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts
+}
+
+// Queue is a SQLite-backed job queue processed by a pool of workers
+type Queue struct {
+	db       *gorm.DB
+	cfg      Config
+	handlers map[string]HandlerFunc
+
+	mu     sync.Mutex // Serializes job claiming between workers
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewQueue creates a new job queue
+func NewQueue(db *gorm.DB, cfg Config) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Minute
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = 5 * time.Minute
+	}
+
+	return &Queue{
+		db:       db,
+		cfg:      cfg,
+		handlers: make(map[string]HandlerFunc),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job type. Must be called before Start.
+func (q *Queue) Register(jobType string, handler HandlerFunc) {
+	q.handlers[jobType] = handler
+}
+
+// Enqueue persists a new job using tx so it commits atomically with the
+// caller's changes. Call Wake after the transaction commits.
+func (q *Queue) Enqueue(tx *gorm.DB, jobType string, requestID *uint, payload interface{}) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobQueued,
+		MaxAttempts: q.cfg.MaxAttempts,
+		RunAt:       time.Now(),
+		RequestID:   requestID,
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Wake signals idle workers that new jobs are available
+func (q *Queue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start recovers jobs interrupted by a previous shutdown and starts the workers
+func (q *Queue) Start() {
+	result := q.db.Model(&models.Job{}).
+		Where("status = ?", models.JobRunning).
+		Updates(map[string]interface{}{
+			"status":    models.JobQueued,
+			"locked_at": nil,
+			"run_at":    time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("Failed to recover interrupted jobs: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Requeued %d jobs interrupted by shutdown", result.RowsAffected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	log.Printf("Job queue started with %d workers", q.cfg.Workers)
+}
+
+// Stop signals the workers to exit and waits for running jobs to finish
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+}
+
+// Retry requeues a failed or cancelled job with a fresh set of attempts. Jobs
+// of exclusive types are not retried while the request has another active one.
+func (q *Queue) Retry(jobID uint) (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var job models.Job
+	if err := q.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if !job.CanBeRetried() {
+		return nil, ErrJobNotRetryable
+	}
+	if exclusiveTypes[job.Type] && job.RequestID != nil {
+		active, err := q.ActiveJobForRequest(job.Type, *job.RequestID)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			return nil, ErrJobActive
+		}
+	}
+
+	// Keep the attempt count so the attempt history stays numbered in order
+	job.Status = models.JobQueued
+	job.MaxAttempts = job.Attempts + q.cfg.MaxAttempts
+	job.RunAt = time.Now()
+	job.FinishedAt = nil
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+
+	q.Wake()
+	return &job, nil
+}
+
+// Cancel cancels a queued job so it is never picked up
+func (q *Queue) Cancel(jobID uint) (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var job models.Job
+	if err := q.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if !job.CanBeCancelled() {
+		return nil, ErrJobNotCancellable
+	}
+
+	now := time.Now()
+	job.Status = models.JobCancelled
+	job.FinishedAt = &now
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain all due jobs before waiting again
+		for ctx.Err() == nil {
+			job, err := q.claim()
+			if err != nil {
+				log.Printf("Failed to claim job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			q.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// claim atomically marks the next due job as running
+func (q *Queue) claim() (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Find rather than First, which logs every idle poll as a missing record
+	var job models.Job
+	found := q.db.
+		Where("status = ? AND run_at <= ?", models.JobQueued, time.Now()).
+		Order("run_at ASC, id ASC").
+		Limit(1).
+		Find(&job)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if found.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.Status = models.JobRunning
+	job.Attempts++
+	job.LockedAt = &now
+
+	result := q.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, models.JobQueued).
+		Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": job.LockedAt,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func (q *Queue) process(ctx context.Context, job *models.Job) {
+	attempt := models.JobAttempt{
+		JobID:     job.ID,
+		Attempt:   job.Attempts,
+		Status:    models.JobRunning,
+		StartedAt: time.Now(),
+	}
+	q.db.Create(&attempt)
+
+	err := q.run(ctx, job)
+
+	now := time.Now()
+	attempt.FinishedAt = &now
+	updates := map[string]interface{}{
+		"locked_at": nil,
+	}
+
+	switch {
+	case err == nil:
+		attempt.Status = models.JobSucceeded
+		updates["status"] = models.JobSucceeded
+		updates["last_error"] = ""
+		updates["finished_at"] = now
+	case IsFinalAttempt(job, err):
+		attempt.Status = models.JobFailed
+		attempt.Error = err.Error()
+		updates["status"] = models.JobFailed
+		updates["last_error"] = err.Error()
+		updates["finished_at"] = now
+		log.Printf("Job %d (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+	default:
+		attempt.Status = models.JobFailed
+		attempt.Error = err.Error()
+		updates["status"] = models.JobQueued
+		updates["last_error"] = err.Error()
+		updates["run_at"] = now.Add(q.backoff(job.Attempts))
+		log.Printf("Job %d (%s) attempt %d failed, retrying: %v", job.ID, job.Type, job.Attempts, err)
+	}
+
+	q.db.Save(&attempt)
+	q.db.Model(&models.Job{}).Where("id = ?", job.ID).Updates(updates)
+}
+
+// run executes the job handler, converting panics into errors
+func (q *Queue) run(ctx context.Context, job *models.Job) (err error) {
+	handler, ok := q.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.JobTimeout)
+	defer cancel()
+	return handler(ctx, job)
+}
+
+// backoff returns the exponential delay before the given attempt is retried
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= q.cfg.MaxBackoff {
+			return q.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
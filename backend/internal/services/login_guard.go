@@ -0,0 +1,338 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+)
+
+// Failed logins for an email allowed before each further attempt has to wait,
+// doubling from one second up to loginMaxDelay. IPs may be shared by a whole
+// office, so their delays start at half of MaxIPFailures.
+const (
+	loginFreeFailures = 2
+	loginMaxDelay     = 30 * time.Second
+)
+
+var ErrThrottleNotFound = errors.New("login lockout not found")
+
+// LoginGuardConfig configures failed login tracking
+type LoginGuardConfig struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	FailureWindow      time.Duration
+	LockoutDuration    time.Duration
+}
+
+// LoginBlockedError is returned when a login is refused without checking the password
+type LoginBlockedError struct {
+	Locked     bool // Locked out, rather than asked to wait between attempts
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed logins, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed logins, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds returns the wait rounded up to whole seconds
+func (e *LoginBlockedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// LoginGuard tracks failed logins per email and per client IP in the database,
+// slowing down and then locking out repeated failures
+type LoginGuard struct {
+	db  *gorm.DB
+	cfg LoginGuardConfig
+}
+
+// NewLoginGuard creates a new login guard
+func NewLoginGuard(db *gorm.DB, cfg LoginGuardConfig) *LoginGuard {
+	if cfg.MaxAccountFailures <= 0 {
+		cfg.MaxAccountFailures = 5
+	}
+	if cfg.MaxIPFailures <= 0 {
+		cfg.MaxIPFailures = 20
+	}
+	if cfg.FailureWindow <= 0 {
+		cfg.FailureWindow = 15 * time.Minute
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = 15 * time.Minute
+	}
+	return &LoginGuard{db: db, cfg: cfg}
+}
+
+// loginDelay returns how long to wait after the given number of failures
+// when the first freeFailures are allowed without delay
+func loginDelay(failures, freeFailures int) time.Duration {
+	if failures <= freeFailures {
+		return 0
+	}
+	exponent := failures - freeFailures - 1
+	if exponent >= 5 {
+		return loginMaxDelay
+	}
+	delay := time.Second << exponent
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// throttleKeys returns the account and IP keys of a login attempt
+func throttleKeys(email, ip string) map[models.LoginThrottleKind]string {
+	return map[models.LoginThrottleKind]string{
+		models.LoginThrottleAccount: strings.ToLower(strings.TrimSpace(email)),
+		models.LoginThrottleIP:      ip,
+	}
+}
+
+// freeFailures returns the failures allowed before delays start
+func (g *LoginGuard) freeFailures(kind models.LoginThrottleKind) int {
+	if kind == models.LoginThrottleIP && g.cfg.MaxIPFailures/2 > loginFreeFailures {
+		return g.cfg.MaxIPFailures / 2
+	}
+	return loginFreeFailures
+}
+
+// stale checks if the throttle's failures are old enough to be forgotten
+func (g *LoginGuard) stale(t *models.LoginThrottle, now time.Time) bool {
+	if t.LockedUntil != nil {
+		return !t.IsLocked(now)
+	}
+	return t.LastFailureAt == nil || now.Sub(*t.LastFailureAt) > g.cfg.FailureWindow
+}
+
+// maxFailures returns the failures that lock out an email or IP
+func (g *LoginGuard) maxFailures(kind models.LoginThrottleKind) int {
+	if kind == models.LoginThrottleIP {
+		return g.cfg.MaxIPFailures
+	}
+	return g.cfg.MaxAccountFailures
+}
+
+// blockedBy returns why the throttle refuses a login attempt now, or nil.
+// Attempts counted by Begin that would reach the lockout are still being
+// verified, so further attempts wait for their outcome.
+func (g *LoginGuard) blockedBy(t *models.LoginThrottle, now time.Time) *LoginBlockedError {
+	if g.stale(t, now) {
+		return nil
+	}
+	if t.IsLocked(now) {
+		return &LoginBlockedError{Locked: true, RetryAfter: t.LockedUntil.Sub(now)}
+	}
+	if t.Failures >= g.maxFailures(t.Kind) {
+		return &LoginBlockedError{RetryAfter: time.Second}
+	}
+	if wait := t.LastFailureAt.Add(loginDelay(t.Failures, g.freeFailures(t.Kind))).Sub(now); wait > 0 {
+		return &LoginBlockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Check refuses a login attempt for a locked out email or IP, or one that came
+// too soon after the previous failure
+func (g *LoginGuard) Check(email string, client ClientInfo) error {
+	var throttles []models.LoginThrottle
+	query := g.db.Where("1 = 0")
+	for kind, identifier := range throttleKeys(email, client.IPAddress) {
+		query = query.Or("kind = ? AND identifier = ?", kind, identifier)
+	}
+	if err := query.Find(&throttles).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var blocked *LoginBlockedError
+	for i := range throttles {
+		candidate := g.blockedBy(&throttles[i], now)
+		if candidate != nil && (blocked == nil || candidate.RetryAfter > blocked.RetryAfter) {
+			blocked = candidate
+		}
+	}
+
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// Begin checks a login attempt like Check and, unless it is refused, counts
+// it as a failure of the email and the client IP before the password or code
+// is verified, so that parallel attempts cannot all pass the check. The
+// attempt is then settled with RecordFailure, or with Cancel if it did not
+// fail.
+func (g *LoginGuard) Begin(email string, client ClientInfo) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for kind, identifier := range throttleKeys(email, client.IPAddress) {
+			throttle := models.LoginThrottle{Kind: kind, Identifier: identifier}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&throttle).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("kind = ? AND identifier = ?", kind, identifier).First(&throttle).Error; err != nil {
+				return err
+			}
+
+			updates := map[string]interface{}{
+				"failures":        gorm.Expr("failures + 1"),
+				"last_failure_at": now,
+			}
+			if g.stale(&throttle, now) {
+				updates["failures"] = 1
+				updates["locked_until"] = nil
+			} else if blocked := g.blockedBy(&throttle, now); blocked != nil {
+				return blocked
+			}
+
+			// Conditional on the count read, so that of two concurrent
+			// attempts only one is let through on it
+			result := tx.Model(&models.LoginThrottle{}).
+				Where("id = ? AND failures = ?", throttle.ID, throttle.Failures).
+				Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return &LoginBlockedError{RetryAfter: time.Second}
+			}
+		}
+		return nil
+	})
+}
+
+// Cancel takes back the failure Begin counted for an attempt that did not
+// fail, such as one with the right password
+func (g *LoginGuard) Cancel(email string, client ClientInfo) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		for kind, identifier := range throttleKeys(email, client.IPAddress) {
+			err := tx.Model(&models.LoginThrottle{}).
+				Where("kind = ? AND identifier = ? AND failures > 0", kind, identifier).
+				Update("failures", gorm.Expr("failures - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordFailure settles an attempt counted by Begin as a failed login of the
+// email and the client IP, locking out either that reached its maximum.
+// Returns a LoginBlockedError if the failure locked either of them out.
+func (g *LoginGuard) RecordFailure(email string, userID *uint, client ClientInfo) error {
+	var lockedFor time.Duration
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for kind, identifier := range throttleKeys(email, client.IPAddress) {
+			// Gone if an admin unlocked it or a login succeeded meanwhile
+			var throttle models.LoginThrottle
+			err := tx.Where("kind = ? AND identifier = ?", kind, identifier).First(&throttle).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if kind == models.LoginThrottleAccount && userID != nil {
+				throttle.UserID = userID
+				if err := tx.Model(&throttle).Update("user_id", userID).Error; err != nil {
+					return err
+				}
+			}
+			if throttle.Failures < g.maxFailures(kind) || throttle.LockedUntil != nil {
+				continue
+			}
+
+			// Conditional so concurrent failures lock it out once
+			lockedUntil := now.Add(g.cfg.LockoutDuration)
+			result := tx.Model(&models.LoginThrottle{}).
+				Where("id = ? AND locked_until IS NULL", throttle.ID).
+				Update("locked_until", lockedUntil)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			throttle.LockedUntil = &lockedUntil
+			lockedFor = g.cfg.LockoutDuration
+
+			var auditUserID uint
+			if throttle.UserID != nil {
+				auditUserID = *throttle.UserID
+			}
+			err = audit.Record(tx, audit.Entry{
+				UserID:     auditUserID,
+				Action:     models.AuditActionLockout,
+				Resource:   models.AuditResourceLoginThrottle,
+				ResourceID: throttle.ID,
+				After:      throttle,
+				IPAddress:  client.IPAddress,
+				UserAgent:  client.UserAgent,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if lockedFor > 0 {
+		return &LoginBlockedError{Locked: true, RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// RecordSuccess clears the failed logins of an email after a successful login.
+// IP failures are kept so a valid account cannot be used to reset them.
+func (g *LoginGuard) RecordSuccess(email string) error {
+	return g.db.
+		Where("kind = ? AND identifier = ?", models.LoginThrottleAccount, throttleKeys(email, "")[models.LoginThrottleAccount]).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+// Unlock clears the failed logins and any lockout of an email or IP,
+// returning the throttle as it was
+func (g *LoginGuard) Unlock(tx *gorm.DB, id uint) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := tx.First(&throttle, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrThrottleNotFound
+		}
+		return nil, err
+	}
+	if err := tx.Delete(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// UnlockAccount clears the failed logins and any lockout of a user's email
+func (g *LoginGuard) UnlockAccount(tx *gorm.DB, user *models.User) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := tx.Where("kind = ? AND identifier = ?", models.LoginThrottleAccount, strings.ToLower(user.Email)).First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrThrottleNotFound
+		}
+		return nil, err
+	}
+	return g.Unlock(tx, throttle.ID)
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes each message to an .eml file in a directory and logs it,
+// for local development and testing. Without a directory the whole message
+// is written to the log.
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+// NewFileMailer creates a new file mailer, creating the directory if needed
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes the message
+func (m *FileMailer) Send(msg Message) error {
+	data := format(m.from, msg)
+	to := strings.Join(msg.To, ", ")
+
+	if m.dir == "" {
+		log.Printf("Mail to %s:\n%s", to, data)
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), m.seq.Add(1)%1000)
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s: %q saved to %s", to, msg.Subject, path)
+	return nil
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+// SMTPConfig configures the SMTP server mail is relayed through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Optional; authenticates with PLAIN over TLS
+	Password string
+}
+
+// SMTPMailer sends mail through an SMTP server, upgrading to TLS when the
+// server supports STARTTLS
+type SMTPMailer struct {
+	cfg  SMTPConfig
+	from string
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+func NewSMTPMailer(cfg SMTPConfig, from string) *SMTPMailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPMailer{cfg: cfg, from: from}
+}
+
+// Send delivers the message
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+	return smtp.SendMail(addr, auth, m.from, msg.To, format(m.from, msg))
+}
@@ -3,6 +3,7 @@ package metadata
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,6 +20,7 @@ type ProductMetadata struct {
 	Price       *float64 `json:"price,omitempty"`
 	Currency    string   `json:"currency,omitempty"`
 	SiteName    string   `json:"site_name,omitempty"`
+	Category    string   `json:"category,omitempty"`
 }
 
 // Extractor extracts metadata from product URLs
@@ -83,6 +85,7 @@ func (e *Extractor) ExtractFromURL(url string) (*ProductMetadata, error) {
 		}
 	}
 	metadata.Currency = e.getMetaContent(doc, "og:price:currency")
+	metadata.Category = e.getMetaContent(doc, "product:category")
 
 	// 2. Fallback to Twitter Card tags
 	if metadata.Title == "" {
@@ -254,6 +257,11 @@ func (e *Extractor) extractAmazon(doc *goquery.Document, metadata *ProductMetada
 		}
 	}
 
+	// Category from breadcrumbs
+	if metadata.Category == "" {
+		metadata.Category = e.getBreadcrumbs(doc, "#wayfinding-breadcrumbs_feature_div ul li a")
+	}
+
 	if metadata.SiteName == "" {
 		metadata.SiteName = "Amazon"
 	}
@@ -283,6 +291,11 @@ func (e *Extractor) extractMercadoLibre(doc *goquery.Document, metadata *Product
 		}
 	}
 
+	// Category from breadcrumbs
+	if metadata.Category == "" {
+		metadata.Category = e.getBreadcrumbs(doc, ".andes-breadcrumb__item a")
+	}
+
 	if metadata.SiteName == "" {
 		metadata.SiteName = "MercadoLibre"
 	}
@@ -292,6 +305,17 @@ func (e *Extractor) extractMercadoLibre(doc *goquery.Document, metadata *Product
 	}
 }
 
+// getBreadcrumbs joins the text of breadcrumb links into a category path
+func (e *Extractor) getBreadcrumbs(doc *goquery.Document, selector string) string {
+	var parts []string
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		if text := strings.TrimSpace(s.Text()); text != "" {
+			parts = append(parts, text)
+		}
+	})
+	return strings.Join(parts, " > ")
+}
+
 // parsePrice parses a price string into a float64
 func (e *Extractor) parsePrice(priceStr string) (float64, error) {
 	// Remove currency symbols and whitespace
@@ -362,3 +386,13 @@ func (e *Extractor) cleanTitle(title, siteName string) string {
 
 	return title
 }
+
+// SiteNameFromURL returns the host of a product URL without the www prefix,
+// used when the page does not declare a site name
+func SiteNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
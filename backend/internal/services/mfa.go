@@ -0,0 +1,364 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+	"vista-backend/pkg/crypto"
+	"vista-backend/pkg/totp"
+)
+
+// Recovery codes issued per user, each 10 characters shown as xxxxx-xxxxx
+const recoveryCodeCount = 10
+
+var (
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrMFAMandatory      = errors.New("two-factor authentication is mandatory for this role")
+)
+
+// MFAConfig configures two-factor authentication
+type MFAConfig struct {
+	Enforce         bool
+	Issuer          string
+	ChallengeExpiry time.Duration
+}
+
+// MFAEnrollment is a new TOTP secret for the user to add to their authenticator
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_url"`
+}
+
+// MFAService manages TOTP enrollment and verification. Secrets are stored
+// encrypted and recovery codes as SHA-256 hashes.
+type MFAService struct {
+	db         *gorm.DB
+	encryption *crypto.EncryptionService
+	roles      *RoleService
+	cfg        MFAConfig
+}
+
+// NewMFAService creates a new two-factor authentication service
+func NewMFAService(db *gorm.DB, encryption *crypto.EncryptionService, roles *RoleService, cfg MFAConfig) *MFAService {
+	if cfg.Issuer == "" {
+		cfg.Issuer = "IRIS Vista"
+	}
+	if cfg.ChallengeExpiry <= 0 {
+		cfg.ChallengeExpiry = 5 * time.Minute
+	}
+	return &MFAService{db: db, encryption: encryption, roles: roles, cfg: cfg}
+}
+
+// Required reports whether the policy makes two-factor authentication
+// mandatory for the user, which it does for roles marked require_mfa
+func (ms *MFAService) Required(user *models.User) bool {
+	return ms.cfg.Enforce && ms.roles.RequiresMFA(user.Role)
+}
+
+// Enroll generates a new secret for the user. It is not used to log in until
+// the user confirms it with Enable.
+func (ms *MFAService) Enroll(user *models.User) (*MFAEnrollment, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := ms.encryption.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conditional update so enrollment cannot replace the secret of an enabled user
+	result := ms.db.Model(&models.User{}).Where("id = ? AND mfa_enabled = ?", user.ID, false).Updates(map[string]interface{}{
+		"mfa_secret":    encrypted,
+		"mfa_last_step": 0,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	user.MFASecret = encrypted
+	user.MFALastStep = 0
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(ms.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms enrollment with a code from the authenticator, turning on
+// two-factor authentication. Returns the user's recovery codes.
+func (ms *MFAService) Enable(user *models.User, code string, client ClientInfo) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	var codes []string
+	err := ms.db.Transaction(func(tx *gorm.DB) error {
+		if err := ms.verifyTOTP(tx, user, code); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"mfa_enabled":    true,
+			"mfa_enabled_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		user.MFAEnabled = true
+		user.MFAEnabledAt = &now
+
+		var err error
+		if codes, err = ms.replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionMFAEnable,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks the second factor of a login: a TOTP code, or else a recovery
+// code, which is used up
+func (ms *MFAService) Verify(user *models.User, code, recoveryCode string, client ClientInfo) error {
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if strings.TrimSpace(code) != "" {
+		return ms.verifyTOTP(ms.db, user, code)
+	}
+
+	return ms.db.Transaction(func(tx *gorm.DB) error {
+		remaining, err := ms.redeemRecoveryCode(tx, user.ID, recoveryCode)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionRecoveryLogin,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			After:      map[string]int64{"recovery_codes_remaining": remaining},
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+}
+
+// Disable turns off two-factor authentication after checking the user's
+// password and a current code. Not allowed where the policy requires it.
+func (ms *MFAService) Disable(user *models.User, password, code string, client ClientInfo) error {
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if ms.Required(user) {
+		return ErrMFAMandatory
+	}
+	if !VerifyPassword(password, user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	return ms.db.Transaction(func(tx *gorm.DB) error {
+		if err := ms.verifyTOTP(tx, user, code); err != nil {
+			return err
+		}
+		if err := ms.Reset(tx, user.ID); err != nil {
+			return err
+		}
+		user.MFAEnabled = false
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionMFADisable,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code
+func (ms *MFAService) RegenerateRecoveryCodes(user *models.User, code string, client ClientInfo) ([]string, error) {
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	var codes []string
+	err := ms.db.Transaction(func(tx *gorm.DB) error {
+		if err := ms.verifyTOTP(tx, user, code); err != nil {
+			return err
+		}
+
+		var err error
+		if codes, err = ms.replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionRecoveryCodes,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RecoveryCodesRemaining returns how many unused recovery codes the user has
+func (ms *MFAService) RecoveryCodesRemaining(userID uint) (int64, error) {
+	return countRecoveryCodes(ms.db, userID)
+}
+
+// Reset turns off two-factor authentication and removes the user's secret and
+// recovery codes, e.g. when an admin resets a user who lost their authenticator
+func (ms *MFAService) Reset(tx *gorm.DB, userID uint) error {
+	err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"mfa_enabled":    false,
+		"mfa_secret":     "",
+		"mfa_last_step":  0,
+		"mfa_enabled_at": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
+
+// verifyTOTP checks a code against the user's secret. Each time step is
+// accepted once, so an observed code cannot be replayed.
+func (ms *MFAService) verifyTOTP(tx *gorm.DB, user *models.User, code string) error {
+	if user.MFASecret == "" {
+		return ErrMFANotEnrolled
+	}
+	secret, err := ms.encryption.Decrypt(user.MFASecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND mfa_last_step < ?", user.ID, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	user.MFALastStep = step
+	return nil
+}
+
+// redeemRecoveryCode marks a matching unused recovery code as used and
+// returns how many remain
+func (ms *MFAService) redeemRecoveryCode(tx *gorm.DB, userID uint, code string) (int64, error) {
+	hash := hashRecoveryCode(code)
+	if hash == "" {
+		return 0, ErrInvalidMFACode
+	}
+
+	// Conditional update so a code cannot be redeemed twice concurrently
+	result := tx.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInvalidMFACode
+	}
+	return countRecoveryCodes(tx, userID)
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and issues new ones
+func (ms *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]models.MFARecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		rows[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func countRecoveryCodes(tx *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Recovery codes avoid characters that are easily confused when typed
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+	}
+	return string(buf[:5]) + "-" + string(buf[5:]), nil
+}
+
+// hashRecoveryCode normalizes a recovery code as typed and hashes it
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
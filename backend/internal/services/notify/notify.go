@@ -0,0 +1,270 @@
+// Package notify tells users about purchase request events: requesters about
+// decisions on their requests, and approvers about requests awaiting them.
+// Each notification is stored for the in-app list and emailed through the
+// job queue, as the user's preferences for its type allow.
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/internal/services/mail"
+)
+
+// Notifier is an event listener that notifies the users an event concerns.
+// The user who caused an event is never notified of it.
+type Notifier struct {
+	jobQueue *jobs.Queue
+	appURL   string // Frontend linked to from email
+}
+
+// NewNotifier creates a new notifier
+func NewNotifier(jobQueue *jobs.Queue, appURL string) *Notifier {
+	return &Notifier{jobQueue: jobQueue, appURL: strings.TrimRight(appURL, "/")}
+}
+
+// message is a notification for a set of recipients
+type message struct {
+	Type  models.NotificationType
+	Title string
+	Body  string
+	Path  string // Frontend page the email links to
+}
+
+// Handle notifies the requester and approvers concerned by the event
+func (n *Notifier) Handle(tx *gorm.DB, event *events.Event) error {
+	request := event.Request
+	requester := &request.Requester
+	if requester.ID != request.RequesterID {
+		requester = &models.User{}
+		if err := tx.Unscoped().First(requester, request.RequesterID).Error; err != nil {
+			return err
+		}
+	}
+	requesters := []models.User{*requester}
+	number := request.RequestNumber
+
+	switch event.Type {
+	case events.RequestCreated:
+		return n.notifyApprovers(tx, event, requester)
+
+	case events.RequestStepApproved:
+		err := n.send(tx, event, requesters, message{
+			Type:  models.NotifyStepApproved,
+			Title: fmt.Sprintf("Approval step %d of %s approved", event.Step.Level, number),
+			Body:  fmt.Sprintf("%s of %s was approved and your request moved to the next approver.", stepName(event.Step), number),
+			Path:  "/requests",
+		})
+		if err != nil {
+			return err
+		}
+		return n.notifyApprovers(tx, event, requester)
+
+	case events.RequestApproved:
+		return n.send(tx, event, requesters, message{
+			Type:  models.NotifyRequestApproved,
+			Title: fmt.Sprintf("%s was approved", number),
+			Body:  withComment(fmt.Sprintf("Your purchase request %s for %s was approved.", number, describe(request)), event.Comment),
+			Path:  "/requests",
+		})
+
+	case events.RequestRejected:
+		return n.send(tx, event, requesters, message{
+			Type:  models.NotifyRequestRejected,
+			Title: fmt.Sprintf("%s was rejected", number),
+			Body:  withComment(fmt.Sprintf("Your purchase request %s for %s was rejected.", number, describe(request)), request.RejectionReason),
+			Path:  "/requests",
+		})
+
+	case events.RequestInfoRequested:
+		return n.send(tx, event, requesters, message{
+			Type:  models.NotifyInfoRequested,
+			Title: fmt.Sprintf("More information needed for %s", number),
+			Body:  withComment(fmt.Sprintf("An approver needs more information about your purchase request %s before deciding on it.", number), request.InfoRequestNote),
+			Path:  "/requests",
+		})
+
+	case events.RequestResubmitted:
+		return n.notifyApprovers(tx, event, requester)
+
+	case events.RequestCommented:
+		return n.notifyComment(tx, event, requester)
+
+	case events.OrderPurchased:
+		return n.send(tx, event, requesters, message{
+			Type:  models.NotifyRequestPurchased,
+			Title: fmt.Sprintf("%s was purchased", number),
+			Body:  withComment(fmt.Sprintf("Your purchase request %s for %s has been purchased.", number, describe(request)), request.PurchaseNotes),
+			Path:  "/requests",
+		})
+	}
+	return nil
+}
+
+// notifyApprovers tells the approvers of the request's pending step that it
+// awaits them
+func (n *Notifier) notifyApprovers(tx *gorm.DB, event *events.Event, requester *models.User) error {
+	request := event.Request
+	if len(request.ApprovalSteps) == 0 {
+		if err := tx.Where("request_id = ?", request.ID).Order("level ASC").Find(&request.ApprovalSteps).Error; err != nil {
+			return err
+		}
+	}
+	step := request.PendingStep()
+	if step == nil {
+		return nil
+	}
+	approvers, err := approval.Approvers(tx, step, requester)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%s awaits your approval", request.RequestNumber)
+	if request.IsUrgent() {
+		title = "Urgent: " + title
+	}
+	return n.send(tx, event, approvers, message{
+		Type:  models.NotifyApprovalPending,
+		Title: title,
+		Body:  fmt.Sprintf("%s requested %s. It awaits %s.", requester.Name, describe(request), stepName(step)),
+		Path:  "/approvals",
+	})
+}
+
+// notifyComment tells the users mentioned in a comment, and the requester of
+// comments by others, that it was posted
+func (n *Notifier) notifyComment(tx *gorm.DB, event *events.Event, requester *models.User) error {
+	comment := event.RequestComment
+	author := &comment.Author
+	if author.ID != comment.AuthorID {
+		author = &models.User{}
+		if err := tx.Unscoped().First(author, comment.AuthorID).Error; err != nil {
+			return err
+		}
+	}
+	number := event.Request.RequestNumber
+
+	mentioned := false
+	for _, user := range comment.Mentions {
+		if user.ID == requester.ID {
+			mentioned = true
+		}
+	}
+	if len(comment.Mentions) > 0 {
+		err := n.send(tx, event, comment.Mentions, message{
+			Type:  models.NotifyRequestComment,
+			Title: fmt.Sprintf("%s mentioned you on %s", author.Name, number),
+			Body:  withComment(fmt.Sprintf("%s mentioned you in a comment on purchase request %s.", author.Name, number), comment.Body),
+			Path:  "/requests",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if mentioned {
+		return nil
+	}
+	return n.send(tx, event, []models.User{*requester}, message{
+		Type:  models.NotifyRequestComment,
+		Title: fmt.Sprintf("New comment on %s", number),
+		Body:  withComment(fmt.Sprintf("%s commented on your purchase request %s.", author.Name, number), comment.Body),
+		Path:  "/requests",
+	})
+}
+
+// send stores the message for the recipients who want it in-app and queues
+// it by email for those who want it by email
+func (n *Notifier) send(tx *gorm.DB, event *events.Event, recipients []models.User, msg message) error {
+	userIDs := make([]uint, 0, len(recipients))
+	for _, user := range recipients {
+		userIDs = append(userIDs, user.ID)
+	}
+	var preferences []models.NotificationPreference
+	if err := tx.Where("user_id IN ? AND type = ?", userIDs, msg.Type).Find(&preferences).Error; err != nil {
+		return err
+	}
+	preferenceOf := make(map[uint]models.NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		preferenceOf[preference.UserID] = preference
+	}
+
+	requestID := event.Request.ID
+	for _, user := range recipients {
+		if user.ID == event.ActorID {
+			continue
+		}
+		preference, ok := preferenceOf[user.ID]
+		if !ok {
+			preference = models.NotificationPreference{InApp: true, Email: true}
+		}
+
+		if preference.InApp {
+			notification := models.Notification{
+				UserID:    user.ID,
+				Type:      msg.Type,
+				RequestID: &requestID,
+				Title:     msg.Title,
+				Body:      msg.Body,
+			}
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+		}
+		if preference.Email && user.Email != "" {
+			if _, err := n.jobQueue.EnqueueEmail(tx, &requestID, n.email(&user, msg)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) email(user *models.User, msg message) mail.Message {
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: msg.Title,
+		Body: fmt.Sprintf(`Hello %s,
+
+%s
+
+Open IRIS Vista to see it:
+
+%s
+
+You can choose which notifications you receive by email in IRIS Vista.
+`, user.Name, msg.Body, n.appURL+msg.Path),
+	}
+}
+
+// describe names what was requested and its amount
+func describe(request *models.PurchaseRequest) string {
+	what := request.ProductTitle
+	if len(request.Items) > 1 {
+		what = fmt.Sprintf("%d items", len(request.Items))
+	}
+	if what == "" {
+		what = "an item"
+	}
+	return fmt.Sprintf("%s (%.2f %s)", what, request.Amount(), request.Currency)
+}
+
+// stepName returns the step's name, or its level for unnamed steps
+func stepName(step *models.ApprovalStep) string {
+	if step.Name == "" {
+		return fmt.Sprintf("approval step %d", step.Level)
+	}
+	return step.Name
+}
+
+// withComment appends a comment to a notification body
+func withComment(body, comment string) string {
+	if comment == "" {
+		return body
+	}
+	return body + "\n\n" + comment
+}
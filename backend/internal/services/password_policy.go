@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+// bcrypt ignores anything past the first 72 bytes of a password
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// PasswordPolicyConfig configures the rules new passwords must follow
+type PasswordPolicyConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DenyListFile  string        // Extra passwords to reject, one per line
+	MaxAge        time.Duration // 0 disables expiry
+	HistorySize   int           // Recent passwords, including the current one, that cannot be reused
+}
+
+// PasswordRules describes the policy to clients so they can show it
+// next to password fields
+type PasswordRules struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	HistorySize      int  `json:"history_size"`
+	MaxAgeDays       int  `json:"max_age_days"`
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	problems := e.Problems
+	if len(problems) == 1 {
+		return "Password " + problems[0]
+	}
+	return "Password " + strings.Join(problems[:len(problems)-1], ", ") + " and " + problems[len(problems)-1]
+}
+
+// PasswordPolicy validates new passwords and stores them, keeping a history
+// of earlier hashes so recent passwords are not reused
+type PasswordPolicy struct {
+	cfg      PasswordPolicyConfig
+	denyList map[string]bool
+}
+
+// NewPasswordPolicy creates a password policy with the built-in list of
+// common passwords plus any from the configured deny-list file
+func NewPasswordPolicy(cfg PasswordPolicyConfig) (*PasswordPolicy, error) {
+	if cfg.MinLength < 1 {
+		cfg.MinLength = 1
+	}
+
+	pp := &PasswordPolicy{cfg: cfg, denyList: make(map[string]bool)}
+	pp.addDenied(strings.NewReader(commonPasswords))
+
+	if cfg.DenyListFile != "" {
+		file, err := os.Open(cfg.DenyListFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open password deny-list: %w", err)
+		}
+		defer file.Close()
+		if err := pp.addDenied(file); err != nil {
+			return nil, fmt.Errorf("failed to read password deny-list: %w", err)
+		}
+	}
+	return pp, nil
+}
+
+func (pp *PasswordPolicy) addDenied(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pp.denyList[strings.ToLower(line)] = true
+	}
+	return scanner.Err()
+}
+
+// Rules returns the policy's requirements
+func (pp *PasswordPolicy) Rules() PasswordRules {
+	return PasswordRules{
+		MinLength:        pp.cfg.MinLength,
+		RequireUppercase: pp.cfg.RequireUpper,
+		RequireLowercase: pp.cfg.RequireLower,
+		RequireDigit:     pp.cfg.RequireDigit,
+		RequireSymbol:    pp.cfg.RequireSymbol,
+		HistorySize:      pp.cfg.HistorySize,
+		MaxAgeDays:       int(pp.cfg.MaxAge / (24 * time.Hour)),
+	}
+}
+
+// Validate checks a password against the policy, returning a
+// PasswordPolicyError listing what is wrong with it. The user's name and
+// email may not be part of their password.
+func (pp *PasswordPolicy) Validate(password string, user *models.User) error {
+	var problems []string
+
+	length := len([]rune(password))
+	if length < pp.cfg.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", pp.cfg.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d characters long", maxPasswordBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if pp.cfg.RequireUpper && !hasUpper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if pp.cfg.RequireLower && !hasLower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if pp.cfg.RequireDigit && !hasDigit {
+		problems = append(problems, "must contain a digit")
+	}
+	if pp.cfg.RequireSymbol && !hasSymbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	if pp.isCommon(password) {
+		problems = append(problems, "is too common")
+	}
+	if user != nil && containsPersonalInfo(password, user) {
+		problems = append(problems, "must not contain your name or email")
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// isCommon reports whether the password is on the deny-list, also ignoring
+// the digits and symbols people tend to append to a common word
+func (pp *PasswordPolicy) isCommon(password string) bool {
+	lower := strings.ToLower(password)
+	if pp.denyList[lower] {
+		return true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return base != "" && pp.denyList[base]
+}
+
+func containsPersonalInfo(password string, user *models.User) bool {
+	lower := strings.ToLower(password)
+	parts := strings.Fields(strings.ToLower(user.Name))
+	if local, _, ok := strings.Cut(strings.ToLower(user.Email), "@"); ok {
+		parts = append(parts, local)
+	}
+	for _, part := range parts {
+		// Short names would reject too many unrelated passwords
+		if len([]rune(part)) >= 4 && strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPassword validates a new password for the user and stores it, recording
+// the old hash in the password history. Passwords in the user's recent
+// history are rejected. mustChange forces the user to choose another password
+// before they can use the API, for passwords set by someone else.
+func (pp *PasswordPolicy) SetPassword(tx *gorm.DB, user *models.User, password string, mustChange bool) error {
+	if err := pp.Validate(password, user); err != nil {
+		return err
+	}
+
+	reused, err := pp.isRecent(tx, user, password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		if pp.cfg.HistorySize == 1 {
+			return &PasswordPolicyError{Problems: []string{"must be different from your current password"}}
+		}
+		return &PasswordPolicyError{Problems: []string{fmt.Sprintf("must not be one of your last %d passwords", pp.cfg.HistorySize)}}
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if pp.cfg.HistorySize > 1 {
+		if err := tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}).Error; err != nil {
+			return err
+		}
+	}
+	if err := pp.trimHistory(tx, user.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"password_hash":        hashedPassword,
+		"password_changed_at":  now,
+		"must_change_password": mustChange,
+	}).Error
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hashedPassword
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = mustChange
+	return nil
+}
+
+// isRecent reports whether the password is the user's current one or one of
+// the earlier passwords the history size covers
+func (pp *PasswordPolicy) isRecent(tx *gorm.DB, user *models.User, password string) (bool, error) {
+	if pp.cfg.HistorySize < 1 {
+		return false, nil
+	}
+	if VerifyPassword(password, user.PasswordHash) {
+		return true, nil
+	}
+
+	var history []models.PasswordHistory
+	err := tx.Where("user_id = ?", user.ID).Order("id DESC").Limit(pp.cfg.HistorySize - 1).Find(&history).Error
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range history {
+		if VerifyPassword(password, entry.PasswordHash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// trimHistory deletes history entries the history size no longer covers
+func (pp *PasswordPolicy) trimHistory(tx *gorm.DB, userID uint) error {
+	var ids []uint
+	if err := tx.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).Order("id DESC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	keep := pp.cfg.HistorySize - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if len(ids) <= keep {
+		return nil
+	}
+	return tx.Where("id IN ?", ids[keep:]).Delete(&models.PasswordHistory{}).Error
+}
+
+// Expired reports whether the user's password is older than the maximum age
+func (pp *PasswordPolicy) Expired(user *models.User) bool {
+	if pp.cfg.MaxAge <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > pp.cfg.MaxAge
+}
+
+// IsPasswordPolicyError reports whether err is a policy violation, so
+// handlers can report it as a validation error
+func IsPasswordPolicyError(err error) bool {
+	var policyErr *PasswordPolicyError
+	return errors.As(err, &policyErr)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+	"vista-backend/internal/services/mail"
+	"vista-backend/pkg/jwt"
+)
+
+// A new reset link is not sent while one issued this recently is outstanding,
+// so the forgotten password form cannot be used to flood an inbox
+const resetRequestCooldown = time.Minute
+
+var ErrInvalidPasswordToken = errors.New("password link is invalid, expired or already used")
+
+// PasswordTokenConfig configures password reset and invitation links
+type PasswordTokenConfig struct {
+	AppURL       string // Frontend the links point to
+	ResetExpiry  time.Duration
+	InviteExpiry time.Duration
+}
+
+// PasswordTokenService emails signed, single-use links that let a user set
+// their password: reset links for forgotten passwords and invitations for
+// new users
+type PasswordTokenService struct {
+	db          *gorm.DB
+	jwtService  *jwt.JWTService
+	authService *AuthService
+	passwords   *PasswordPolicy
+	mailer      mail.Mailer
+	cfg         PasswordTokenConfig
+}
+
+// NewPasswordTokenService creates a new password token service
+func NewPasswordTokenService(db *gorm.DB, jwtService *jwt.JWTService, authService *AuthService, passwords *PasswordPolicy, mailer mail.Mailer, cfg PasswordTokenConfig) *PasswordTokenService {
+	if cfg.ResetExpiry <= 0 {
+		cfg.ResetExpiry = time.Hour
+	}
+	if cfg.InviteExpiry <= 0 {
+		cfg.InviteExpiry = 72 * time.Hour
+	}
+	cfg.AppURL = strings.TrimRight(cfg.AppURL, "/")
+	return &PasswordTokenService{
+		db:          db,
+		jwtService:  jwtService,
+		authService: authService,
+		passwords:   passwords,
+		mailer:      mailer,
+		cfg:         cfg,
+	}
+}
+
+// RequestReset emails a reset link to the active user with the email, if
+// any and they can sign in with a password. The outcome is not reported so
+// callers cannot probe for accounts, and the mail is sent in the background
+// so the response time does not either.
+func (ps *PasswordTokenService) RequestReset(email string) error {
+	var user models.User
+	if err := ps.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive() || user.PasswordLoginDisabled {
+		return nil
+	}
+
+	var recent int64
+	err := ps.db.Model(&models.PasswordToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL AND created_at > ?", user.ID, models.PasswordTokenReset, time.Now().Add(-resetRequestCooldown)).
+		Count(&recent).Error
+	if err != nil || recent > 0 {
+		return err
+	}
+
+	token, err := ps.issue(ps.db, &user, models.PasswordTokenReset, nil)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := ps.mailer.Send(ps.resetMessage(&user, token)); err != nil {
+			log.Printf("Failed to send password reset email to %s: %v", user.Email, err)
+		}
+	}()
+	return nil
+}
+
+// Invite emails the user a link to set their password, replacing any earlier
+// invitation. invitedBy is the admin sending it.
+func (ps *PasswordTokenService) Invite(user *models.User, invitedBy uint) error {
+	token, err := ps.issue(ps.db, user, models.PasswordTokenInvite, &invitedBy)
+	if err != nil {
+		return err
+	}
+	return ps.mailer.Send(ps.inviteMessage(user, token))
+}
+
+// ResetPassword sets the password of the user a reset or invitation link was
+// sent to. The link is used up, the user's other links are discarded and
+// their sessions are signed out. A password the policy rejects leaves the
+// link unused so the user can try another.
+func (ps *PasswordTokenService) ResetPassword(token, password string, client ClientInfo) (*models.User, error) {
+	claims, err := ps.jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, ErrInvalidPasswordToken
+	}
+	purpose, ok := tokenPurposes[claims.Type]
+	if !ok || claims.ID == "" {
+		return nil, ErrInvalidPasswordToken
+	}
+
+	var user models.User
+	err = ps.db.Transaction(func(tx *gorm.DB) error {
+		// Conditional update so a link cannot be used twice concurrently
+		now := time.Now()
+		result := tx.Model(&models.PasswordToken{}).
+			Where("token_id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", claims.ID, claims.UserID, purpose, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidPasswordToken
+		}
+
+		if err := tx.First(&user, claims.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidPasswordToken
+			}
+			return err
+		}
+		if !user.IsActive() {
+			return ErrUserInactive
+		}
+
+		if err := ps.passwords.SetPassword(tx, &user, password, false); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordToken{}).Error; err != nil {
+			return err
+		}
+		if _, err := ps.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedPasswordChanged, 0); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionPasswordChange,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			After:      map[string]string{"via": string(purpose)},
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+var tokenPurposes = map[jwt.TokenType]models.PasswordTokenPurpose{
+	jwt.PasswordResetToken: models.PasswordTokenReset,
+	jwt.InviteToken:        models.PasswordTokenInvite,
+}
+
+// issue signs a new link token for the user, discarding their unused tokens
+// of the same purpose
+func (ps *PasswordTokenService) issue(tx *gorm.DB, user *models.User, purpose models.PasswordTokenPurpose, createdBy *uint) (string, error) {
+	tokenType, expiry := jwt.PasswordResetToken, ps.cfg.ResetExpiry
+	if purpose == models.PasswordTokenInvite {
+		tokenType, expiry = jwt.InviteToken, ps.cfg.InviteExpiry
+	}
+
+	token, tokenID, err := ps.jwtService.GenerateOneTimeToken(user.ID, user.Email, tokenType, expiry)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).Delete(&models.PasswordToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordToken{
+			UserID:      user.ID,
+			Purpose:     purpose,
+			TokenID:     tokenID,
+			ExpiresAt:   time.Now().Add(expiry),
+			CreatedByID: createdBy,
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (ps *PasswordTokenService) link(path, token string) string {
+	return ps.cfg.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (ps *PasswordTokenService) resetMessage(user *models.User, token string) mail.Message {
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your IRIS Vista password",
+		Body: fmt.Sprintf(`Hello %s,
+
+We received a request to reset the password of your IRIS Vista account.
+Open this link to choose a new password:
+
+%s
+
+The link can be used once and expires in %s. If you did not ask to reset
+your password you can ignore this email.
+`, user.Name, ps.link("/reset-password", token), formatExpiry(ps.cfg.ResetExpiry)),
+	}
+}
+
+func (ps *PasswordTokenService) inviteMessage(user *models.User, token string) mail.Message {
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: "You have been invited to IRIS Vista",
+		Body: fmt.Sprintf(`Hello %s,
+
+An account has been created for you on IRIS Vista, the supply chain and
+procurement system. Open this link to set your password and sign in:
+
+%s
+
+The link can be used once and expires in %s.
+`, user.Name, ps.link("/reset-password", token)+"&invite=1", formatExpiry(ps.cfg.InviteExpiry)),
+	}
+}
+
+// formatExpiry describes a link lifetime in whole hours or minutes
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d minutes", int(d/time.Minute))
+}
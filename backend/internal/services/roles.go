@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("a role with this name already exists")
+	ErrInvalidRoleName   = errors.New("role names are 2 to 50 lowercase letters, digits and underscores, starting with a letter")
+	ErrRoleInUse         = errors.New("role is assigned to users or approval steps")
+	ErrBuiltInRole       = errors.New("built-in roles cannot be deleted")
+	ErrAdminRoleLocked   = errors.New("the admin role always has every permission")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// RoleInput is the editable part of a role
+type RoleInput struct {
+	DisplayName string
+	Description string
+	RequireMFA  bool
+	Permissions []string
+}
+
+// RoleService resolves the permissions roles grant and manages roles. Roles
+// are cached in memory because every authenticated request checks them; call
+// Invalidate after changing them.
+type RoleService struct {
+	db *gorm.DB
+
+	mu      sync.RWMutex
+	roles   map[models.UserRole]*models.Role // nil until loaded
+	version uint64                           // Bumped by Invalidate so a load racing it is not cached
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+// Permissions returns the permissions the role grants; unknown roles grant none
+func (rs *RoleService) Permissions(role models.UserRole) ([]string, error) {
+	r, err := rs.cached(role)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.PermissionNames(), nil
+}
+
+// Grants reports whether the held permissions include every permission the
+// role grants, so that a user holding them gives no more access by assigning it
+func (rs *RoleService) Grants(held []string, role models.UserRole) (bool, error) {
+	permissions, err := rs.Permissions(role)
+	if err != nil {
+		return false, err
+	}
+	return HoldsPermissions(held, permissions), nil
+}
+
+// HoldsPermissions reports whether the held permissions include every one of
+// the permissions
+func HoldsPermissions(held, permissions []string) bool {
+	holder := models.User{Permissions: held}
+	for _, p := range permissions {
+		if !holder.Can(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiresMFA reports whether the role's users must use two-factor authentication
+func (rs *RoleService) RequiresMFA(role models.UserRole) bool {
+	r, err := rs.cached(role)
+	return err == nil && r != nil && r.RequireMFA
+}
+
+// Exists reports whether the role exists
+func (rs *RoleService) Exists(role models.UserRole) bool {
+	r, err := rs.cached(role)
+	return err == nil && r != nil
+}
+
+// Invalidate drops the cached roles so the next check reads them again
+func (rs *RoleService) Invalidate() {
+	rs.mu.Lock()
+	rs.roles = nil
+	rs.version++
+	rs.mu.Unlock()
+}
+
+func (rs *RoleService) cached(name models.UserRole) (*models.Role, error) {
+	rs.mu.RLock()
+	roles, version := rs.roles, rs.version
+	rs.mu.RUnlock()
+	if roles != nil {
+		return roles[name], nil
+	}
+
+	var all []models.Role
+	if err := rs.db.Preload("Permissions").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	roles = make(map[models.UserRole]*models.Role, len(all))
+	for i := range all {
+		roles[models.UserRole(all[i].Name)] = &all[i]
+	}
+
+	rs.mu.Lock()
+	if rs.version == version {
+		rs.roles = roles
+	}
+	rs.mu.Unlock()
+	return roles[name], nil
+}
+
+// List returns all roles with their permissions
+func (rs *RoleService) List() ([]models.Role, error) {
+	var roles []models.Role
+	err := rs.db.Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("permissions.id ASC") }).
+		Order("id ASC").Find(&roles).Error
+	return roles, err
+}
+
+// Get returns a role with its permissions
+func (rs *RoleService) Get(id uint) (*models.Role, error) {
+	var role models.Role
+	if err := rs.db.Preload("Permissions").First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Create creates a role with the permissions of the input
+func (rs *RoleService) Create(tx *gorm.DB, name string, input RoleInput) (*models.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoleName
+	}
+	var count int64
+	if err := tx.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrRoleExists
+	}
+
+	permissions, err := rs.lookupPermissions(tx, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := models.Role{
+		Name:        name,
+		DisplayName: input.DisplayName,
+		Description: input.Description,
+		RequireMFA:  input.RequireMFA,
+		Permissions: permissions,
+	}
+	if err := tx.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Update replaces the role's details and permissions. The admin role keeps
+// every permission.
+func (rs *RoleService) Update(tx *gorm.DB, role *models.Role, input RoleInput) error {
+	permissions, err := rs.lookupPermissions(tx, input.Permissions)
+	if err != nil {
+		return err
+	}
+	if role.Name == string(models.RoleAdmin) {
+		granted := make(map[string]bool, len(permissions))
+		for _, p := range permissions {
+			granted[p.Name] = true
+		}
+		for _, definition := range models.PermissionDefinitions {
+			if !granted[definition.Name] {
+				return ErrAdminRoleLocked
+			}
+		}
+	}
+
+	role.DisplayName = input.DisplayName
+	role.Description = input.Description
+	role.RequireMFA = input.RequireMFA
+	if err := tx.Model(role).Select("display_name", "description", "require_mfa").Updates(role).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(role).Association("Permissions").Replace(permissions); err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	return nil
+}
+
+// Delete deletes a custom role that no user or approval step uses
+func (rs *RoleService) Delete(tx *gorm.DB, role *models.Role) error {
+	if role.BuiltIn {
+		return ErrBuiltInRole
+	}
+
+	var users, ruleSteps, openSteps int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("role = ?", role.Name).Count(&users).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.ApprovalRuleStep{}).Where("approver_role = ?", role.Name).Count(&ruleSteps).Error; err != nil {
+		return err
+	}
+	// Steps not yet reached count too, as the chain stalls once it gets to them
+	openRequests := tx.Model(&models.PurchaseRequest{}).Select("id").
+		Where("status IN ?", []models.RequestStatus{models.StatusPending, models.StatusInfoRequested})
+	if err := tx.Model(&models.ApprovalStep{}).
+		Where("approver_role = ? AND status IN ?", role.Name, []models.ApprovalStepStatus{models.StepWaiting, models.StepPending}).
+		Where("request_id IN (?)", openRequests).
+		Count(&openSteps).Error; err != nil {
+		return err
+	}
+	if users > 0 || ruleSteps > 0 || openSteps > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	return tx.Delete(role).Error
+}
+
+// lookupPermissions loads the named permissions, rejecting unknown names
+func (rs *RoleService) lookupPermissions(tx *gorm.DB, names []string) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	if err := tx.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, name)
+		}
+	}
+	return permissions, nil
+}
+
+// ListPermissions returns every permission a role can grant
+func (rs *RoleService) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := rs.db.Order("id ASC").Find(&permissions).Error
+	return permissions, err
+}
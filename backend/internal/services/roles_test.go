@@ -0,0 +1,142 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"vista-backend/internal/models"
+	"vista-backend/internal/services"
+	"vista-backend/internal/testutil"
+)
+
+func TestHoldsPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		held        []string
+		permissions []string
+		want        bool
+	}{
+		{"no permissions needed", nil, nil, true},
+		{"all held", []string{models.PermUsersManage, models.PermRolesManage}, []string{models.PermRolesManage}, true},
+		{"one missing", []string{models.PermUsersManage}, []string{models.PermUsersManage, models.PermRolesManage}, false},
+		{"none held", nil, []string{models.PermUsersManage}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.HoldsPermissions(tt.held, tt.permissions); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantsBuiltInRoles(t *testing.T) {
+	rs := services.NewRoleService(testutil.NewDB(t))
+
+	admin, err := rs.Permissions(models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("Permissions: %v", err)
+	}
+	manager, err := rs.Permissions(models.RoleGeneralManager)
+	if err != nil {
+		t.Fatalf("Permissions: %v", err)
+	}
+	if len(manager) == 0 || len(manager) >= len(admin) {
+		t.Fatalf("got %d general manager and %d admin permissions, want a proper subset", len(manager), len(admin))
+	}
+
+	tests := []struct {
+		name string
+		held []string
+		role models.UserRole
+		want bool
+	}{
+		{"admin grants any role", admin, models.RoleGeneralManager, true},
+		{"admin grants admin", admin, models.RoleAdmin, true},
+		{"manager grants own role", manager, models.RoleGeneralManager, true},
+		{"manager cannot grant admin", manager, models.RoleAdmin, false},
+		{"anyone grants employee", nil, models.RoleEmployee, true},
+		{"users.manage alone cannot grant manager", []string{models.PermUsersManage}, models.RoleGeneralManager, false},
+		{"unknown role grants nothing", nil, "no_such_role", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rs.Grants(tt.held, tt.role)
+			if err != nil {
+				t.Fatalf("Grants: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantsSeesUpdatedRole(t *testing.T) {
+	db := testutil.NewDB(t)
+	rs := services.NewRoleService(db)
+	held := []string{models.PermBudgetsView}
+
+	role, err := rs.Create(db, "auditor", services.RoleInput{DisplayName: "Auditor", Permissions: held})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	rs.Invalidate()
+	if ok, err := rs.Grants(held, "auditor"); err != nil || !ok {
+		t.Fatalf("got %v, %v, want the role granted", ok, err)
+	}
+
+	// Once the role grants more than is held it can no longer be assigned
+	input := services.RoleInput{DisplayName: "Auditor", Permissions: []string{models.PermBudgetsView, models.PermAuditView}}
+	if err := rs.Update(db, role, input); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	rs.Invalidate()
+	if ok, err := rs.Grants(held, "auditor"); err != nil || ok {
+		t.Errorf("got %v, %v, want the role refused", ok, err)
+	}
+}
+
+func TestDeleteRefusedWhileWaitingStepsUseRole(t *testing.T) {
+	db := testutil.NewDB(t)
+	rs := services.NewRoleService(db)
+
+	role, err := rs.Create(db, "auditor", services.RoleInput{DisplayName: "Auditor"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	requester := models.User{Email: "requester@example.com", PasswordHash: "x", Name: "Requester", Role: models.RoleEmployee, Status: "active"}
+	if err := db.Create(&requester).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	request := models.PurchaseRequest{
+		RequestNumber: models.GenerateRequestNumber(db),
+		Currency:      "MXN",
+		Quantity:      1,
+		RequesterID:   requester.ID,
+		Status:        models.StatusPending,
+		CurrentStep:   1,
+	}
+	if err := db.Create(&request).Error; err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	steps := []models.ApprovalStep{
+		{RequestID: request.ID, Level: 1, ApproverRole: models.RoleGeneralManager, Status: models.StepPending},
+		{RequestID: request.ID, Level: 2, ApproverRole: "auditor", Status: models.StepWaiting},
+	}
+	if err := db.Create(&steps).Error; err != nil {
+		t.Fatalf("create steps: %v", err)
+	}
+
+	if err := rs.Delete(db, role); !errors.Is(err, services.ErrRoleInUse) {
+		t.Fatalf("delete with a waiting step: got %v, want ErrRoleInUse", err)
+	}
+
+	// Steps of a decided request are never reached
+	if err := db.Model(&request).Update("status", models.StatusRejected).Error; err != nil {
+		t.Fatalf("reject request: %v", err)
+	}
+	if err := rs.Delete(db, role); err != nil {
+		t.Errorf("delete once the request is decided: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+	"vista-backend/pkg/jwt"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked or has expired")
+	ErrTokenReused     = errors.New("refresh token reuse detected")
+
+	ErrMFASetupRequired = errors.New("two-factor authentication must be set up before continuing")
+)
+
+// ClientInfo identifies the client a session is used from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+func (ci ClientInfo) userAgent() string {
+	if len(ci.UserAgent) > 500 {
+		return ci.UserAgent[:500]
+	}
+	return ci.UserAgent
+}
+
+// startSession opens a session for the user and issues its first token pair
+func (as *AuthService) startSession(user *models.User, client ClientInfo) (*jwt.TokenPair, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.userAgent(),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(as.jwtService.RefreshTokenExpiry()),
+	}
+
+	var tokens *jwt.TokenPair
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = as.jwtService.GenerateTokenPair(user.ID, user.Email, string(user.Role), session.ID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&session).Update("refresh_token_id", tokens.RefreshTokenID).Error
+	})
+	return tokens, err
+}
+
+// ValidateSession checks that the session an access token was issued for is
+// still active and belongs to the token's user
+func (as *AuthService) ValidateSession(sessionID, userID uint) error {
+	if sessionID == 0 {
+		return ErrSessionRevoked
+	}
+
+	var session models.Session
+	err := as.db.Select("id", "user_id", "expires_at", "revoked_at").First(&session, sessionID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID || !session.IsActive() {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (as *AuthService) ListSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := as.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession revokes one of the user's sessions
+func (as *AuthService) RevokeSession(userID, sessionID uint, reason string) error {
+	result := as.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes all of a user's sessions except exceptID (0 for none),
+// returning the number revoked. Pass the transaction that changes the user.
+func (as *AuthService) RevokeUserSessions(tx *gorm.DB, userID uint, reason string, exceptID uint) (int64, error) {
+	query := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != 0 {
+		query = query.Where("id <> ?", exceptID)
+	}
+	result := query.Updates(map[string]interface{}{
+		"revoked_at":     time.Now(),
+		"revoked_reason": reason,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeOtherSessions revokes all of the user's sessions except the current one
+func (as *AuthService) RevokeOtherSessions(userID, currentID uint) (int64, error) {
+	return as.RevokeUserSessions(as.db, userID, models.SessionRevokedByUser, currentID)
+}
+
+// rotateSession exchanges a session's current refresh token for a new token pair.
+// A refresh token that is not the session's current one has already been used,
+// so the session is revoked.
+func (as *AuthService) rotateSession(claims *jwt.Claims, client ClientInfo) (*jwt.TokenPair, error) {
+	var session models.Session
+	if err := as.db.First(&session, claims.SessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if session.UserID != claims.UserID || !session.IsActive() {
+		return nil, ErrSessionRevoked
+	}
+	if session.RefreshTokenID != claims.ID {
+		return nil, as.revokeReusedSession(&session, client)
+	}
+
+	// Verify user still exists and is active
+	var user models.User
+	if err := as.db.First(&user, claims.UserID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.IsActive() {
+		return nil, ErrUserInactive
+	}
+
+	// Users whose role came to require two-factor authentication after they
+	// logged in have to log in again to enroll
+	if as.mfa.Required(&user) && !user.MFAEnabled {
+		if err := as.RevokeSession(user.ID, session.ID, models.SessionRevokedMFARequired); err != nil {
+			return nil, err
+		}
+		return nil, ErrMFASetupRequired
+	}
+
+	tokens, err := as.jwtService.GenerateTokenPair(user.ID, user.Email, string(user.Role), session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conditional update so the same refresh token cannot be rotated twice
+	now := time.Now()
+	result := as.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_id = ? AND revoked_at IS NULL", session.ID, claims.ID).
+		Updates(map[string]interface{}{
+			"refresh_token_id": tokens.RefreshTokenID,
+			"ip_address":       client.IPAddress,
+			"user_agent":       client.userAgent(),
+			"last_used_at":     now,
+			"expires_at":       now.Add(as.jwtService.RefreshTokenExpiry()),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, as.revokeReusedSession(&session, client)
+	}
+
+	return tokens, nil
+}
+
+// revokeReusedSession revokes a session whose refresh token was presented after
+// being rotated and records the reuse in the audit log
+func (as *AuthService) revokeReusedSession(session *models.Session, client ClientInfo) error {
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", session.ID).Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": models.SessionRevokedTokenReuse,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			UserID:     session.UserID,
+			Action:     models.AuditActionTokenReuse,
+			Resource:   models.AuditResourceSession,
+			ResourceID: session.ID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/audit"
+	"vista-backend/pkg/oidc"
+)
+
+// How long a user has to sign in at the identity provider
+const ssoLoginExpiry = 10 * time.Minute
+
+var (
+	ErrSSODisabled        = errors.New("single sign-on is not configured")
+	ErrInvalidSSOState    = errors.New("single sign-on login is invalid or has expired")
+	ErrSSONotProvisioned  = errors.New("no account for this single sign-on user")
+	ErrSSOEmailUnverified = errors.New("identity provider has not verified the email")
+	ErrSSOAccountConflict = errors.New("account is linked to another single sign-on user")
+)
+
+// SSOConfig configures how identity provider users map to local users
+type SSOConfig struct {
+	ProviderName  string
+	GroupsClaim   string
+	RoleMapping   map[string]models.UserRole // Provider group to role
+	DefaultRole   models.UserRole            // Role of provisioned users in no mapped group; empty refuses them
+	AutoProvision bool
+	SyncRoles     bool
+	TrustEmail    bool // Link existing accounts by email when the provider does not say it verified it
+}
+
+// SSOService signs users in with an OpenID Connect provider. Users are
+// matched by the provider's subject, or the first time by email, and can be
+// created on their first login with a role from their groups.
+type SSOService struct {
+	db          *gorm.DB
+	authService *AuthService
+	roles       *RoleService
+	provider    *oidc.Provider
+	cfg         SSOConfig
+}
+
+// NewSSOService creates a new single sign-on service; a nil provider leaves SSO off
+func NewSSOService(db *gorm.DB, authService *AuthService, roles *RoleService, provider *oidc.Provider, cfg SSOConfig) *SSOService {
+	if cfg.ProviderName == "" {
+		cfg.ProviderName = "Single sign-on"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &SSOService{db: db, authService: authService, roles: roles, provider: provider, cfg: cfg}
+}
+
+// ParseRoleMapping parses group=role pairs separated by commas, such as
+// "vista-admins=admin,purchasing=supply_chain_manager"
+func ParseRoleMapping(mapping string, roles *RoleService) (map[string]models.UserRole, error) {
+	mapped := make(map[string]models.UserRole)
+	for _, pair := range strings.Split(mapping, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected group=role", pair)
+		}
+		if !roles.Exists(models.UserRole(role)) {
+			return nil, fmt.Errorf("unknown role %q in role mapping", role)
+		}
+		mapped[group] = models.UserRole(role)
+	}
+	return mapped, nil
+}
+
+// Enabled reports whether single sign-on is configured
+func (ss *SSOService) Enabled() bool {
+	return ss.provider != nil
+}
+
+// ProviderName returns the name shown on the sign-in button
+func (ss *SSOService) ProviderName() string {
+	return ss.cfg.ProviderName
+}
+
+// Begin starts a login and returns the identity provider URL to send the
+// browser to, and the key the browser keeps to complete it. Only the browser
+// holding the key can complete the login, so a code and state from someone
+// else's login cannot sign it in to their account.
+func (ss *SSOService) Begin(ctx context.Context) (authURL, browserKey string, err error) {
+	if !ss.Enabled() {
+		return "", "", ErrSSODisabled
+	}
+
+	login := models.SSOLogin{ExpiresAt: time.Now().Add(ssoLoginExpiry)}
+	for _, value := range []*string{&login.State, &login.Nonce, &login.CodeVerifier, &browserKey} {
+		random, err := oidc.RandomString()
+		if err != nil {
+			return "", "", err
+		}
+		*value = random
+	}
+	login.BrowserKeyHash = hashBrowserKey(browserKey)
+
+	authURL, err = ss.provider.AuthCodeURL(ctx, login.State, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Logins that were abandoned at the provider are cleaned up as new ones start
+	if err := ss.db.Where("expires_at < ?", time.Now()).Delete(&models.SSOLogin{}).Error; err != nil {
+		return "", "", err
+	}
+	if err := ss.db.Create(&login).Error; err != nil {
+		return "", "", err
+	}
+	return authURL, browserKey, nil
+}
+
+func hashBrowserKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Complete finishes a login with the code and state the identity provider
+// redirected back with, and the key Begin gave the browser. The user then
+// continues like a password login, including two-factor authentication.
+func (ss *SSOService) Complete(ctx context.Context, code, state, browserKey string, client ClientInfo) (*LoginResult, error) {
+	if !ss.Enabled() {
+		return nil, ErrSSODisabled
+	}
+
+	login, err := ss.consume(state, browserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := ss.provider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = ss.db.Transaction(func(tx *gorm.DB) error {
+		return ss.resolveUser(tx, claims, &user, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrUserInactive
+	}
+	// A lockout from failed second factors also holds for single sign-on
+	if err := ss.authService.loginGuard.Check(user.Email, client); err != nil {
+		return nil, err
+	}
+
+	return ss.authService.finishLogin(&user, client)
+}
+
+// consume deletes the pending login with the state so it cannot be used
+// twice, once the browser key shows it is completed by the browser that began it
+func (ss *SSOService) consume(state, browserKey string) (*models.SSOLogin, error) {
+	if state == "" || browserKey == "" {
+		return nil, ErrInvalidSSOState
+	}
+
+	var login models.SSOLogin
+	if err := ss.db.Where("state = ?", state).First(&login).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSSOState
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(login.BrowserKeyHash), []byte(hashBrowserKey(browserKey))) != 1 {
+		return nil, ErrInvalidSSOState
+	}
+
+	// Conditional delete so concurrent callbacks cannot both use it
+	result := ss.db.Where("id = ? AND expires_at > ?", login.ID, time.Now()).Delete(&models.SSOLogin{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidSSOState
+	}
+	return &login, nil
+}
+
+// resolveUser finds the user the claims identify, linking an existing
+// account by email or provisioning a new one, and updates their role from
+// their groups
+func (ss *SSOService) resolveUser(tx *gorm.DB, claims *oidc.Claims, user *models.User, client ClientInfo) error {
+	mappedRole := ss.mapRole(claims.Strings(ss.cfg.GroupsClaim))
+
+	err := tx.Where("oidc_subject = ?", claims.Subject).First(user).Error
+	switch {
+	case err == nil:
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	default:
+		if err := ss.linkOrProvision(tx, claims, mappedRole, user, client); err != nil {
+			return err
+		}
+	}
+
+	if !ss.cfg.SyncRoles || mappedRole == "" || mappedRole == user.Role {
+		return nil
+	}
+	oldRole := user.Role
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("role", mappedRole).Error; err != nil {
+		return err
+	}
+	user.Role = mappedRole
+
+	// Sessions carry the role they were opened with; the new session is
+	// opened after this transaction
+	if _, err := ss.authService.RevokeUserSessions(tx, user.ID, models.SessionRevokedRoleChanged, 0); err != nil {
+		return err
+	}
+	return audit.Record(tx, audit.Entry{
+		UserID:     user.ID,
+		Action:     models.AuditActionRoleChange,
+		Resource:   models.AuditResourceUser,
+		ResourceID: user.ID,
+		Before:     map[string]string{"role": string(oldRole)},
+		After:      map[string]string{"role": string(mappedRole), "via": "sso"},
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+	})
+}
+
+// linkOrProvision links the provider user to the account with their email,
+// or creates one if provisioning is on
+func (ss *SSOService) linkOrProvision(tx *gorm.DB, claims *oidc.Claims, mappedRole models.UserRole, user *models.User, client ClientInfo) error {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return ErrSSONotProvisioned
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return ErrSSOEmailUnverified
+	}
+
+	err := tx.Unscoped().Where("LOWER(email) = ?", email).First(user).Error
+	if err == nil {
+		// Linking hands the account to whoever controls the email at the
+		// provider, so providers that do not say they verified it are only
+		// trusted when configured to be
+		if claims.EmailVerified == nil && !ss.cfg.TrustEmail {
+			return ErrSSOEmailUnverified
+		}
+		if user.DeletedAt.Valid {
+			return ErrUserInactive
+		}
+		if user.OIDCSubject != nil {
+			return ErrSSOAccountConflict
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("oidc_subject", claims.Subject).Error; err != nil {
+			return err
+		}
+		user.OIDCSubject = &claims.Subject
+		return audit.Record(tx, audit.Entry{
+			UserID:     user.ID,
+			Action:     models.AuditActionSSOLink,
+			Resource:   models.AuditResourceUser,
+			ResourceID: user.ID,
+			After:      map[string]string{"subject": claims.Subject},
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+		})
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	role := mappedRole
+	if role == "" {
+		role = ss.cfg.DefaultRole
+	}
+	if !ss.cfg.AutoProvision || role == "" {
+		return ErrSSONotProvisioned
+	}
+
+	// Provisioned users sign in with SSO only; the random password is never used
+	hashedPassword, err := HashPassword(uuid.NewString())
+	if err != nil {
+		return err
+	}
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	now := time.Now()
+	*user = models.User{
+		Email:                 email,
+		PasswordHash:          hashedPassword,
+		Name:                  name,
+		Role:                  role,
+		Status:                "active",
+		PasswordChangedAt:     &now,
+		OIDCSubject:           &claims.Subject,
+		PasswordLoginDisabled: true,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	return audit.Record(tx, audit.Entry{
+		UserID:     user.ID,
+		Action:     models.AuditActionCreate,
+		Resource:   models.AuditResourceUser,
+		ResourceID: user.ID,
+		After:      map[string]string{"email": user.Email, "name": user.Name, "role": string(user.Role), "via": "sso"},
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+	})
+}
+
+// mapRole returns the role the groups map to, or "" if none do. A user in
+// several mapped groups gets the role with the most permissions.
+func (ss *SSOService) mapRole(groups []string) models.UserRole {
+	var role models.UserRole
+	rank := -1
+	for _, group := range groups {
+		mapped, ok := ss.cfg.RoleMapping[group]
+		if !ok {
+			continue
+		}
+		permissions, err := ss.roles.Permissions(mapped)
+		if err == nil && len(permissions) > rank {
+			role, rank = mapped, len(permissions)
+		}
+	}
+	return role
+}
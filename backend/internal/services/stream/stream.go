@@ -0,0 +1,278 @@
+// Package stream pushes purchase request events to connected clients as they
+// happen. Events are recorded in the transaction that caused them and
+// published once it has committed, to each subscriber allowed to see the
+// request: the users who can view it, and order managers for approved and
+// purchased requests.
+package stream
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/visibility"
+)
+
+const (
+	pollInterval  = time.Second    // How often committed events are looked for
+	retention     = 24 * time.Hour // How long events are kept for clients catching up
+	pruneInterval = time.Hour
+	bufferSize    = 64 // Messages a subscriber can fall behind before it is dropped
+)
+
+// Message is an event as sent to a subscriber, with the state of the request
+// when it was published
+type Message struct {
+	ID               uint                 `json:"id"`
+	Type             events.Type          `json:"type"`
+	RequestID        uint                 `json:"request_id"`
+	RequestNumber    string               `json:"request_number"`
+	Status           models.RequestStatus `json:"status"`
+	CurrentStep      int                  `json:"current_step"`
+	AddedToCart      bool                 `json:"added_to_cart"`
+	CartError        string               `json:"cart_error,omitempty"`
+	ActorID          uint                 `json:"actor_id,omitempty"`
+	AwaitingApproval bool                 `json:"awaiting_approval"` // The subscriber can act on the request's pending approval step
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+// Subscription receives the messages for one connected client
+type Subscription struct {
+	userID      uint
+	permissions []string
+	messages    chan Message
+	closed      bool
+}
+
+// Messages returns the channel messages are sent on. It is closed when the
+// subscriber falls too far behind, its user is gone, or the broker stops.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Broker is an event listener that records events and publishes them to
+// subscribers once committed. SQLite serializes writes, so event IDs become
+// visible in order and polling for IDs after the last one misses none.
+type Broker struct {
+	db *gorm.DB
+
+	mu          sync.Mutex // Guards subscribers and lastID
+	subscribers map[*Subscription]struct{}
+	lastID      uint // Last event published
+	lastPrune   time.Time
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewBroker creates a new event stream broker
+func NewBroker(db *gorm.DB) *Broker {
+	return &Broker{
+		db:          db,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Handle records the event to be published once its transaction commits
+func (b *Broker) Handle(tx *gorm.DB, event *events.Event) error {
+	return tx.Create(&models.RequestEvent{
+		Type:      string(event.Type),
+		RequestID: event.Request.ID,
+		ActorID:   event.ActorID,
+	}).Error
+}
+
+// Start publishes the events recorded from now on
+func (b *Broker) Start() error {
+	var last models.RequestEvent
+	if err := b.db.Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	b.lastID = last.ID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.poll()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops publishing and closes every subscription
+func (b *Broker) Stop() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		b.close(sub)
+	}
+}
+
+// Subscribe starts sending the user the events they can see. Events after
+// lastEventID that are still kept are sent first, up to the buffer size, so
+// a client that reconnects can catch up; 0 only sends new events.
+func (b *Broker) Subscribe(userID uint, permissions []string, lastEventID uint) (*Subscription, error) {
+	sub := &Subscription{
+		userID:      userID,
+		permissions: permissions,
+		messages:    make(chan Message, bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > 0 && lastEventID < b.lastID {
+		var missed []models.RequestEvent
+		if err := b.db.Where("id > ? AND id <= ?", lastEventID, b.lastID).
+			Order("id ASC").Limit(bufferSize).Find(&missed).Error; err != nil {
+			return nil, err
+		}
+		b.publish(missed, []*Subscription{sub})
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe stops sending messages to the subscription
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+	b.close(sub)
+}
+
+// poll publishes the events committed since the last poll and prunes old ones
+func (b *Broker) poll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var recorded []models.RequestEvent
+	if err := b.db.Where("id > ?", b.lastID).Order("id ASC").Find(&recorded).Error; err != nil {
+		log.Printf("Failed to fetch request events: %v", err)
+		return
+	}
+	if len(recorded) > 0 {
+		b.lastID = recorded[len(recorded)-1].ID
+
+		subs := make([]*Subscription, 0, len(b.subscribers))
+		for sub := range b.subscribers {
+			subs = append(subs, sub)
+		}
+		b.publish(recorded, subs)
+	}
+
+	if time.Since(b.lastPrune) >= pruneInterval {
+		if err := b.db.Where("created_at < ?", time.Now().Add(-retention)).Delete(&models.RequestEvent{}).Error; err != nil {
+			log.Printf("Failed to prune request events: %v", err)
+		}
+		b.lastPrune = time.Now()
+	}
+}
+
+// publish sends each event to the subscribers who can see its request. Users
+// are reloaded for every batch, so changes to their scopes and delegations
+// apply to open streams.
+func (b *Broker) publish(recorded []models.RequestEvent, subs []*Subscription) {
+	if len(subs) == 0 {
+		return
+	}
+
+	users := make(map[uint]*models.User)
+	for _, sub := range subs {
+		if _, ok := users[sub.userID]; ok {
+			continue
+		}
+		user, err := visibility.LoadUser(b.db, sub.userID, sub.permissions)
+		if err != nil {
+			user = nil // Deleted users get no more messages
+		}
+		users[sub.userID] = user
+	}
+
+	for i := range recorded {
+		event := &recorded[i]
+
+		var request models.PurchaseRequest
+		err := b.db.Unscoped().
+			Preload("Requester", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Preload("ApprovalSteps").
+			First(&request, event.RequestID).Error
+		if err != nil {
+			log.Printf("Failed to load request %d for event %d: %v", event.RequestID, event.ID, err)
+			continue
+		}
+
+		for _, sub := range subs {
+			user := users[sub.userID]
+			if user == nil {
+				b.close(sub)
+				continue
+			}
+			if visibility.CanViewOrder(user, &request) {
+				b.send(sub, newMessage(event, &request, user))
+			}
+		}
+	}
+}
+
+// send queues the message for the subscriber, dropping subscribers that have
+// fallen behind; their client reconnects and catches up
+func (b *Broker) send(sub *Subscription, msg Message) {
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.messages <- msg:
+	default:
+		log.Printf("Dropping event stream of user %d: %d messages behind", sub.userID, bufferSize)
+		b.close(sub)
+	}
+}
+
+func (b *Broker) close(sub *Subscription) {
+	if !sub.closed {
+		sub.closed = true
+		close(sub.messages)
+	}
+}
+
+func newMessage(event *models.RequestEvent, request *models.PurchaseRequest, user *models.User) Message {
+	msg := Message{
+		ID:            event.ID,
+		Type:          events.Type(event.Type),
+		RequestID:     request.ID,
+		RequestNumber: request.RequestNumber,
+		Status:        request.Status,
+		CurrentStep:   request.CurrentStep,
+		AddedToCart:   request.AddedToCart,
+		CartError:     request.CartError,
+		ActorID:       event.ActorID,
+		CreatedAt:     event.CreatedAt,
+	}
+	if step := request.PendingStep(); request.IsPending() && step != nil {
+		msg.AwaitingApproval = step.IsAssignedTo(user, &request.Requester) ||
+			step.DelegationFor(user, &request.Requester) != nil
+	}
+	return msg
+}
@@ -0,0 +1,134 @@
+// Package visibility decides which purchase requests a user can see. Users
+// with requests.view_all see every request, unless they have been assigned
+// department or cost center scopes, which limit them to the requests of
+// requesters in those scopes. Everyone sees their own requests, and the
+// requests whose approval they can act on, including as a delegate.
+package visibility
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+)
+
+var ErrInvalidScope = errors.New("scope values cannot be empty")
+
+// LoadUser loads a user with their scopes, the delegations to them active
+// now, and the given permissions
+func LoadUser(db *gorm.DB, userID uint, permissions []string) (*models.User, error) {
+	now := time.Now()
+	var user models.User
+	if err := db.Preload("Scopes").
+		Preload("Delegations", "starts_at <= ? AND ends_at > ?", now, now).
+		Preload("Delegations.Delegator.Scopes").
+		First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	user.Permissions = permissions
+	return &user, nil
+}
+
+// ScopedRequesters returns a subquery of the IDs of the users in the user's
+// scopes, including deleted users so their requests stay visible
+func ScopedRequesters(db *gorm.DB, user *models.User) *gorm.DB {
+	departments, costCenters := user.ScopeValues()
+	return db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Model(&models.User{}).
+		Select("id").
+		Where("department IN ? OR cost_center IN ?", departments, costCenters)
+}
+
+// Covered returns a query scope limiting purchase requests to those of
+// requesters in the user's scopes; users without scopes are not limited
+func Covered(user *models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(user.Scopes) == 0 {
+			return db
+		}
+		return db.Where("purchase_requests.requester_id IN (?)", ScopedRequesters(db, user))
+	}
+}
+
+// Requests returns a query scope limiting purchase requests to those the user
+// can list: their own, and with requests.view_all those they cover
+func Requests(user *models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !user.Can(models.PermRequestsViewAll) {
+			return db.Where("purchase_requests.requester_id = ?", user.ID)
+		}
+		if len(user.Scopes) == 0 {
+			return db
+		}
+		return db.Where("purchase_requests.requester_id = ? OR purchase_requests.requester_id IN (?)",
+			user.ID, ScopedRequesters(db, user))
+	}
+}
+
+// CanView checks if the user can see the request: their own, one they cover
+// with requests.view_all, or one with an approval step assigned or delegated
+// to them. The request's Requester and ApprovalSteps must be loaded.
+func CanView(user *models.User, request *models.PurchaseRequest) bool {
+	if request.RequesterID == user.ID {
+		return true
+	}
+	if user.Can(models.PermRequestsViewAll) && user.Covers(&request.Requester) {
+		return true
+	}
+	for i := range request.ApprovalSteps {
+		step := &request.ApprovalSteps[i]
+		if step.IsAssignedTo(user, &request.Requester) || step.DelegationFor(user, &request.Requester) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CanViewOrder checks if the user can see the request, or can handle it as
+// an order manager once it is approved or purchased. The request's Requester
+// and ApprovalSteps must be loaded.
+func CanViewOrder(user *models.User, request *models.PurchaseRequest) bool {
+	if CanView(user, request) {
+		return true
+	}
+	return user.Can(models.PermOrdersManage) &&
+		(request.Status == models.StatusApproved || request.Status == models.StatusPurchased)
+}
+
+// SetScopes replaces the user's scopes with the departments and cost centers
+func SetScopes(tx *gorm.DB, userID uint, departments, costCenters []string) ([]models.UserScope, error) {
+	scopes := []models.UserScope{}
+	seen := make(map[models.UserScope]bool)
+	add := func(scopeType models.ScopeType, values []string) error {
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return ErrInvalidScope
+			}
+			scope := models.UserScope{UserID: userID, Type: scopeType, Value: value}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+		return nil
+	}
+	if err := add(models.ScopeDepartment, departments); err != nil {
+		return nil, err
+	}
+	if err := add(models.ScopeCostCenter, costCenters); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserScope{}).Error; err != nil {
+		return nil, err
+	}
+	if len(scopes) > 0 {
+		if err := tx.Create(&scopes).Error; err != nil {
+			return nil, err
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned for receivers on loopback, private,
+// link-local and other internal addresses
+var ErrAddressNotAllowed = errors.New("webhook receivers on internal network addresses are not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip.Addr.IsPrivate does not cover
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublicAddr reports whether deliveries may be sent to the address
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// checkHost rejects hosts that name an internal address without resolving
+// them, so obviously internal URLs fail when the webhook is saved
+func checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrAddressNotAllowed
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !isPublicAddr(addr) {
+		return ErrAddressNotAllowed
+	}
+	return nil
+}
+
+// newClient returns the HTTP client deliveries are sent with. Unless
+// allowPrivate is set, every connection is checked after DNS resolution so a
+// receiver cannot point the server at internal services, including through
+// a hostname that resolves to one. Redirects are never followed.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, address)
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	// No proxy, so the checked address is the one the delivery goes to
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
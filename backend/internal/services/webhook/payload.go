@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/events"
+)
+
+// Payload is the JSON body posted to webhooks
+type Payload struct {
+	ID        string      `json:"id"` // Event ID, the same in every webhook's delivery of the event and in retries
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// EventData is the data of a request event
+type EventData struct {
+	Request RequestData `json:"request"`
+	Actor   *UserData   `json:"actor,omitempty"` // Absent for events raised by background jobs
+	Step    *StepData   `json:"step,omitempty"`
+	Comment string      `json:"comment,omitempty"` // Approver comment, purchase notes or cart error
+}
+
+// TestData is the data of a test event
+type TestData struct {
+	Message string      `json:"message"`
+	Webhook WebhookData `json:"webhook"`
+}
+
+type WebhookData struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type RequestData struct {
+	ID              uint                 `json:"id"`
+	RequestNumber   string               `json:"request_number"`
+	Status          models.RequestStatus `json:"status"`
+	Urgency         models.Urgency       `json:"urgency"`
+	Justification   string               `json:"justification"`
+	URL             string               `json:"url,omitempty"`
+	ProductTitle    string               `json:"product_title"`
+	Amount          float64              `json:"amount"`
+	Currency        string               `json:"currency"`
+	Requester       UserData             `json:"requester"`
+	Items           []ItemData           `json:"items"`
+	IsAmazonURL     bool                 `json:"is_amazon_url"`
+	AddedToCart     bool                 `json:"added_to_cart"`
+	CartError       string               `json:"cart_error,omitempty"`
+	RejectionReason string               `json:"rejection_reason,omitempty"`
+	InfoRequestNote string               `json:"info_request_note,omitempty"`
+	PurchaseNotes   string               `json:"purchase_notes,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	ApprovedAt      *time.Time           `json:"approved_at,omitempty"`
+	RejectedAt      *time.Time           `json:"rejected_at,omitempty"`
+	PurchasedAt     *time.Time           `json:"purchased_at,omitempty"`
+}
+
+type ItemData struct {
+	LineNumber   int      `json:"line_number"`
+	SKU          string   `json:"sku,omitempty"`
+	ProductTitle string   `json:"product_title"`
+	URL          string   `json:"url,omitempty"`
+	Quantity     int      `json:"quantity"`
+	UnitPrice    *float64 `json:"unit_price,omitempty"`
+	LineTotal    float64  `json:"line_total"`
+	AddedToCart  bool     `json:"added_to_cart"`
+}
+
+type UserData struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Department string `json:"department,omitempty"`
+	CostCenter string `json:"cost_center,omitempty"`
+}
+
+type StepData struct {
+	Level  int                       `json:"level"`
+	Name   string                    `json:"name"`
+	Status models.ApprovalStepStatus `json:"status"`
+}
+
+// eventData builds the payload data of a request event, loading the
+// requester, line items and acting user when the event does not carry them
+func eventData(tx *gorm.DB, event *events.Event) (*EventData, error) {
+	request := event.Request
+
+	requester := request.Requester
+	if requester.ID != request.RequesterID {
+		if err := tx.Unscoped().First(&requester, request.RequesterID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	items := request.Items
+	if len(items) == 0 {
+		if err := tx.Where("request_id = ?", request.ID).Order("line_number ASC").Find(&items).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	data := &EventData{
+		Request: RequestData{
+			ID:              request.ID,
+			RequestNumber:   request.RequestNumber,
+			Status:          request.Status,
+			Urgency:         request.Urgency,
+			Justification:   request.Justification,
+			URL:             request.URL,
+			ProductTitle:    request.ProductTitle,
+			Amount:          request.Amount(),
+			Currency:        request.Currency,
+			Requester:       *userData(&requester),
+			Items:           make([]ItemData, len(items)),
+			IsAmazonURL:     request.IsAmazonURL,
+			AddedToCart:     request.AddedToCart,
+			CartError:       request.CartError,
+			RejectionReason: request.RejectionReason,
+			InfoRequestNote: request.InfoRequestNote,
+			PurchaseNotes:   request.PurchaseNotes,
+			CreatedAt:       request.CreatedAt,
+			ApprovedAt:      request.ApprovedAt,
+			RejectedAt:      request.RejectedAt,
+			PurchasedAt:     request.PurchasedAt,
+		},
+		Comment: event.Comment,
+	}
+	for i, item := range items {
+		data.Request.Items[i] = ItemData{
+			LineNumber:   item.LineNumber,
+			SKU:          item.SKU,
+			ProductTitle: item.ProductTitle,
+			URL:          item.URL,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			LineTotal:    item.LineTotal,
+			AddedToCart:  item.AddedToCart,
+		}
+	}
+
+	if event.ActorID != 0 {
+		var actor models.User
+		if err := tx.Unscoped().First(&actor, event.ActorID).Error; err != nil {
+			return nil, err
+		}
+		data.Actor = userData(&actor)
+	}
+	if event.Step != nil {
+		data.Step = &StepData{Level: event.Step.Level, Name: event.Step.Name, Status: event.Step.Status}
+	}
+	return data, nil
+}
+
+func userData(user *models.User) *UserData {
+	return &UserData{
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Department: user.Department,
+		CostCenter: user.CostCenter,
+	}
+}
@@ -0,0 +1,348 @@
+// Package webhook posts purchase request events to admin-managed external
+// URLs. Each delivery is a JSON payload signed with the webhook's secret and
+// sent by a background job, so failed deliveries are retried with backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"vista-backend/internal/models"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/pkg/crypto"
+)
+
+// TestEvent is the event type of deliveries sent by SendTest
+const TestEvent = "webhook.test"
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Vista-Event"
+	HeaderDelivery  = "X-Vista-Delivery"
+	HeaderSignature = "X-Vista-Signature"
+)
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log
+const maxResponseBody = 1024
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrNoEventTypes     = errors.New("webhook must subscribe to at least one event type")
+	ErrUnknownEventType = errors.New("unknown event type")
+	ErrSecretTooShort   = errors.New("webhook secret must be at least 16 characters")
+)
+
+// Service manages webhook secrets and delivers events to webhooks. It is an
+// event listener: subscribed webhooks get a delivery queued in the
+// transaction that caused the event.
+type Service struct {
+	db            *gorm.DB
+	jobQueue      *jobs.Queue
+	encryptionSvc *crypto.EncryptionService
+	client        *http.Client
+	allowPrivate  bool
+}
+
+// NewService creates a new webhook service. Receivers that take longer than
+// timeout to respond fail the attempt, and receivers on internal network
+// addresses are refused unless allowPrivate is set.
+func NewService(db *gorm.DB, jobQueue *jobs.Queue, encryptionSvc *crypto.EncryptionService, timeout time.Duration, allowPrivate bool) *Service {
+	return &Service{
+		db:            db,
+		jobQueue:      jobQueue,
+		encryptionSvc: encryptionSvc,
+		client:        newClient(timeout, allowPrivate),
+		allowPrivate:  allowPrivate,
+	}
+}
+
+// DeliveryPayload is the payload of a webhook delivery job
+type DeliveryPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// Validate checks a webhook's URL and event types. URLs naming an internal
+// address are refused here; hostnames are checked again when delivering.
+func (s *Service) Validate(rawURL string, eventTypes []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if !s.allowPrivate {
+		if err := checkHost(u.Hostname()); err != nil {
+			return err
+		}
+	}
+	if len(eventTypes) == 0 {
+		return ErrNoEventTypes
+	}
+	for _, eventType := range eventTypes {
+		if !isEventType(eventType) {
+			return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+		}
+	}
+	return nil
+}
+
+func isEventType(eventType string) bool {
+	for _, t := range events.Types {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns a webhook
+func (s *Service) Get(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := s.db.First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// SetSecret encrypts and stores a signing secret on the webhook
+func (s *Service) SetSecret(webhook *models.Webhook, secret string) error {
+	if len(secret) < 16 {
+		return ErrSecretTooShort
+	}
+	encrypted, err := s.encryptionSvc.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	webhook.EncryptedSecret = encrypted
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" keyed with the secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handle queues a delivery of the event to every active webhook subscribed to it
+func (s *Service) Handle(tx *gorm.DB, event *events.Event) error {
+	var webhooks []models.Webhook
+	if err := tx.Where("is_active = ?", true).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return err
+	}
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(string(event.Type)) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	data, err := eventData(tx, event)
+	if err != nil {
+		return err
+	}
+	payload := Payload{
+		ID:        uuid.New().String(),
+		Type:      string(event.Type),
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	requestID := event.Request.ID
+	for _, webhook := range subscribed {
+		delivery := models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   payload.ID,
+			EventType: payload.Type,
+			RequestID: &requestID,
+			Payload:   string(body),
+			Status:    models.DeliveryPending,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		job, err := s.jobQueue.Enqueue(tx, models.JobTypeWebhook, &requestID, DeliveryPayload{DeliveryID: delivery.ID})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&delivery).Update("job_id", job.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleJob is the job handler that sends a queued delivery. A failed
+// attempt leaves the delivery pending until the job gives up on it.
+func (s *Service) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload DeliveryPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	var delivery models.WebhookDelivery
+	if err := s.db.First(&delivery, payload.DeliveryID).Error; err != nil {
+		return jobs.Permanent(fmt.Errorf("delivery %d not found: %w", payload.DeliveryID, err))
+	}
+	if delivery.Status == models.DeliverySucceeded {
+		return nil
+	}
+
+	var webhook models.Webhook
+	err := s.db.First(&webhook, delivery.WebhookID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err != nil || !webhook.IsActive {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = "webhook was deleted or deactivated"
+		s.db.Save(&delivery)
+		return jobs.Permanent(errors.New(delivery.Error))
+	}
+
+	err = s.deliver(ctx, &webhook, &delivery)
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+	case jobs.IsFinalAttempt(job, err):
+		delivery.Status = models.DeliveryFailed
+	default:
+		delivery.Status = models.DeliveryPending
+	}
+	if saveErr := s.db.Save(&delivery).Error; saveErr != nil && err == nil {
+		return saveErr
+	}
+	return err
+}
+
+// SendTest sends a test event to the webhook right away, without retries,
+// and returns the logged delivery
+func (s *Service) SendTest(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	payload := Payload{
+		ID:        uuid.New().String(),
+		Type:      TestEvent,
+		CreatedAt: time.Now(),
+		Data: TestData{
+			Message: "Test event from IRIS Vista",
+			Webhook: WebhookData{ID: webhook.ID, Name: webhook.Name},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   payload.ID,
+		EventType: payload.Type,
+		Payload:   string(body),
+		Status:    models.DeliveryPending,
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	delivery.Status = models.DeliverySucceeded
+	if err := s.deliver(ctx, webhook, &delivery); err != nil {
+		delivery.Status = models.DeliveryFailed
+	}
+	if err := s.db.Save(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// deliver posts the delivery's payload to the webhook and records the
+// outcome of the attempt on the delivery, without saving it
+func (s *Service) deliver(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+	delivery.DurationMs = 0
+
+	err := s.post(ctx, webhook, delivery)
+	if err != nil {
+		delivery.Error = err.Error()
+		return err
+	}
+	now := time.Now()
+	delivery.DeliveredAt = &now
+	return nil
+}
+
+func (s *Service) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	secret, err := s.encryptionSvc.Decrypt(webhook.EncryptedSecret)
+	if err != nil {
+		return jobs.Permanent(errors.New("failed to decrypt webhook secret"))
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid webhook URL: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "IRIS-Vista-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderSignature, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body)))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if errors.Is(err, ErrAddressNotAllowed) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(responseBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
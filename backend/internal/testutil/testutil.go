@@ -0,0 +1,29 @@
+// Package testutil provides the database the service and handler tests run
+// against.
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"vista-backend/migrations"
+)
+
+// NewDB opens a SQLite database in a temporary directory of the test and
+// migrates it, seeding the built-in roles and permissions
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := migrations.RunMigrations(db); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return db
+}
@@ -2,16 +2,30 @@ package main
 
 import (
 	"log"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"vista-backend/config"
 	"vista-backend/internal/handlers"
 	"vista-backend/internal/middleware"
+	"vista-backend/internal/models"
 	"vista-backend/internal/services"
 	"vista-backend/internal/services/amazon"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/attachments"
+	"vista-backend/internal/services/budget"
+	"vista-backend/internal/services/currency"
+	"vista-backend/internal/services/events"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/services/jobs"
+	"vista-backend/internal/services/mail"
+	"vista-backend/internal/services/notify"
+	"vista-backend/internal/services/stream"
+	"vista-backend/internal/services/webhook"
 	"vista-backend/migrations"
 	"vista-backend/pkg/crypto"
 	"vista-backend/pkg/jwt"
+	"vista-backend/pkg/oidc"
 )
 
 func main() {
@@ -41,21 +55,176 @@ func main() {
 		log.Fatalf("Failed to initialize encryption service: %v", err)
 	}
 
-	authService := services.NewAuthService(db, jwtService)
+	loginGuard := services.NewLoginGuard(db, services.LoginGuardConfig{
+		MaxAccountFailures: cfg.Login.MaxAccountFailures,
+		MaxIPFailures:      cfg.Login.MaxIPFailures,
+		FailureWindow:      cfg.Login.FailureWindow,
+		LockoutDuration:    cfg.Login.LockoutDuration,
+	})
+	roleService := services.NewRoleService(db)
+	mfaService := services.NewMFAService(db, encryptionService, roleService, services.MFAConfig{
+		Enforce:         cfg.MFA.Enforce,
+		Issuer:          cfg.MFA.Issuer,
+		ChallengeExpiry: cfg.MFA.ChallengeExpiry,
+	})
+	passwordPolicy, err := services.NewPasswordPolicy(services.PasswordPolicyConfig{
+		MinLength:     cfg.Password.MinLength,
+		RequireUpper:  cfg.Password.RequireUpper,
+		RequireLower:  cfg.Password.RequireLower,
+		RequireDigit:  cfg.Password.RequireDigit,
+		RequireSymbol: cfg.Password.RequireSymbol,
+		DenyListFile:  cfg.Password.DenyListFile,
+		MaxAge:        cfg.Password.MaxAge,
+		HistorySize:   cfg.Password.HistorySize,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize password policy: %v", err)
+	}
+	authService := services.NewAuthService(db, jwtService, loginGuard, mfaService, passwordPolicy, roleService)
+	mailer, err := mail.New(mail.Config{
+		Driver: cfg.Mail.Driver,
+		From:   cfg.Mail.From,
+		SMTP: mail.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+		},
+		OutboxDir: cfg.Mail.OutboxDir,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	var oidcProvider *oidc.Provider
+	if cfg.OIDC.IssuerURL != "" {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       strings.Fields(cfg.OIDC.Scopes),
+		})
+	}
+	roleMapping, err := services.ParseRoleMapping(cfg.OIDC.RoleMapping, roleService)
+	if err != nil {
+		log.Fatalf("Invalid OIDC_ROLE_MAPPING: %v", err)
+	}
+	// "none" provisions only users in a mapped group
+	defaultRole := models.UserRole(cfg.OIDC.DefaultRole)
+	if defaultRole == "none" {
+		defaultRole = ""
+	} else if !roleService.Exists(defaultRole) {
+		log.Fatalf("Invalid OIDC_DEFAULT_ROLE: %s", cfg.OIDC.DefaultRole)
+	}
+	ssoService := services.NewSSOService(db, authService, roleService, oidcProvider, services.SSOConfig{
+		ProviderName:  cfg.OIDC.ProviderName,
+		GroupsClaim:   cfg.OIDC.GroupsClaim,
+		RoleMapping:   roleMapping,
+		DefaultRole:   defaultRole,
+		AutoProvision: cfg.OIDC.AutoProvision,
+		SyncRoles:     cfg.OIDC.SyncRoles,
+		TrustEmail:    cfg.OIDC.TrustEmail,
+	})
+	passwordTokens := services.NewPasswordTokenService(db, jwtService, authService, passwordPolicy, mailer, services.PasswordTokenConfig{
+		AppURL:       cfg.Server.AppURL,
+		ResetExpiry:  cfg.Password.ResetExpiry,
+		InviteExpiry: cfg.Password.InviteExpiry,
+	})
 	amazonService := amazon.NewAutomationService()
+	chainService := approval.NewChainService(db)
+	converter := currency.NewConverter(db, cfg.Currency.BaseCurrency)
+	budgetTracker := budget.NewTracker(db, converter)
+	inventoryService := inventory.NewService(db)
+
+	// Exchange rates and base currency amounts
+	if cfg.Currency.RatesFile != "" {
+		if n, err := converter.ImportFile(cfg.Currency.RatesFile); err != nil {
+			log.Printf("Failed to import exchange rates from %s: %v", cfg.Currency.RatesFile, err)
+		} else {
+			log.Printf("Imported %d exchange rates from %s", n, cfg.Currency.RatesFile)
+		}
+	}
+	if n, err := converter.NormalizeMissing(); err != nil {
+		log.Printf("Failed to normalize request amounts: %v", err)
+	} else if n > 0 {
+		log.Printf("Normalized %d request amounts to %s", n, converter.Base())
+	}
+
+	// Background job queue
+	jobQueue := jobs.NewQueue(db, jobs.Config{
+		Workers:      cfg.Jobs.Workers,
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		PollInterval: cfg.Jobs.PollInterval,
+		BaseBackoff:  cfg.Jobs.RetryBackoff,
+		MaxBackoff:   cfg.Jobs.MaxBackoff,
+	})
+
+	// Request lifecycle events, the notifications they send, the webhooks they
+	// call and the live streams they are published to
+	webhookService := webhook.NewService(db, jobQueue, encryptionService, cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivate)
+	streamBroker := stream.NewBroker(db)
+	dispatcher := events.NewDispatcher(notify.NewNotifier(jobQueue, cfg.Server.AppURL), webhookService, streamBroker)
+
+	jobQueue.Register(models.JobTypeAmazonCart, jobs.NewAmazonCartHandler(db, amazonService, encryptionService, dispatcher))
+	jobQueue.Register(models.JobTypeEmail, jobs.NewEmailHandler(mailer))
+	jobQueue.Register(models.JobTypeWebhook, webhookService.HandleJob)
+	jobQueue.Start()
+	defer jobQueue.Stop()
+
+	if err := streamBroker.Start(); err != nil {
+		log.Fatalf("Failed to start event stream: %v", err)
+	}
+	defer streamBroker.Stop()
+
+	// Low stock alerts and replenishment
+	stockMonitor := inventory.NewMonitor(db, chainService, converter, jobQueue, dispatcher, inventory.MonitorConfig{
+		Interval:      cfg.Inventory.CheckInterval,
+		AutoReplenish: cfg.Inventory.AutoReplenish,
+	})
+	stockMonitor.Start()
+	defer stockMonitor.Stop()
+
+	// Files attached to requests, kept out of the public uploads directory
+	attachmentStore, err := attachments.NewStore(cfg.Attachments.Dir, cfg.Attachments.MaxSize)
+	if err != nil {
+		log.Fatalf("Failed to create attachments directory: %v", err)
+	}
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(db)
-	productHandler := handlers.NewProductHandler(db)
-	requestHandler := handlers.NewRequestHandler(db)
-	approvalHandler := handlers.NewApprovalHandler(db, amazonService, encryptionService)
-	adminHandler := handlers.NewAdminHandler(db, encryptionService, amazonService)
+	authHandler := handlers.NewAuthHandler(authService, mfaService, passwordTokens, passwordPolicy, ssoService)
+	userHandler := handlers.NewUserHandler(db, authService, mfaService, passwordTokens, passwordPolicy, roleService)
+	productHandler := handlers.NewProductHandler(db, inventoryService)
+	requestHandler := handlers.NewRequestHandler(db, chainService, converter, jobQueue, dispatcher)
+	commentHandler := handlers.NewCommentHandler(db, roleService, attachmentStore, jobQueue, dispatcher)
+	attachmentHandler := handlers.NewAttachmentHandler(db, attachmentStore)
+	cartHandler := handlers.NewCartHandler(db)
+	inventoryHandler := handlers.NewInventoryHandler(db, stockMonitor)
+	approvalHandler := handlers.NewApprovalHandler(db, jobQueue, chainService, budgetTracker, converter, inventoryService, dispatcher)
+	approvalRuleHandler := handlers.NewApprovalRuleHandler(db, roleService)
+	delegationHandler := handlers.NewDelegationHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db)
+	streamHandler := handlers.NewStreamHandler(streamBroker, authService)
+	filterRuleHandler := handlers.NewFilterRuleHandler(db)
+	jobHandler := handlers.NewJobHandler(db, jobQueue)
+	budgetHandler := handlers.NewBudgetHandler(db)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(db, converter)
+	analyticsHandler := handlers.NewAnalyticsHandler(db, converter)
+	auditLogHandler := handlers.NewAuditLogHandler(db)
+	loginLockoutHandler := handlers.NewLoginLockoutHandler(db, loginGuard)
+	roleHandler := handlers.NewRoleHandler(db, roleService)
+	webhookHandler := handlers.NewWebhookHandler(db, webhookService)
+	adminHandler := handlers.NewAdminHandler(db, encryptionService, amazonService, jobQueue, budgetTracker, converter, inventoryService, attachmentStore, dispatcher)
 	uploadHandler := handlers.NewUploadHandler()
 
 	// Setup router
 	router := gin.Default()
 
+	// Client IPs key the login throttle, so forwarded headers are only
+	// believed from configured proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// CORS middleware
 	corsConfig := middleware.DefaultCORSConfig()
 	corsConfig.AllowOrigins = cfg.Server.AllowOrigins
@@ -73,20 +242,37 @@ func main() {
 		{
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA)
+			auth.POST("/mfa/setup", authHandler.BeginMFASetup)
+			auth.POST("/mfa/setup/confirm", authHandler.ConfirmMFASetup)
+			auth.POST("/password/forgot", authHandler.ForgotPassword)
+			auth.POST("/password/reset", authHandler.ResetPassword)
+			auth.GET("/password/policy", authHandler.GetPasswordPolicy)
+			auth.GET("/sso", authHandler.GetSSOStatus)
+			auth.GET("/sso/authorize", authHandler.BeginSSO)
+			auth.POST("/sso/callback", authHandler.CompleteSSO)
 		}
 
-		// Auth routes (protected)
+		// Auth routes (protected, also open to users who must change their password)
 		authProtected := v1.Group("/auth")
-		authProtected.Use(middleware.Auth(jwtService))
+		authProtected.Use(middleware.AuthAllowingPasswordChange(jwtService, authService))
 		{
 			authProtected.POST("/logout", authHandler.Logout)
 			authProtected.GET("/me", authHandler.Me)
+			authProtected.GET("/sessions", authHandler.ListSessions)
+			authProtected.DELETE("/sessions", authHandler.RevokeOtherSessions)
+			authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
+			authProtected.GET("/mfa", authHandler.GetMFAStatus)
+			authProtected.POST("/mfa/enroll", authHandler.EnrollMFA)
+			authProtected.POST("/mfa/enable", authHandler.EnableMFA)
+			authProtected.POST("/mfa/disable", authHandler.DisableMFA)
+			authProtected.POST("/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
 		}
 
-		// User routes (admin only)
+		// User routes (users.manage)
 		users := v1.Group("/users")
-		users.Use(middleware.Auth(jwtService))
-		users.Use(middleware.RequireAdmin())
+		users.Use(middleware.Auth(jwtService, authService))
+		users.Use(middleware.RequirePermission(models.PermUsersManage))
 		{
 			users.GET("", userHandler.ListUsers)
 			users.GET("/:id", userHandler.GetUser)
@@ -94,38 +280,54 @@ func main() {
 			users.PUT("/:id", userHandler.UpdateUser)
 			users.DELETE("/:id", userHandler.DeleteUser)
 			users.PATCH("/:id/toggle", userHandler.ToggleUserStatus)
+			users.POST("/:id/unlock", loginLockoutHandler.UnlockUser)
+			users.POST("/:id/mfa/reset", userHandler.ResetUserMFA)
+			users.POST("/:id/invite", userHandler.InviteUser)
+			users.GET("/:id/scopes", userHandler.GetUserScopes)
+			users.PUT("/:id/scopes", userHandler.SetUserScopes)
 		}
 
 		// User self-service routes
 		profile := v1.Group("/profile")
-		profile.Use(middleware.Auth(jwtService))
+		profile.Use(middleware.AuthAllowingPasswordChange(jwtService, authService))
 		{
 			profile.PUT("/password", userHandler.ChangePassword)
 		}
 
 		// Product routes (all authenticated users)
 		products := v1.Group("/products")
-		products.Use(middleware.Auth(jwtService))
+		products.Use(middleware.Auth(jwtService, authService))
 		{
 			products.GET("", productHandler.ListProducts)
 			products.GET("/categories", productHandler.GetCategories)
 			products.GET("/:id", productHandler.GetProduct)
 		}
 
-		// Product management routes (admin/supply chain)
+		// Product management routes (products.write, stock needs inventory.manage)
 		productsMgmt := v1.Group("/products")
-		productsMgmt.Use(middleware.Auth(jwtService))
-		productsMgmt.Use(middleware.RequireAdminOrSupplyChain())
+		productsMgmt.Use(middleware.Auth(jwtService, authService))
+		{
+			productsMgmt.POST("", middleware.RequirePermission(models.PermProductsWrite), productHandler.CreateProduct)
+			productsMgmt.PUT("/:id", middleware.RequirePermission(models.PermProductsWrite), productHandler.UpdateProduct)
+			productsMgmt.DELETE("/:id", middleware.RequirePermission(models.PermProductsWrite), productHandler.DeleteProduct)
+			productsMgmt.PATCH("/:id/stock", middleware.RequirePermission(models.PermInventoryManage), productHandler.UpdateStock)
+			productsMgmt.GET("/:id/stock-movements", middleware.RequirePermission(models.PermInventoryManage), productHandler.ListStockMovements)
+			productsMgmt.POST("/:id/stock/reconcile", middleware.RequirePermission(models.PermInventoryManage), productHandler.ReconcileStock)
+		}
+
+		// Inventory routes (inventory.manage)
+		inventoryRoutes := v1.Group("/inventory")
+		inventoryRoutes.Use(middleware.Auth(jwtService, authService))
+		inventoryRoutes.Use(middleware.RequirePermission(models.PermInventoryManage))
 		{
-			productsMgmt.POST("", productHandler.CreateProduct)
-			productsMgmt.PUT("/:id", productHandler.UpdateProduct)
-			productsMgmt.DELETE("/:id", productHandler.DeleteProduct)
-			productsMgmt.PATCH("/:id/stock", productHandler.UpdateStock)
+			inventoryRoutes.GET("/alerts", inventoryHandler.ListStockAlerts)
+			inventoryRoutes.POST("/alerts/check", inventoryHandler.CheckStock)
+			inventoryRoutes.POST("/alerts/:id/replenish", inventoryHandler.ReplenishStockAlert)
 		}
 
 		// Purchase request routes (all authenticated users)
 		requests := v1.Group("/purchase-requests")
-		requests.Use(middleware.Auth(jwtService))
+		requests.Use(middleware.Auth(jwtService, authService))
 		{
 			requests.POST("/extract-metadata", requestHandler.ExtractMetadata)
 			requests.POST("", requestHandler.CreateRequest)
@@ -133,52 +335,182 @@ func main() {
 			requests.GET("/:id", requestHandler.GetRequest)
 			requests.PUT("/:id", requestHandler.UpdateRequest)
 			requests.DELETE("/:id", requestHandler.CancelRequest)
+			requests.GET("/:id/comments", commentHandler.ListComments)
+			requests.POST("/:id/comments", commentHandler.CreateComment)
+			requests.GET("/:id/mentionable-users", commentHandler.ListMentionableUsers)
+			requests.GET("/:id/attachments", attachmentHandler.ListAttachments)
+			requests.POST("/:id/attachments", attachmentHandler.UploadAttachments)
+			requests.GET("/:id/attachments/:attachmentId", attachmentHandler.DownloadAttachment)
+			requests.DELETE("/:id/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
 		}
 
-		// All requests route (for admin/gm/scm)
+		// Shopping cart routes (all authenticated users)
+		cart := v1.Group("/cart")
+		cart.Use(middleware.Auth(jwtService, authService))
+		{
+			cart.GET("", cartHandler.GetCart)
+			cart.DELETE("", cartHandler.ClearCart)
+			cart.POST("/items", cartHandler.AddCartItem)
+			cart.PUT("/items/:id", cartHandler.UpdateCartItem)
+			cart.DELETE("/items/:id", cartHandler.RemoveCartItem)
+			cart.POST("/checkout", requestHandler.CheckoutCart)
+		}
+
+		// All requests route (everyone's with requests.view_all, otherwise the caller's own)
 		allRequests := v1.Group("/requests")
-		allRequests.Use(middleware.Auth(jwtService))
-		allRequests.Use(middleware.CanViewAllRequests())
+		allRequests.Use(middleware.Auth(jwtService, authService))
 		{
 			allRequests.GET("", requestHandler.ListRequests)
 		}
 
-		// Approval routes (general manager)
+		// Approval routes (any user assigned to an approval step)
 		approvals := v1.Group("/approvals")
-		approvals.Use(middleware.Auth(jwtService))
-		approvals.Use(middleware.RequireApprover())
+		approvals.Use(middleware.Auth(jwtService, authService))
 		{
 			approvals.GET("", approvalHandler.ListPendingApprovals)
-			approvals.GET("/stats", approvalHandler.GetApprovalStats)
+			approvals.GET("/stats", middleware.RequirePermission(models.PermRequestsViewStats), approvalHandler.GetApprovalStats)
 			approvals.GET("/:id", approvalHandler.GetApprovalDetails)
 			approvals.POST("/:id/approve", approvalHandler.ApproveRequest)
 			approvals.POST("/:id/reject", approvalHandler.RejectRequest)
 			approvals.POST("/:id/request-info", approvalHandler.RequestInfo)
 		}
 
-		// Admin routes
+		// Notification routes (own notifications)
+		notifications := v1.Group("/notifications")
+		notifications.Use(middleware.Auth(jwtService, authService))
+		{
+			notifications.GET("", notificationHandler.ListNotifications)
+			notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+			notifications.POST("/read-all", notificationHandler.MarkAllNotificationsRead)
+			notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
+			notifications.GET("/preferences", notificationHandler.GetNotificationPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdateNotificationPreferences)
+		}
+
+		// Live request updates as Server-Sent Events
+		eventStream := v1.Group("/events")
+		eventStream.Use(middleware.Auth(jwtService, authService))
+		{
+			eventStream.GET("/stream", streamHandler.StreamEvents)
+		}
+
+		// Delegation routes (own delegations; others' need users.manage)
+		delegations := v1.Group("/delegations")
+		delegations.Use(middleware.Auth(jwtService, authService))
+		{
+			delegations.GET("", delegationHandler.ListDelegations)
+			delegations.GET("/delegates", delegationHandler.ListDelegates)
+			delegations.POST("", delegationHandler.CreateDelegation)
+			delegations.DELETE("/:id", delegationHandler.DeleteDelegation)
+		}
+
+		// Budget routes (budgets.view, changes need budgets.manage)
+		budgets := v1.Group("/budgets")
+		budgets.Use(middleware.Auth(jwtService, authService))
+		budgets.Use(middleware.RequirePermission(models.PermBudgetsView, models.PermBudgetsManage))
+		{
+			budgets.GET("", budgetHandler.ListBudgets)
+			budgets.GET("/utilization", budgetHandler.GetUtilization)
+			budgets.GET("/:id", budgetHandler.GetBudget)
+			budgets.POST("", middleware.RequirePermission(models.PermBudgetsManage), budgetHandler.CreateBudget)
+			budgets.PUT("/:id", middleware.RequirePermission(models.PermBudgetsManage), budgetHandler.UpdateBudget)
+			budgets.DELETE("/:id", middleware.RequirePermission(models.PermBudgetsManage), budgetHandler.DeleteBudget)
+		}
+
+		// Analytics routes (analytics.view)
+		analytics := v1.Group("/analytics")
+		analytics.Use(middleware.Auth(jwtService, authService))
+		analytics.Use(middleware.RequirePermission(models.PermAnalyticsView))
+		{
+			analytics.GET("/summary", analyticsHandler.GetSummary)
+			analytics.GET("/spend-by-month", analyticsHandler.GetSpendByMonth)
+			analytics.GET("/top-requesters", analyticsHandler.GetTopRequesters)
+			analytics.GET("/top-departments", analyticsHandler.GetTopDepartments)
+			analytics.GET("/top-cost-centers", analyticsHandler.GetTopCostCenters)
+			analytics.GET("/top-sites", analyticsHandler.GetTopSites)
+			analytics.GET("/lead-times", analyticsHandler.GetLeadTimes)
+			analytics.GET("/rejection-rates", analyticsHandler.GetRejectionRates)
+		}
+
+		// Admin routes, each behind the permission for its area
 		admin := v1.Group("/admin")
-		admin.Use(middleware.Auth(jwtService))
-		admin.Use(middleware.RequireAdmin())
+		admin.Use(middleware.Auth(jwtService, authService))
 		{
-			admin.GET("/dashboard", adminHandler.GetDashboardStats)
+			admin.GET("/dashboard", middleware.RequirePermission(models.PermAdminDashboard), adminHandler.GetDashboardStats)
 
 			// Amazon config
-			admin.GET("/amazon/config", adminHandler.GetAmazonConfig)
-			admin.PUT("/amazon/config", adminHandler.SaveAmazonConfig)
-			admin.POST("/amazon/test", adminHandler.TestAmazonConnection)
-			admin.GET("/amazon/session", adminHandler.GetAmazonSessionStatus)
+			amazonConfig := middleware.RequirePermission(models.PermAmazonConfigure)
+			admin.GET("/amazon/config", amazonConfig, adminHandler.GetAmazonConfig)
+			admin.PUT("/amazon/config", amazonConfig, adminHandler.SaveAmazonConfig)
+			admin.POST("/amazon/test", amazonConfig, adminHandler.TestAmazonConnection)
+			admin.GET("/amazon/session", amazonConfig, adminHandler.GetAmazonSessionStatus)
 
 			// Approved orders management
-			admin.GET("/approved-orders", adminHandler.GetApprovedOrders)
-			admin.PATCH("/orders/:id/purchased", adminHandler.MarkAsPurchased)
-			admin.POST("/orders/:id/retry-cart", adminHandler.RetryAddToCart)
+			ordersManage := middleware.RequirePermission(models.PermOrdersManage)
+			admin.GET("/approved-orders", ordersManage, adminHandler.GetApprovedOrders)
+			admin.PATCH("/orders/:id/purchased", ordersManage, adminHandler.MarkAsPurchased)
+			admin.POST("/orders/:id/retry-cart", ordersManage, adminHandler.RetryAddToCart)
+
+			// Exchange rates
+			exchangeRatesManage := middleware.RequirePermission(models.PermExchangeRatesManage)
+			admin.GET("/exchange-rates", exchangeRatesManage, exchangeRateHandler.ListExchangeRates)
+			admin.POST("/exchange-rates", exchangeRatesManage, exchangeRateHandler.CreateExchangeRate)
+			admin.POST("/exchange-rates/import", exchangeRatesManage, exchangeRateHandler.ImportExchangeRates)
+			admin.DELETE("/exchange-rates/:id", exchangeRatesManage, exchangeRateHandler.DeleteExchangeRate)
+
+			// Background jobs
+			jobsManage := middleware.RequirePermission(models.PermJobsManage)
+			admin.GET("/jobs", jobsManage, jobHandler.ListJobs)
+			admin.GET("/jobs/:id", jobsManage, jobHandler.GetJob)
+			admin.POST("/jobs/:id/retry", jobsManage, jobHandler.RetryJob)
+			admin.POST("/jobs/:id/cancel", jobsManage, jobHandler.CancelJob)
+
+			// Webhooks
+			webhooksManage := middleware.RequirePermission(models.PermWebhooksManage)
+			admin.GET("/webhooks", webhooksManage, webhookHandler.ListWebhooks)
+			admin.GET("/webhooks/events", webhooksManage, webhookHandler.ListWebhookEvents)
+			admin.POST("/webhooks", webhooksManage, webhookHandler.CreateWebhook)
+			admin.PUT("/webhooks/:id", webhooksManage, webhookHandler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", webhooksManage, webhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", webhooksManage, webhookHandler.ListWebhookDeliveries)
+			admin.POST("/webhooks/:id/test", webhooksManage, webhookHandler.TestWebhook)
+
+			// Approval chains
+			approvalRulesManage := middleware.RequirePermission(models.PermApprovalRulesManage)
+			admin.GET("/approval-rules", approvalRulesManage, approvalRuleHandler.ListApprovalRules)
+			admin.POST("/approval-rules", approvalRulesManage, approvalRuleHandler.CreateApprovalRule)
+			admin.PUT("/approval-rules/:id", approvalRulesManage, approvalRuleHandler.UpdateApprovalRule)
+			admin.DELETE("/approval-rules/:id", approvalRulesManage, approvalRuleHandler.DeleteApprovalRule)
+
+			// Filter rules
+			filterRulesManage := middleware.RequirePermission(models.PermFilterRulesManage)
+			admin.GET("/filter-rules", filterRulesManage, filterRuleHandler.ListFilterRules)
+			admin.POST("/filter-rules", filterRulesManage, filterRuleHandler.CreateFilterRule)
+			admin.PUT("/filter-rules/:id", filterRulesManage, filterRuleHandler.UpdateFilterRule)
+			admin.PATCH("/filter-rules/:id/toggle", filterRulesManage, filterRuleHandler.ToggleFilterRule)
+			admin.DELETE("/filter-rules/:id", filterRulesManage, filterRuleHandler.DeleteFilterRule)
+
+			// Audit log
+			admin.GET("/audit-logs", middleware.RequirePermission(models.PermAuditView), auditLogHandler.ListAuditLogs)
+
+			// Failed logins and lockouts
+			usersManage := middleware.RequirePermission(models.PermUsersManage)
+			admin.GET("/login-lockouts", usersManage, loginLockoutHandler.ListLoginLockouts)
+			admin.DELETE("/login-lockouts/:id", usersManage, loginLockoutHandler.UnlockLogin)
+
+			// Roles and permissions; user admins list roles to assign them
+			rolesManage := middleware.RequirePermission(models.PermRolesManage)
+			admin.GET("/permissions", rolesManage, roleHandler.ListPermissions)
+			admin.GET("/roles", middleware.RequirePermission(models.PermRolesManage, models.PermUsersManage), roleHandler.ListRoles)
+			admin.POST("/roles", rolesManage, roleHandler.CreateRole)
+			admin.PUT("/roles/:id", rolesManage, roleHandler.UpdateRole)
+			admin.DELETE("/roles/:id", rolesManage, roleHandler.DeleteRole)
 		}
 
-		// Upload routes (admin/supply chain)
+		// Upload routes (products.write)
 		upload := v1.Group("/upload")
-		upload.Use(middleware.Auth(jwtService))
-		upload.Use(middleware.RequireAdminOrSupplyChain())
+		upload.Use(middleware.Auth(jwtService, authService))
+		upload.Use(middleware.RequirePermission(models.PermProductsWrite))
 		{
 			upload.GET("/requirements", uploadHandler.GetUploadRequirements)
 			upload.POST("/image", uploadHandler.UploadImage)
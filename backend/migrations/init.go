@@ -1,11 +1,15 @@
 package migrations
 
 import (
+	"errors"
 	"log"
 
 	"gorm.io/gorm"
 	"vista-backend/internal/models"
 	"vista-backend/internal/services"
+	"vista-backend/internal/services/approval"
+	"vista-backend/internal/services/inventory"
+	"vista-backend/internal/services/metadata"
 )
 
 // RunMigrations runs all database migrations
@@ -20,11 +24,60 @@ func RunMigrations(db *gorm.DB) error {
 		&models.RequestHistory{},
 		&models.AmazonConfig{},
 		&models.AuditLog{},
+		&models.ApprovalRule{},
+		&models.ApprovalRuleStep{},
+		&models.ApprovalStep{},
+		&models.FilterRule{},
+		&models.Job{},
+		&models.JobAttempt{},
+		&models.Budget{},
+		&models.ExchangeRate{},
+		&models.CartItem{},
+		&models.RequestItem{},
+		&models.StockMovement{},
+		&models.StockAlert{},
+		&models.Session{},
+		&models.LoginThrottle{},
+		&models.MFARecoveryCode{},
+		&models.PasswordToken{},
+		&models.PasswordHistory{},
+		&models.SSOLogin{},
+		&models.Permission{},
+		&models.Role{},
+		&models.UserScope{},
+		&models.ApprovalDelegation{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.RequestEvent{},
+		&models.RequestComment{},
+		&models.RequestAttachment{},
 	)
 	if err != nil {
 		return err
 	}
 
+	if err := backfillApprovalSteps(db); err != nil {
+		return err
+	}
+
+	if err := backfillSiteNames(db); err != nil {
+		return err
+	}
+
+	if err := backfillRequestItems(db); err != nil {
+		return err
+	}
+
+	if err := backfillPasswordChanges(db); err != nil {
+		return err
+	}
+
+	if err := syncRoles(db); err != nil {
+		return err
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
@@ -44,19 +97,20 @@ func SeedData(db *gorm.DB) error {
 		}
 
 		admin := models.User{
-			Email:        "admin@company.com",
-			PasswordHash: hashedPassword,
-			Name:         "System Admin",
-			Role:         models.RoleAdmin,
-			CompanyCode:  "CC-001",
-			CostCenter:   "CC-ADMIN",
-			Department:   "IT",
-			Status:       "active",
+			Email:              "admin@company.com",
+			PasswordHash:       hashedPassword,
+			Name:               "System Admin",
+			Role:               models.RoleAdmin,
+			CompanyCode:        "CC-001",
+			CostCenter:         "CC-ADMIN",
+			Department:         "IT",
+			Status:             "active",
+			MustChangePassword: true,
 		}
 		if err := db.Create(&admin).Error; err != nil {
 			return err
 		}
-		log.Println("Created default admin user: admin@company.com / admin123")
+		log.Println("Created default admin user: admin@company.com / admin123 (must be changed at first login)")
 	}
 
 	// Seed sample users
@@ -65,34 +119,37 @@ func SeedData(db *gorm.DB) error {
 	if userCount <= 1 {
 		sampleUsers := []models.User{
 			{
-				Email:        "gm@company.com",
-				PasswordHash: mustHash("password123"),
-				Name:         "John Smith",
-				Role:         models.RoleGeneralManager,
-				CompanyCode:  "CC-001",
-				CostCenter:   "CC-1001",
-				Department:   "Operations",
-				Status:       "active",
+				Email:              "gm@company.com",
+				PasswordHash:       mustHash("password123"),
+				Name:               "John Smith",
+				Role:               models.RoleGeneralManager,
+				CompanyCode:        "CC-001",
+				CostCenter:         "CC-1001",
+				Department:         "Operations",
+				Status:             "active",
+				MustChangePassword: true,
 			},
 			{
-				Email:        "scm@company.com",
-				PasswordHash: mustHash("password123"),
-				Name:         "Alice Wang",
-				Role:         models.RoleSupplyChainManager,
-				CompanyCode:  "CC-001",
-				CostCenter:   "CC-2001",
-				Department:   "Supply Chain",
-				Status:       "active",
+				Email:              "scm@company.com",
+				PasswordHash:       mustHash("password123"),
+				Name:               "Alice Wang",
+				Role:               models.RoleSupplyChainManager,
+				CompanyCode:        "CC-001",
+				CostCenter:         "CC-2001",
+				Department:         "Supply Chain",
+				Status:             "active",
+				MustChangePassword: true,
 			},
 			{
-				Email:        "employee@company.com",
-				PasswordHash: mustHash("password123"),
-				Name:         "Bob Chen",
-				Role:         models.RoleEmployee,
-				CompanyCode:  "CC-001",
-				CostCenter:   "CC-3001",
-				Department:   "Engineering",
-				Status:       "active",
+				Email:              "employee@company.com",
+				PasswordHash:       mustHash("password123"),
+				Name:               "Bob Chen",
+				Role:               models.RoleEmployee,
+				CompanyCode:        "CC-001",
+				CostCenter:         "CC-3001",
+				Department:         "Engineering",
+				Status:             "active",
+				MustChangePassword: true,
 			},
 		}
 
@@ -147,10 +204,194 @@ func SeedData(db *gorm.DB) error {
 		log.Println("Created sample products")
 	}
 
+	// Account for stock that predates the movement ledger, including seeded stock
+	opened, err := inventory.OpenBalances(db)
+	if err != nil {
+		return err
+	}
+	if opened > 0 {
+		log.Printf("Recorded opening stock balances for %d products", opened)
+	}
+
 	log.Println("Database seeding completed successfully")
 	return nil
 }
 
+// backfillApprovalSteps starts approval chains for open requests created
+// before multi-level approvals existed
+func backfillApprovalSteps(db *gorm.DB) error {
+	var requests []models.PurchaseRequest
+	if err := db.
+		Where("status IN ?", []models.RequestStatus{models.StatusPending, models.StatusInfoRequested}).
+		Where("NOT EXISTS (SELECT 1 FROM approval_steps WHERE approval_steps.request_id = purchase_requests.id)").
+		Find(&requests).Error; err != nil {
+		return err
+	}
+
+	chainSvc := approval.NewChainService(db)
+	for i := range requests {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return chainSvc.StartChain(tx, &requests[i])
+		}); err != nil {
+			return err
+		}
+	}
+	if len(requests) > 0 {
+		log.Printf("Started approval chains for %d existing requests", len(requests))
+	}
+	return nil
+}
+
+// backfillSiteNames derives the site of requests created before it was stored
+func backfillSiteNames(db *gorm.DB) error {
+	var requests []models.PurchaseRequest
+	if err := db.Select("id", "url").Where("site_name = '' OR site_name IS NULL").Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		siteName := metadata.SiteNameFromURL(request.URL)
+		if siteName == "" {
+			continue
+		}
+		if err := db.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Update("site_name", siteName).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillRequestItems gives single-product requests created before line items
+// existed their first line and total
+func backfillRequestItems(db *gorm.DB) error {
+	var requests []models.PurchaseRequest
+	if err := db.Where("id NOT IN (?)", db.Model(&models.RequestItem{}).Select("request_id")).Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		item := models.RequestItem{
+			RequestID:       request.ID,
+			LineNumber:      1,
+			URL:             request.URL,
+			ProductTitle:    request.ProductTitle,
+			ProductImageURL: request.ProductImageURL,
+			SiteName:        request.SiteName,
+			Quantity:        request.Quantity,
+			UnitPrice:       request.EstimatedPrice,
+			Currency:        request.Currency,
+			IsAmazonURL:     request.IsAmazonURL,
+			AmazonASIN:      request.AmazonASIN,
+			AddedToCart:     request.AddedToCart,
+			AddedToCartAt:   request.AddedToCartAt,
+		}
+		item.LineTotal = item.Subtotal()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.PurchaseRequest{}).Where("id = ?", request.ID).Update("total_amount", item.LineTotal).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(requests) > 0 {
+		log.Printf("Created line items for %d existing requests", len(requests))
+	}
+	return nil
+}
+
+// seededPasswords are the well-known passwords of the seeded accounts
+var seededPasswords = map[string]string{
+	"admin@company.com":    "admin123",
+	"gm@company.com":       "password123",
+	"scm@company.com":      "password123",
+	"employee@company.com": "password123",
+}
+
+// backfillPasswordChanges dates the passwords of users created before password
+// changes were tracked, so they expire with the policy's maximum age. Seeded
+// accounts still using their well-known password must change it.
+func backfillPasswordChanges(db *gorm.DB) error {
+	var users []models.User
+	if err := db.Select("id", "email", "password_hash", "created_at").Where("password_changed_at IS NULL").Find(&users).Error; err != nil {
+		return err
+	}
+
+	forced := 0
+	for _, user := range users {
+		updates := map[string]interface{}{"password_changed_at": user.CreatedAt}
+		if password, ok := seededPasswords[user.Email]; ok && services.VerifyPassword(password, user.PasswordHash) {
+			updates["must_change_password"] = true
+			forced++
+		}
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if forced > 0 {
+		log.Printf("Required %d seeded accounts to change their default password", forced)
+	}
+	return nil
+}
+
+// syncRoles stores the permissions the code checks, creates the built-in roles
+// the first time and gives the admin role every permission, including ones
+// added since the last start
+func syncRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, definition := range models.PermissionDefinitions {
+			permission := models.Permission{Name: definition.Name}
+			if err := tx.Where(permission).Assign(models.Permission{Description: definition.Description}).FirstOrCreate(&permission).Error; err != nil {
+				return err
+			}
+		}
+		var all []models.Permission
+		if err := tx.Find(&all).Error; err != nil {
+			return err
+		}
+		byName := make(map[string]models.Permission, len(all))
+		for _, permission := range all {
+			byName[permission.Name] = permission
+		}
+
+		for _, builtIn := range models.BuiltInRoles {
+			var role models.Role
+			err := tx.Where("name = ?", builtIn.Name).First(&role).Error
+			if err == nil && builtIn.Name != string(models.RoleAdmin) {
+				continue
+			}
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			permissions := make([]models.Permission, 0, len(builtIn.Permissions))
+			for _, p := range builtIn.Permissions {
+				permissions = append(permissions, byName[p.Name])
+			}
+			if builtIn.Name == string(models.RoleAdmin) {
+				permissions = all
+			}
+
+			if role.ID == 0 {
+				role = builtIn
+				role.BuiltIn = true
+				role.Permissions = nil
+				if err := tx.Create(&role).Error; err != nil {
+					return err
+				}
+				log.Printf("Created built-in role %s", role.Name)
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func mustHash(password string) string {
 	hash, err := services.HashPassword(password)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
@@ -18,6 +19,16 @@ type TokenType string
 const (
 	AccessToken  TokenType = "access"
 	RefreshToken TokenType = "refresh"
+
+	// Challenge tokens carry a login that passed the password check to its
+	// second factor; they cannot be used to call the API
+	MFAChallengeToken TokenType = "mfa_challenge"
+	MFASetupToken     TokenType = "mfa_setup"
+
+	// One-time tokens sent by email to set a password; their IDs are stored
+	// so each can be used once
+	PasswordResetToken TokenType = "password_reset"
+	InviteToken        TokenType = "invite"
 )
 
 type Claims struct {
@@ -25,6 +36,8 @@ type Claims struct {
 	Email  string    `json:"email"`
 	Role   string    `json:"role"`
 	Type   TokenType `json:"type"`
+	// SessionID identifies the login session the token was issued for
+	SessionID uint `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -43,23 +56,33 @@ func NewJWTService(secretKey string, accessExpiry, refreshExpiry time.Duration)
 	}
 }
 
-// GenerateAccessToken generates an access token for the user
-func (js *JWTService) GenerateAccessToken(userID uint, email, role string) (string, error) {
-	return js.generateToken(userID, email, role, AccessToken, js.accessTokenExpiry)
+// RefreshTokenExpiry returns how long refresh tokens are valid
+func (js *JWTService) RefreshTokenExpiry() time.Duration {
+	return js.refreshTokenExpiry
 }
 
-// GenerateRefreshToken generates a refresh token for the user
-func (js *JWTService) GenerateRefreshToken(userID uint, email, role string) (string, error) {
-	return js.generateToken(userID, email, role, RefreshToken, js.refreshTokenExpiry)
+// GenerateAccessToken generates an access token for the user's session
+func (js *JWTService) GenerateAccessToken(userID uint, email, role string, sessionID uint) (string, error) {
+	token, _, err := js.generateToken(userID, email, role, sessionID, AccessToken, js.accessTokenExpiry)
+	return token, err
 }
 
-func (js *JWTService) generateToken(userID uint, email, role string, tokenType TokenType, expiry time.Duration) (string, error) {
+// GenerateRefreshToken generates a refresh token for the user's session and returns it with its ID
+func (js *JWTService) GenerateRefreshToken(userID uint, email, role string, sessionID uint) (string, string, error) {
+	return js.generateToken(userID, email, role, sessionID, RefreshToken, js.refreshTokenExpiry)
+}
+
+// generateToken signs a token with a random ID (jti), returning the token and its ID
+func (js *JWTService) generateToken(userID uint, email, role string, sessionID uint, tokenType TokenType, expiry time.Duration) (string, string, error) {
+	tokenID := uuid.NewString()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		Type:   tokenType,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		Type:      tokenType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -67,7 +90,22 @@ func (js *JWTService) generateToken(userID uint, email, role string, tokenType T
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(js.secretKey)
+	signed, err := token.SignedString(js.secretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, tokenID, nil
+}
+
+// GenerateChallengeToken generates a short-lived token for a login waiting on its second factor
+func (js *JWTService) GenerateChallengeToken(userID uint, email, role string, tokenType TokenType, expiry time.Duration) (string, error) {
+	token, _, err := js.generateToken(userID, email, role, 0, tokenType, expiry)
+	return token, err
+}
+
+// GenerateOneTimeToken generates a token for a single action and returns it with its ID
+func (js *JWTService) GenerateOneTimeToken(userID uint, email string, tokenType TokenType, expiry time.Duration) (string, string, error) {
+	return js.generateToken(userID, email, "", 0, tokenType, expiry)
 }
 
 // ValidateToken validates a token and returns the claims
@@ -118,28 +156,43 @@ func (js *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error)
 	return claims, nil
 }
 
+// ValidateChallengeToken validates a challenge token of the given type
+func (js *JWTService) ValidateChallengeToken(tokenString string, tokenType TokenType) (*Claims, error) {
+	claims, err := js.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // TokenPair represents both access and refresh tokens
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"` // Access token expiry in seconds
+
+	RefreshTokenID string `json:"-"` // jti of the refresh token, stored with the session
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func (js *JWTService) GenerateTokenPair(userID uint, email, role string) (*TokenPair, error) {
-	accessToken, err := js.GenerateAccessToken(userID, email, role)
+// GenerateTokenPair generates both access and refresh tokens for the user's session
+func (js *JWTService) GenerateTokenPair(userID uint, email, role string, sessionID uint) (*TokenPair, error) {
+	accessToken, err := js.GenerateAccessToken(userID, email, role, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := js.GenerateRefreshToken(userID, email, role)
+	refreshToken, refreshTokenID, err := js.GenerateRefreshToken(userID, email, role, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(js.accessTokenExpiry.Seconds()),
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		ExpiresIn:      int64(js.accessTokenExpiry.Seconds()),
+		RefreshTokenID: refreshTokenID,
 	}, nil
 }
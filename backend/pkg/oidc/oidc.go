@@ -0,0 +1,365 @@
+// Package oidc is a minimal OpenID Connect relying party for the
+// authorization code flow with PKCE
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing keys are fetched again for an unknown key ID at most this often,
+// so tokens with made up key IDs cannot make us hammer the provider
+const jwksRefreshInterval = time.Minute
+
+var (
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	ErrNonceMismatch  = errors.New("ID token nonce does not match the login")
+)
+
+// Config identifies the provider and this client registered with it
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims are the identity claims of a verified login
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // nil when the provider does not say
+	Name          string
+	Raw           map[string]interface{}
+}
+
+// Strings returns a claim that holds a string or a list of strings, such as groups
+func (c *Claims) Strings(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to an OpenID provider. Its discovery document and signing
+// keys are fetched on first use, so the server starts while the provider is down.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	meta          *metadata
+	keys          map[string]interface{} // Public keys by key ID
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates a provider client
+func NewProvider(cfg Config) *Provider {
+	cfg.IssuerURL = strings.TrimRight(cfg.IssuerURL, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AuthCodeURL returns the provider URL to send the browser to. state and
+// nonce tie the response to this login, and verifier is the PKCE code
+// verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the claims of its
+// verified ID token, completed from the userinfo endpoint when the ID token
+// has no email
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token request failed: %d %s %s", status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+
+	claims, err := p.verify(ctx, meta, tokens.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != "" && claims.Raw["nonce"] != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	if claims.Email == "" && meta.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		if err := p.addUserinfo(ctx, meta, tokens.AccessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// verify checks the ID token's signature, issuer, audience and expiry
+func (p *Provider) verify(ctx context.Context, meta *metadata, idToken string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims := &Claims{Raw: raw}
+	claims.fill()
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// addUserinfo fills in claims from the userinfo endpoint
+func (p *Provider) addUserinfo(ctx context.Context, meta *metadata, accessToken string, claims *Claims) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.UserinfoEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	info := map[string]interface{}{}
+	status, err := p.doJSON(req, &info)
+	if err != nil {
+		return fmt.Errorf("userinfo request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("userinfo request failed: %d", status)
+	}
+	// The response must be about the same user as the ID token
+	if info["sub"] != claims.Subject {
+		return fmt.Errorf("%w: userinfo subject does not match", ErrInvalidIDToken)
+	}
+
+	for name, value := range info {
+		if _, ok := claims.Raw[name]; !ok {
+			claims.Raw[name] = value
+		}
+	}
+	claims.fill()
+	return nil
+}
+
+func (c *Claims) fill() {
+	c.Subject, _ = c.Raw["sub"].(string)
+	c.Email, _ = c.Raw["email"].(string)
+	c.Name, _ = c.Raw["name"].(string)
+	c.EmailVerified = nil
+	switch v := c.Raw["email_verified"].(type) {
+	case bool:
+		c.EmailVerified = &v
+	case string: // Some providers send it as a string
+		verified := v == "true"
+		c.EmailVerified = &verified
+	}
+}
+
+// discover fetches and caches the provider's discovery document
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	status, err := p.doJSON(req, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery failed: %d", status)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery failed: issuer %q does not match %q", meta.Issuer, p.cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery failed: document is missing endpoints")
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key returns the provider's signing key with the ID, fetching the key set
+// again when the provider has rotated its keys
+func (p *Provider) key(ctx context.Context, meta *metadata, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx, meta.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID; tokens without a key ID match a single key
+func (p *Provider) lookupKey(kid string) interface{} {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwkSet
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch signing keys: %d", status)
+	}
+	return set.publicKeys(), nil
+}
+
+// doJSON sends the request and decodes a JSON response, returning its status
+func (p *Provider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// RandomString returns a URL-safe random string for states, nonces and
+// PKCE verifiers
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -65,6 +65,15 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
+// CreatedWithMessage sends a 201 created response with a message
+func CreatedWithMessage(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
 // NoContent sends a 204 no content response
 func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
@@ -115,6 +124,10 @@ func Conflict(c *gin.Context, message string) {
 	Error(c, http.StatusConflict, "CONFLICT", message)
 }
 
+func TooManyRequests(c *gin.Context, message string) {
+	Error(c, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", message)
+}
+
 func InternalServerError(c *gin.Context, message string) {
 	Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
 }
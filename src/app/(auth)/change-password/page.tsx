@@ -0,0 +1,249 @@
+'use client';
+
+import { useEffect, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import { AxiosError } from 'axios';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import type { ApiResponse, PasswordRules } from '@/types';
+import { Loader2 } from 'lucide-react';
+
+// Where users land when their password was set by an admin, is a seeded
+// default or has expired. The rest of the app is blocked until it is changed.
+export default function ChangePasswordPage() {
+  const router = useRouter();
+  const { user, isAuthenticated, isLoading: isAuthLoading, refreshUser, logout } = useAuth();
+  const { language } = useLanguage();
+  const [currentPassword, setCurrentPassword] = useState('');
+  const [newPassword, setNewPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [rules, setRules] = useState<PasswordRules | null>(null);
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Change your password',
+      subtitle: 'You need to choose a new password before continuing',
+      currentPassword: 'Current password',
+      newPassword: 'New password',
+      confirmPassword: 'Confirm new password',
+      requirements: 'Your password must:',
+      minLength: (n: number) => `Be at least ${n} characters long`,
+      uppercase: 'Contain an uppercase letter',
+      lowercase: 'Contain a lowercase letter',
+      digit: 'Contain a digit',
+      symbol: 'Contain a symbol',
+      history: (n: number) => `Not be one of your last ${n} passwords`,
+      common: 'Not be a common password or contain your name or email',
+      submit: 'Change password',
+      submitting: 'Saving...',
+      mismatch: 'Passwords do not match',
+      wrongPassword: 'Current password is incorrect',
+      error: 'Failed to change password',
+      signOut: 'Sign out',
+    },
+    zh: {
+      title: '修改密码',
+      subtitle: '继续之前，您需要设置新密码',
+      currentPassword: '当前密码',
+      newPassword: '新密码',
+      confirmPassword: '确认新密码',
+      requirements: '密码必须：',
+      minLength: (n: number) => `至少 ${n} 个字符`,
+      uppercase: '包含大写字母',
+      lowercase: '包含小写字母',
+      digit: '包含数字',
+      symbol: '包含符号',
+      history: (n: number) => `不能与最近 ${n} 次使用的密码相同`,
+      common: '不能是常见密码，也不能包含您的姓名或邮箱',
+      submit: '修改密码',
+      submitting: '保存中...',
+      mismatch: '两次输入的密码不一致',
+      wrongPassword: '当前密码不正确',
+      error: '修改密码失败',
+      signOut: '退出登录',
+    },
+    es: {
+      title: 'Cambie su contraseña',
+      subtitle: 'Debe elegir una nueva contraseña antes de continuar',
+      currentPassword: 'Contraseña actual',
+      newPassword: 'Nueva contraseña',
+      confirmPassword: 'Confirmar nueva contraseña',
+      requirements: 'Su contraseña debe:',
+      minLength: (n: number) => `Tener al menos ${n} caracteres`,
+      uppercase: 'Contener una letra mayúscula',
+      lowercase: 'Contener una letra minúscula',
+      digit: 'Contener un dígito',
+      symbol: 'Contener un símbolo',
+      history: (n: number) => `No ser una de sus últimas ${n} contraseñas`,
+      common: 'No ser una contraseña común ni contener su nombre o correo',
+      submit: 'Cambiar contraseña',
+      submitting: 'Guardando...',
+      mismatch: 'Las contraseñas no coinciden',
+      wrongPassword: 'La contraseña actual es incorrecta',
+      error: 'No se pudo cambiar la contraseña',
+      signOut: 'Cerrar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  useEffect(() => {
+    if (!isAuthLoading && !isAuthenticated) {
+      router.push('/login');
+    }
+  }, [isAuthLoading, isAuthenticated, router]);
+
+  useEffect(() => {
+    authApi.getPasswordPolicy().then(setRules).catch(() => setRules(null));
+  }, []);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    if (newPassword !== confirmPassword) {
+      setError(t.mismatch);
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      await authApi.changePassword(currentPassword, newPassword);
+      await refreshUser();
+      router.push('/');
+    } catch (err) {
+      const response = (err as AxiosError<ApiResponse<unknown>>).response;
+      if (response?.status === 422) {
+        setError(response.data.error?.message || t.error);
+      } else {
+        setError(response?.status === 400 ? t.wrongPassword : t.error);
+      }
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleSignOut = async () => {
+    await logout();
+    router.push('/login');
+  };
+
+  const requirements = rules
+    ? [
+        t.minLength(rules.min_length),
+        rules.require_uppercase && t.uppercase,
+        rules.require_lowercase && t.lowercase,
+        rules.require_digit && t.digit,
+        rules.require_symbol && t.symbol,
+        rules.history_size > 1 && t.history(rules.history_size),
+        t.common,
+      ].filter((item): item is string => !!item)
+    : [];
+
+  const inputClassName =
+    'w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20';
+
+  if (isAuthLoading || !user) {
+    return <Loader2 className="h-8 w-8 animate-spin text-[#75534B]" />;
+  }
+
+  return (
+    <div className="w-full max-w-md p-8">
+      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
+        <div className="text-center mb-6">
+          <h2 className="text-xl text-[#2C2C2C] mb-1" style={{ fontWeight: 600 }}>
+            {t.title}
+          </h2>
+          <p className="text-sm text-[#6E6B67]">{t.subtitle}</p>
+        </div>
+
+        {error && (
+          <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
+            {error}
+          </div>
+        )}
+
+        {requirements.length > 0 && (
+          <div className="mb-4 rounded-lg bg-[#F9F8F6] border border-[#E4E1DD] p-3 text-xs text-[#6E6B67]">
+            <p className="mb-1 font-medium text-[#2C2C2C]">{t.requirements}</p>
+            <ul className="list-disc pl-4 space-y-0.5">
+              {requirements.map((item) => (
+                <li key={item}>{item}</li>
+              ))}
+            </ul>
+          </div>
+        )}
+
+        <form onSubmit={handleSubmit} className="space-y-4">
+          <div>
+            <label htmlFor="currentPassword" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+              {t.currentPassword}
+            </label>
+            <input
+              id="currentPassword"
+              type="password"
+              autoComplete="current-password"
+              value={currentPassword}
+              onChange={(e) => setCurrentPassword(e.target.value)}
+              required
+              className={inputClassName}
+            />
+          </div>
+
+          <div>
+            <label htmlFor="newPassword" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+              {t.newPassword}
+            </label>
+            <input
+              id="newPassword"
+              type="password"
+              autoComplete="new-password"
+              value={newPassword}
+              onChange={(e) => setNewPassword(e.target.value)}
+              required
+              className={inputClassName}
+            />
+          </div>
+
+          <div>
+            <label htmlFor="confirmPassword" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+              {t.confirmPassword}
+            </label>
+            <input
+              id="confirmPassword"
+              type="password"
+              autoComplete="new-password"
+              value={confirmPassword}
+              onChange={(e) => setConfirmPassword(e.target.value)}
+              required
+              className={inputClassName}
+            />
+          </div>
+
+          <button
+            type="submit"
+            disabled={isLoading}
+            className="w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+          >
+            {isLoading ? (
+              <>
+                <Loader2 className="h-4 w-4 animate-spin" />
+                {t.submitting}
+              </>
+            ) : (
+              t.submit
+            )}
+          </button>
+        </form>
+
+        <div className="mt-6 text-center text-sm">
+          <button type="button" onClick={handleSignOut} className="text-[#75534B] hover:underline">
+            {t.signOut}
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
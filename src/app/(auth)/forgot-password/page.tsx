@@ -0,0 +1,140 @@
+'use client';
+
+import { useState } from 'react';
+import Link from 'next/link';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import { Loader2, MailCheck } from 'lucide-react';
+
+export default function ForgotPasswordPage() {
+  const { language } = useLanguage();
+  const [email, setEmail] = useState('');
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+  const [sent, setSent] = useState(false);
+
+  const text = {
+    en: {
+      title: 'Forgot your password?',
+      subtitle: 'Enter your email and we will send you a link to reset it',
+      email: 'Email',
+      emailPlaceholder: 'Enter your email',
+      send: 'Send reset link',
+      sending: 'Sending...',
+      sentTitle: 'Check your email',
+      sentMessage: 'If an account exists for this email, a password reset link has been sent. The link expires soon and can be used once.',
+      error: 'Something went wrong, please try again',
+      backToLogin: 'Back to sign in',
+    },
+    zh: {
+      title: '忘记密码？',
+      subtitle: '输入您的邮箱，我们将发送重置密码的链接',
+      email: '邮箱',
+      emailPlaceholder: '输入您的邮箱',
+      send: '发送重置链接',
+      sending: '发送中...',
+      sentTitle: '请查收邮件',
+      sentMessage: '如果该邮箱存在账户，重置密码的链接已发送。链接很快过期且只能使用一次。',
+      error: '出现错误，请重试',
+      backToLogin: '返回登录',
+    },
+    es: {
+      title: '¿Olvidó su contraseña?',
+      subtitle: 'Ingrese su correo y le enviaremos un enlace para restablecerla',
+      email: 'Correo Electrónico',
+      emailPlaceholder: 'Ingrese su correo',
+      send: 'Enviar enlace',
+      sending: 'Enviando...',
+      sentTitle: 'Revise su correo',
+      sentMessage: 'Si existe una cuenta con este correo, se envió un enlace para restablecer la contraseña. El enlace expira pronto y solo puede usarse una vez.',
+      error: 'Algo salió mal, intente de nuevo',
+      backToLogin: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setIsLoading(true);
+
+    try {
+      await authApi.forgotPassword(email);
+      setSent(true);
+    } catch (err) {
+      setError(t.error);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className="w-full max-w-md p-8">
+      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
+        {sent ? (
+          <div className="text-center">
+            <MailCheck className="mx-auto mb-3 h-10 w-10 text-[#75534B]" />
+            <h2 className="text-xl text-[#2C2C2C] mb-2" style={{ fontWeight: 600 }}>
+              {t.sentTitle}
+            </h2>
+            <p className="text-sm text-[#6E6B67]">{t.sentMessage}</p>
+          </div>
+        ) : (
+          <>
+            <div className="text-center mb-6">
+              <h2 className="text-xl text-[#2C2C2C] mb-1" style={{ fontWeight: 600 }}>
+                {t.title}
+              </h2>
+              <p className="text-sm text-[#6E6B67]">{t.subtitle}</p>
+            </div>
+
+            {error && (
+              <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
+                {error}
+              </div>
+            )}
+
+            <form onSubmit={handleSubmit} className="space-y-4">
+              <div>
+                <label htmlFor="email" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+                  {t.email}
+                </label>
+                <input
+                  id="email"
+                  type="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  placeholder={t.emailPlaceholder}
+                  required
+                  className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                />
+              </div>
+
+              <button
+                type="submit"
+                disabled={isLoading}
+                className="w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+              >
+                {isLoading ? (
+                  <>
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                    {t.sending}
+                  </>
+                ) : (
+                  t.send
+                )}
+              </button>
+            </form>
+          </>
+        )}
+
+        <div className="mt-6 text-center text-sm">
+          <Link href="/login" className="text-[#75534B] hover:underline">
+            {t.backToLogin}
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -1,14 +1,21 @@
 'use client';
 
-import { useState } from 'react';
+import { useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
 import { useRouter } from 'next/navigation';
+import { AxiosError } from 'axios';
 import { useAuth } from '@/contexts/AuthContext';
 import { useLanguage } from '@/contexts/LanguageContext';
-import { Globe, ChevronDown, Eye, EyeOff, Loader2 } from 'lucide-react';
+import { Globe, ChevronDown, Eye, EyeOff, Loader2, ShieldCheck } from 'lucide-react';
+import { authApi } from '@/lib/api';
+import type { ApiResponse, MFAChallenge, MFAEnrollment, SSOStatus } from '@/types';
+
+// Login steps: password, then a TOTP code, or first-time 2FA setup and its recovery codes
+type LoginStep = 'credentials' | 'verify' | 'setup' | 'recovery-codes';
 
 export default function LoginPage() {
   const router = useRouter();
-  const { login } = useAuth();
+  const { login, loginWithSso, verifyMfa, confirmMfaSetup } = useAuth();
   const { language, setLanguage } = useLanguage();
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
@@ -16,6 +23,14 @@ export default function LoginPage() {
   const [error, setError] = useState('');
   const [isLoading, setIsLoading] = useState(false);
   const [showLangMenu, setShowLangMenu] = useState(false);
+  const [step, setStep] = useState<LoginStep>('credentials');
+  const [challenge, setChallenge] = useState<MFAChallenge | null>(null);
+  const [enrollment, setEnrollment] = useState<MFAEnrollment | null>(null);
+  const [code, setCode] = useState('');
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [sso, setSso] = useState<SSOStatus | null>(null);
+  const ssoCallbackHandled = useRef(false);
 
   const text = {
     en: {
@@ -26,8 +41,31 @@ export default function LoginPage() {
       password: 'Password',
       passwordPlaceholder: 'Enter your password',
       signIn: 'Sign In',
+      forgotPassword: 'Forgot password?',
       signingIn: 'Signing in...',
       loginError: 'Invalid email or password',
+      passwordLoginDisabled: 'Password sign-in is disabled for this account. Use single sign-on.',
+      or: 'or',
+      ssoSignIn: (provider: string) => `Sign in with ${provider}`,
+      ssoError: 'Single sign-on failed. Please try again.',
+      mfaTitle: 'Two-factor authentication',
+      mfaSubtitle: 'Enter the 6-digit code from your authenticator app',
+      recoverySubtitle: 'Enter one of your recovery codes',
+      code: 'Authentication code',
+      recoveryCode: 'Recovery code',
+      useRecoveryCode: 'Use a recovery code',
+      useAuthenticator: 'Use your authenticator app',
+      verify: 'Verify',
+      verifying: 'Verifying...',
+      codeError: 'Invalid or expired code',
+      setupTitle: 'Set up two-factor authentication',
+      setupSubtitle: 'Two-factor authentication is required for your role. Add this key to your authenticator app, then enter the code it shows.',
+      secretKey: 'Setup key',
+      openAuthenticator: 'Open in authenticator app',
+      recoveryTitle: 'Save your recovery codes',
+      recoverySubtitle2: 'Each code can be used once if you lose access to your authenticator. They will not be shown again.',
+      continue: 'Continue',
+      backToLogin: 'Back to sign in',
       languages: {
         en: 'English',
         zh: '中文',
@@ -42,8 +80,31 @@ export default function LoginPage() {
       password: '密码',
       passwordPlaceholder: '输入您的密码',
       signIn: '登录',
+      forgotPassword: '忘记密码？',
       signingIn: '登录中...',
       loginError: '邮箱或密码错误',
+      passwordLoginDisabled: '此账户已禁用密码登录，请使用单点登录。',
+      or: '或',
+      ssoSignIn: (provider: string) => `使用 ${provider} 登录`,
+      ssoError: '单点登录失败，请重试。',
+      mfaTitle: '双重验证',
+      mfaSubtitle: '输入身份验证器应用中的6位验证码',
+      recoverySubtitle: '输入一个恢复码',
+      code: '验证码',
+      recoveryCode: '恢复码',
+      useRecoveryCode: '使用恢复码',
+      useAuthenticator: '使用身份验证器应用',
+      verify: '验证',
+      verifying: '验证中...',
+      codeError: '验证码无效或已过期',
+      setupTitle: '设置双重验证',
+      setupSubtitle: '您的角色必须启用双重验证。请将此密钥添加到身份验证器应用，然后输入其显示的验证码。',
+      secretKey: '设置密钥',
+      openAuthenticator: '在身份验证器应用中打开',
+      recoveryTitle: '保存您的恢复码',
+      recoverySubtitle2: '如果无法使用身份验证器，每个恢复码可使用一次。恢复码不会再次显示。',
+      continue: '继续',
+      backToLogin: '返回登录',
       languages: {
         en: 'English',
         zh: '中文',
@@ -58,8 +119,31 @@ export default function LoginPage() {
       password: 'Contraseña',
       passwordPlaceholder: 'Ingrese su contraseña',
       signIn: 'Iniciar Sesión',
+      forgotPassword: '¿Olvidó su contraseña?',
       signingIn: 'Iniciando sesión...',
       loginError: 'Correo o contraseña inválidos',
+      passwordLoginDisabled: 'El inicio de sesión con contraseña está deshabilitado para esta cuenta. Use el inicio de sesión único.',
+      or: 'o',
+      ssoSignIn: (provider: string) => `Iniciar sesión con ${provider}`,
+      ssoError: 'El inicio de sesión único falló. Inténtelo de nuevo.',
+      mfaTitle: 'Autenticación de dos factores',
+      mfaSubtitle: 'Ingrese el código de 6 dígitos de su aplicación de autenticación',
+      recoverySubtitle: 'Ingrese uno de sus códigos de recuperación',
+      code: 'Código de autenticación',
+      recoveryCode: 'Código de recuperación',
+      useRecoveryCode: 'Usar un código de recuperación',
+      useAuthenticator: 'Usar su aplicación de autenticación',
+      verify: 'Verificar',
+      verifying: 'Verificando...',
+      codeError: 'Código inválido o expirado',
+      setupTitle: 'Configurar autenticación de dos factores',
+      setupSubtitle: 'Su rol requiere autenticación de dos factores. Agregue esta clave a su aplicación de autenticación e ingrese el código que muestra.',
+      secretKey: 'Clave de configuración',
+      openAuthenticator: 'Abrir en la aplicación de autenticación',
+      recoveryTitle: 'Guarde sus códigos de recuperación',
+      recoverySubtitle2: 'Cada código puede usarse una vez si pierde acceso a su autenticador. No se mostrarán de nuevo.',
+      continue: 'Continuar',
+      backToLogin: 'Volver a iniciar sesión',
       languages: {
         en: 'English',
         zh: '中文',
@@ -76,21 +160,114 @@ export default function LoginPage() {
     es: 'ES',
   };
 
+  // Goes to the app, or to the second factor step the login needs
+  const continueLogin = async (mfaChallenge: MFAChallenge | null) => {
+    if (!mfaChallenge) {
+      router.push('/');
+      return;
+    }
+
+    setChallenge(mfaChallenge);
+    setCode('');
+    if (mfaChallenge.setup_required) {
+      setEnrollment(await authApi.beginMfaSetup(mfaChallenge.challenge_token));
+      setStep('setup');
+    } else {
+      setStep('verify');
+    }
+  };
+
+  useEffect(() => {
+    authApi.getSsoStatus().then(setSso).catch(() => setSso(null));
+  }, []);
+
+  // The identity provider redirects back here with a code and state, or an error
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    const ssoCode = params.get('code');
+    const state = params.get('state');
+    if (ssoCallbackHandled.current || (!ssoCode && !params.get('error'))) return;
+    ssoCallbackHandled.current = true;
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (!ssoCode || !state) {
+      setError(t.ssoError);
+      return;
+    }
+
+    setIsLoading(true);
+    loginWithSso(ssoCode, state)
+      .then(continueLogin)
+      .catch((err) => {
+        const response = (err as AxiosError<ApiResponse<unknown>>).response;
+        setError(response?.data?.error?.message || t.ssoError);
+      })
+      .finally(() => setIsLoading(false));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setError('');
     setIsLoading(true);
 
     try {
-      await login({ email, password });
-      router.push('/');
+      await continueLogin(await login({ email, password }));
+    } catch (err) {
+      const response = (err as AxiosError<ApiResponse<unknown>>).response;
+      setError(response?.status === 403 ? t.passwordLoginDisabled : t.loginError);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleSsoSignIn = async () => {
+    setError('');
+    setIsLoading(true);
+    try {
+      window.location.href = await authApi.beginSso();
+    } catch (err) {
+      setError(t.ssoError);
+      setIsLoading(false);
+    }
+  };
+
+  const handleCodeSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!challenge) return;
+    setError('');
+    setIsLoading(true);
+
+    try {
+      if (step === 'setup') {
+        const response = await confirmMfaSetup(challenge.challenge_token, code);
+        setRecoveryCodes(response.recovery_codes ?? []);
+        setStep('recovery-codes');
+      } else {
+        await verifyMfa(challenge.challenge_token, code, useRecoveryCode);
+        router.push('/');
+      }
     } catch (err) {
-      setError(t.loginError);
+      setError(t.codeError);
     } finally {
       setIsLoading(false);
     }
   };
 
+  const backToCredentials = () => {
+    setStep('credentials');
+    setChallenge(null);
+    setEnrollment(null);
+    setCode('');
+    setUseRecoveryCode(false);
+    setError('');
+  };
+
+  const inputClassName =
+    'w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20';
+  const submitClassName =
+    'w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2';
+
   return (
     <div className="w-full max-w-md p-8">
       {/* Language Switcher */}
@@ -149,90 +326,222 @@ export default function LoginPage() {
         <p className="text-sm text-[#75534B]">Supply Chain & Procurement</p>
       </div>
 
-      {/* Login Form */}
-      <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
-        <div className="text-center mb-6">
-          <h2
-            className="text-xl text-[#2C2C2C] mb-1"
-            style={{ fontWeight: 600 }}
-          >
-            {t.welcomeBack}
-          </h2>
-          <p className="text-sm text-[#6E6B67]">{t.signInToContinue}</p>
-        </div>
-
-        {error && (
-          <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
-            {error}
+      {/* Second factor */}
+      {step !== 'credentials' && (
+        <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
+          <div className="text-center mb-6">
+            <ShieldCheck className="mx-auto mb-2 h-8 w-8 text-[#75534B]" />
+            <h2
+              className="text-xl text-[#2C2C2C] mb-1"
+              style={{ fontWeight: 600 }}
+            >
+              {step === 'setup' ? t.setupTitle : step === 'recovery-codes' ? t.recoveryTitle : t.mfaTitle}
+            </h2>
+            <p className="text-sm text-[#6E6B67]">
+              {step === 'setup'
+                ? t.setupSubtitle
+                : step === 'recovery-codes'
+                  ? t.recoverySubtitle2
+                  : useRecoveryCode
+                    ? t.recoverySubtitle
+                    : t.mfaSubtitle}
+            </p>
           </div>
-        )}
 
-        <form onSubmit={handleSubmit} className="space-y-4">
-          <div>
-            <label
-              htmlFor="email"
-              className="block text-sm font-medium text-[#2C2C2C] mb-1.5"
+          {error && (
+            <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
+              {error}
+            </div>
+          )}
+
+          {step === 'recovery-codes' ? (
+            <div className="space-y-4">
+              <div className="grid grid-cols-2 gap-2 rounded-lg bg-[#F9F8F6] p-4 font-mono text-sm text-[#2C2C2C]">
+                {recoveryCodes.map((recoveryCode) => (
+                  <span key={recoveryCode}>{recoveryCode}</span>
+                ))}
+              </div>
+              <button type="button" onClick={() => router.push('/')} className={submitClassName}>
+                {t.continue}
+              </button>
+            </div>
+          ) : (
+            <form onSubmit={handleCodeSubmit} className="space-y-4">
+              {step === 'setup' && enrollment && (
+                <div className="rounded-lg bg-[#F9F8F6] p-4 text-sm">
+                  <p className="font-medium text-[#2C2C2C] mb-1">{t.secretKey}</p>
+                  <p className="font-mono break-all text-[#2C2C2C]">{enrollment.secret}</p>
+                  <a href={enrollment.otpauth_url} className="mt-2 inline-block text-[#75534B] hover:underline">
+                    {t.openAuthenticator}
+                  </a>
+                </div>
+              )}
+
+              <div>
+                <label
+                  htmlFor="code"
+                  className="block text-sm font-medium text-[#2C2C2C] mb-1.5"
+                >
+                  {useRecoveryCode ? t.recoveryCode : t.code}
+                </label>
+                <input
+                  id="code"
+                  type="text"
+                  inputMode={useRecoveryCode ? 'text' : 'numeric'}
+                  autoComplete="one-time-code"
+                  value={code}
+                  onChange={(e) => setCode(e.target.value)}
+                  placeholder={useRecoveryCode ? 'xxxxx-xxxxx' : '123456'}
+                  required
+                  autoFocus
+                  className={inputClassName}
+                />
+              </div>
+
+              <button type="submit" disabled={isLoading} className={submitClassName}>
+                {isLoading ? (
+                  <>
+                    <Loader2 className="h-4 w-4 animate-spin" />
+                    {t.verifying}
+                  </>
+                ) : (
+                  t.verify
+                )}
+              </button>
+
+              <div className="flex justify-between text-xs">
+                <button type="button" onClick={backToCredentials} className="text-[#6E6B67] hover:text-[#2C2C2C]">
+                  {t.backToLogin}
+                </button>
+                {step === 'verify' && (
+                  <button
+                    type="button"
+                    onClick={() => {
+                      setUseRecoveryCode(!useRecoveryCode);
+                      setCode('');
+                      setError('');
+                    }}
+                    className="text-[#75534B] hover:underline"
+                  >
+                    {useRecoveryCode ? t.useAuthenticator : t.useRecoveryCode}
+                  </button>
+                )}
+              </div>
+            </form>
+          )}
+        </div>
+      )}
+
+      {/* Login Form */}
+      {step === 'credentials' && (
+        <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
+          <div className="text-center mb-6">
+            <h2
+              className="text-xl text-[#2C2C2C] mb-1"
+              style={{ fontWeight: 600 }}
             >
-              {t.email}
-            </label>
-            <input
-              id="email"
-              type="email"
-              value={email}
-              onChange={(e) => setEmail(e.target.value)}
-              placeholder={t.emailPlaceholder}
-              required
-              className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
-            />
+              {t.welcomeBack}
+            </h2>
+            <p className="text-sm text-[#6E6B67]">{t.signInToContinue}</p>
           </div>
 
-          <div>
-            <label
-              htmlFor="password"
-              className="block text-sm font-medium text-[#2C2C2C] mb-1.5"
-            >
-              {t.password}
-            </label>
-            <div className="relative">
+          {error && (
+            <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
+              {error}
+            </div>
+          )}
+
+          <form onSubmit={handleSubmit} className="space-y-4">
+            <div>
+              <label
+                htmlFor="email"
+                className="block text-sm font-medium text-[#2C2C2C] mb-1.5"
+              >
+                {t.email}
+              </label>
               <input
-                id="password"
-                type={showPassword ? 'text' : 'password'}
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-                placeholder={t.passwordPlaceholder}
+                id="email"
+                type="email"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                placeholder={t.emailPlaceholder}
                 required
-                className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 pr-12 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
               />
+            </div>
+
+            <div>
+              <label
+                htmlFor="password"
+                className="block text-sm font-medium text-[#2C2C2C] mb-1.5"
+              >
+                {t.password}
+              </label>
+              <div className="relative">
+                <input
+                  id="password"
+                  type={showPassword ? 'text' : 'password'}
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  placeholder={t.passwordPlaceholder}
+                  required
+                  className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 pr-12 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                />
+                <button
+                  type="button"
+                  onClick={() => setShowPassword(!showPassword)}
+                  className="absolute right-3 top-1/2 -translate-y-1/2 text-[#6E6B67] hover:text-[#2C2C2C] transition-colors"
+                >
+                  {showPassword ? (
+                    <EyeOff className="h-5 w-5" />
+                  ) : (
+                    <Eye className="h-5 w-5" />
+                  )}
+                </button>
+              </div>
+            </div>
+
+            <button
+              type="submit"
+              disabled={isLoading}
+              className="w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+            >
+              {isLoading ? (
+                <>
+                  <Loader2 className="h-4 w-4 animate-spin" />
+                  {t.signingIn}
+                </>
+              ) : (
+                t.signIn
+              )}
+            </button>
+
+            <div className="text-center text-sm">
+              <Link href="/forgot-password" className="text-[#75534B] hover:underline">
+                {t.forgotPassword}
+              </Link>
+            </div>
+          </form>
+
+          {sso?.enabled && (
+            <>
+              <div className="my-4 flex items-center gap-3 text-xs text-[#6E6B67]">
+                <div className="h-px flex-1 bg-[#E4E1DD]" />
+                {t.or}
+                <div className="h-px flex-1 bg-[#E4E1DD]" />
+              </div>
               <button
                 type="button"
-                onClick={() => setShowPassword(!showPassword)}
-                className="absolute right-3 top-1/2 -translate-y-1/2 text-[#6E6B67] hover:text-[#2C2C2C] transition-colors"
+                onClick={handleSsoSignIn}
+                disabled={isLoading}
+                className="w-full rounded-lg border border-[#75534B] bg-white px-4 py-3 text-sm font-medium text-[#75534B] transition-all hover:bg-[#F9F8F6] active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed"
               >
-                {showPassword ? (
-                  <EyeOff className="h-5 w-5" />
-                ) : (
-                  <Eye className="h-5 w-5" />
-                )}
+                {t.ssoSignIn(sso.provider_name || 'SSO')}
               </button>
-            </div>
-          </div>
-
-          <button
-            type="submit"
-            disabled={isLoading}
-            className="w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
-          >
-            {isLoading ? (
-              <>
-                <Loader2 className="h-4 w-4 animate-spin" />
-                {t.signingIn}
-              </>
-            ) : (
-              t.signIn
-            )}
-          </button>
-        </form>
-      </div>
+            </>
+          )}
+        </div>
+      )}
 
       {/* Demo credentials hint */}
       <div className="mt-4 rounded-lg bg-white/50 p-4 text-center text-xs text-[#6E6B67] border border-[#E4E1DD]/50">
@@ -0,0 +1,175 @@
+'use client';
+
+import { Suspense, useState } from 'react';
+import Link from 'next/link';
+import { useRouter, useSearchParams } from 'next/navigation';
+import { AxiosError } from 'axios';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { authApi } from '@/lib/api';
+import type { ApiResponse } from '@/types';
+import { Loader2 } from 'lucide-react';
+
+// Sets a password from the link of a reset email or an invitation (?invite=1)
+function ResetPasswordForm() {
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const token = searchParams.get('token') ?? '';
+  const isInvite = searchParams.get('invite') === '1';
+  const { language } = useLanguage();
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+
+  const text = {
+    en: {
+      resetTitle: 'Choose a new password',
+      inviteTitle: 'Welcome to IRIS Vista',
+      subtitle: 'Set the password you will use to sign in',
+      password: 'New password',
+      confirmPassword: 'Confirm password',
+      submit: 'Set password',
+      submitting: 'Saving...',
+      mismatch: 'Passwords do not match',
+      invalidLink: 'This link is invalid, has expired or has already been used',
+      error: 'Failed to set password',
+      backToLogin: 'Back to sign in',
+    },
+    zh: {
+      resetTitle: '设置新密码',
+      inviteTitle: '欢迎使用 IRIS Vista',
+      subtitle: '设置您用于登录的密码',
+      password: '新密码',
+      confirmPassword: '确认密码',
+      submit: '设置密码',
+      submitting: '保存中...',
+      mismatch: '两次输入的密码不一致',
+      invalidLink: '链接无效、已过期或已被使用',
+      error: '设置密码失败',
+      backToLogin: '返回登录',
+    },
+    es: {
+      resetTitle: 'Elija una nueva contraseña',
+      inviteTitle: 'Bienvenido a IRIS Vista',
+      subtitle: 'Establezca la contraseña que usará para iniciar sesión',
+      password: 'Nueva contraseña',
+      confirmPassword: 'Confirmar contraseña',
+      submit: 'Establecer contraseña',
+      submitting: 'Guardando...',
+      mismatch: 'Las contraseñas no coinciden',
+      invalidLink: 'Este enlace es inválido, expiró o ya fue usado',
+      error: 'No se pudo establecer la contraseña',
+      backToLogin: 'Volver a iniciar sesión',
+    },
+  };
+
+  const t = text[language];
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    if (password !== confirmPassword) {
+      setError(t.mismatch);
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      await authApi.resetPassword(token, password);
+      router.push('/login');
+    } catch (err) {
+      const response = (err as AxiosError<ApiResponse<unknown>>).response;
+      if (response?.status === 422) {
+        // The password breaks the policy; the message lists what to fix
+        setError(response.data.error?.message || t.error);
+      } else {
+        setError(response?.status === 400 ? t.invalidLink : t.error);
+      }
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const inputClassName =
+    'w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all placeholder:text-[#6E6B67] focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20';
+
+  return (
+    <div className="rounded-2xl bg-white p-8 shadow-lg border border-[#E4E1DD]">
+      <div className="text-center mb-6">
+        <h2 className="text-xl text-[#2C2C2C] mb-1" style={{ fontWeight: 600 }}>
+          {isInvite ? t.inviteTitle : t.resetTitle}
+        </h2>
+        <p className="text-sm text-[#6E6B67]">{t.subtitle}</p>
+      </div>
+
+      {(error || !token) && (
+        <div className="mb-4 rounded-lg bg-red-50 border border-red-200 p-3 text-sm text-red-600">
+          {error || t.invalidLink}
+        </div>
+      )}
+
+      <form onSubmit={handleSubmit} className="space-y-4">
+        <div>
+          <label htmlFor="password" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+            {t.password}
+          </label>
+          <input
+            id="password"
+            type="password"
+            autoComplete="new-password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            required
+            className={inputClassName}
+          />
+        </div>
+
+        <div>
+          <label htmlFor="confirmPassword" className="block text-sm font-medium text-[#2C2C2C] mb-1.5">
+            {t.confirmPassword}
+          </label>
+          <input
+            id="confirmPassword"
+            type="password"
+            autoComplete="new-password"
+            value={confirmPassword}
+            onChange={(e) => setConfirmPassword(e.target.value)}
+            required
+            className={inputClassName}
+          />
+        </div>
+
+        <button
+          type="submit"
+          disabled={isLoading || !token}
+          className="w-full rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-3 text-sm font-medium text-white shadow-md transition-all hover:shadow-lg active:scale-[0.98] disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+        >
+          {isLoading ? (
+            <>
+              <Loader2 className="h-4 w-4 animate-spin" />
+              {t.submitting}
+            </>
+          ) : (
+            t.submit
+          )}
+        </button>
+      </form>
+
+      <div className="mt-6 text-center text-sm">
+        <Link href="/login" className="text-[#75534B] hover:underline">
+          {t.backToLogin}
+        </Link>
+      </div>
+    </div>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <div className="w-full max-w-md p-8">
+      <Suspense fallback={<Loader2 className="mx-auto h-8 w-8 animate-spin text-[#75534B]" />}>
+        <ResetPasswordForm />
+      </Suspense>
+    </div>
+  );
+}
@@ -2,6 +2,7 @@
 
 import { useEffect, useState } from 'react';
 import Image from 'next/image';
+import { AxiosError } from 'axios';
 import {
   ShoppingCart,
   Package,
@@ -11,22 +12,33 @@ import {
   Loader2,
   RefreshCw,
   Filter,
+  FileText,
+  Paperclip,
+  X,
 } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
 import { Badge } from '@/components/ui/badge';
-import { adminApi } from '@/lib/api';
-import type { PurchaseRequest } from '@/types';
+import { adminApi, eventsApi } from '@/lib/api';
+import type { ApiResponse, PurchaseRequest, RequestEventType } from '@/types';
 import { useLanguage } from '@/contexts/LanguageContext';
+import { RequestAttachments, ACCEPTED_FILES, MAX_FILES } from '@/components/requests/RequestAttachments';
 
 type FilterType = 'all' | 'amazon_cart' | 'pending_manual' | 'purchased';
 
+const ORDER_EVENTS: RequestEventType[] = ['request.approved', 'order.purchased', 'cart.added', 'cart.failed'];
+
 export default function ApprovedOrdersPage() {
   const { language } = useLanguage();
   const [orders, setOrders] = useState<PurchaseRequest[]>([]);
   const [isLoading, setIsLoading] = useState(true);
   const [filter, setFilter] = useState<FilterType>('all');
   const [processingId, setProcessingId] = useState<number | null>(null);
+  // Order whose documents are shown, and where it is marked purchased
+  const [selectedOrder, setSelectedOrder] = useState<PurchaseRequest | null>(null);
+  const [purchaseNotes, setPurchaseNotes] = useState('');
+  const [invoices, setInvoices] = useState<File[]>([]);
+  const [purchaseError, setPurchaseError] = useState('');
 
   const text = {
     en: {
@@ -47,6 +59,12 @@ export default function ApprovedOrdersPage() {
       inCart: 'In Cart',
       cartError: 'Cart Error',
       pending: 'Pending',
+      documents: 'Documents',
+      purchaseNotes: 'Purchase notes',
+      addInvoices: 'Attach invoices',
+      confirmPurchased: 'Confirm Purchase',
+      tooManyFiles: `You can attach up to ${MAX_FILES} invoices`,
+      purchaseFailed: 'Failed to mark as purchased',
     },
     zh: {
       title: '已批准订单',
@@ -66,6 +84,12 @@ export default function ApprovedOrdersPage() {
       inCart: '在购物车中',
       cartError: '购物车错误',
       pending: '待处理',
+      documents: '文件',
+      purchaseNotes: '采购备注',
+      addInvoices: '附加发票',
+      confirmPurchased: '确认购买',
+      tooManyFiles: `最多可附加 ${MAX_FILES} 张发票`,
+      purchaseFailed: '标记为已购买失败',
     },
     es: {
       title: 'Órdenes Aprobadas',
@@ -85,13 +109,19 @@ export default function ApprovedOrdersPage() {
       inCart: 'En Carrito',
       cartError: 'Error de Carrito',
       pending: 'Pendiente',
+      documents: 'Documentos',
+      purchaseNotes: 'Notas de compra',
+      addInvoices: 'Adjuntar facturas',
+      confirmPurchased: 'Confirmar Compra',
+      tooManyFiles: `Puede adjuntar hasta ${MAX_FILES} facturas`,
+      purchaseFailed: 'No se pudo marcar como comprado',
     },
   };
 
   const t = text[language];
 
-  const fetchOrders = async () => {
-    setIsLoading(true);
+  const fetchOrders = async (showLoading = true) => {
+    if (showLoading) setIsLoading(true);
     try {
       const response = await adminApi.getApprovedOrders({ filter });
       setOrders(response.data || []);
@@ -106,13 +136,45 @@ export default function ApprovedOrdersPage() {
     fetchOrders();
   }, [filter]);
 
+  // Reload quietly when an order is approved, purchased or its cart automation finishes
+  useEffect(() => {
+    return eventsApi.subscribe({
+      onEvent: (event) => {
+        if (ORDER_EVENTS.includes(event.type)) fetchOrders(false);
+      },
+      onReconnect: () => fetchOrders(false),
+    });
+  }, [filter]);
+
+  const openOrder = (order: PurchaseRequest) => {
+    setSelectedOrder(order);
+    setPurchaseNotes('');
+    setInvoices([]);
+    setPurchaseError('');
+  };
+
+  const handleInvoices = (selected: FileList | null) => {
+    if (!selected) return;
+    const next = [...invoices, ...Array.from(selected)];
+    if (next.length > MAX_FILES) {
+      setPurchaseError(t.tooManyFiles);
+      return;
+    }
+    setPurchaseError('');
+    setInvoices(next);
+  };
+
   const handleMarkPurchased = async (id: number) => {
     setProcessingId(id);
+    setPurchaseError('');
     try {
-      await adminApi.markAsPurchased(id, 'Marked as purchased from admin panel');
+      await adminApi.markAsPurchased(id, purchaseNotes || 'Marked as purchased from admin panel', invoices);
+      setSelectedOrder(null);
       fetchOrders();
     } catch (error) {
       console.error('Failed to mark as purchased:', error);
+      const response = (error as AxiosError<ApiResponse<unknown>>).response;
+      setPurchaseError(response?.data?.error?.message || t.purchaseFailed);
     } finally {
       setProcessingId(null);
     }
@@ -177,7 +239,7 @@ export default function ApprovedOrdersPage() {
           <h1 className="text-2xl font-bold text-[#75534B]">{t.title}</h1>
           <p className="text-gray-600">{t.subtitle}</p>
         </div>
-        <Button variant="outline" onClick={fetchOrders} disabled={isLoading}>
+        <Button variant="outline" onClick={() => fetchOrders()} disabled={isLoading}>
           <RefreshCw className={`h-4 w-4 mr-2 ${isLoading ? 'animate-spin' : ''}`} />
           Refresh
         </Button>
@@ -284,6 +346,10 @@ export default function ApprovedOrdersPage() {
                     {t.viewProduct}
                   </Button>
 
+                  <Button variant="outline" size="sm" onClick={() => openOrder(order)} title={t.documents}>
+                    <FileText className="h-3 w-3" />
+                  </Button>
+
                   {order.status !== 'purchased' && (
                     <>
                       {order.is_amazon_url && order.cart_error && (
@@ -303,7 +369,7 @@ export default function ApprovedOrdersPage() {
                       <Button
                         size="sm"
                         className="bg-green-600 hover:bg-green-700"
-                        onClick={() => handleMarkPurchased(order.id)}
+                        onClick={() => openOrder(order)}
                         disabled={processingId === order.id}
                       >
                         {processingId === order.id ? (
@@ -320,6 +386,85 @@ export default function ApprovedOrdersPage() {
           ))}
         </div>
       )}
+
+      {/* Documents and purchase confirmation */}
+      {selectedOrder && (
+        <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/50 p-4">
+          <div className="w-full max-w-lg max-h-[90vh] overflow-y-auto rounded-lg bg-white p-6 shadow-xl">
+            <div className="flex items-start justify-between">
+              <div>
+                <h2 className="text-lg font-semibold text-[#75534B]">{selectedOrder.request_number}</h2>
+                <p className="text-sm text-gray-600">{selectedOrder.product_title}</p>
+              </div>
+              <button onClick={() => setSelectedOrder(null)} className="text-gray-500 hover:text-gray-700">
+                <X className="h-5 w-5" />
+              </button>
+            </div>
+
+            <RequestAttachments request={selectedOrder} />
+
+            {selectedOrder.status !== 'purchased' && (
+              <div className="mt-6 pt-4 border-t border-[#E4E1DD] space-y-3">
+                <p className="text-sm font-semibold text-[#2C2C2C]">{t.markPurchased}</p>
+                <textarea
+                  value={purchaseNotes}
+                  onChange={(e) => setPurchaseNotes(e.target.value)}
+                  placeholder={t.purchaseNotes}
+                  rows={2}
+                  className="w-full rounded-lg border border-[#E4E1DD] px-3 py-2 text-sm focus:outline-none focus:border-[#75534B]"
+                />
+
+                <div>
+                  <label className="inline-flex cursor-pointer items-center gap-1 text-sm text-gray-600 hover:text-gray-900">
+                    <Paperclip className="h-4 w-4" />
+                    {t.addInvoices}
+                    <input
+                      type="file"
+                      multiple
+                      accept={ACCEPTED_FILES}
+                      className="hidden"
+                      onChange={(e) => {
+                        handleInvoices(e.target.files);
+                        e.target.value = '';
+                      }}
+                    />
+                  </label>
+                  {invoices.length > 0 && (
+                    <div className="mt-2 flex flex-wrap gap-2">
+                      {invoices.map((file, idx) => (
+                        <span
+                          key={`${file.name}-${idx}`}
+                          className="flex items-center gap-1 rounded border border-[#E4E1DD] px-2 py-1 text-xs text-gray-600"
+                        >
+                          {file.name}
+                          <button onClick={() => setInvoices(invoices.filter((_, i) => i !== idx))}>
+                            <X className="h-3 w-3" />
+                          </button>
+                        </span>
+                      ))}
+                    </div>
+                  )}
+                </div>
+
+                {purchaseError && <p className="text-sm text-red-600">{purchaseError}</p>}
+
+                <Button
+                  className="w-full bg-green-600 hover:bg-green-700"
+                  onClick={() => handleMarkPurchased(selectedOrder.id)}
+                  disabled={processingId === selectedOrder.id}
+                >
+                  {processingId === selectedOrder.id ? (
+                    <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+                  ) : (
+                    <CheckCircle className="h-4 w-4 mr-2" />
+                  )}
+                  {t.confirmPurchased}
+                </Button>
+              </div>
+            )}
+          </div>
+        </div>
+      )}
     </div>
   );
 }
@@ -12,6 +12,8 @@ import {
   ShoppingBag,
   Loader2,
   ArrowRight,
+  ShieldCheck,
+  Webhook,
 } from 'lucide-react';
 import { useLanguage } from '@/contexts/LanguageContext';
 import { adminApi } from '@/lib/api';
@@ -40,6 +42,10 @@ export default function AdminPage() {
       amazonConfigDesc: 'Configure Amazon Business integration',
       filterRulesTitle: 'Filter Rules',
       filterRulesDesc: 'Manage product filtering rules',
+      roles: 'Roles & Permissions',
+      rolesDesc: 'Define roles and what they can do',
+      webhooks: 'Webhooks',
+      webhooksDesc: 'Send request events to ERP and chat tools',
     },
     zh: {
       title: '管理后台',
@@ -58,6 +64,10 @@ export default function AdminPage() {
       amazonConfigDesc: '配置Amazon Business集成',
       filterRulesTitle: '筛选规则',
       filterRulesDesc: '管理产品筛选规则',
+      roles: '角色与权限',
+      rolesDesc: '定义角色及其权限',
+      webhooks: 'Webhooks',
+      webhooksDesc: '将申请事件发送到ERP和聊天工具',
     },
     es: {
       title: 'Panel de Admin',
@@ -76,6 +86,10 @@ export default function AdminPage() {
       amazonConfigDesc: 'Configurar integración con Amazon Business',
       filterRulesTitle: 'Reglas de Filtro',
       filterRulesDesc: 'Gestionar reglas de filtrado de productos',
+      roles: 'Roles y Permisos',
+      rolesDesc: 'Definir roles y lo que pueden hacer',
+      webhooks: 'Webhooks',
+      webhooksDesc: 'Enviar eventos de solicitudes a ERP y herramientas de chat',
     },
   };
 
@@ -165,6 +179,20 @@ export default function AdminPage() {
       href: '/admin/filter-rules',
       color: 'bg-[#75534B]',
     },
+    {
+      icon: ShieldCheck,
+      title: t.roles,
+      description: t.rolesDesc,
+      href: '/admin/roles',
+      color: 'bg-[#4BAF7E]',
+    },
+    {
+      icon: Webhook,
+      title: t.webhooks,
+      description: t.webhooksDesc,
+      href: '/admin/webhooks',
+      color: 'bg-[#3F8F8F]',
+    },
   ];
 
   return (
@@ -0,0 +1,385 @@
+'use client';
+
+import { useState, useEffect } from 'react';
+import { ShieldCheck, Plus, Edit, Trash2, X, Loader2, Lock } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { AxiosError } from 'axios';
+import { rolesApi } from '@/lib/api';
+import { Badge } from '@/components/ui/badge';
+import type { ApiResponse, Permission, Role, RoleInput } from '@/types';
+
+const emptyForm: Required<RoleInput> = {
+  name: '',
+  display_name: '',
+  description: '',
+  require_mfa: false,
+  permissions: [],
+};
+
+export default function RolesPage() {
+  const { language } = useLanguage();
+  const [roles, setRoles] = useState<Role[]>([]);
+  const [permissions, setPermissions] = useState<Permission[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [showModal, setShowModal] = useState(false);
+  const [editingRole, setEditingRole] = useState<Role | null>(null);
+  const [isSubmitting, setIsSubmitting] = useState(false);
+  const [formData, setFormData] = useState<Required<RoleInput>>(emptyForm);
+
+  const text = {
+    en: {
+      title: 'Roles & Permissions',
+      subtitle: 'Define what each role can do',
+      addRole: 'Add Role',
+      name: 'Name',
+      namePlaceholder: 'finance_viewer',
+      nameHint: 'Lowercase letters, digits and underscores. Cannot be changed later.',
+      displayName: 'Display Name',
+      description: 'Description',
+      requireMfa: 'Require two-factor authentication',
+      permissions: 'Permissions',
+      builtIn: 'Built-in',
+      users: 'users',
+      noPermissions: 'No permissions',
+      adminLocked: 'The admin role always has every permission',
+      createRole: 'Create Role',
+      editRole: 'Edit Role',
+      save: 'Save',
+      cancel: 'Cancel',
+    },
+    zh: {
+      title: '角色与权限',
+      subtitle: '定义每个角色可以执行的操作',
+      addRole: '添加角色',
+      name: '名称',
+      namePlaceholder: 'finance_viewer',
+      nameHint: '小写字母、数字和下划线，创建后不可更改。',
+      displayName: '显示名称',
+      description: '描述',
+      requireMfa: '要求双重身份验证',
+      permissions: '权限',
+      builtIn: '内置',
+      users: '位用户',
+      noPermissions: '无权限',
+      adminLocked: '管理员角色始终拥有所有权限',
+      createRole: '创建角色',
+      editRole: '编辑角色',
+      save: '保存',
+      cancel: '取消',
+    },
+    es: {
+      title: 'Roles y Permisos',
+      subtitle: 'Definir lo que cada rol puede hacer',
+      addRole: 'Agregar Rol',
+      name: 'Nombre',
+      namePlaceholder: 'finance_viewer',
+      nameHint: 'Letras minúsculas, dígitos y guiones bajos. No se puede cambiar después.',
+      displayName: 'Nombre Visible',
+      description: 'Descripción',
+      requireMfa: 'Requerir autenticación de dos factores',
+      permissions: 'Permisos',
+      builtIn: 'Predefinido',
+      users: 'usuarios',
+      noPermissions: 'Sin permisos',
+      adminLocked: 'El rol de administrador siempre tiene todos los permisos',
+      createRole: 'Crear Rol',
+      editRole: 'Editar Rol',
+      save: 'Guardar',
+      cancel: 'Cancelar',
+    },
+  };
+
+  const t = text[language];
+
+  useEffect(() => {
+    fetchData();
+  }, []);
+
+  const fetchData = async () => {
+    try {
+      const [roleData, permissionData] = await Promise.all([rolesApi.list(), rolesApi.permissions()]);
+      setRoles(roleData);
+      setPermissions(permissionData);
+    } catch (error) {
+      console.error('Failed to fetch roles:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleOpenModal = (role?: Role) => {
+    if (role) {
+      setEditingRole(role);
+      setFormData({
+        name: role.name,
+        display_name: role.display_name,
+        description: role.description || '',
+        require_mfa: role.require_mfa,
+        permissions: role.permissions,
+      });
+    } else {
+      setEditingRole(null);
+      setFormData(emptyForm);
+    }
+    setShowModal(true);
+  };
+
+  const togglePermission = (name: string) => {
+    setFormData((prev) => ({
+      ...prev,
+      permissions: prev.permissions.includes(name)
+        ? prev.permissions.filter((p) => p !== name)
+        : [...prev.permissions, name],
+    }));
+  };
+
+  const handleSubmit = async () => {
+    setIsSubmitting(true);
+    try {
+      if (editingRole) {
+        // The name is ignored; roles keep the name they were created with
+        await rolesApi.update(editingRole.id, formData);
+      } else {
+        await rolesApi.create(formData);
+      }
+      setShowModal(false);
+      fetchData();
+    } catch (error) {
+      console.error('Failed to save role:', error);
+      const message = (error as AxiosError<ApiResponse<unknown>>).response?.data?.error?.message;
+      alert(message || 'Failed to save role');
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  const handleDelete = async (role: Role) => {
+    if (!confirm(`Delete role "${role.display_name}"?`)) return;
+    try {
+      await rolesApi.delete(role.id);
+      fetchData();
+    } catch (error) {
+      console.error('Failed to delete role:', error);
+      // Roles still assigned to users or approval steps cannot be deleted
+      const message = (error as AxiosError<ApiResponse<unknown>>).response?.data?.error?.message;
+      alert(message || 'Failed to delete role');
+    }
+  };
+
+  const isAdminRole = editingRole?.name === 'admin';
+
+  if (isLoading) {
+    return (
+      <div className="flex items-center justify-center h-96">
+        <Loader2 className="h-8 w-8 animate-spin text-[#75534B]" />
+      </div>
+    );
+  }
+
+  return (
+    <div className="min-h-screen bg-[#F9F8F6]">
+      {/* Header */}
+      <section className="border-b border-[#E4E1DD] bg-white px-8 py-8">
+        <div className="mx-auto max-w-7xl flex items-center justify-between">
+          <div className="flex items-center gap-4">
+            <div className="h-12 w-12 rounded-xl bg-[#75534B] flex items-center justify-center">
+              <ShieldCheck className="h-6 w-6 text-white" />
+            </div>
+            <div>
+              <h1 className="text-3xl text-[#2C2C2C]" style={{ fontWeight: 600 }}>
+                {t.title}
+              </h1>
+              <p className="text-base text-[#6E6B67]">{t.subtitle}</p>
+            </div>
+          </div>
+          <button
+            onClick={() => handleOpenModal()}
+            className="flex items-center gap-2 rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-5 py-3 text-white font-medium shadow-sm transition-all hover:shadow-lg active:scale-95"
+          >
+            <Plus className="h-5 w-5" />
+            {t.addRole}
+          </button>
+        </div>
+      </section>
+
+      {/* Roles List */}
+      <section className="px-8 py-8">
+        <div className="mx-auto max-w-7xl space-y-4">
+          {roles.map((role) => (
+            <div
+              key={role.id}
+              className="rounded-xl bg-white border border-[#E4E1DD] p-6 shadow-sm hover:shadow-md transition-all"
+            >
+              <div className="flex items-start justify-between">
+                <div>
+                  <div className="flex items-center gap-3 mb-1">
+                    <h3 className="font-semibold text-[#2C2C2C]">{role.display_name}</h3>
+                    <span className="text-sm text-[#6E6B67]">{role.name}</span>
+                    {role.built_in && (
+                      <Badge className="bg-[#75534B]/10 text-[#75534B] hover:bg-[#75534B]/10 border-0">
+                        {t.builtIn}
+                      </Badge>
+                    )}
+                    {role.require_mfa && (
+                      <Badge className="bg-[#3A6EA5]/10 text-[#3A6EA5] hover:bg-[#3A6EA5]/10 border-0">
+                        2FA
+                      </Badge>
+                    )}
+                  </div>
+                  {role.description && (
+                    <p className="text-sm text-[#6E6B67] mb-2">{role.description}</p>
+                  )}
+                  <p className="text-sm text-[#6E6B67] mb-3">
+                    {role.user_count} {t.users}
+                  </p>
+                  <div className="flex flex-wrap gap-2">
+                    {role.permissions.length === 0 ? (
+                      <span className="text-sm text-[#6E6B67]">{t.noPermissions}</span>
+                    ) : (
+                      role.permissions.map((permission) => (
+                        <span
+                          key={permission}
+                          className="rounded-md bg-[#F9F8F6] border border-[#E4E1DD] px-2 py-1 text-xs text-[#2C2C2C]"
+                        >
+                          {permission}
+                        </span>
+                      ))
+                    )}
+                  </div>
+                </div>
+
+                <div className="flex items-center gap-3">
+                  <button
+                    onClick={() => handleOpenModal(role)}
+                    className="p-2 text-[#75534B] hover:bg-[#75534B]/10 rounded-lg transition-colors"
+                  >
+                    <Edit className="h-4 w-4" />
+                  </button>
+                  {!role.built_in && (
+                    <button
+                      onClick={() => handleDelete(role)}
+                      className="p-2 text-[#D1625B] hover:bg-[#D1625B]/10 rounded-lg transition-colors"
+                    >
+                      <Trash2 className="h-4 w-4" />
+                    </button>
+                  )}
+                </div>
+              </div>
+            </div>
+          ))}
+        </div>
+      </section>
+
+      {/* Role Modal */}
+      {showModal && (
+        <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/40 backdrop-blur-sm p-4">
+          <div className="w-full max-w-2xl rounded-xl bg-white shadow-2xl max-h-[90vh] flex flex-col">
+            <div className="bg-gradient-to-r from-[#75534B] to-[#5D423C] p-6 rounded-t-xl flex items-center justify-between">
+              <h2 className="text-xl text-white font-semibold">
+                {editingRole ? t.editRole : t.createRole}
+              </h2>
+              <button onClick={() => setShowModal(false)} className="text-white hover:text-white/80">
+                <X className="h-6 w-6" />
+              </button>
+            </div>
+
+            <div className="p-6 space-y-4 overflow-y-auto">
+              <div className="grid grid-cols-2 gap-4">
+                <div>
+                  <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                    {t.name} <span className="text-[#EF4444]">*</span>
+                  </label>
+                  <input
+                    type="text"
+                    value={formData.name}
+                    disabled={!!editingRole}
+                    placeholder={t.namePlaceholder}
+                    onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+                    className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20 disabled:bg-[#F9F8F6] disabled:text-[#6E6B67]"
+                  />
+                  {!editingRole && <p className="mt-1 text-xs text-[#6E6B67]">{t.nameHint}</p>}
+                </div>
+                <div>
+                  <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                    {t.displayName} <span className="text-[#EF4444]">*</span>
+                  </label>
+                  <input
+                    type="text"
+                    value={formData.display_name}
+                    onChange={(e) => setFormData({ ...formData, display_name: e.target.value })}
+                    className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                  />
+                </div>
+              </div>
+
+              <div>
+                <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                  {t.description}
+                </label>
+                <input
+                  type="text"
+                  value={formData.description}
+                  onChange={(e) => setFormData({ ...formData, description: e.target.value })}
+                  className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                />
+              </div>
+
+              <label className="flex items-center gap-2 text-sm text-[#2C2C2C]">
+                <input
+                  type="checkbox"
+                  checked={formData.require_mfa}
+                  onChange={(e) => setFormData({ ...formData, require_mfa: e.target.checked })}
+                />
+                {t.requireMfa}
+              </label>
+
+              <div>
+                <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">{t.permissions}</label>
+                {isAdminRole && (
+                  <p className="mb-2 flex items-center gap-2 text-xs text-[#6E6B67]">
+                    <Lock className="h-3 w-3" />
+                    {t.adminLocked}
+                  </p>
+                )}
+                <div className="space-y-2 rounded-lg border border-[#E4E1DD] p-4">
+                  {permissions.map((permission) => (
+                    <label key={permission.name} className="flex items-start gap-3 text-sm">
+                      <input
+                        type="checkbox"
+                        className="mt-1"
+                        checked={formData.permissions.includes(permission.name)}
+                        disabled={isAdminRole}
+                        onChange={() => togglePermission(permission.name)}
+                      />
+                      <span>
+                        <span className="font-medium text-[#2C2C2C]">{permission.name}</span>
+                        <span className="block text-xs text-[#6E6B67]">{permission.description}</span>
+                      </span>
+                    </label>
+                  ))}
+                </div>
+              </div>
+            </div>
+
+            <div className="border-t border-[#E4E1DD] p-6 flex items-center justify-between">
+              <button
+                onClick={() => setShowModal(false)}
+                className="px-6 py-3 text-[#6E6B67] font-medium transition-colors hover:text-[#2C2C2C]"
+              >
+                {t.cancel}
+              </button>
+              <button
+                onClick={handleSubmit}
+                disabled={isSubmitting || !formData.name || !formData.display_name}
+                className="px-6 py-3 rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] text-white font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 disabled:opacity-50 flex items-center gap-2"
+              >
+                {isSubmitting && <Loader2 className="h-4 w-4 animate-spin" />}
+                {t.save}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
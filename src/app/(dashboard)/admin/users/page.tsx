@@ -11,15 +11,18 @@ import {
   ToggleRight,
   X,
   Loader2,
+  Mail,
 } from 'lucide-react';
 import { useLanguage } from '@/contexts/LanguageContext';
-import { usersApi } from '@/lib/api';
+import { AxiosError } from 'axios';
+import { usersApi, rolesApi } from '@/lib/api';
 import { Badge } from '@/components/ui/badge';
-import type { User } from '@/types';
+import type { ApiResponse, Role, User } from '@/types';
 
 export default function UsersPage() {
   const { language } = useLanguage();
   const [users, setUsers] = useState<User[]>([]);
+  const [roles, setRoles] = useState<Role[]>([]);
   const [isLoading, setIsLoading] = useState(true);
   const [searchQuery, setSearchQuery] = useState('');
   const [showModal, setShowModal] = useState(false);
@@ -33,7 +36,10 @@ export default function UsersPage() {
     department: '',
     cost_center: '',
     company_code: '',
+    password_login_disabled: false,
   });
+  // Comma separated departments and cost centers the user manages
+  const [scopeData, setScopeData] = useState({ departments: '', cost_centers: '' });
 
   const text = {
     en: {
@@ -56,8 +62,15 @@ export default function UsersPage() {
       editUser: 'Edit User',
       password: 'Password',
       passwordPlaceholder: 'Leave blank to keep current',
+      invitePlaceholder: 'Leave blank to email an invitation',
+      sendInvite: 'Send invitation to set password',
+      inviteSent: 'Invitation sent',
       costCenter: 'Cost Center',
       companyCode: 'Company Code',
+      ssoOnly: 'Single sign-on only (disable password sign-in)',
+      scopeDepartments: 'Manages Departments',
+      scopeCostCenters: 'Manages Cost Centers',
+      scopeHint: 'Comma separated. When set, the user only sees and approves requests from these; leave both empty for company-wide access.',
       save: 'Save',
       cancel: 'Cancel',
       roles: {
@@ -87,8 +100,15 @@ export default function UsersPage() {
       editUser: '编辑用户',
       password: '密码',
       passwordPlaceholder: '留空保持当前密码',
+      invitePlaceholder: '留空则通过邮件发送邀请',
+      sendInvite: '发送设置密码的邀请',
+      inviteSent: '邀请已发送',
       costCenter: '成本中心',
       companyCode: '公司代码',
+      ssoOnly: '仅限单点登录（禁用密码登录）',
+      scopeDepartments: '管理的部门',
+      scopeCostCenters: '管理的成本中心',
+      scopeHint: '用逗号分隔。设置后，该用户只能查看和审批来自这些部门或成本中心的请求；都留空则可访问全公司。',
       save: '保存',
       cancel: '取消',
       roles: {
@@ -118,8 +138,15 @@ export default function UsersPage() {
       editUser: 'Editar Usuario',
       password: 'Contraseña',
       passwordPlaceholder: 'Dejar en blanco para mantener actual',
+      invitePlaceholder: 'Dejar en blanco para enviar una invitación por correo',
+      sendInvite: 'Enviar invitación para establecer contraseña',
+      inviteSent: 'Invitación enviada',
       costCenter: 'Centro de Costos',
       companyCode: 'Código de Empresa',
+      ssoOnly: 'Solo inicio de sesión único (deshabilitar contraseña)',
+      scopeDepartments: 'Departamentos que Gestiona',
+      scopeCostCenters: 'Centros de Costos que Gestiona',
+      scopeHint: 'Separados por comas. Si se definen, el usuario solo ve y aprueba solicitudes de estos; deje ambos vacíos para acceso a toda la empresa.',
       save: 'Guardar',
       cancel: 'Cancelar',
       roles: {
@@ -135,6 +162,7 @@ export default function UsersPage() {
 
   useEffect(() => {
     fetchUsers();
+    fetchRoles();
   }, []);
 
   const fetchUsers = async () => {
@@ -148,9 +176,43 @@ export default function UsersPage() {
     }
   };
 
+  const fetchRoles = async () => {
+    try {
+      setRoles(await rolesApi.list());
+    } catch (error) {
+      console.error('Failed to fetch roles:', error);
+    }
+  };
+
+  // Built-in roles are translated; custom roles show the name admins gave them
+  const roleLabel = (role: string) =>
+    t.roles[role as keyof typeof t.roles] || roles.find((r) => r.name === role)?.display_name || role;
+
+  const roleOptions = roles.length > 0 ? roles.map((r) => r.name) : Object.keys(t.roles);
+
+  const splitList = (value: string) =>
+    value
+      .split(',')
+      .map((item) => item.trim())
+      .filter(Boolean);
+
+  const fetchScopes = async (user: User) => {
+    try {
+      const scopes = await usersApi.getScopes(user.id);
+      setScopeData({
+        departments: scopes.departments.join(', '),
+        cost_centers: scopes.cost_centers.join(', '),
+      });
+    } catch (error) {
+      console.error('Failed to fetch scopes:', error);
+    }
+  };
+
   const handleOpenModal = (user?: User) => {
+    setScopeData({ departments: '', cost_centers: '' });
     if (user) {
       setEditingUser(user);
+      fetchScopes(user);
       setFormData({
         name: user.name,
         email: user.email,
@@ -159,6 +221,7 @@ export default function UsersPage() {
         department: user.department,
         cost_center: user.cost_center,
         company_code: user.company_code,
+        password_login_disabled: !!user.password_login_disabled,
       });
     } else {
       setEditingUser(null);
@@ -170,6 +233,7 @@ export default function UsersPage() {
         department: '',
         cost_center: '',
         company_code: '',
+        password_login_disabled: false,
       });
     }
     setShowModal(true);
@@ -186,19 +250,34 @@ export default function UsersPage() {
           department: formData.department,
           cost_center: formData.cost_center,
           company_code: formData.company_code,
+          password_login_disabled: formData.password_login_disabled,
         };
         await usersApi.update(editingUser.id, updateData);
+        await usersApi.setScopes(editingUser.id, {
+          departments: splitList(scopeData.departments),
+          cost_centers: splitList(scopeData.cost_centers),
+        });
       } else {
-        await usersApi.create({
+        // Without a password the user is emailed an invitation to set one,
+        // unless they only sign in with single sign-on
+        const created = await usersApi.create({
           ...formData,
-          password: formData.password,
+          password: formData.password || undefined,
         });
+        if (scopeData.departments.trim() || scopeData.cost_centers.trim()) {
+          await usersApi.setScopes(created.id, {
+            departments: splitList(scopeData.departments),
+            cost_centers: splitList(scopeData.cost_centers),
+          });
+        }
       }
       setShowModal(false);
       fetchUsers();
     } catch (error) {
       console.error('Failed to save user:', error);
-      alert('Failed to save user');
+      // Passwords breaking the policy come back with the rules they break
+      const message = (error as AxiosError<ApiResponse<unknown>>).response?.data?.error?.message;
+      alert(message || 'Failed to save user');
     } finally {
       setIsSubmitting(false);
     }
@@ -213,6 +292,15 @@ export default function UsersPage() {
     }
   };
 
+  const handleInvite = async (user: User) => {
+    try {
+      await usersApi.invite(user.id);
+      alert(t.inviteSent);
+    } catch (error) {
+      console.error('Failed to send invitation:', error);
+    }
+  };
+
   const handleDelete = async (user: User) => {
     if (!confirm(`Delete user ${user.name}?`)) return;
     try {
@@ -319,7 +407,7 @@ export default function UsersPage() {
                       <td className="px-6 py-4 text-sm text-[#6E6B67]">{user.email}</td>
                       <td className="px-6 py-4">
                         <Badge className="bg-[#75534B]/10 text-[#75534B] hover:bg-[#75534B]/10 border-0">
-                          {t.roles[user.role as keyof typeof t.roles] || user.role}
+                          {roleLabel(user.role)}
                         </Badge>
                       </td>
                       <td className="px-6 py-4 text-sm text-[#6E6B67]">
@@ -351,6 +439,13 @@ export default function UsersPage() {
                           >
                             <Edit className="h-4 w-4" />
                           </button>
+                          <button
+                            onClick={() => handleInvite(user)}
+                            title={t.sendInvite}
+                            className="p-2 text-[#75534B] hover:bg-[#75534B]/10 rounded-lg transition-colors"
+                          >
+                            <Mail className="h-4 w-4" />
+                          </button>
                           <button
                             onClick={() => handleDelete(user)}
                             className="p-2 text-[#D1625B] hover:bg-[#D1625B]/10 rounded-lg transition-colors"
@@ -412,13 +507,13 @@ export default function UsersPage() {
               {!editingUser && (
                 <div>
                   <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
-                    {t.password} <span className="text-[#EF4444]">*</span>
+                    {t.password}
                   </label>
                   <input
                     type="password"
                     value={formData.password}
                     onChange={(e) => setFormData({ ...formData, password: e.target.value })}
-                    placeholder={editingUser ? t.passwordPlaceholder : ''}
+                    placeholder={t.invitePlaceholder}
                     className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
                   />
                 </div>
@@ -431,18 +526,30 @@ export default function UsersPage() {
                 <select
                   value={formData.role}
                   onChange={(e) =>
-                    setFormData({ ...formData, role: e.target.value as User['role'] })
+                    setFormData({ ...formData, role: e.target.value })
                   }
                   className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
                 >
-                  {Object.entries(t.roles).map(([key, label]) => (
-                    <option key={key} value={key}>
-                      {label}
+                  {roleOptions.map((role) => (
+                    <option key={role} value={role}>
+                      {roleLabel(role)}
                     </option>
                   ))}
                 </select>
               </div>
 
+              <label className="flex items-center gap-2 text-sm text-[#2C2C2C]">
+                <input
+                  type="checkbox"
+                  checked={formData.password_login_disabled}
+                  onChange={(e) =>
+                    setFormData({ ...formData, password_login_disabled: e.target.checked })
+                  }
+                  className="h-4 w-4 rounded border-[#E4E1DD] accent-[#75534B]"
+                />
+                {t.ssoOnly}
+              </label>
+
               <div className="grid grid-cols-2 gap-4">
                 <div>
                   <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
@@ -471,6 +578,32 @@ export default function UsersPage() {
                   />
                 </div>
               </div>
+
+              <div className="grid grid-cols-2 gap-4">
+                <div>
+                  <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                    {t.scopeDepartments}
+                  </label>
+                  <input
+                    type="text"
+                    value={scopeData.departments}
+                    onChange={(e) => setScopeData({ ...scopeData, departments: e.target.value })}
+                    className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                  />
+                </div>
+                <div>
+                  <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                    {t.scopeCostCenters}
+                  </label>
+                  <input
+                    type="text"
+                    value={scopeData.cost_centers}
+                    onChange={(e) => setScopeData({ ...scopeData, cost_centers: e.target.value })}
+                    className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                  />
+                </div>
+              </div>
+              <p className="text-xs text-[#6E6B67]">{t.scopeHint}</p>
             </div>
 
             <div className="border-t border-[#E4E1DD] p-6 flex items-center justify-between">
@@ -0,0 +1,541 @@
+'use client';
+
+import { useState, useEffect } from 'react';
+import { Webhook as WebhookIcon, Plus, Edit, Trash2, X, Loader2, Send, History, Copy } from 'lucide-react';
+import { useLanguage } from '@/contexts/LanguageContext';
+import { AxiosError } from 'axios';
+import { webhooksApi } from '@/lib/api';
+import { Badge } from '@/components/ui/badge';
+import type { ApiResponse, RequestEventType, Webhook, WebhookDelivery, WebhookInput } from '@/types';
+
+const emptyForm: WebhookInput = {
+  name: '',
+  url: '',
+  events: [],
+  is_active: true,
+  secret: '',
+  rotate_secret: false,
+};
+
+const deliveryStatusStyles: Record<WebhookDelivery['status'], string> = {
+  succeeded: 'bg-[#4BAF7E]/10 text-[#4BAF7E]',
+  pending: 'bg-[#E1A948]/10 text-[#C79438]',
+  failed: 'bg-[#D1625B]/10 text-[#D1625B]',
+};
+
+export default function WebhooksPage() {
+  const { language } = useLanguage();
+  const [webhooks, setWebhooks] = useState<Webhook[]>([]);
+  const [eventTypes, setEventTypes] = useState<RequestEventType[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [showModal, setShowModal] = useState(false);
+  const [editingWebhook, setEditingWebhook] = useState<Webhook | null>(null);
+  const [isSubmitting, setIsSubmitting] = useState(false);
+  const [formData, setFormData] = useState<WebhookInput>(emptyForm);
+  const [newSecret, setNewSecret] = useState<string | null>(null);
+  const [testingId, setTestingId] = useState<number | null>(null);
+  const [logWebhook, setLogWebhook] = useState<Webhook | null>(null);
+  const [deliveries, setDeliveries] = useState<WebhookDelivery[]>([]);
+
+  const text = {
+    en: {
+      title: 'Webhooks',
+      subtitle: 'Send signed request events to external systems',
+      addWebhook: 'Add Webhook',
+      name: 'Name',
+      url: 'Payload URL',
+      events: 'Events',
+      active: 'Active',
+      inactive: 'Inactive',
+      secret: 'Signing secret',
+      secretHint: 'Leave empty to generate one.',
+      rotateSecret: 'Generate a new signing secret',
+      secretShown: 'Copy the signing secret now; it will not be shown again.',
+      lastDelivery: 'Last delivery',
+      never: 'No deliveries yet',
+      sendTest: 'Send test event',
+      testDelivered: 'Test event delivered',
+      testFailed: 'Test event failed',
+      deliveries: 'Delivery log',
+      attempts: 'attempts',
+      noDeliveries: 'No deliveries yet',
+      createWebhook: 'Create Webhook',
+      editWebhook: 'Edit Webhook',
+      save: 'Save',
+      cancel: 'Cancel',
+      close: 'Close',
+    },
+    zh: {
+      title: 'Webhooks',
+      subtitle: '向外部系统发送签名的申请事件',
+      addWebhook: '添加 Webhook',
+      name: '名称',
+      url: '接收 URL',
+      events: '事件',
+      active: '启用',
+      inactive: '停用',
+      secret: '签名密钥',
+      secretHint: '留空将自动生成。',
+      rotateSecret: '生成新的签名密钥',
+      secretShown: '请立即复制签名密钥，之后将不再显示。',
+      lastDelivery: '最近投递',
+      never: '暂无投递',
+      sendTest: '发送测试事件',
+      testDelivered: '测试事件已送达',
+      testFailed: '测试事件失败',
+      deliveries: '投递日志',
+      attempts: '次尝试',
+      noDeliveries: '暂无投递',
+      createWebhook: '创建 Webhook',
+      editWebhook: '编辑 Webhook',
+      save: '保存',
+      cancel: '取消',
+      close: '关闭',
+    },
+    es: {
+      title: 'Webhooks',
+      subtitle: 'Enviar eventos firmados de solicitudes a sistemas externos',
+      addWebhook: 'Agregar Webhook',
+      name: 'Nombre',
+      url: 'URL de destino',
+      events: 'Eventos',
+      active: 'Activo',
+      inactive: 'Inactivo',
+      secret: 'Secreto de firma',
+      secretHint: 'Déjelo vacío para generar uno.',
+      rotateSecret: 'Generar un nuevo secreto de firma',
+      secretShown: 'Copie el secreto de firma ahora; no se volverá a mostrar.',
+      lastDelivery: 'Última entrega',
+      never: 'Sin entregas todavía',
+      sendTest: 'Enviar evento de prueba',
+      testDelivered: 'Evento de prueba entregado',
+      testFailed: 'Falló el evento de prueba',
+      deliveries: 'Registro de entregas',
+      attempts: 'intentos',
+      noDeliveries: 'Sin entregas todavía',
+      createWebhook: 'Crear Webhook',
+      editWebhook: 'Editar Webhook',
+      save: 'Guardar',
+      cancel: 'Cancelar',
+      close: 'Cerrar',
+    },
+  };
+
+  const t = text[language];
+
+  useEffect(() => {
+    fetchData();
+  }, []);
+
+  const fetchData = async () => {
+    try {
+      const [webhookData, eventData] = await Promise.all([webhooksApi.list(), webhooksApi.events()]);
+      setWebhooks(webhookData);
+      setEventTypes(eventData);
+    } catch (error) {
+      console.error('Failed to fetch webhooks:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleOpenModal = (webhook?: Webhook) => {
+    if (webhook) {
+      setEditingWebhook(webhook);
+      setFormData({
+        name: webhook.name,
+        url: webhook.url,
+        events: webhook.events,
+        is_active: webhook.is_active,
+        secret: '',
+        rotate_secret: false,
+      });
+    } else {
+      setEditingWebhook(null);
+      setFormData(emptyForm);
+    }
+    setShowModal(true);
+  };
+
+  const toggleEvent = (eventType: RequestEventType) => {
+    setFormData((prev) => ({
+      ...prev,
+      events: prev.events.includes(eventType)
+        ? prev.events.filter((e) => e !== eventType)
+        : [...prev.events, eventType],
+    }));
+  };
+
+  const handleSubmit = async () => {
+    setIsSubmitting(true);
+    try {
+      const saved = editingWebhook
+        ? await webhooksApi.update(editingWebhook.id, formData)
+        : await webhooksApi.create(formData);
+      setShowModal(false);
+      if (saved.secret) {
+        setNewSecret(saved.secret);
+      }
+      fetchData();
+    } catch (error) {
+      console.error('Failed to save webhook:', error);
+      const message = (error as AxiosError<ApiResponse<unknown>>).response?.data?.error?.message;
+      alert(message || 'Failed to save webhook');
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  const handleDelete = async (webhook: Webhook) => {
+    if (!confirm(`Delete webhook "${webhook.name}"?`)) return;
+    try {
+      await webhooksApi.delete(webhook.id);
+      fetchData();
+    } catch (error) {
+      console.error('Failed to delete webhook:', error);
+      alert('Failed to delete webhook');
+    }
+  };
+
+  const handleTest = async (webhook: Webhook) => {
+    setTestingId(webhook.id);
+    try {
+      const delivery = await webhooksApi.test(webhook.id);
+      if (delivery.status === 'succeeded') {
+        alert(`${t.testDelivered} (${delivery.response_status}, ${delivery.duration_ms} ms)`);
+      } else {
+        alert(`${t.testFailed}: ${delivery.error}`);
+      }
+      fetchData();
+    } catch (error) {
+      console.error('Failed to send test event:', error);
+      alert(t.testFailed);
+    } finally {
+      setTestingId(null);
+    }
+  };
+
+  const openLog = async (webhook: Webhook) => {
+    setLogWebhook(webhook);
+    setDeliveries([]);
+    try {
+      const result = await webhooksApi.deliveries(webhook.id, { per_page: 50 });
+      setDeliveries(result.data || []);
+    } catch (error) {
+      console.error('Failed to fetch deliveries:', error);
+    }
+  };
+
+  if (isLoading) {
+    return (
+      <div className="flex items-center justify-center h-96">
+        <Loader2 className="h-8 w-8 animate-spin text-[#75534B]" />
+      </div>
+    );
+  }
+
+  return (
+    <div className="min-h-screen bg-[#F9F8F6]">
+      {/* Header */}
+      <section className="border-b border-[#E4E1DD] bg-white px-8 py-8">
+        <div className="mx-auto max-w-7xl flex items-center justify-between">
+          <div className="flex items-center gap-4">
+            <div className="h-12 w-12 rounded-xl bg-[#75534B] flex items-center justify-center">
+              <WebhookIcon className="h-6 w-6 text-white" />
+            </div>
+            <div>
+              <h1 className="text-3xl text-[#2C2C2C]" style={{ fontWeight: 600 }}>
+                {t.title}
+              </h1>
+              <p className="text-base text-[#6E6B67]">{t.subtitle}</p>
+            </div>
+          </div>
+          <button
+            onClick={() => handleOpenModal()}
+            className="flex items-center gap-2 rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-5 py-3 text-white font-medium shadow-sm transition-all hover:shadow-lg active:scale-95"
+          >
+            <Plus className="h-5 w-5" />
+            {t.addWebhook}
+          </button>
+        </div>
+      </section>
+
+      {/* New secret */}
+      {newSecret && (
+        <section className="px-8 pt-8">
+          <div className="mx-auto max-w-7xl rounded-xl border border-[#E1A948] bg-[#E1A948]/10 p-4 flex items-center justify-between gap-4">
+            <div className="min-w-0">
+              <p className="text-sm text-[#2C2C2C] mb-1">{t.secretShown}</p>
+              <code className="block truncate text-sm text-[#2C2C2C]">{newSecret}</code>
+            </div>
+            <div className="flex items-center gap-2">
+              <button
+                onClick={() => navigator.clipboard.writeText(newSecret)}
+                className="p-2 text-[#75534B] hover:bg-[#75534B]/10 rounded-lg transition-colors"
+              >
+                <Copy className="h-4 w-4" />
+              </button>
+              <button
+                onClick={() => setNewSecret(null)}
+                className="p-2 text-[#6E6B67] hover:bg-[#6E6B67]/10 rounded-lg transition-colors"
+              >
+                <X className="h-4 w-4" />
+              </button>
+            </div>
+          </div>
+        </section>
+      )}
+
+      {/* Webhooks List */}
+      <section className="px-8 py-8">
+        <div className="mx-auto max-w-7xl space-y-4">
+          {webhooks.map((webhook) => (
+            <div
+              key={webhook.id}
+              className="rounded-xl bg-white border border-[#E4E1DD] p-6 shadow-sm hover:shadow-md transition-all"
+            >
+              <div className="flex items-start justify-between gap-4">
+                <div className="min-w-0">
+                  <div className="flex items-center gap-3 mb-1">
+                    <h3 className="font-semibold text-[#2C2C2C]">{webhook.name}</h3>
+                    <Badge
+                      className={`border-0 ${
+                        webhook.is_active
+                          ? 'bg-[#4BAF7E]/10 text-[#4BAF7E] hover:bg-[#4BAF7E]/10'
+                          : 'bg-[#6E6B67]/10 text-[#6E6B67] hover:bg-[#6E6B67]/10'
+                      }`}
+                    >
+                      {webhook.is_active ? t.active : t.inactive}
+                    </Badge>
+                  </div>
+                  <p className="text-sm text-[#6E6B67] mb-3 truncate">{webhook.url}</p>
+                  <div className="flex flex-wrap gap-2 mb-3">
+                    {webhook.events.map((eventType) => (
+                      <span
+                        key={eventType}
+                        className="rounded-md bg-[#F9F8F6] border border-[#E4E1DD] px-2 py-1 text-xs text-[#2C2C2C]"
+                      >
+                        {eventType}
+                      </span>
+                    ))}
+                  </div>
+                  <p className="text-xs text-[#6E6B67]">
+                    {t.lastDelivery}:{' '}
+                    {webhook.last_delivery ? (
+                      <>
+                        <span className={`rounded px-1.5 py-0.5 ${deliveryStatusStyles[webhook.last_delivery.status]}`}>
+                          {webhook.last_delivery.status}
+                        </span>{' '}
+                        {webhook.last_delivery.event_type} ·{' '}
+                        {new Date(webhook.last_delivery.created_at).toLocaleString()}
+                      </>
+                    ) : (
+                      t.never
+                    )}
+                  </p>
+                </div>
+
+                <div className="flex items-center gap-3">
+                  <button
+                    onClick={() => handleTest(webhook)}
+                    disabled={testingId === webhook.id}
+                    title={t.sendTest}
+                    className="p-2 text-[#3A6EA5] hover:bg-[#3A6EA5]/10 rounded-lg transition-colors disabled:opacity-50"
+                  >
+                    {testingId === webhook.id ? (
+                      <Loader2 className="h-4 w-4 animate-spin" />
+                    ) : (
+                      <Send className="h-4 w-4" />
+                    )}
+                  </button>
+                  <button
+                    onClick={() => openLog(webhook)}
+                    title={t.deliveries}
+                    className="p-2 text-[#75534B] hover:bg-[#75534B]/10 rounded-lg transition-colors"
+                  >
+                    <History className="h-4 w-4" />
+                  </button>
+                  <button
+                    onClick={() => handleOpenModal(webhook)}
+                    className="p-2 text-[#75534B] hover:bg-[#75534B]/10 rounded-lg transition-colors"
+                  >
+                    <Edit className="h-4 w-4" />
+                  </button>
+                  <button
+                    onClick={() => handleDelete(webhook)}
+                    className="p-2 text-[#D1625B] hover:bg-[#D1625B]/10 rounded-lg transition-colors"
+                  >
+                    <Trash2 className="h-4 w-4" />
+                  </button>
+                </div>
+              </div>
+            </div>
+          ))}
+        </div>
+      </section>
+
+      {/* Webhook Modal */}
+      {showModal && (
+        <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/40 backdrop-blur-sm p-4">
+          <div className="w-full max-w-2xl rounded-xl bg-white shadow-2xl max-h-[90vh] flex flex-col">
+            <div className="bg-gradient-to-r from-[#75534B] to-[#5D423C] p-6 rounded-t-xl flex items-center justify-between">
+              <h2 className="text-xl text-white font-semibold">
+                {editingWebhook ? t.editWebhook : t.createWebhook}
+              </h2>
+              <button onClick={() => setShowModal(false)} className="text-white hover:text-white/80">
+                <X className="h-6 w-6" />
+              </button>
+            </div>
+
+            <div className="p-6 space-y-4 overflow-y-auto">
+              <div>
+                <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                  {t.name} <span className="text-[#EF4444]">*</span>
+                </label>
+                <input
+                  type="text"
+                  value={formData.name}
+                  onChange={(e) => setFormData({ ...formData, name: e.target.value })}
+                  className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                />
+              </div>
+
+              <div>
+                <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                  {t.url} <span className="text-[#EF4444]">*</span>
+                </label>
+                <input
+                  type="url"
+                  value={formData.url}
+                  placeholder="https://erp.example.com/hooks/vista"
+                  onChange={(e) => setFormData({ ...formData, url: e.target.value })}
+                  className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                />
+              </div>
+
+              {editingWebhook ? (
+                <label className="flex items-center gap-2 text-sm text-[#2C2C2C]">
+                  <input
+                    type="checkbox"
+                    checked={!!formData.rotate_secret}
+                    onChange={(e) => setFormData({ ...formData, rotate_secret: e.target.checked })}
+                  />
+                  {t.rotateSecret}
+                </label>
+              ) : (
+                <div>
+                  <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">{t.secret}</label>
+                  <input
+                    type="text"
+                    value={formData.secret}
+                    onChange={(e) => setFormData({ ...formData, secret: e.target.value })}
+                    className="w-full rounded-lg border border-[#E4E1DD] bg-white px-4 py-3 text-sm text-[#2C2C2C] transition-all focus:border-[#75534B] focus:outline-none focus:ring-2 focus:ring-[#75534B]/20"
+                  />
+                  <p className="mt-1 text-xs text-[#6E6B67]">{t.secretHint}</p>
+                </div>
+              )}
+
+              <label className="flex items-center gap-2 text-sm text-[#2C2C2C]">
+                <input
+                  type="checkbox"
+                  checked={formData.is_active}
+                  onChange={(e) => setFormData({ ...formData, is_active: e.target.checked })}
+                />
+                {t.active}
+              </label>
+
+              <div>
+                <label className="mb-2 block text-sm font-semibold text-[#2C2C2C]">
+                  {t.events} <span className="text-[#EF4444]">*</span>
+                </label>
+                <div className="grid grid-cols-2 gap-2 rounded-lg border border-[#E4E1DD] p-4">
+                  {eventTypes.map((eventType) => (
+                    <label key={eventType} className="flex items-center gap-3 text-sm text-[#2C2C2C]">
+                      <input
+                        type="checkbox"
+                        checked={formData.events.includes(eventType)}
+                        onChange={() => toggleEvent(eventType)}
+                      />
+                      {eventType}
+                    </label>
+                  ))}
+                </div>
+              </div>
+            </div>
+
+            <div className="border-t border-[#E4E1DD] p-6 flex items-center justify-between">
+              <button
+                onClick={() => setShowModal(false)}
+                className="px-6 py-3 text-[#6E6B67] font-medium transition-colors hover:text-[#2C2C2C]"
+              >
+                {t.cancel}
+              </button>
+              <button
+                onClick={handleSubmit}
+                disabled={isSubmitting || !formData.name || !formData.url || formData.events.length === 0}
+                className="px-6 py-3 rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] text-white font-medium shadow-sm transition-all hover:shadow-lg active:scale-95 disabled:opacity-50 flex items-center gap-2"
+              >
+                {isSubmitting && <Loader2 className="h-4 w-4 animate-spin" />}
+                {t.save}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
+      {/* Delivery Log Modal */}
+      {logWebhook && (
+        <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/40 backdrop-blur-sm p-4">
+          <div className="w-full max-w-4xl rounded-xl bg-white shadow-2xl max-h-[90vh] flex flex-col">
+            <div className="bg-gradient-to-r from-[#75534B] to-[#5D423C] p-6 rounded-t-xl flex items-center justify-between">
+              <h2 className="text-xl text-white font-semibold">
+                {t.deliveries} · {logWebhook.name}
+              </h2>
+              <button onClick={() => setLogWebhook(null)} className="text-white hover:text-white/80">
+                <X className="h-6 w-6" />
+              </button>
+            </div>
+
+            <div className="p-6 overflow-y-auto divide-y divide-[#E4E1DD]">
+              {deliveries.length === 0 && <p className="text-sm text-[#6E6B67]">{t.noDeliveries}</p>}
+              {deliveries.map((delivery) => (
+                <details key={delivery.id} className="py-3">
+                  <summary className="flex cursor-pointer items-center gap-3 text-sm">
+                    <span className={`rounded px-1.5 py-0.5 text-xs ${deliveryStatusStyles[delivery.status]}`}>
+                      {delivery.status}
+                    </span>
+                    <span className="font-medium text-[#2C2C2C]">{delivery.event_type}</span>
+                    <span className="text-[#6E6B67]">
+                      {delivery.response_status || '—'} · {delivery.attempts} {t.attempts} · {delivery.duration_ms} ms
+                    </span>
+                    <span className="ml-auto text-xs text-[#6E6B67]">
+                      {new Date(delivery.created_at).toLocaleString()}
+                    </span>
+                  </summary>
+                  {delivery.error && <p className="mt-2 text-xs text-[#D1625B]">{delivery.error}</p>}
+                  <pre className="mt-2 max-h-64 overflow-auto rounded-lg bg-[#F9F8F6] p-3 text-xs text-[#2C2C2C]">
+                    {JSON.stringify(JSON.parse(delivery.payload), null, 2)}
+                  </pre>
+                  {delivery.response_body && (
+                    <pre className="mt-2 max-h-32 overflow-auto rounded-lg bg-[#F9F8F6] p-3 text-xs text-[#6E6B67]">
+                      {delivery.response_body}
+                    </pre>
+                  )}
+                </details>
+              ))}
+            </div>
+
+            <div className="border-t border-[#E4E1DD] p-6 flex justify-end">
+              <button
+                onClick={() => setLogWebhook(null)}
+                className="px-6 py-3 text-[#6E6B67] font-medium transition-colors hover:text-[#2C2C2C]"
+              >
+                {t.close}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
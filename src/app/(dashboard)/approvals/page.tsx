@@ -11,11 +11,15 @@ import {
   AlertCircle,
   CheckCircle,
   Loader2,
+  UserCheck,
+  Trash2,
 } from 'lucide-react';
 import { useLanguage } from '@/contexts/LanguageContext';
-import { approvalsApi } from '@/lib/api';
+import { approvalsApi, delegationsApi, eventsApi } from '@/lib/api';
 import { Badge } from '@/components/ui/badge';
-import type { PurchaseRequest } from '@/types';
+import { RequestAttachments } from '@/components/requests/RequestAttachments';
+import { RequestConversation } from '@/components/requests/RequestConversation';
+import type { DelegateOption, Delegation, PurchaseRequest } from '@/types';
 
 export default function ApprovalsPage() {
   const { language } = useLanguage();
@@ -25,10 +29,25 @@ export default function ApprovalsPage() {
   const [selectedApproval, setSelectedApproval] = useState<PurchaseRequest | null>(null);
   const [comment, setComment] = useState('');
   const [isSubmitting, setIsSubmitting] = useState(false);
+  const [delegations, setDelegations] = useState<Delegation[]>([]);
+  const [delegates, setDelegates] = useState<DelegateOption[]>([]);
+  const [delegationForm, setDelegationForm] = useState({ delegate_id: '', ends_at: '', reason: '' });
 
   const text = {
     en: {
       title: 'Approvals',
+      delegations: 'Delegations',
+      delegationsHint: 'Let someone approve on your behalf while you are away',
+      delegateTo: 'Delegate to',
+      selectUser: 'Select a user',
+      until: 'Until',
+      reason: 'Reason',
+      delegate: 'Delegate',
+      noDelegations: 'No delegations',
+      onBehalfOf: 'on behalf of',
+      delegationActive: 'Active',
+      delegationScheduled: 'Scheduled',
+      delegationFailed: 'Failed to save delegation',
       subtitle: 'Review and Approve Purchase Requests',
       pending: 'Pending',
       approved: 'Approved',
@@ -65,6 +84,18 @@ export default function ApprovalsPage() {
     },
     zh: {
       title: '审批',
+      delegations: '委托',
+      delegationsHint: '外出期间委托他人代您审批',
+      delegateTo: '委托给',
+      selectUser: '选择用户',
+      until: '截止',
+      reason: '原因',
+      delegate: '委托',
+      noDelegations: '没有委托',
+      onBehalfOf: '代表',
+      delegationActive: '生效中',
+      delegationScheduled: '已计划',
+      delegationFailed: '保存委托失败',
       subtitle: '审核和批准采购请求',
       pending: '待审批',
       approved: '已批准',
@@ -101,6 +132,18 @@ export default function ApprovalsPage() {
     },
     es: {
       title: 'Aprobaciones',
+      delegations: 'Delegaciones',
+      delegationsHint: 'Permita que alguien apruebe en su nombre mientras está ausente',
+      delegateTo: 'Delegar a',
+      selectUser: 'Seleccione un usuario',
+      until: 'Hasta',
+      reason: 'Motivo',
+      delegate: 'Delegar',
+      noDelegations: 'No hay delegaciones',
+      onBehalfOf: 'en nombre de',
+      delegationActive: 'Activa',
+      delegationScheduled: 'Programada',
+      delegationFailed: 'No se pudo guardar la delegación',
       subtitle: 'Revisar y Aprobar Solicitudes de Compra',
       pending: 'Pendiente',
       approved: 'Aprobado',
@@ -141,8 +184,53 @@ export default function ApprovalsPage() {
 
   useEffect(() => {
     fetchApprovals();
+    fetchDelegations();
+    delegationsApi.delegates().then(setDelegates).catch(() => setDelegates([]));
   }, []);
 
+  // Reload the queue when a request is submitted, decided on or withdrawn
+  useEffect(() => {
+    return eventsApi.subscribe({
+      onEvent: (event) => {
+        if (!event.type.startsWith('cart.')) fetchApprovals();
+      },
+      onReconnect: fetchApprovals,
+    });
+  }, []);
+
+  const fetchDelegations = async () => {
+    try {
+      setDelegations(await delegationsApi.list());
+    } catch (error) {
+      console.error('Failed to fetch delegations:', error);
+    }
+  };
+
+  const handleCreateDelegation = async () => {
+    if (!delegationForm.delegate_id || !delegationForm.ends_at) return;
+    try {
+      await delegationsApi.create({
+        delegate_id: Number(delegationForm.delegate_id),
+        ends_at: new Date(delegationForm.ends_at).toISOString(),
+        reason: delegationForm.reason,
+      });
+      setDelegationForm({ delegate_id: '', ends_at: '', reason: '' });
+      fetchDelegations();
+    } catch (error) {
+      console.error('Failed to create delegation:', error);
+      alert(t.delegationFailed);
+    }
+  };
+
+  const handleDeleteDelegation = async (id: number) => {
+    try {
+      await delegationsApi.delete(id);
+      fetchDelegations();
+    } catch (error) {
+      console.error('Failed to delete delegation:', error);
+    }
+  };
+
   const fetchApprovals = async () => {
     try {
       const response = await approvalsApi.listPending({ per_page: 50 });
@@ -352,6 +440,87 @@ export default function ApprovalsPage() {
               </table>
             </div>
           )}
+
+          {/* Delegations */}
+          <div className="mt-8 rounded-lg bg-white shadow-sm border border-[#E4E1DD] p-6">
+            <div className="flex items-center gap-2 mb-1">
+              <UserCheck className="h-5 w-5 text-[#75534B]" />
+              <h2 className="text-lg font-semibold text-[#2C2C2C]">{t.delegations}</h2>
+            </div>
+            <p className="text-sm text-[#6E6B67] mb-4">{t.delegationsHint}</p>
+
+            <div className="flex flex-wrap items-end gap-3 mb-4">
+              <label className="text-sm text-[#6E6B67]">
+                {t.delegateTo}
+                <select
+                  value={delegationForm.delegate_id}
+                  onChange={(e) => setDelegationForm({ ...delegationForm, delegate_id: e.target.value })}
+                  className="mt-1 block rounded-lg border border-[#E4E1DD] px-3 py-2 text-sm text-[#2C2C2C]"
+                >
+                  <option value="">{t.selectUser}</option>
+                  {delegates.map((u) => (
+                    <option key={u.id} value={u.id}>
+                      {u.name}
+                    </option>
+                  ))}
+                </select>
+              </label>
+              <label className="text-sm text-[#6E6B67]">
+                {t.until}
+                <input
+                  type="datetime-local"
+                  value={delegationForm.ends_at}
+                  onChange={(e) => setDelegationForm({ ...delegationForm, ends_at: e.target.value })}
+                  className="mt-1 block rounded-lg border border-[#E4E1DD] px-3 py-2 text-sm text-[#2C2C2C]"
+                />
+              </label>
+              <label className="flex-1 text-sm text-[#6E6B67]">
+                {t.reason}
+                <input
+                  type="text"
+                  value={delegationForm.reason}
+                  onChange={(e) => setDelegationForm({ ...delegationForm, reason: e.target.value })}
+                  className="mt-1 block w-full rounded-lg border border-[#E4E1DD] px-3 py-2 text-sm text-[#2C2C2C]"
+                />
+              </label>
+              <button
+                onClick={handleCreateDelegation}
+                disabled={!delegationForm.delegate_id || !delegationForm.ends_at}
+                className="rounded-lg bg-gradient-to-r from-[#75534B] to-[#5D423C] px-4 py-2 text-sm font-medium text-white disabled:opacity-50"
+              >
+                {t.delegate}
+              </button>
+            </div>
+
+            {delegations.length === 0 ? (
+              <p className="text-sm text-[#9B9792]">{t.noDelegations}</p>
+            ) : (
+              <ul className="divide-y divide-[#E4E1DD]">
+                {delegations.map((d) => (
+                  <li key={d.id} className="flex items-center justify-between py-3 text-sm">
+                    <div>
+                      <span className="font-medium text-[#2C2C2C]">
+                        {d.delegator?.name} → {d.delegate?.name}
+                      </span>
+                      <span className="ml-2 text-[#6E6B67]">
+                        {new Date(d.starts_at).toLocaleString()} – {new Date(d.ends_at).toLocaleString()}
+                      </span>
+                      {d.reason && <span className="ml-2 text-[#9B9792]">{d.reason}</span>}
+                    </div>
+                    <div className="flex items-center gap-3">
+                      <Badge variant="outline">{d.active ? t.delegationActive : t.delegationScheduled}</Badge>
+                      <button
+                        onClick={() => handleDeleteDelegation(d.id)}
+                        className="text-[#9B9792] hover:text-[#D1625B]"
+                      >
+                        <Trash2 className="h-4 w-4" />
+                      </button>
+                    </div>
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
         </div>
       </section>
 
@@ -422,6 +591,7 @@ export default function ApprovalsPage() {
                                 </p>
                                 <p className="text-sm text-[#6E6B67]">
                                   {step.user?.name || 'System'}
+                                  {step.on_behalf_of && ` (${t.onBehalfOf} ${step.on_behalf_of.name})`}
                                 </p>
                               </div>
                               <p className="text-xs text-[#6E6B67]">
@@ -495,6 +665,10 @@ export default function ApprovalsPage() {
                 </div>
               </div>
 
+              <RequestAttachments request={selectedApproval} />
+
+              <RequestConversation request={selectedApproval} />
+
               {/* Decision Section */}
               {selectedApproval.status === 'pending' && (
                 <div className="rounded-lg bg-[#F9F8F6] p-6 border border-[#E4E1DD]">
@@ -68,7 +68,7 @@ const emptyFormData: ProductFormData = {
 
 export default function InventoryPage() {
   const { language } = useLanguage();
-  const { user } = useAuth();
+  const { hasPermission } = useAuth();
   const [products, setProducts] = useState<Product[]>([]);
   const [loading, setLoading] = useState(true);
   const [searchTerm, setSearchTerm] = useState('');
@@ -87,7 +87,7 @@ export default function InventoryPage() {
   const [totalPages, setTotalPages] = useState(1);
   const [total, setTotal] = useState(0);
 
-  const canEdit = user?.role === 'admin' || user?.role === 'supply_chain_manager';
+  const canEdit = hasPermission('products.write');
 
   const text = {
     en: {
@@ -13,13 +13,16 @@ export default function DashboardLayout({
   children: React.ReactNode;
 }) {
   const router = useRouter();
-  const { isAuthenticated, isLoading } = useAuth();
+  const { user, isAuthenticated, isLoading } = useAuth();
+  const mustChangePassword = !!user?.password_change_required;
 
   useEffect(() => {
     if (!isLoading && !isAuthenticated) {
       router.push('/login');
+    } else if (mustChangePassword) {
+      router.push('/change-password');
     }
-  }, [isAuthenticated, isLoading, router]);
+  }, [isAuthenticated, isLoading, mustChangePassword, router]);
 
   if (isLoading) {
     return (
@@ -32,7 +35,7 @@ export default function DashboardLayout({
     );
   }
 
-  if (!isAuthenticated) {
+  if (!isAuthenticated || mustChangePassword) {
     return null;
   }
 
@@ -25,7 +25,7 @@ import { adminApi, approvalsApi } from '@/lib/api';
 import type { DashboardStats, ApprovalStats } from '@/types';
 
 export default function HomePage() {
-  const { user } = useAuth();
+  const { user, hasPermission } = useAuth();
   const { language } = useLanguage();
   const [dashboardStats, setDashboardStats] = useState<DashboardStats | null>(null);
   const [approvalStats, setApprovalStats] = useState<ApprovalStats | null>(null);
@@ -360,11 +360,11 @@ export default function HomePage() {
   useEffect(() => {
     const fetchStats = async () => {
       try {
-        if (user?.role === 'admin') {
+        if (hasPermission('admin.dashboard')) {
           const stats = await adminApi.getDashboardStats();
           setDashboardStats(stats);
         }
-        if (user?.role === 'admin' || user?.role === 'general_manager') {
+        if (hasPermission('requests.view_stats')) {
           const stats = await approvalsApi.getStats();
           setApprovalStats(stats);
         }
@@ -376,7 +376,7 @@ export default function HomePage() {
     };
 
     fetchStats();
-  }, [user?.role]);
+  }, [user?.permissions]);
 
   if (isLoading) {
     return (
@@ -2,10 +2,12 @@
 
 import { useState, useEffect } from 'react';
 import Link from 'next/link';
-import { ClipboardList, Plus, Loader2, Eye, X, Clock, CheckCircle, XCircle, ArrowRight, User } from 'lucide-react';
+import { ClipboardList, Plus, Loader2, Eye, X, Clock, CheckCircle, XCircle, ArrowRight, User, RotateCcw } from 'lucide-react';
 import { useLanguage } from '@/contexts/LanguageContext';
 import { requestsApi } from '@/lib/api';
 import { Badge } from '@/components/ui/badge';
+import { RequestAttachments } from '@/components/requests/RequestAttachments';
+import { RequestConversation } from '@/components/requests/RequestConversation';
 import type { PurchaseRequest } from '@/types';
 
 export default function RequestsPage() {
@@ -49,6 +51,7 @@ export default function RequestsPage() {
         processing: 'Processing',
         completed: 'Completed',
         cancelled: 'Cancelled',
+        info_requested: 'Information Requested',
       },
       history: 'History',
       actions: {
@@ -58,9 +61,11 @@ export default function RequestsPage() {
         returned: 'Returned for Revision',
         cancelled: 'Cancelled',
         modified: 'Modified',
+        resubmitted: 'Information Provided and Resubmitted',
       },
       by: 'by',
       noHistory: 'No history available',
+      onBehalfOf: 'on behalf of',
       rejectionReason: 'Rejection Reason',
       notes: 'Notes',
     },
@@ -97,6 +102,7 @@ export default function RequestsPage() {
         processing: '处理中',
         completed: '已完成',
         cancelled: '已取消',
+        info_requested: '需要补充信息',
       },
       history: '历史记录',
       actions: {
@@ -106,9 +112,11 @@ export default function RequestsPage() {
         returned: '退回修改',
         cancelled: '已取消',
         modified: '已修改',
+        resubmitted: '已补充信息并重新提交',
       },
       by: '由',
       noHistory: '暂无历史记录',
+      onBehalfOf: '代表',
       rejectionReason: '拒绝原因',
       notes: '备注',
     },
@@ -145,6 +153,7 @@ export default function RequestsPage() {
         processing: 'Procesando',
         completed: 'Completado',
         cancelled: 'Cancelado',
+        info_requested: 'Información Solicitada',
       },
       history: 'Historial',
       actions: {
@@ -154,9 +163,11 @@ export default function RequestsPage() {
         returned: 'Devuelto para Revisión',
         cancelled: 'Cancelado',
         modified: 'Modificado',
+        resubmitted: 'Información Enviada y Reenviada',
       },
       by: 'por',
       noHistory: 'Sin historial disponible',
+      onBehalfOf: 'en nombre de',
       rejectionReason: 'Motivo de Rechazo',
       notes: 'Notas',
     },
@@ -199,6 +210,7 @@ export default function RequestsPage() {
       processing: { bg: '#3A6EA5', text: '#3A6EA5' },
       completed: { bg: '#4BAF7E', text: '#4BAF7E' },
       cancelled: { bg: '#6B7280', text: '#6B7280' },
+      info_requested: { bg: '#E1A948', text: '#E1A948' },
     };
     const s = statusMap[status] || statusMap.pending;
     return (
@@ -226,6 +238,8 @@ export default function RequestsPage() {
         return <XCircle className="h-4 w-4 text-[#D1625B]" />;
       case 'returned':
         return <ArrowRight className="h-4 w-4 text-[#E1A948]" />;
+      case 'resubmitted':
+        return <RotateCcw className="h-4 w-4 text-[#3A6EA5]" />;
       case 'cancelled':
         return <X className="h-4 w-4 text-[#6B7280]" />;
       default:
@@ -243,6 +257,8 @@ export default function RequestsPage() {
         return '#D1625B';
       case 'returned':
         return '#E1A948';
+      case 'resubmitted':
+        return '#3A6EA5';
       case 'cancelled':
         return '#6B7280';
       default:
@@ -424,15 +440,15 @@ export default function RequestsPage() {
                       </tr>
                     </thead>
                     <tbody>
-                      {selectedRequest.items.map((item, idx) => (
-                        <tr key={idx} className="border-b border-[#E4E1DD] last:border-0">
-                          <td className="px-4 py-2 text-sm text-[#2C2C2C]">{item.name}</td>
+                      {selectedRequest.items.map((item) => (
+                        <tr key={item.id} className="border-b border-[#E4E1DD] last:border-0">
+                          <td className="px-4 py-2 text-sm text-[#2C2C2C]">{item.product_title}</td>
                           <td className="px-4 py-2 text-sm text-[#6E6B67]">{item.quantity}</td>
                           <td className="px-4 py-2 text-sm text-[#6E6B67]">
-                            ${item.unit_price.toFixed(2)}
+                            ${(item.unit_price ?? 0).toFixed(2)} {item.currency}
                           </td>
                           <td className="px-4 py-2 text-sm font-semibold text-[#2C2C2C]">
-                            ${item.total_price.toFixed(2)}
+                            ${item.line_total.toFixed(2)}
                           </td>
                         </tr>
                       ))}
@@ -502,6 +518,7 @@ export default function RequestsPage() {
                               <User className="h-3 w-3" />
                               <span>
                                 {historyItem.user?.name || `User #${historyItem.user_id}`}
+                                {historyItem.on_behalf_of && ` (${t.onBehalfOf} ${historyItem.on_behalf_of.name})`}
                               </span>
                               <span>•</span>
                               <span>
@@ -517,6 +534,16 @@ export default function RequestsPage() {
                   <p className="text-sm text-[#9B9792]">{t.noHistory}</p>
                 )}
               </div>
+
+              <RequestAttachments request={selectedRequest} />
+
+              <RequestConversation
+                request={selectedRequest}
+                onResubmitted={() => {
+                  fetchRequests();
+                  viewRequestDetails(selectedRequest.id);
+                }}
+              />
             </div>
           </div>
         </div>
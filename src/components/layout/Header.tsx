@@ -3,10 +3,11 @@
 import { useState } from 'react';
 import Link from 'next/link';
 import { useRouter } from 'next/navigation';
-import { Search, Bell, Globe, ShoppingCart, ChevronDown, LogOut } from 'lucide-react';
+import { Search, Globe, ShoppingCart, ChevronDown, LogOut } from 'lucide-react';
 import { useAuth } from '@/contexts/AuthContext';
 import { useLanguage, Language } from '@/contexts/LanguageContext';
 import { useCart } from '@/contexts/CartContext';
+import { NotificationsMenu } from './NotificationsMenu';
 
 export function Header() {
   const router = useRouter();
@@ -126,10 +127,7 @@ export function Header() {
           </Link>
 
           {/* Notifications */}
-          <button className="relative flex h-10 w-10 items-center justify-center rounded-xl text-[#75534B] transition-all duration-200 hover:bg-[#F9F8F6] active:scale-95">
-            <Bell className="h-5 w-5" />
-            <span className="absolute top-2 right-2 h-2 w-2 rounded-full bg-[#D1625B]"></span>
-          </button>
+          <NotificationsMenu />
 
           {/* Language Switch */}
           <div className="relative">
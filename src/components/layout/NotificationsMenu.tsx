@@ -0,0 +1,272 @@
+'use client';
+
+import { useCallback, useEffect, useState } from 'react';
+import { useRouter } from 'next/navigation';
+import { Bell, CheckCheck, Settings, ArrowLeft } from 'lucide-react';
+import { notificationsApi } from '@/lib/api';
+import { useLanguage } from '@/contexts/LanguageContext';
+import type { Notification, NotificationPreference, NotificationType } from '@/types';
+
+// How often the unread count is refreshed
+const POLL_INTERVAL = 60_000;
+
+export function NotificationsMenu() {
+  const router = useRouter();
+  const { language } = useLanguage();
+  const [open, setOpen] = useState(false);
+  const [showPreferences, setShowPreferences] = useState(false);
+  const [unreadCount, setUnreadCount] = useState(0);
+  const [notifications, setNotifications] = useState<Notification[]>([]);
+  const [preferences, setPreferences] = useState<NotificationPreference[]>([]);
+  const [loading, setLoading] = useState(false);
+
+  const text = {
+    en: {
+      notifications: 'Notifications',
+      markAllRead: 'Mark all read',
+      empty: 'No notifications yet',
+      preferences: 'Notification settings',
+      inApp: 'In-app',
+      email: 'Email',
+      types: {
+        approval_pending: 'Requests awaiting my approval',
+        request_step_approved: 'Approval step approved',
+        request_approved: 'Request approved',
+        request_rejected: 'Request rejected',
+        request_info_requested: 'More information requested',
+        request_purchased: 'Request purchased',
+        request_comment: 'Comments and mentions',
+      },
+    },
+    zh: {
+      notifications: '通知',
+      markAllRead: '全部标为已读',
+      empty: '暂无通知',
+      preferences: '通知设置',
+      inApp: '站内',
+      email: '邮件',
+      types: {
+        approval_pending: '待我审批的申请',
+        request_step_approved: '审批步骤已通过',
+        request_approved: '申请已批准',
+        request_rejected: '申请已拒绝',
+        request_info_requested: '需要补充信息',
+        request_purchased: '申请已采购',
+        request_comment: '评论和提及',
+      },
+    },
+    es: {
+      notifications: 'Notificaciones',
+      markAllRead: 'Marcar todo como leído',
+      empty: 'Aún no hay notificaciones',
+      preferences: 'Configuración de notificaciones',
+      inApp: 'En la app',
+      email: 'Correo',
+      types: {
+        approval_pending: 'Solicitudes pendientes de mi aprobación',
+        request_step_approved: 'Paso de aprobación aprobado',
+        request_approved: 'Solicitud aprobada',
+        request_rejected: 'Solicitud rechazada',
+        request_info_requested: 'Se solicitó más información',
+        request_purchased: 'Solicitud comprada',
+        request_comment: 'Comentarios y menciones',
+      },
+    },
+  };
+
+  const t = text[language];
+
+  const refreshCount = useCallback(async () => {
+    try {
+      setUnreadCount(await notificationsApi.unreadCount());
+    } catch {
+      // The count is refreshed again on the next poll
+    }
+  }, []);
+
+  useEffect(() => {
+    refreshCount();
+    const interval = setInterval(refreshCount, POLL_INTERVAL);
+    return () => clearInterval(interval);
+  }, [refreshCount]);
+
+  const toggle = async () => {
+    const next = !open;
+    setOpen(next);
+    setShowPreferences(false);
+    if (!next) return;
+
+    setLoading(true);
+    try {
+      const result = await notificationsApi.list({ per_page: 10 });
+      setNotifications(result.data || []);
+      refreshCount();
+    } catch (error) {
+      console.error('Failed to load notifications:', error);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const openNotification = async (notification: Notification) => {
+    if (!notification.read_at) {
+      try {
+        const updated = await notificationsApi.markRead(notification.id);
+        setNotifications((current) => current.map((n) => (n.id === updated.id ? updated : n)));
+        setUnreadCount((count) => Math.max(count - 1, 0));
+      } catch (error) {
+        console.error('Failed to mark notification read:', error);
+      }
+    }
+    setOpen(false);
+    router.push(notification.type === 'approval_pending' ? '/approvals' : '/requests');
+  };
+
+  const markAllRead = async () => {
+    try {
+      await notificationsApi.markAllRead();
+      const now = new Date().toISOString();
+      setNotifications((current) => current.map((n) => ({ ...n, read_at: n.read_at || now })));
+      setUnreadCount(0);
+    } catch (error) {
+      console.error('Failed to mark notifications read:', error);
+    }
+  };
+
+  const openPreferences = async () => {
+    setShowPreferences(true);
+    try {
+      setPreferences(await notificationsApi.getPreferences());
+    } catch (error) {
+      console.error('Failed to load notification preferences:', error);
+    }
+  };
+
+  const togglePreference = async (type: NotificationType, channel: 'in_app' | 'email') => {
+    const preference = preferences.find((p) => p.type === type);
+    if (!preference) return;
+    try {
+      setPreferences(
+        await notificationsApi.updatePreferences([{ ...preference, [channel]: !preference[channel] }])
+      );
+    } catch (error) {
+      console.error('Failed to update notification preferences:', error);
+    }
+  };
+
+  return (
+    <div className="relative">
+      <button
+        onClick={toggle}
+        title={t.notifications}
+        className="relative flex h-10 w-10 items-center justify-center rounded-xl text-[#75534B] transition-all duration-200 hover:bg-[#F9F8F6] active:scale-95"
+      >
+        <Bell className="h-5 w-5" />
+        {unreadCount > 0 && (
+          <span className="absolute -top-1 -right-1 flex h-5 min-w-5 items-center justify-center rounded-full bg-[#D1625B] px-1 text-xs text-white shadow-md">
+            {unreadCount > 99 ? '99+' : unreadCount}
+          </span>
+        )}
+      </button>
+
+      {open && (
+        <div className="absolute right-0 top-12 w-96 rounded-xl bg-white shadow-lg border border-[#E4E1DD] overflow-hidden z-50">
+          <div className="flex items-center justify-between px-4 py-3 border-b border-[#E4E1DD]">
+            {showPreferences ? (
+              <button
+                onClick={() => setShowPreferences(false)}
+                className="flex items-center gap-2 text-sm font-semibold text-[#2C2C2C]"
+              >
+                <ArrowLeft className="h-4 w-4" />
+                {t.preferences}
+              </button>
+            ) : (
+              <>
+                <p className="text-sm font-semibold text-[#2C2C2C]">{t.notifications}</p>
+                <div className="flex items-center gap-1">
+                  <button
+                    onClick={markAllRead}
+                    disabled={unreadCount === 0}
+                    title={t.markAllRead}
+                    className="rounded-lg p-1.5 text-[#75534B] hover:bg-[#F9F8F6] disabled:opacity-40"
+                  >
+                    <CheckCheck className="h-4 w-4" />
+                  </button>
+                  <button
+                    onClick={openPreferences}
+                    title={t.preferences}
+                    className="rounded-lg p-1.5 text-[#75534B] hover:bg-[#F9F8F6]"
+                  >
+                    <Settings className="h-4 w-4" />
+                  </button>
+                </div>
+              </>
+            )}
+          </div>
+
+          {showPreferences ? (
+            <div className="max-h-96 overflow-y-auto">
+              <div className="grid grid-cols-[1fr_auto_auto] gap-x-4 px-4 py-2 text-xs text-[#6E6B67]">
+                <span></span>
+                <span>{t.inApp}</span>
+                <span>{t.email}</span>
+              </div>
+              {preferences.map((preference) => (
+                <div
+                  key={preference.type}
+                  className="grid grid-cols-[1fr_auto_auto] items-center gap-x-4 px-4 py-2 text-sm text-[#2C2C2C]"
+                >
+                  <span>{t.types[preference.type]}</span>
+                  <input
+                    type="checkbox"
+                    checked={preference.in_app}
+                    onChange={() => togglePreference(preference.type, 'in_app')}
+                    className="h-4 w-4 justify-self-center accent-[#75534B]"
+                  />
+                  <input
+                    type="checkbox"
+                    checked={preference.email}
+                    onChange={() => togglePreference(preference.type, 'email')}
+                    className="h-4 w-4 justify-self-center accent-[#75534B]"
+                  />
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="max-h-96 overflow-y-auto">
+              {!loading && notifications.length === 0 && (
+                <p className="px-4 py-6 text-center text-sm text-[#6E6B67]">{t.empty}</p>
+              )}
+              {notifications.map((notification) => (
+                <button
+                  key={notification.id}
+                  onClick={() => openNotification(notification)}
+                  className={`w-full px-4 py-3 text-left border-b border-[#E4E1DD] last:border-b-0 transition-colors hover:bg-[#F9F8F6] ${
+                    notification.read_at ? '' : 'bg-[#75534B]/5'
+                  }`}
+                >
+                  <div className="flex items-start gap-2">
+                    {!notification.read_at && (
+                      <span className="mt-1.5 h-2 w-2 shrink-0 rounded-full bg-[#D1625B]"></span>
+                    )}
+                    <div className="min-w-0">
+                      <p className="text-sm text-[#2C2C2C]" style={{ fontWeight: notification.read_at ? 400 : 600 }}>
+                        {notification.title}
+                      </p>
+                      <p className="mt-0.5 line-clamp-2 whitespace-pre-line text-xs text-[#6E6B67]">
+                        {notification.body}
+                      </p>
+                      <p className="mt-1 text-xs text-[#6E6B67]">
+                        {new Date(notification.created_at).toLocaleString()}
+                      </p>
+                    </div>
+                  </div>
+                </button>
+              ))}
+            </div>
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
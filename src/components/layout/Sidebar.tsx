@@ -21,7 +21,7 @@ interface MenuItem {
   icon: React.ElementType;
   labelKey: string;
   href: string;
-  roles?: string[];
+  permissions?: string[]; // Shown if the user has any of them
 }
 
 const menuItems: MenuItem[] = [
@@ -29,17 +29,17 @@ const menuItems: MenuItem[] = [
   { icon: ShoppingBag, labelKey: 'catalog', href: '/catalog' },
   { icon: ExternalLink, labelKey: 'newPurchase', href: '/purchase/new' },
   { icon: ClipboardList, labelKey: 'requests', href: '/requests' },
-  { icon: CheckSquare, labelKey: 'approvals', href: '/approvals', roles: ['admin', 'general_manager'] },
-  { icon: DollarSign, labelKey: 'orders', href: '/admin/orders', roles: ['admin', 'supply_chain_manager'] },
+  { icon: CheckSquare, labelKey: 'approvals', href: '/approvals', permissions: ['requests.approve_any', 'requests.view_stats'] },
+  { icon: DollarSign, labelKey: 'orders', href: '/admin/orders', permissions: ['orders.manage'] },
   { icon: Package, labelKey: 'inventory', href: '/inventory' },
-  { icon: BarChart3, labelKey: 'analytics', href: '/analytics', roles: ['admin', 'supply_chain_manager'] },
-  { icon: Users, labelKey: 'users', href: '/admin/users', roles: ['admin'] },
-  { icon: Settings, labelKey: 'admin', href: '/admin', roles: ['admin'] },
+  { icon: BarChart3, labelKey: 'analytics', href: '/analytics', permissions: ['analytics.view'] },
+  { icon: Users, labelKey: 'users', href: '/admin/users', permissions: ['users.manage'] },
+  { icon: Settings, labelKey: 'admin', href: '/admin', permissions: ['admin.dashboard'] },
 ];
 
 export function Sidebar() {
   const pathname = usePathname();
-  const { user } = useAuth();
+  const { hasPermission } = useAuth();
   const { language } = useLanguage();
 
   const text = {
@@ -84,8 +84,8 @@ export function Sidebar() {
   const t = text[language];
 
   const filteredMenuItems = menuItems.filter((item) => {
-    if (!item.roles) return true;
-    return user && item.roles.includes(user.role);
+    if (!item.permissions) return true;
+    return hasPermission(...item.permissions);
   });
 
   const isActive = (href: string) => {
@@ -0,0 +1,236 @@
+'use client';
+
+import { useCallback, useEffect, useRef, useState } from 'react';
+import { AxiosError } from 'axios';
+import { Loader2, Paperclip, FileText, Trash2 } from 'lucide-react';
+import { purchaseRequestsApi } from '@/lib/api';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import type { ApiResponse, AttachmentKind, PurchaseRequest, RequestAttachment } from '@/types';
+
+// Match the backend limits on uploads
+export const MAX_FILES = 5;
+export const ACCEPTED_FILES = '.pdf,.doc,.docx,.xls,.xlsx,.ppt,.pptx,.png,.jpg,.jpeg,.gif,.webp';
+
+const KIND_COLORS: Record<AttachmentKind, string> = {
+  quote: '#3A6EA5',
+  spec: '#75534B',
+  invoice: '#4BAF7E',
+  other: '#6B7280',
+};
+
+export function formatFileSize(size: number) {
+  return size >= 1024 * 1024 ? `${(size / (1024 * 1024)).toFixed(1)} MB` : `${Math.max(1, Math.round(size / 1024))} KB`;
+}
+
+interface RequestAttachmentsProps {
+  request: PurchaseRequest;
+}
+
+export function RequestAttachments({ request }: RequestAttachmentsProps) {
+  const { user, hasPermission } = useAuth();
+  const { language } = useLanguage();
+  const [attachments, setAttachments] = useState<RequestAttachment[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [isUploading, setIsUploading] = useState(false);
+  const [error, setError] = useState('');
+  const fileInput = useRef<HTMLInputElement>(null);
+
+  // Requesters add documents while the request is in review until a step is
+  // approved, buyers add invoices once it is approved
+  const uploadKinds: AttachmentKind[] =
+    user?.id === request.requester_id &&
+    (request.status === 'pending' || request.status === 'info_requested') &&
+    request.current_step <= 1
+      ? ['quote', 'spec', 'other']
+      : hasPermission('orders.manage') && (request.status === 'approved' || request.status === 'purchased')
+        ? ['invoice']
+        : [];
+  const [selectedKind, setKind] = useState<AttachmentKind>('quote');
+  const kind = uploadKinds.includes(selectedKind) ? selectedKind : uploadKinds[0];
+
+  const text = {
+    en: {
+      attachments: 'Attachments',
+      empty: 'No attachments',
+      upload: 'Upload',
+      fromComment: 'posted in the conversation',
+      tooManyFiles: `You can upload up to ${MAX_FILES} files at once`,
+      error: 'Failed to upload attachments',
+      deleteError: 'Failed to delete attachment',
+      kinds: {
+        quote: 'Quote',
+        spec: 'Spec Sheet',
+        invoice: 'Invoice',
+        other: 'Other',
+      },
+    },
+    zh: {
+      attachments: '附件',
+      empty: '暂无附件',
+      upload: '上传',
+      fromComment: '发布于讨论中',
+      tooManyFiles: `一次最多可上传 ${MAX_FILES} 个文件`,
+      error: '附件上传失败',
+      deleteError: '附件删除失败',
+      kinds: {
+        quote: '报价单',
+        spec: '规格书',
+        invoice: '发票',
+        other: '其他',
+      },
+    },
+    es: {
+      attachments: 'Adjuntos',
+      empty: 'Sin adjuntos',
+      upload: 'Subir',
+      fromComment: 'publicado en la conversación',
+      tooManyFiles: `Puede subir hasta ${MAX_FILES} archivos a la vez`,
+      error: 'No se pudieron subir los adjuntos',
+      deleteError: 'No se pudo eliminar el adjunto',
+      kinds: {
+        quote: 'Cotización',
+        spec: 'Ficha Técnica',
+        invoice: 'Factura',
+        other: 'Otro',
+      },
+    },
+  };
+
+  const t = text[language];
+
+  const fetchAttachments = useCallback(async () => {
+    try {
+      setAttachments(await purchaseRequestsApi.getAttachments(request.id));
+    } catch (err) {
+      console.error('Failed to fetch attachments:', err);
+    } finally {
+      setIsLoading(false);
+    }
+  }, [request.id]);
+
+  useEffect(() => {
+    setIsLoading(true);
+    fetchAttachments();
+  }, [fetchAttachments, request.status]);
+
+  const errorMessage = (err: unknown, fallback: string) =>
+    (err as AxiosError<ApiResponse<unknown>>).response?.data?.error?.message || fallback;
+
+  const handleUpload = async (selected: FileList | null) => {
+    if (!selected || selected.length === 0) return;
+    if (selected.length > MAX_FILES) {
+      setError(t.tooManyFiles);
+      return;
+    }
+
+    setIsUploading(true);
+    setError('');
+    try {
+      const uploaded = await purchaseRequestsApi.uploadAttachments(request.id, kind, Array.from(selected));
+      setAttachments([...attachments, ...uploaded]);
+    } catch (err) {
+      setError(errorMessage(err, t.error));
+    } finally {
+      setIsUploading(false);
+      if (fileInput.current) fileInput.current.value = '';
+    }
+  };
+
+  const handleDelete = async (attachment: RequestAttachment) => {
+    setError('');
+    try {
+      await purchaseRequestsApi.deleteAttachment(request.id, attachment.id);
+      setAttachments(attachments.filter((a) => a.id !== attachment.id));
+    } catch (err) {
+      setError(errorMessage(err, t.deleteError));
+    }
+  };
+
+  return (
+    <div className="mt-6 pt-4 border-t border-[#E4E1DD]">
+      <p className="text-sm font-semibold text-[#2C2C2C] mb-3">{t.attachments}</p>
+
+      {isLoading ? (
+        <div className="flex justify-center py-4">
+          <Loader2 className="h-5 w-5 animate-spin text-[#75534B]" />
+        </div>
+      ) : attachments.length === 0 ? (
+        <p className="text-sm text-[#9B9792] mb-3">{t.empty}</p>
+      ) : (
+        <div className="space-y-2 mb-3">
+          {attachments.map((attachment) => (
+            <div
+              key={attachment.id}
+              className="flex items-center gap-3 rounded-lg bg-[#F9F8F6] px-3 py-2"
+            >
+              <FileText className="h-4 w-4 flex-shrink-0" style={{ color: KIND_COLORS[attachment.kind] }} />
+              <div className="flex-1 min-w-0">
+                <button
+                  type="button"
+                  onClick={() => purchaseRequestsApi.downloadAttachment(request.id, attachment)}
+                  className="block truncate text-sm text-[#3A6EA5] hover:underline"
+                >
+                  {attachment.file_name}
+                </button>
+                <p className="text-xs text-[#9B9792]">
+                  {t.kinds[attachment.kind]} • {formatFileSize(attachment.size)} •{' '}
+                  {attachment.uploaded_by?.name || `User #${attachment.uploaded_by_id}`} •{' '}
+                  {new Date(attachment.created_at).toLocaleString()}
+                  {attachment.comment_id && ` • ${t.fromComment}`}
+                </p>
+              </div>
+              {attachment.can_delete && (
+                <button
+                  type="button"
+                  onClick={() => handleDelete(attachment)}
+                  className="text-[#9B9792] hover:text-[#D1625B]"
+                >
+                  <Trash2 className="h-4 w-4" />
+                </button>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {error && <p className="text-sm text-[#D1625B] mb-2">{error}</p>}
+
+      {uploadKinds.length > 0 && (
+        <div className="flex items-center gap-2">
+          {uploadKinds.length > 1 && (
+            <select
+              value={kind}
+              onChange={(e) => setKind(e.target.value as AttachmentKind)}
+              className="rounded-lg border border-[#E4E1DD] px-2 py-1.5 text-sm text-[#2C2C2C] focus:outline-none focus:border-[#75534B]"
+            >
+              {uploadKinds.map((k) => (
+                <option key={k} value={k}>
+                  {t.kinds[k]}
+                </option>
+              ))}
+            </select>
+          )}
+          <button
+            type="button"
+            onClick={() => fileInput.current?.click()}
+            disabled={isUploading}
+            className="flex items-center gap-1 rounded-lg border border-[#E4E1DD] px-3 py-1.5 text-sm text-[#2C2C2C] hover:bg-[#F9F8F6] disabled:opacity-50"
+          >
+            {isUploading ? <Loader2 className="h-4 w-4 animate-spin" /> : <Paperclip className="h-4 w-4" />}
+            {t.upload}
+            {uploadKinds.length === 1 && ` ${t.kinds[uploadKinds[0]]}`}
+          </button>
+          <input
+            ref={fileInput}
+            type="file"
+            multiple
+            accept={ACCEPTED_FILES}
+            className="hidden"
+            onChange={(e) => handleUpload(e.target.files)}
+          />
+        </div>
+      )}
+    </div>
+  );
+}
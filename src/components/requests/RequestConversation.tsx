@@ -0,0 +1,318 @@
+'use client';
+
+import { useCallback, useEffect, useRef, useState } from 'react';
+import { AxiosError } from 'axios';
+import { Loader2, Paperclip, Send, X, FileText, MessageSquare, HelpCircle, CornerDownRight } from 'lucide-react';
+import { purchaseRequestsApi } from '@/lib/api';
+import { useAuth } from '@/contexts/AuthContext';
+import { useLanguage } from '@/contexts/LanguageContext';
+import type { ApiResponse, CommentKind, MentionableUser, PurchaseRequest, RequestComment } from '@/types';
+import { ACCEPTED_FILES, MAX_FILES, formatFileSize } from '@/components/requests/RequestAttachments';
+
+interface RequestConversationProps {
+  request: PurchaseRequest;
+  // Called after the requester answers an info request, which resubmits the request
+  onResubmitted?: () => void;
+}
+
+export function RequestConversation({ request, onResubmitted }: RequestConversationProps) {
+  const { user } = useAuth();
+  const { language } = useLanguage();
+  const [comments, setComments] = useState<RequestComment[]>([]);
+  const [mentionable, setMentionable] = useState<MentionableUser[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [body, setBody] = useState('');
+  const [mentions, setMentions] = useState<MentionableUser[]>([]);
+  const [mentionQuery, setMentionQuery] = useState<string | null>(null);
+  const [files, setFiles] = useState<File[]>([]);
+  const [resubmit, setResubmit] = useState(false);
+  const [isPosting, setIsPosting] = useState(false);
+  const [error, setError] = useState('');
+  const fileInput = useRef<HTMLInputElement>(null);
+
+  const text = {
+    en: {
+      conversation: 'Conversation',
+      empty: 'No comments yet',
+      placeholder: 'Write a comment, use @ to mention someone',
+      send: 'Send',
+      attach: 'Attach files',
+      replyAndResubmit: 'Send as my answer and resubmit the request',
+      tooManyFiles: `You can attach up to ${MAX_FILES} files`,
+      error: 'Failed to post comment',
+      kinds: {
+        comment: 'Comment',
+        info_request: 'Information requested',
+        info_response: 'Answer',
+      },
+    },
+    zh: {
+      conversation: '讨论',
+      empty: '暂无评论',
+      placeholder: '输入评论，使用 @ 提及他人',
+      send: '发送',
+      attach: '附加文件',
+      replyAndResubmit: '作为我的回答发送并重新提交请求',
+      tooManyFiles: `最多可附加 ${MAX_FILES} 个文件`,
+      error: '评论发送失败',
+      kinds: {
+        comment: '评论',
+        info_request: '需要补充信息',
+        info_response: '回答',
+      },
+    },
+    es: {
+      conversation: 'Conversación',
+      empty: 'Aún no hay comentarios',
+      placeholder: 'Escriba un comentario, use @ para mencionar a alguien',
+      send: 'Enviar',
+      attach: 'Adjuntar archivos',
+      replyAndResubmit: 'Enviar como mi respuesta y reenviar la solicitud',
+      tooManyFiles: `Puede adjuntar hasta ${MAX_FILES} archivos`,
+      error: 'No se pudo publicar el comentario',
+      kinds: {
+        comment: 'Comentario',
+        info_request: 'Información solicitada',
+        info_response: 'Respuesta',
+      },
+    },
+  };
+
+  const t = text[language];
+
+  const canResubmit = user?.id === request.requester_id && request.status === 'info_requested';
+
+  const fetchComments = useCallback(async () => {
+    try {
+      setComments(await purchaseRequestsApi.getComments(request.id));
+    } catch (err) {
+      console.error('Failed to fetch comments:', err);
+    } finally {
+      setIsLoading(false);
+    }
+  }, [request.id]);
+
+  useEffect(() => {
+    setIsLoading(true);
+    fetchComments();
+    purchaseRequestsApi.getMentionableUsers(request.id).then(setMentionable).catch(() => setMentionable([]));
+  }, [request.id, fetchComments]);
+
+  // An answer is expected while the request waits for information
+  useEffect(() => {
+    setResubmit(canResubmit);
+  }, [canResubmit]);
+
+  const handleBodyChange = (value: string) => {
+    setBody(value);
+    const match = /@([^\s@]*)$/.exec(value);
+    setMentionQuery(match ? match[1].toLowerCase() : null);
+  };
+
+  const addMention = (mentioned: MentionableUser) => {
+    setBody(body.replace(/@[^\s@]*$/, `@${mentioned.name} `));
+    setMentionQuery(null);
+    if (!mentions.some((m) => m.id === mentioned.id)) {
+      setMentions([...mentions, mentioned]);
+    }
+  };
+
+  const handleFiles = (selected: FileList | null) => {
+    if (!selected) return;
+    const next = [...files, ...Array.from(selected)];
+    if (next.length > MAX_FILES) {
+      setError(t.tooManyFiles);
+      return;
+    }
+    setError('');
+    setFiles(next);
+    if (fileInput.current) fileInput.current.value = '';
+  };
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!body.trim()) return;
+
+    setIsPosting(true);
+    setError('');
+    try {
+      // Mentions removed from the text while editing are not sent
+      const mentionIds = mentions.filter((m) => body.includes(`@${m.name}`)).map((m) => m.id);
+      const sentAsAnswer = canResubmit && resubmit;
+      const comment = await purchaseRequestsApi.addComment(request.id, {
+        body,
+        mention_ids: mentionIds,
+        resubmit: sentAsAnswer,
+        files,
+      });
+      setComments([...comments, comment]);
+      setBody('');
+      setMentions([]);
+      setFiles([]);
+      if (sentAsAnswer) {
+        onResubmitted?.();
+      }
+    } catch (err) {
+      const response = (err as AxiosError<ApiResponse<unknown>>).response;
+      setError(response?.data?.error?.message || t.error);
+    } finally {
+      setIsPosting(false);
+    }
+  };
+
+  const getKindIcon = (kind: CommentKind) => {
+    switch (kind) {
+      case 'info_request':
+        return <HelpCircle className="h-4 w-4 text-[#E1A948]" />;
+      case 'info_response':
+        return <CornerDownRight className="h-4 w-4 text-[#3A6EA5]" />;
+      default:
+        return <MessageSquare className="h-4 w-4 text-[#6E6B67]" />;
+    }
+  };
+
+  const suggestions =
+    mentionQuery === null
+      ? []
+      : mentionable.filter(
+          (m) => m.name.toLowerCase().includes(mentionQuery) || m.email.toLowerCase().includes(mentionQuery)
+        );
+
+  return (
+    <div className="mt-6 pt-4 border-t border-[#E4E1DD]">
+      <p className="text-sm font-semibold text-[#2C2C2C] mb-3">{t.conversation}</p>
+
+      {isLoading ? (
+        <div className="flex justify-center py-4">
+          <Loader2 className="h-5 w-5 animate-spin text-[#75534B]" />
+        </div>
+      ) : comments.length === 0 ? (
+        <p className="text-sm text-[#9B9792] mb-3">{t.empty}</p>
+      ) : (
+        <div className="space-y-3 mb-4">
+          {comments.map((comment) => (
+            <div
+              key={comment.id}
+              className={`rounded-lg p-3 ${
+                comment.kind === 'info_request'
+                  ? 'bg-[#E1A948]/10 border border-[#E1A948]/30'
+                  : comment.kind === 'info_response'
+                    ? 'bg-[#3A6EA5]/10 border border-[#3A6EA5]/30 ml-6'
+                    : 'bg-[#F9F8F6]'
+              }`}
+            >
+              <div className="flex items-center gap-2 mb-1 text-xs text-[#9B9792]">
+                {getKindIcon(comment.kind)}
+                <span className="font-medium text-sm text-[#2C2C2C]">
+                  {comment.author?.name || `User #${comment.author_id}`}
+                </span>
+                {comment.kind !== 'comment' && <span>{t.kinds[comment.kind]}</span>}
+                <span>•</span>
+                <span>{new Date(comment.created_at).toLocaleString()}</span>
+              </div>
+
+              <p className="text-sm text-[#2C2C2C] whitespace-pre-wrap">{comment.body}</p>
+
+              {comment.attachments?.length > 0 && (
+                <div className="flex flex-wrap gap-2 mt-2">
+                  {comment.attachments.map((attachment) => (
+                    <button
+                      key={attachment.id}
+                      type="button"
+                      onClick={() => purchaseRequestsApi.downloadAttachment(request.id, attachment)}
+                      className="flex items-center gap-1 rounded border border-[#E4E1DD] bg-white px-2 py-1 text-xs text-[#3A6EA5] hover:bg-[#F9F8F6]"
+                    >
+                      <FileText className="h-3 w-3" />
+                      {attachment.file_name}
+                      <span className="text-[#9B9792]">({formatFileSize(attachment.size)})</span>
+                    </button>
+                  ))}
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      <form onSubmit={handleSubmit} className="space-y-2">
+        <div className="relative">
+          <textarea
+            value={body}
+            onChange={(e) => handleBodyChange(e.target.value)}
+            placeholder={t.placeholder}
+            maxLength={5000}
+            rows={3}
+            className="w-full rounded-lg border border-[#E4E1DD] px-3 py-2 text-sm focus:outline-none focus:border-[#75534B]"
+          />
+          {suggestions.length > 0 && (
+            <div className="absolute left-0 bottom-full mb-1 w-64 max-h-48 overflow-y-auto rounded-lg border border-[#E4E1DD] bg-white shadow-lg z-10">
+              {suggestions.map((m) => (
+                <button
+                  key={m.id}
+                  type="button"
+                  onClick={() => addMention(m)}
+                  className="block w-full px-3 py-2 text-left text-sm hover:bg-[#F9F8F6]"
+                >
+                  <span className="text-[#2C2C2C]">{m.name}</span>
+                  <span className="block text-xs text-[#9B9792]">{m.email}</span>
+                </button>
+              ))}
+            </div>
+          )}
+        </div>
+
+        {files.length > 0 && (
+          <div className="flex flex-wrap gap-2">
+            {files.map((file, idx) => (
+              <span
+                key={`${file.name}-${idx}`}
+                className="flex items-center gap-1 rounded border border-[#E4E1DD] px-2 py-1 text-xs text-[#6E6B67]"
+              >
+                {file.name}
+                <button type="button" onClick={() => setFiles(files.filter((_, i) => i !== idx))}>
+                  <X className="h-3 w-3" />
+                </button>
+              </span>
+            ))}
+          </div>
+        )}
+
+        {canResubmit && (
+          <label className="flex items-center gap-2 text-sm text-[#2C2C2C]">
+            <input type="checkbox" checked={resubmit} onChange={(e) => setResubmit(e.target.checked)} />
+            {t.replyAndResubmit}
+          </label>
+        )}
+
+        {error && <p className="text-sm text-[#D1625B]">{error}</p>}
+
+        <div className="flex items-center justify-between">
+          <button
+            type="button"
+            onClick={() => fileInput.current?.click()}
+            className="flex items-center gap-1 text-sm text-[#6E6B67] hover:text-[#2C2C2C]"
+          >
+            <Paperclip className="h-4 w-4" />
+            {t.attach}
+          </button>
+          <input
+            ref={fileInput}
+            type="file"
+            multiple
+            accept={ACCEPTED_FILES}
+            className="hidden"
+            onChange={(e) => handleFiles(e.target.files)}
+          />
+          <button
+            type="submit"
+            disabled={isPosting || !body.trim()}
+            className="flex items-center gap-2 rounded-lg bg-[#75534B] px-4 py-2 text-sm font-medium text-white hover:bg-[#5D423C] disabled:opacity-50"
+          >
+            {isPosting ? <Loader2 className="h-4 w-4 animate-spin" /> : <Send className="h-4 w-4" />}
+            {t.send}
+          </button>
+        </div>
+      </form>
+    </div>
+  );
+}
@@ -1,16 +1,22 @@
 'use client';
 
 import { createContext, useContext, useState, useEffect, ReactNode } from 'react';
-import { authApi, setAccessToken, getAccessToken } from '@/lib/api';
-import type { User, LoginCredentials } from '@/types';
+import { authApi, setAccessToken, getAccessToken, isMfaChallenge } from '@/lib/api';
+import type { User, LoginCredentials, AuthResponse, MFAChallenge } from '@/types';
 
 interface AuthContextType {
   user: User | null;
   isLoading: boolean;
   isAuthenticated: boolean;
-  login: (credentials: LoginCredentials) => Promise<void>;
+  // Resolves with a challenge when the login needs a second factor
+  login: (credentials: LoginCredentials) => Promise<MFAChallenge | null>;
+  loginWithSso: (code: string, state: string) => Promise<MFAChallenge | null>;
+  verifyMfa: (challengeToken: string, code: string, isRecoveryCode?: boolean) => Promise<void>;
+  confirmMfaSetup: (challengeToken: string, code: string) => Promise<AuthResponse>;
   logout: () => Promise<void>;
   refreshUser: () => Promise<void>;
+  // True if the user's role grants any of the permissions
+  hasPermission: (...permissions: string[]) => boolean;
 }
 
 const AuthContext = createContext<AuthContextType | undefined>(undefined);
@@ -43,7 +49,35 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
   const login = async (credentials: LoginCredentials) => {
     const response = await authApi.login(credentials);
+    if (isMfaChallenge(response)) {
+      return response;
+    }
+    setUser(response.user);
+    return null;
+  };
+
+  const loginWithSso = async (code: string, state: string) => {
+    const response = await authApi.completeSso(code, state);
+    if (isMfaChallenge(response)) {
+      return response;
+    }
+    setUser(response.user);
+    return null;
+  };
+
+  const verifyMfa = async (challengeToken: string, code: string, isRecoveryCode = false) => {
+    const response = await authApi.verifyMfa(
+      challengeToken,
+      isRecoveryCode ? { recovery_code: code } : { code }
+    );
+    setUser(response.user);
+  };
+
+  // The caller shows the returned recovery codes before continuing
+  const confirmMfaSetup = async (challengeToken: string, code: string) => {
+    const response = await authApi.confirmMfaSetup(challengeToken, code);
     setUser(response.user);
+    return response;
   };
 
   const logout = async () => {
@@ -54,6 +88,9 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   };
 
+  const hasPermission = (...permissions: string[]) =>
+    !!user?.permissions && permissions.some((permission) => user.permissions!.includes(permission));
+
   return (
     <AuthContext.Provider
       value={{
@@ -61,8 +98,12 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         isLoading,
         isAuthenticated: !!user,
         login,
+        loginWithSso,
+        verifyMfa,
+        confirmMfaSetup,
         logout,
         refreshUser,
+        hasPermission,
       }}
     >
       {children}
@@ -2,13 +2,55 @@ import axios, { AxiosInstance, AxiosError, InternalAxiosRequestConfig } from 'ax
 import type {
   ApiResponse,
   AuthResponse,
+  MFAChallenge,
+  MFAEnrollment,
+  MFAStatus,
+  PasswordRules,
+  SSOStatus,
   LoginCredentials,
   User,
+  UserScopes,
+  Delegation,
+  DelegateOption,
+  DelegationInput,
+  Notification,
+  NotificationPreference,
+  RequestEventType,
+  RequestStreamEvent,
+  Webhook,
+  WebhookDelivery,
+  WebhookInput,
+  Session,
+  Role,
+  RoleInput,
+  Permission,
   Product,
   PurchaseRequest,
+  RequestComment,
+  RequestAttachment,
+  AttachmentKind,
+  CommentInput,
+  MentionableUser,
   AmazonConfig,
   DashboardStats,
-  ApprovalStats
+  ApprovalStats,
+  FilterRule,
+  AuditLog,
+  AuditLogFilters,
+  LoginThrottle,
+  Cart,
+  CartItem,
+  StockMovement,
+  StockAlert,
+  StockMovementInput,
+  StockMovementType,
+  AddCartItemInput,
+  AnalyticsFilters,
+  AnalyticsSummary,
+  SpendByMonth,
+  SpendRanking,
+  LeadTimes,
+  RejectionRate
 } from '@/types';
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || '/api/v1';
@@ -51,20 +93,42 @@ api.interceptors.request.use((config: InternalAxiosRequestConfig) => {
   return config;
 });
 
+// Refresh tokens are single use, so concurrent 401s share one refresh call
+let refreshPromise: Promise<string> | null = null;
+
+const refreshAccessToken = (refreshToken: string): Promise<string> => {
+  if (!refreshPromise) {
+    refreshPromise = axios
+      .post(`${API_BASE_URL}/auth/refresh`, { refresh_token: refreshToken })
+      .then((response) => {
+        const { access_token, refresh_token } = response.data.data;
+        setAccessToken(access_token);
+        localStorage.setItem('refresh_token', refresh_token);
+        return access_token as string;
+      })
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
+// Session storage entry holding the key of a single sign-on login in progress
+const SSO_BROWSER_KEY = 'sso_browser_key';
+
+// A 401 from a login step is a wrong password or code, not an expired session
+const LOGIN_STEP_PATHS = ['/auth/login', '/auth/mfa/verify', '/auth/mfa/setup', '/auth/sso/callback'];
+const isLoginStep = (url?: string) => !!url && LOGIN_STEP_PATHS.some((path) => url.startsWith(path));
+
 // Response interceptor
 api.interceptors.response.use(
   (response) => response,
   async (error: AxiosError) => {
-    if (error.response?.status === 401) {
+    if (error.response?.status === 401 && !isLoginStep(error.config?.url)) {
       const refreshToken = localStorage.getItem('refresh_token');
       if (refreshToken) {
         try {
-          const response = await axios.post(`${API_BASE_URL}/auth/refresh`, {
-            refresh_token: refreshToken,
-          });
-          const { access_token, refresh_token } = response.data.data;
-          setAccessToken(access_token);
-          localStorage.setItem('refresh_token', refresh_token);
+          const access_token = await refreshAccessToken(refreshToken);
 
           const originalRequest = error.config;
           if (originalRequest) {
@@ -80,20 +144,130 @@ api.interceptors.response.use(
         window.location.href = '/login';
       }
     }
+    // The session is only good for changing the password until it is changed
+    const data = error.response?.data as ApiResponse<unknown> | undefined;
+    if (error.response?.status === 403 && data?.error?.code === 'PASSWORD_CHANGE_REQUIRED') {
+      window.location.href = '/change-password';
+    }
     return Promise.reject(error);
   }
 );
 
 // Auth API
+export const isMfaChallenge = (data: AuthResponse | MFAChallenge): data is MFAChallenge =>
+  (data as MFAChallenge).mfa_required === true;
+
+// Stores the tokens of a completed login
+const storeTokens = (data: AuthResponse) => {
+  setAccessToken(data.access_token);
+  localStorage.setItem('refresh_token', data.refresh_token);
+};
+
 export const authApi = {
-  login: async (credentials: LoginCredentials): Promise<AuthResponse> => {
-    const response = await api.post<ApiResponse<AuthResponse>>('/auth/login', credentials);
+  login: async (credentials: LoginCredentials): Promise<AuthResponse | MFAChallenge> => {
+    const response = await api.post<ApiResponse<AuthResponse | MFAChallenge>>('/auth/login', credentials);
+    const data = response.data.data!;
+    if (!isMfaChallenge(data)) {
+      storeTokens(data);
+    }
+    return data;
+  },
+
+  verifyMfa: async (challengeToken: string, code: { code?: string; recovery_code?: string }): Promise<AuthResponse> => {
+    const response = await api.post<ApiResponse<AuthResponse>>('/auth/mfa/verify', {
+      challenge_token: challengeToken,
+      ...code,
+    });
     const data = response.data.data!;
-    setAccessToken(data.access_token);
-    localStorage.setItem('refresh_token', data.refresh_token);
+    storeTokens(data);
     return data;
   },
 
+  beginMfaSetup: async (challengeToken: string): Promise<MFAEnrollment> => {
+    const response = await api.post<ApiResponse<MFAEnrollment>>('/auth/mfa/setup', { challenge_token: challengeToken });
+    return response.data.data!;
+  },
+
+  confirmMfaSetup: async (challengeToken: string, code: string): Promise<AuthResponse> => {
+    const response = await api.post<ApiResponse<AuthResponse>>('/auth/mfa/setup/confirm', {
+      challenge_token: challengeToken,
+      code,
+    });
+    const data = response.data.data!;
+    storeTokens(data);
+    return data;
+  },
+
+  getSsoStatus: async (): Promise<SSOStatus> => {
+    const response = await api.get<ApiResponse<SSOStatus>>('/auth/sso');
+    return response.data.data!;
+  },
+
+  // Returns the identity provider URL to send the browser to. The key that
+  // binds the login to this browser is kept for the callback.
+  beginSso: async (): Promise<string> => {
+    const response = await api.get<ApiResponse<{ authorization_url: string; browser_key: string }>>(
+      '/auth/sso/authorize'
+    );
+    const { authorization_url, browser_key } = response.data.data!;
+    sessionStorage.setItem(SSO_BROWSER_KEY, browser_key);
+    return authorization_url;
+  },
+
+  // Finishes single sign-on with the code and state the provider redirected back with
+  completeSso: async (code: string, state: string): Promise<AuthResponse | MFAChallenge> => {
+    const browser_key = sessionStorage.getItem(SSO_BROWSER_KEY) || '';
+    sessionStorage.removeItem(SSO_BROWSER_KEY);
+    const response = await api.post<ApiResponse<AuthResponse | MFAChallenge>>('/auth/sso/callback', {
+      code,
+      state,
+      browser_key,
+    });
+    const data = response.data.data!;
+    if (!isMfaChallenge(data)) {
+      storeTokens(data);
+    }
+    return data;
+  },
+
+  forgotPassword: async (email: string): Promise<void> => {
+    await api.post('/auth/password/forgot', { email });
+  },
+
+  // Sets a password with the token of a reset or invitation link
+  resetPassword: async (token: string, password: string): Promise<void> => {
+    await api.post('/auth/password/reset', { token, password });
+  },
+
+  getPasswordPolicy: async (): Promise<PasswordRules> => {
+    const response = await api.get<ApiResponse<PasswordRules>>('/auth/password/policy');
+    return response.data.data!;
+  },
+
+  getMfaStatus: async (): Promise<MFAStatus> => {
+    const response = await api.get<ApiResponse<MFAStatus>>('/auth/mfa');
+    return response.data.data!;
+  },
+
+  enrollMfa: async (): Promise<MFAEnrollment> => {
+    const response = await api.post<ApiResponse<MFAEnrollment>>('/auth/mfa/enroll');
+    return response.data.data!;
+  },
+
+  enableMfa: async (code: string): Promise<string[]> => {
+    const response = await api.post<ApiResponse<{ recovery_codes: string[] }>>('/auth/mfa/enable', { code });
+    return response.data.data!.recovery_codes;
+  },
+
+  disableMfa: async (password: string, code: string): Promise<void> => {
+    await api.post('/auth/mfa/disable', { password, code });
+  },
+
+  regenerateRecoveryCodes: async (code: string): Promise<string[]> => {
+    const response = await api.post<ApiResponse<{ recovery_codes: string[] }>>('/auth/mfa/recovery-codes', { code });
+    return response.data.data!.recovery_codes;
+  },
+
   logout: async (): Promise<void> => {
     try {
       await api.post('/auth/logout');
@@ -108,6 +282,20 @@ export const authApi = {
     return response.data.data!;
   },
 
+  listSessions: async (): Promise<Session[]> => {
+    const response = await api.get<ApiResponse<Session[]>>('/auth/sessions');
+    return response.data.data!;
+  },
+
+  revokeSession: async (id: number): Promise<void> => {
+    await api.delete(`/auth/sessions/${id}`);
+  },
+
+  revokeOtherSessions: async (): Promise<{ revoked: number }> => {
+    const response = await api.delete<ApiResponse<{ revoked: number }>>('/auth/sessions');
+    return response.data.data!;
+  },
+
   changePassword: async (currentPassword: string, newPassword: string): Promise<void> => {
     await api.put('/profile/password', {
       current_password: currentPassword,
@@ -128,7 +316,8 @@ export const usersApi = {
     return response.data.data!;
   },
 
-  create: async (data: Partial<User> & { password: string }): Promise<User> => {
+  // Omit the password to email the user an invitation to set it
+  create: async (data: Partial<User> & { password?: string }): Promise<User> => {
     const response = await api.post<ApiResponse<User>>('/users', data);
     return response.data.data!;
   },
@@ -146,6 +335,58 @@ export const usersApi = {
     const response = await api.patch<ApiResponse<User>>(`/users/${id}/toggle`);
     return response.data.data!;
   },
+
+  unlock: async (id: number): Promise<void> => {
+    await api.post(`/users/${id}/unlock`);
+  },
+
+  invite: async (id: number): Promise<void> => {
+    await api.post(`/users/${id}/invite`);
+  },
+
+  resetMfa: async (id: number): Promise<User> => {
+    const response = await api.post<ApiResponse<User>>(`/users/${id}/mfa/reset`);
+    return response.data.data!;
+  },
+
+  getScopes: async (id: number): Promise<UserScopes> => {
+    const response = await api.get<ApiResponse<UserScopes>>(`/users/${id}/scopes`);
+    return response.data.data!;
+  },
+
+  // Replaces the departments and cost centers the user manages
+  setScopes: async (id: number, scopes: UserScopes): Promise<UserScopes> => {
+    const response = await api.put<ApiResponse<UserScopes>>(`/users/${id}/scopes`, scopes);
+    return response.data.data!;
+  },
+};
+
+// Roles API
+export const rolesApi = {
+  list: async (): Promise<Role[]> => {
+    const response = await api.get<ApiResponse<Role[]>>('/admin/roles');
+    return response.data.data || [];
+  },
+
+  permissions: async (): Promise<Permission[]> => {
+    const response = await api.get<ApiResponse<Permission[]>>('/admin/permissions');
+    return response.data.data || [];
+  },
+
+  create: async (data: RoleInput): Promise<Role> => {
+    const response = await api.post<ApiResponse<Role>>('/admin/roles', data);
+    return response.data.data!;
+  },
+
+  // Replaces the role's permissions
+  update: async (id: number, data: RoleInput): Promise<Role> => {
+    const response = await api.put<ApiResponse<Role>>(`/admin/roles/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/admin/roles/${id}`);
+  },
 };
 
 // Products API
@@ -189,15 +430,32 @@ export const productsApi = {
     await api.delete(`/products/${id}`);
   },
 
-  updateStock: async (id: number, stock: number): Promise<Product> => {
-    const response = await api.patch<ApiResponse<Product>>(`/products/${id}/stock`, { stock });
+  // Record a counted stock level as an adjustment
+  updateStock: async (id: number, stock: number, reason?: string): Promise<Product> => {
+    const response = await api.patch<ApiResponse<{ product: Product; movement: StockMovement }>>(`/products/${id}/stock`, { stock, reason });
+    return response.data.data!.product;
+  },
+
+  recordMovement: async (id: number, data: StockMovementInput) => {
+    const response = await api.patch<ApiResponse<{ product: Product; movement: StockMovement }>>(`/products/${id}/stock`, data);
+    return response.data.data!;
+  },
+
+  getMovements: async (id: number, params?: { page?: number; per_page?: number; type?: StockMovementType; request_id?: number }) => {
+    const response = await api.get<ApiResponse<StockMovement[]>>(`/products/${id}/stock-movements`, { params });
+    return response.data;
+  },
+
+  reconcileStock: async (id: number) => {
+    const response = await api.post<ApiResponse<{ product_id: number; previous_stock: number; ledger_stock: number; corrected: boolean }>>(`/products/${id}/stock/reconcile`);
     return response.data.data!;
   },
 };
 
 // Purchase Requests API - New simplified flow
 export interface CreatePurchaseRequestInput {
-  url: string;
+  url?: string;
+  product_id?: number; // Catalog product, instead of a URL
   quantity: number;
   justification: string;
   urgency?: 'normal' | 'urgent';
@@ -246,8 +504,12 @@ export const purchaseRequestsApi = {
     return response.data.data!;
   },
 
-  // Update a request (only if pending or info_requested)
-  update: async (id: number, data: Partial<CreatePurchaseRequestInput>): Promise<PurchaseRequest> => {
+  // Update a request (only if pending or info_requested). While information is
+  // requested this resubmits it, and the response is added to its conversation.
+  update: async (
+    id: number,
+    data: Partial<CreatePurchaseRequestInput> & { response?: string }
+  ): Promise<PurchaseRequest> => {
     const response = await api.put<ApiResponse<PurchaseRequest>>(`/purchase-requests/${id}`, data);
     return response.data.data!;
   },
@@ -256,6 +518,111 @@ export const purchaseRequestsApi = {
   cancel: async (id: number): Promise<void> => {
     await api.delete(`/purchase-requests/${id}`);
   },
+
+  // Conversation on a request, oldest first
+  getComments: async (id: number): Promise<RequestComment[]> => {
+    const response = await api.get<ApiResponse<RequestComment[]>>(`/purchase-requests/${id}/comments`);
+    return response.data.data || [];
+  },
+
+  addComment: async (id: number, input: CommentInput): Promise<RequestComment> => {
+    const form = new FormData();
+    form.append('body', input.body);
+    input.mention_ids?.forEach((userId) => form.append('mention_ids', String(userId)));
+    if (input.resubmit) form.append('resubmit', 'true');
+    input.files?.forEach((file) => form.append('files', file));
+    const response = await api.post<ApiResponse<RequestComment>>(`/purchase-requests/${id}/comments`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+    return response.data.data!;
+  },
+
+  getMentionableUsers: async (id: number): Promise<MentionableUser[]> => {
+    const response = await api.get<ApiResponse<MentionableUser[]>>(`/purchase-requests/${id}/mentionable-users`);
+    return response.data.data || [];
+  },
+
+  // Quotes, specs and invoices, including the files posted with comments
+  getAttachments: async (id: number): Promise<RequestAttachment[]> => {
+    const response = await api.get<ApiResponse<RequestAttachment[]>>(`/purchase-requests/${id}/attachments`);
+    return response.data.data || [];
+  },
+
+  uploadAttachments: async (id: number, kind: AttachmentKind, files: File[]): Promise<RequestAttachment[]> => {
+    const form = new FormData();
+    form.append('kind', kind);
+    files.forEach((file) => form.append('files', file));
+    const response = await api.post<ApiResponse<RequestAttachment[]>>(`/purchase-requests/${id}/attachments`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+    return response.data.data || [];
+  },
+
+  deleteAttachment: async (requestId: number, attachmentId: number): Promise<void> => {
+    await api.delete(`/purchase-requests/${requestId}/attachments/${attachmentId}`);
+  },
+
+  // Attachments need the access token, so they are fetched and saved from a blob
+  downloadAttachment: async (requestId: number, attachment: RequestAttachment): Promise<void> => {
+    const response = await api.get<Blob>(`/purchase-requests/${requestId}/attachments/${attachment.id}`, {
+      responseType: 'blob',
+    });
+    const url = URL.createObjectURL(response.data);
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = attachment.file_name;
+    link.click();
+    URL.revokeObjectURL(url);
+  },
+};
+
+export const inventoryApi = {
+  getAlerts: async (params?: { page?: number; per_page?: number; status?: 'open' | 'resolved'; product_id?: number }) => {
+    const response = await api.get<ApiResponse<StockAlert[]>>('/inventory/alerts', { params });
+    return response.data;
+  },
+
+  // Run the low stock check now instead of waiting for the scheduler
+  checkAlerts: async () => {
+    const response = await api.post<ApiResponse<{ raised: number; resolved: number; requested: number }>>('/inventory/alerts/check');
+    return response.data.data!;
+  },
+
+  replenish: async (alertId: number): Promise<PurchaseRequest> => {
+    const response = await api.post<ApiResponse<PurchaseRequest>>(`/inventory/alerts/${alertId}/replenish`);
+    return response.data.data!;
+  },
+};
+
+export const cartApi = {
+  get: async (): Promise<Cart> => {
+    const response = await api.get<ApiResponse<Cart>>('/cart');
+    return response.data.data!;
+  },
+
+  addItem: async (data: AddCartItemInput): Promise<CartItem> => {
+    const response = await api.post<ApiResponse<CartItem>>('/cart/items', data);
+    return response.data.data!;
+  },
+
+  updateItem: async (id: number, data: { quantity?: number; unit_price?: number; currency?: string }): Promise<CartItem> => {
+    const response = await api.put<ApiResponse<CartItem>>(`/cart/items/${id}`, data);
+    return response.data.data!;
+  },
+
+  removeItem: async (id: number): Promise<void> => {
+    await api.delete(`/cart/items/${id}`);
+  },
+
+  clear: async (): Promise<void> => {
+    await api.delete('/cart');
+  },
+
+  // Submit the whole cart as one purchase request
+  checkout: async (data: { justification: string; urgency?: 'normal' | 'urgent' }): Promise<PurchaseRequest> => {
+    const response = await api.post<ApiResponse<PurchaseRequest>>('/cart/checkout', data);
+    return response.data.data!;
+  },
 };
 
 // Legacy requests API for backward compatibility
@@ -311,6 +678,179 @@ export const approvalsApi = {
   },
 };
 
+// Delegations API
+export const delegationsApi = {
+  list: async (params?: { all?: boolean; include_expired?: boolean }): Promise<Delegation[]> => {
+    const response = await api.get<ApiResponse<Delegation[]>>('/delegations', { params });
+    return response.data.data || [];
+  },
+
+  delegates: async (): Promise<DelegateOption[]> => {
+    const response = await api.get<ApiResponse<DelegateOption[]>>('/delegations/delegates');
+    return response.data.data || [];
+  },
+
+  create: async (data: DelegationInput): Promise<Delegation> => {
+    const response = await api.post<ApiResponse<Delegation>>('/delegations', data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/delegations/${id}`);
+  },
+};
+
+// Notifications API
+export const notificationsApi = {
+  list: async (params?: { page?: number; per_page?: number; unread?: boolean }): Promise<ApiResponse<Notification[]>> => {
+    const response = await api.get<ApiResponse<Notification[]>>('/notifications', { params });
+    return response.data;
+  },
+
+  unreadCount: async (): Promise<number> => {
+    const response = await api.get<ApiResponse<{ count: number }>>('/notifications/unread-count');
+    return response.data.data?.count || 0;
+  },
+
+  markRead: async (id: number): Promise<Notification> => {
+    const response = await api.post<ApiResponse<Notification>>(`/notifications/${id}/read`);
+    return response.data.data!;
+  },
+
+  markAllRead: async (): Promise<void> => {
+    await api.post('/notifications/read-all');
+  },
+
+  getPreferences: async (): Promise<NotificationPreference[]> => {
+    const response = await api.get<ApiResponse<NotificationPreference[]>>('/notifications/preferences');
+    return response.data.data || [];
+  },
+
+  updatePreferences: async (preferences: NotificationPreference[]): Promise<NotificationPreference[]> => {
+    const response = await api.put<ApiResponse<NotificationPreference[]>>('/notifications/preferences', { preferences });
+    return response.data.data || [];
+  },
+};
+
+// Live request events. EventSource cannot send the Authorization header, so
+// the Server-Sent Events stream is read with fetch. The stream reconnects after
+// the delay the server asks for, refreshing an expired access token, and
+// resumes after the last event received.
+export const eventsApi = {
+  subscribe: (handlers: {
+    onEvent: (event: RequestStreamEvent) => void;
+    onReconnect?: () => void; // Events may have been missed while disconnected
+  }): (() => void) => {
+    const controller = new AbortController();
+    let lastEventId = '';
+    let retryDelay = 5000;
+    let connected = false;
+
+    const dispatch = (block: string) => {
+      let data = '';
+      for (const line of block.split('\n')) {
+        const [field, ...rest] = line.split(':');
+        const value = rest.join(':').replace(/^ /, '');
+        if (field === 'id') lastEventId = value;
+        else if (field === 'data') data += value;
+        else if (field === 'retry' && Number(value) > 0) retryDelay = Number(value);
+      }
+      if (data) {
+        handlers.onEvent(JSON.parse(data) as RequestStreamEvent);
+      }
+    };
+
+    const connect = async () => {
+      while (!controller.signal.aborted) {
+        try {
+          const headers: Record<string, string> = { Accept: 'text/event-stream' };
+          const token = getAccessToken();
+          if (token) headers.Authorization = `Bearer ${token}`;
+          if (lastEventId) headers['Last-Event-ID'] = lastEventId;
+
+          const response = await fetch(`${API_BASE_URL}/events/stream`, {
+            headers,
+            credentials: 'include',
+            signal: controller.signal,
+          });
+          if (response.status === 401) {
+            const refreshToken = localStorage.getItem('refresh_token');
+            if (!refreshToken) return;
+            await refreshAccessToken(refreshToken);
+            continue;
+          }
+          if (!response.ok || !response.body) {
+            throw new Error(`Event stream failed with status ${response.status}`);
+          }
+          if (connected) handlers.onReconnect?.();
+          connected = true;
+
+          const reader = response.body.getReader();
+          const decoder = new TextDecoder();
+          let buffer = '';
+          for (;;) {
+            const { value, done } = await reader.read();
+            if (done) break;
+            buffer += decoder.decode(value, { stream: true }).replace(/\r\n?/g, '\n');
+            let end;
+            while ((end = buffer.indexOf('\n\n')) >= 0) {
+              dispatch(buffer.slice(0, end));
+              buffer = buffer.slice(end + 2);
+            }
+          }
+        } catch (error) {
+          if (controller.signal.aborted) return;
+          console.error('Event stream disconnected:', error);
+        }
+        await new Promise((resolve) => setTimeout(resolve, retryDelay));
+      }
+    };
+
+    connect();
+    return () => controller.abort();
+  },
+};
+
+export const webhooksApi = {
+export const webhooksApi = {
+  list: async (): Promise<Webhook[]> => {
+    const response = await api.get<ApiResponse<Webhook[]>>('/admin/webhooks');
+    return response.data.data || [];
+  },
+
+  events: async (): Promise<RequestEventType[]> => {
+    const response = await api.get<ApiResponse<RequestEventType[]>>('/admin/webhooks/events');
+    return response.data.data || [];
+  },
+
+  create: async (data: WebhookInput): Promise<Webhook> => {
+    const response = await api.post<ApiResponse<Webhook>>('/admin/webhooks', data);
+    return response.data.data!;
+  },
+
+  update: async (id: number, data: WebhookInput): Promise<Webhook> => {
+    const response = await api.put<ApiResponse<Webhook>>(`/admin/webhooks/${id}`, data);
+    return response.data.data!;
+  },
+
+  delete: async (id: number): Promise<void> => {
+    await api.delete(`/admin/webhooks/${id}`);
+  },
+
+  deliveries: async (
+    id: number,
+    params?: { page?: number; per_page?: number; status?: string; event_type?: string }
+  ): Promise<ApiResponse<WebhookDelivery[]>> => {
+    const response = await api.get<ApiResponse<WebhookDelivery[]>>(`/admin/webhooks/${id}/deliveries`, { params });
+    return response.data;
+  },
+
+  test: async (id: number): Promise<WebhookDelivery> => {
+    const response = await api.post<ApiResponse<WebhookDelivery>>(`/admin/webhooks/${id}/test`);
+    return response.data.data!;
+  },
+};
+
 // Admin API
 export const adminApi = {
   getDashboardStats: async (): Promise<DashboardStats> => {
@@ -364,8 +904,18 @@ export const adminApi = {
     return response.data;
   },
 
-  markAsPurchased: async (id: number, notes?: string): Promise<PurchaseRequest> => {
-    const response = await api.patch<ApiResponse<PurchaseRequest>>(`/admin/orders/${id}/purchased`, { notes });
+  // Invoices are sent along as a multipart form
+  markAsPurchased: async (id: number, notes?: string, invoices: File[] = []): Promise<PurchaseRequest> => {
+    if (invoices.length === 0) {
+      const response = await api.patch<ApiResponse<PurchaseRequest>>(`/admin/orders/${id}/purchased`, { notes });
+      return response.data.data!;
+    }
+    const form = new FormData();
+    if (notes) form.append('notes', notes);
+    invoices.forEach((file) => form.append('invoices', file));
+    const response = await api.patch<ApiResponse<PurchaseRequest>>(`/admin/orders/${id}/purchased`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
     return response.data.data!;
   },
 
@@ -373,6 +923,93 @@ export const adminApi = {
     const response = await api.post<ApiResponse<PurchaseRequest>>(`/admin/orders/${id}/retry-cart`);
     return response.data.data!;
   },
+
+  // Filter Rules
+  listFilterRules: async (): Promise<FilterRule[]> => {
+    const response = await api.get<ApiResponse<FilterRule[]>>('/admin/filter-rules');
+    return response.data.data!;
+  },
+
+  createFilterRule: async (data: {
+    name: string;
+    description?: string;
+    rule_type: FilterRule['rule_type'];
+    value: string;
+    action?: FilterRule['action'];
+    priority: number;
+    is_active: boolean;
+  }): Promise<FilterRule> => {
+    const response = await api.post<ApiResponse<FilterRule>>('/admin/filter-rules', data);
+    return response.data.data!;
+  },
+
+  updateFilterRule: async (id: number, data: {
+    name: string;
+    description?: string;
+    rule_type: FilterRule['rule_type'];
+    value: string;
+    action?: FilterRule['action'];
+    priority: number;
+    is_active: boolean;
+  }): Promise<FilterRule> => {
+    const response = await api.put<ApiResponse<FilterRule>>(`/admin/filter-rules/${id}`, data);
+    return response.data.data!;
+  },
+
+  toggleFilterRule: async (id: number): Promise<FilterRule> => {
+    const response = await api.patch<ApiResponse<FilterRule>>(`/admin/filter-rules/${id}/toggle`);
+    return response.data.data!;
+  },
+
+  deleteFilterRule: async (id: number): Promise<void> => {
+    await api.delete(`/admin/filter-rules/${id}`);
+  },
+
+  // Audit Log
+  listAuditLogs: async (params?: AuditLogFilters) => {
+    const response = await api.get<ApiResponse<AuditLog[]>>('/admin/audit-logs', { params });
+    return response.data;
+  },
+
+  // Failed logins and lockouts
+  listLoginLockouts: async (params?: { kind?: LoginThrottle['kind']; locked?: boolean }): Promise<LoginThrottle[]> => {
+    const response = await api.get<ApiResponse<LoginThrottle[]>>('/admin/login-lockouts', { params });
+    return response.data.data!;
+  },
+
+  unlockLogin: async (id: number): Promise<void> => {
+    await api.delete(`/admin/login-lockouts/${id}`);
+  },
+};
+
+// Analytics API
+type SpendRankingDimension = 'requesters' | 'departments' | 'cost-centers' | 'sites';
+
+export const analyticsApi = {
+  getSummary: async (params?: AnalyticsFilters): Promise<AnalyticsSummary> => {
+    const response = await api.get<ApiResponse<AnalyticsSummary>>('/analytics/summary', { params });
+    return response.data.data!;
+  },
+
+  getSpendByMonth: async (params?: AnalyticsFilters) => {
+    const response = await api.get<ApiResponse<{ base_currency: string; months: SpendByMonth[] }>>('/analytics/spend-by-month', { params });
+    return response.data.data!;
+  },
+
+  getTop: async (dimension: SpendRankingDimension, params?: AnalyticsFilters) => {
+    const response = await api.get<ApiResponse<{ base_currency: string; items: SpendRanking[] }>>(`/analytics/top-${dimension}`, { params });
+    return response.data.data!;
+  },
+
+  getLeadTimes: async (params?: AnalyticsFilters): Promise<LeadTimes> => {
+    const response = await api.get<ApiResponse<LeadTimes>>('/analytics/lead-times', { params });
+    return response.data.data!;
+  },
+
+  getRejectionRates: async (params?: AnalyticsFilters) => {
+    const response = await api.get<ApiResponse<{ overall: RejectionRate; by_department: RejectionRate[] }>>('/analytics/rejection-rates', { params });
+    return response.data.data!;
+  },
 };
 
 export default api;
@@ -1,5 +1,6 @@
 // User types
-export type UserRole = 'admin' | 'supply_chain_manager' | 'general_manager' | 'employee';
+// Built-in roles are admin, supply_chain_manager, general_manager and employee; admins can add more
+export type UserRole = string;
 
 export interface User {
   id: number;
@@ -10,6 +11,55 @@ export interface User {
   cost_center: string;
   department: string;
   status: 'active' | 'inactive';
+  mfa_enabled?: boolean;
+  // Set for admin-assigned, seeded and expired passwords; only the password can be changed until it is
+  password_change_required?: boolean;
+  // Single sign-on only users cannot sign in with a password
+  password_login_disabled?: boolean;
+  sso_linked?: boolean;
+  // Granted by the role; only returned for the signed-in user
+  permissions?: string[];
+}
+
+// Departments and cost centers a manager is limited to; empty means company-wide
+export interface UserScopes {
+  departments: string[];
+  cost_centers: string[];
+}
+
+// Role and permission types
+export interface Permission {
+  name: string;
+  description: string;
+}
+
+export interface Role {
+  id: number;
+  name: string;
+  display_name: string;
+  description: string;
+  require_mfa: boolean;
+  built_in: boolean;
+  permissions: string[];
+  user_count: number;
+}
+
+export interface RoleInput {
+  name?: string; // Only on create
+  display_name: string;
+  description: string;
+  require_mfa: boolean;
+  permissions: string[];
+}
+
+export interface Session {
+  id: number;
+  ip_address: string;
+  user_agent: string;
+  current: boolean;
+  last_used_at: string;
+  expires_at: string;
+  created_at: string;
 }
 
 export interface LoginCredentials {
@@ -22,6 +72,43 @@ export interface AuthResponse {
   access_token: string;
   refresh_token: string;
   expires_in: number;
+  recovery_codes?: string[]; // Shown once after 2FA setup during login
+}
+
+// Returned by login instead of tokens when a second factor is needed
+export interface MFAChallenge {
+  mfa_required: true;
+  setup_required: boolean;
+  challenge_token: string;
+  expires_in: number;
+}
+
+// Whether the login page offers single sign-on
+export interface SSOStatus {
+  enabled: boolean;
+  provider_name?: string;
+}
+
+export interface MFAEnrollment {
+  secret: string;
+  otpauth_url: string;
+}
+
+export interface PasswordRules {
+  min_length: number;
+  require_uppercase: boolean;
+  require_lowercase: boolean;
+  require_digit: boolean;
+  require_symbol: boolean;
+  history_size: number;
+  max_age_days: number;
+}
+
+export interface MFAStatus {
+  enabled: boolean;
+  required: boolean;
+  enabled_at?: string;
+  recovery_codes_remaining: number;
 }
 
 // Product types
@@ -53,6 +140,7 @@ export interface Product {
   price: number;
   currency: string;
   stock: number;
+  reserved_stock?: number;
   min_stock?: number;
   max_stock?: number;
   location?: string;
@@ -65,6 +153,48 @@ export interface Product {
   images?: ProductImage[];
 }
 
+// Inventory types
+export type StockMovementType = 'receipt' | 'issue' | 'adjustment' | 'transfer' | 'return';
+
+export interface StockMovement {
+  id: number;
+  product_id: number;
+  type: StockMovementType;
+  quantity: number;
+  stock_change: number;
+  stock_before: number;
+  stock_after: number;
+  reason: string;
+  from_location?: string;
+  to_location?: string;
+  user_id?: number;
+  user?: User;
+  request_id?: number;
+  created_at: string;
+}
+
+export interface StockAlert {
+  id: number;
+  product_id: number;
+  product?: Product;
+  status: 'open' | 'resolved';
+  available_stock: number;
+  min_stock: number;
+  max_stock: number;
+  replenishment_request_id?: number;
+  replenishment_request?: PurchaseRequest;
+  created_at: string;
+  resolved_at?: string;
+}
+
+export interface StockMovementInput {
+  type: StockMovementType;
+  quantity?: number;
+  location?: string;
+  reason?: string;
+  request_id?: number;
+}
+
 // Cart types
 export interface CartItem {
   product: Product;
@@ -75,6 +205,52 @@ export interface CartItem {
 export type RequestStatus = 'pending' | 'approved' | 'rejected' | 'info_requested' | 'purchased';
 export type Urgency = 'normal' | 'urgent';
 
+export type CommentKind = 'comment' | 'info_request' | 'info_response';
+
+// Requesters attach quotes, specs and other documents; buyers attach invoices
+export type AttachmentKind = 'quote' | 'spec' | 'invoice' | 'other';
+
+export interface RequestAttachment {
+  id: number;
+  request_id: number;
+  comment_id?: number; // Set for files posted with a comment
+  kind: AttachmentKind;
+  uploaded_by_id: number;
+  uploaded_by?: User;
+  file_name: string;
+  content_type: string;
+  size: number;
+  created_at: string;
+  can_delete?: boolean; // Only returned when listing a request's attachments
+}
+
+// A message in a request's conversation, including the rounds of an info request
+export interface RequestComment {
+  id: number;
+  request_id: number;
+  author_id: number;
+  author: User;
+  kind: CommentKind;
+  body: string;
+  mentions: User[];
+  attachments: RequestAttachment[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CommentInput {
+  body: string;
+  mention_ids?: number[];
+  resubmit?: boolean; // Answer an info request and send the request back to its approvers
+  files?: File[];
+}
+
+export interface MentionableUser {
+  id: number;
+  name: string;
+  email: string;
+}
+
 export interface RequestHistory {
   id: number;
   user_id: number;
@@ -83,9 +259,133 @@ export interface RequestHistory {
   comment: string;
   old_status: string;
   new_status: string;
+  on_behalf_of?: User; // Delegator the user acted for
+  created_at: string;
+}
+
+// Lets a delegate approve on the delegator's behalf, e.g. while out of office
+export interface Delegation {
+  id: number;
+  delegator: User;
+  delegate: User;
+  starts_at: string;
+  ends_at: string;
+  department: string; // Empty for every department
+  cost_center: string; // Empty for every cost center
+  reason: string;
+  active: boolean;
+  created_at: string;
+}
+
+// A user who can be picked as a delegate
+export interface DelegateOption {
+  id: number;
+  name: string;
+}
+
+export interface DelegationInput {
+  delegator_id?: number; // Defaults to yourself; others need users.manage
+  delegate_id: number;
+  starts_at?: string;
+  ends_at: string;
+  department?: string;
+  cost_center?: string;
+  reason?: string;
+}
+
+export type NotificationType =
+  | 'approval_pending'
+  | 'request_step_approved'
+  | 'request_approved'
+  | 'request_rejected'
+  | 'request_info_requested'
+  | 'request_purchased'
+  | 'request_comment';
+
+export interface Notification {
+  id: number;
+  type: NotificationType;
+  request_id?: number;
+  title: string;
+  body: string;
+  read_at?: string;
+  created_at: string;
+}
+
+// Channels a user receives a notification type on; both default to on
+export interface NotificationPreference {
+  type: NotificationType;
+  in_app: boolean;
+  email: boolean;
+}
+
+export type RequestEventType =
+  | 'request.created'
+  | 'request.step_approved'
+  | 'request.approved'
+  | 'request.rejected'
+  | 'request.info_requested'
+  | 'request.resubmitted'
+  | 'request.cancelled'
+  | 'request.commented'
+  | 'order.purchased'
+  | 'cart.added'
+  | 'cart.failed';
+
+// A request event pushed over /events/stream, with the request's state when it was sent
+export interface RequestStreamEvent {
+  id: number;
+  type: RequestEventType;
+  request_id: number;
+  request_number: string;
+  status: RequestStatus;
+  current_step: number;
+  added_to_cart: boolean;
+  cart_error?: string;
+  actor_id?: number; // Absent for events raised by background jobs
+  awaiting_approval: boolean; // The current user can act on the pending approval step
   created_at: string;
 }
 
+export interface WebhookDelivery {
+  id: number;
+  webhook_id: number;
+  event_id: string; // Shared by every delivery of one event
+  event_type: RequestEventType | 'webhook.test';
+  request_id?: number;
+  payload: string;
+  status: 'pending' | 'succeeded' | 'failed';
+  attempts: number;
+  response_status?: number;
+  response_body?: string;
+  error?: string;
+  duration_ms: number;
+  job_id?: number;
+  delivered_at?: string;
+  created_at: string;
+}
+
+export interface Webhook {
+  id: number;
+  name: string;
+  url: string;
+  events: RequestEventType[];
+  is_active: boolean;
+  secret?: string; // Only returned when created or changed
+  created_at: string;
+  updated_at: string;
+  last_delivery?: WebhookDelivery;
+}
+
+export interface WebhookInput {
+  name: string;
+  url: string;
+  events: RequestEventType[];
+  is_active: boolean;
+  secret?: string; // Generated on create when empty
+  rotate_secret?: boolean;
+}
+
 export interface PurchaseRequest {
   id: number;
   request_number: string;
@@ -118,6 +418,7 @@ export interface PurchaseRequest {
   amazon_asin?: string;
 
   // Approval info
+  current_step: number; // Level of the chain's current step, past 1 once a step is approved
   approved_by?: User;
   approved_by_id?: number;
   approved_at?: string;
@@ -136,6 +437,12 @@ export interface PurchaseRequest {
   purchased_at?: string;
   purchase_notes?: string;
 
+  is_replenishment?: boolean;
+
+  // Line items
+  items?: RequestItem[];
+  total_amount?: number;
+
   // History
   history?: RequestHistory[];
 
@@ -145,12 +452,63 @@ export interface PurchaseRequest {
 
   // Legacy fields for backward compatibility
   type?: string;
-  total_amount?: number;
   cost_center?: string;
   purpose?: string;
   notes?: string;
   priority?: string;
-  items?: any[];
+}
+
+export interface RequestItem {
+  id: number;
+  line_number: number;
+  product_id?: number;
+  sku?: string;
+  url?: string;
+  product_title: string;
+  product_image_url: string;
+  site_name?: string;
+  quantity: number;
+  unit_price?: number;
+  currency: string;
+  line_total: number;
+  fulfillment_status?: 'reserved' | 'external_purchase' | 'issued';
+  reserved_quantity?: number;
+  is_amazon_url: boolean;
+  added_to_cart: boolean;
+  added_to_cart_at?: string;
+}
+
+// Cart types
+export interface CartItem {
+  id: number;
+  user_id: number;
+  product_id?: number;
+  product?: Product;
+  url?: string;
+  product_title: string;
+  product_image_url: string;
+  site_name?: string;
+  unit_price?: number;
+  currency: string;
+  quantity: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface Cart {
+  items: CartItem[];
+  totals: Record<string, number>;
+}
+
+export interface AddCartItemInput {
+  product_id?: number;
+  url?: string;
+  product_title?: string;
+  product_image_url?: string;
+  site_name?: string;
+  unit_price?: number;
+  currency?: string;
+  quantity?: number;
 }
 
 // Amazon Config types
@@ -168,6 +526,109 @@ export interface AmazonConfig {
   updated_at: string;
 }
 
+export interface AuditLog {
+  id: number;
+  user_id: number;
+  user?: User;
+  action: string;
+  resource: 'user' | 'product' | 'amazon_config' | 'purchase_request' | 'session' | 'login_throttle';
+  resource_id: number;
+  old_value: string; // JSON snapshot before the change
+  new_value: string; // JSON snapshot after the change
+  ip_address: string;
+  user_agent: string;
+  created_at: string;
+}
+
+export interface LoginThrottle {
+  id: number;
+  kind: 'account' | 'ip';
+  identifier: string; // Email or IP address
+  user_id?: number;
+  failures: number;
+  last_failure_at?: string;
+  locked_until?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface AuditLogFilters {
+  page?: number;
+  per_page?: number;
+  user_id?: number;
+  resource?: AuditLog['resource'];
+  resource_id?: number;
+  action?: string;
+  from?: string; // YYYY-MM-DD
+  to?: string;
+}
+
+export interface FilterRule {
+  id: number;
+  name: string;
+  description?: string;
+  rule_type: 'price_max' | 'price_min' | 'category_allow' | 'category_block' | 'supplier_block' | 'keyword_block';
+  value: string;
+  action: 'block' | 'flag';
+  priority: number;
+  is_active: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+// Analytics types
+export interface AnalyticsFilters {
+  from?: string;
+  to?: string;
+  department?: string;
+  cost_center?: string;
+  limit?: number;
+}
+
+export interface AnalyticsSummary {
+  base_currency: string;
+  total_requests: number;
+  pending: number;
+  approved: number;
+  rejected: number;
+  purchased: number;
+  total_spend: number;
+  purchased_spend: number;
+  average_request: number;
+  rejection_rate: number;
+  unconverted_spend: number;
+}
+
+export interface SpendByMonth {
+  month: string;
+  requests: number;
+  amount: number;
+  purchased_amount: number;
+}
+
+export interface SpendRanking {
+  key: string;
+  label: string;
+  requests: number;
+  amount: number;
+}
+
+export interface LeadTimes {
+  approved_requests: number;
+  purchased_requests: number;
+  avg_hours_to_approval: number | null;
+  avg_hours_to_purchase: number | null;
+  avg_hours_end_to_end: number | null;
+  max_hours_to_approval: number | null;
+}
+
+export interface RejectionRate {
+  department: string;
+  decided: number;
+  rejected: number;
+  rate: number;
+}
+
 // API Response types
 export interface ApiResponse<T> {
   success: boolean;